@@ -152,6 +152,26 @@ func ExampleProtocol_aead() {
 	// plaintext  = hello world
 }
 
+func ExampleSealMessage() {
+	key := []byte("my-secret-key")
+	nonce := []byte("actually random")
+	ad := []byte("some authenticated data")
+
+	// Equivalent to the transcript in the Protocol AEAD example.
+	ciphertext := thyrse.SealMessage("com.example.aead", key, nonce, ad, []byte("hello world"))
+	fmt.Printf("ciphertext = %x\n", ciphertext)
+
+	plaintext, err := thyrse.OpenMessage("com.example.aead", key, nonce, ad, ciphertext)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("plaintext  = %s\n", plaintext)
+
+	// Output:
+	// ciphertext = ab37236f076eb69b9f9ffa33c77ced426d61cc395cba2fc64244e6384b0e17259ebb8ac6bcbf8ea29ccfbe
+	// plaintext  = hello world
+}
+
 func ExampleProtocol_hpke() {
 	encrypt := func(receiver *ecdh.PublicKey, plaintext []byte) []byte {
 		// This should be randomly generated, but it would make the test always fail.
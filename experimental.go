@@ -0,0 +1,131 @@
+//go:build thyrse_experimental
+
+package thyrse
+
+import (
+	"crypto/subtle"
+	"runtime"
+
+	"github.com/codahale/thyrse/hazmat/secmem"
+)
+
+// Experimental reports whether the package was built with the thyrse_experimental build tag, which enables operations
+// whose transcript encodings are not yet part of the stable specification.
+//
+// The operations in this file, along with [Pipeline], are only available in experimental builds. Their APIs and
+// encodings may change before they are added to the stable specification.
+const Experimental = true
+
+// CommitmentSize is the size in bytes of the detached commitment returned by [Protocol.MaskCommit].
+const CommitmentSize = 16
+
+// JoinSize is the size in bytes of the value [Protocol.Join] derives from the joined protocol.
+const JoinSize = 32
+
+// Split clones the protocol state into n independent branches like [Protocol.ForkN], but without caller-provided values,
+// for callers which need several parallel subprotocols and have no natural value to tell them apart. Each branch's
+// value is derived from the protocol's transcript before the fork, so it is secret as long as the transcript is, and
+// ForkN binds each branch's ordinal alongside it, so the branches are distinct from each other and from the base
+// without relying on the caller to choose distinct values. Panics if n is negative.
+func (p *Protocol) Split(label string, n int) []*Protocol {
+	if n < 0 {
+		panic("thyrse: Split n must not be negative")
+	}
+	if n == 0 {
+		return p.ForkN(label)
+	}
+
+	// Derive the branch values from a clone, leaving the base's transcript as ForkN expects.
+	c := p.Clone()
+	buf := c.Derive(label, nil, n*splitValueSize)
+	c.Clear()
+	defer secmem.Wipe(buf)
+
+	values := make([][]byte, n)
+	for i := range values {
+		values[i] = buf[i*splitValueSize : (i+1)*splitValueSize]
+	}
+	return p.ForkN(label, values...)
+}
+
+// splitValueSize is the size in bytes of each branch value derived by [Protocol.Split].
+const splitValueSize = 32
+
+// Join absorbs a [JoinSize]-byte value derived from other's transcript into the protocol's transcript, committing the
+// protocol to everything other has absorbed, e.g. to merge a forked subprotocol's result back into its parent, or to
+// combine per-direction transcripts at the end of a handshake. Joins are ordered: joining a into b differs from joining
+// b into a, and joining several protocols depends on the order in which they are joined.
+//
+// It is equivalent to calling [Protocol.Mix] with label and the output of other's [Protocol.Derive] with label and an
+// output length of JoinSize, so other advances as Derive would. Join a clone of other to leave it unchanged.
+func (p *Protocol) Join(label string, other *Protocol) {
+	v := DeriveArray[[JoinSize]byte](other, label)
+	p.Mix(label, v[:])
+	secmem.Wipe(v[:])
+}
+
+// MaskCommit encrypts plaintext like [Protocol.Mask], and also returns a detached [CommitmentSize]-byte commitment to
+// the transcript after the ciphertext is absorbed. The ciphertext is the same length as the plaintext, which suits
+// fixed-width storage fields; the commitment is stored separately (e.g. in an integrity column or stream) and passed to
+// [Protocol.UnmaskVerify] to authenticate the ciphertext.
+//
+// Confidentiality requires that the transcript contains at least one unpredictable input (see [Protocol.Mix]).
+func (p *Protocol) MaskCommit(label string, dst, plaintext []byte) (ciphertext, commitment []byte) {
+	ciphertext = p.Mask(label, dst, plaintext)
+	commitment = p.Derive(label, nil, CommitmentSize)
+	return ciphertext, commitment
+}
+
+// UnmaskVerify decrypts ciphertext encrypted with [Protocol.MaskCommit] and verifies it against the detached
+// commitment.
+//
+// On success, returns the plaintext. On failure, returns an error wrapping ErrInvalidCiphertext, and no plaintext is
+// released. The protocol's transcript diverges from the sender's if the ciphertext was modified.
+func (p *Protocol) UnmaskVerify(label string, dst, ciphertext, commitment []byte) ([]byte, error) {
+	ret := p.Unmask(label, dst, ciphertext)
+	plaintext := ret[len(dst):]
+
+	expected := DeriveArray[[CommitmentSize]byte](p, label)
+	if subtle.ConstantTimeCompare(expected[:], commitment) != 1 {
+		secmem.Wipe(plaintext)
+		if len(commitment) < CommitmentSize {
+			return nil, ErrTruncated
+		}
+		return nil, ErrTagMismatch
+	}
+
+	return ret, nil
+}
+
+// TryOpen decrypts and authenticates sealed data like [Protocol.Open], but leaves the protocol unchanged if
+// authentication fails. It opens the sealed data with a clone of the protocol, and adopts the clone's state only if the
+// tag is valid, e.g. for servers which try a message against several candidate sessions or keys.
+//
+// This gives up Open's fail-closed behavior: after a failure the protocol remains usable, so an attacker who can submit
+// messages can make any number of forgery attempts against a single state instead of one. With [TagSize]-byte tags
+// this is not a practical threat, but callers SHOULD limit the number of failures they tolerate and close the session
+// once the limit is reached. TryOpen is also more expensive than Open, since it clones the protocol state.
+func (p *Protocol) TryOpen(label string, dst, sealed []byte) ([]byte, error) {
+	c := p.Clone()
+	ret, err := c.Open(label, dst, sealed)
+	if err != nil {
+		c.Clear()
+		return nil, err
+	}
+	p.adopt(c)
+	return ret, nil
+}
+
+// adopt clears the protocol and replaces its state with c's. The state is moved, not copied, so c MUST NOT be used
+// afterward.
+func (p *Protocol) adopt(c *Protocol) {
+	p.Clear()
+	if c.secure != nil {
+		c.cleanup.Stop()
+	}
+	*p = *c
+	if p.secure != nil {
+		p.cleanup = runtime.AddCleanup(p, (*secureState).clear, p.secure)
+	}
+	*c = Protocol{}
+}
@@ -0,0 +1,235 @@
+//go:build thyrse_experimental
+
+package thyrse
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTryOpen(t *testing.T) {
+	key := []byte("32-byte-key-material-for-testing!")
+	enc := newKeyed("test.seal", key)
+	sealed := enc.Seal("message", nil, []byte("secret"))
+
+	for name, secure := range map[string]bool{"heap": false, "secure": true} {
+		newDec := func() *Protocol {
+			if secure {
+				p := NewSecure("test.seal")
+				p.Mix("key", key)
+				return p
+			}
+			return newKeyed("test.seal", key)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dec := newDec()
+			tampered := bytes.Clone(sealed)
+			tampered[0] ^= 0xFF
+			if _, err := dec.TryOpen("message", nil, tampered); !errors.Is(err, ErrTagMismatch) {
+				t.Fatalf("got %v, want ErrTagMismatch", err)
+			}
+			if _, err := dec.TryOpen("message", nil, sealed[:TagSize-1]); !errors.Is(err, ErrTruncated) {
+				t.Fatalf("got %v, want ErrTruncated", err)
+			}
+			if dec.Equal(newDec()) != 1 {
+				t.Fatal("failed TryOpen changed the protocol")
+			}
+
+			opened, err := dec.TryOpen("message", nil, sealed)
+			if err != nil {
+				t.Fatalf("TryOpen: %v", err)
+			}
+			if got, want := string(opened), "secret"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if got, want := dec.Secure(), secure; got != want {
+				t.Errorf("Secure() = %v, want %v", got, want)
+			}
+
+			ref := newDec()
+			if _, err := ref.Open("message", nil, sealed); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if got, want := dec.Derive("check", nil, 32), ref.Derive("check", nil, 32); !bytes.Equal(got, want) {
+				t.Error("successful TryOpen diverged from Open")
+			}
+		})
+	}
+
+	t.Run("rekey policy", func(t *testing.T) {
+		var rekeys int
+		dec := newKeyed("test.seal", key)
+		dec.SetRekeyPolicy(RekeyPolicy{Messages: 1, OnRekey: func(Stats) { rekeys++ }})
+
+		tampered := bytes.Clone(sealed)
+		tampered[0] ^= 0xFF
+		if _, err := dec.TryOpen("message", nil, tampered); !errors.Is(err, ErrTagMismatch) {
+			t.Fatalf("got %v, want ErrTagMismatch", err)
+		}
+		if got, want := rekeys, 0; got != want {
+			t.Errorf("OnRekey called %d times after a failed TryOpen, want = %d", got, want)
+		}
+
+		if _, err := dec.TryOpen("message", nil, sealed); err != nil {
+			t.Fatalf("TryOpen: %v", err)
+		}
+		if got, want := rekeys, 1; got != want {
+			t.Errorf("OnRekey called %d times after a successful TryOpen, want = %d", got, want)
+		}
+	})
+}
+
+func TestMaskCommit(t *testing.T) {
+	key := []byte("key-material")
+
+	t.Run("round trip", func(t *testing.T) {
+		pt := []byte("fixed-width")
+
+		enc := newKeyed("test", key)
+		ct, commitment := enc.MaskCommit("field", nil, pt)
+		if got, want := len(ct), len(pt); got != want {
+			t.Fatalf("len(ciphertext) = %d, want %d", got, want)
+		}
+		if got, want := len(commitment), CommitmentSize; got != want {
+			t.Fatalf("len(commitment) = %d, want %d", got, want)
+		}
+
+		dec := newKeyed("test", key)
+		got, err := dec.UnmaskVerify("field", nil, ct, commitment)
+		if err != nil {
+			t.Fatalf("UnmaskVerify: %v", err)
+		}
+		if !bytes.Equal(got, pt) {
+			t.Fatalf("got %q, want %q", got, pt)
+		}
+		if enc.Equal(dec) != 1 {
+			t.Fatal("transcripts diverged after successful UnmaskVerify")
+		}
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		ct, commitment := newKeyed("test", key).MaskCommit("field", nil, []byte("fixed-width"))
+		ct[0] ^= 1
+
+		got, err := newKeyed("test", key).UnmaskVerify("field", nil, ct, commitment)
+		if !errors.Is(err, ErrInvalidCiphertext) {
+			t.Fatalf("got %v, want ErrInvalidCiphertext", err)
+		}
+		if got != nil {
+			t.Fatalf("got %x, want nil", got)
+		}
+	})
+
+	t.Run("tampered commitment", func(t *testing.T) {
+		ct, commitment := newKeyed("test", key).MaskCommit("field", nil, []byte("fixed-width"))
+		commitment[0] ^= 1
+
+		if _, err := newKeyed("test", key).UnmaskVerify("field", nil, ct, commitment); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Fatalf("got %v, want ErrInvalidCiphertext", err)
+		}
+	})
+
+	t.Run("dst prefix preserved", func(t *testing.T) {
+		ct, commitment := newKeyed("test", key).MaskCommit("field", nil, []byte("value"))
+
+		got, err := newKeyed("test", key).UnmaskVerify("field", []byte("prefix:"), ct, commitment)
+		if err != nil {
+			t.Fatalf("UnmaskVerify: %v", err)
+		}
+		if got, want := string(got), "prefix:value"; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestSplit(t *testing.T) {
+	t.Run("distinct branches", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		branches := p.Split("worker", 3)
+		if got, want := len(branches), 3; got != want {
+			t.Fatalf("Split() len = %d, want %d", got, want)
+		}
+
+		all := [][]byte{p.Derive("out", nil, 32)}
+		for _, b := range branches {
+			all = append(all, b.Derive("out", nil, 32))
+		}
+		for i := range all {
+			for j := i + 1; j < len(all); j++ {
+				if bytes.Equal(all[i], all[j]) {
+					t.Fatalf("outputs %d and %d are identical", i, j)
+				}
+			}
+		}
+	})
+
+	t.Run("equivalent to ForkN with derived values", func(t *testing.T) {
+		p1, p2 := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+		values := p2.Clone().Derive("worker", nil, 2*splitValueSize)
+		got, want := p1.Split("worker", 2), p2.ForkN("worker", values[:splitValueSize], values[splitValueSize:])
+		if p1.Equal(p2) != 1 || got[0].Equal(want[0]) != 1 || got[1].Equal(want[1]) != 1 {
+			t.Error("Split() differs from ForkN() with derived values")
+		}
+	})
+
+	t.Run("values depend on transcript", func(t *testing.T) {
+		a := newKeyed("test", []byte("key")).Split("worker", 1)[0]
+		b := newKeyed("test", []byte("other")).ForkN("worker", nil)[0]
+		c := newKeyed("test", []byte("key")).ForkN("worker", nil)[0]
+		if a.Equal(c) == 1 || b.Equal(c) == 1 {
+			t.Error("Split() used empty values")
+		}
+	})
+
+	t.Run("zero branches", func(t *testing.T) {
+		p1, p2 := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+		if got := p1.Split("worker", 0); len(got) != 0 {
+			t.Fatalf("Split() len = %d, want 0", len(got))
+		}
+		p2.ForkN("worker")
+		if p1.Equal(p2) != 1 {
+			t.Error("Split(0) differs from ForkN() with no values")
+		}
+	})
+}
+
+func TestJoin(t *testing.T) {
+	t.Run("equivalent to Derive and Mix", func(t *testing.T) {
+		p1, other1 := New("test"), newKeyed("other", []byte("key"))
+		p1.Join("sub", other1)
+
+		p2, other2 := New("test"), newKeyed("other", []byte("key"))
+		p2.Mix("sub", other2.Derive("sub", nil, JoinSize))
+
+		if p1.Equal(p2) != 1 || other1.Equal(other2) != 1 {
+			t.Error("Join() differs from Derive() and Mix()")
+		}
+	})
+
+	t.Run("binds other transcript", func(t *testing.T) {
+		p1, p2 := New("test"), New("test")
+		p1.Join("sub", newKeyed("other", []byte("a")))
+		p2.Join("sub", newKeyed("other", []byte("b")))
+		if p1.Equal(p2) != 0 {
+			t.Error("joining different transcripts produced the same state")
+		}
+	})
+
+	t.Run("merge fork", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		sub := p.Split("sub", 1)[0]
+		sub.Mix("result", []byte("done"))
+		p.Join("sub", sub)
+
+		q := newKeyed("test", []byte("key"))
+		qsub := q.Split("sub", 1)[0]
+		qsub.Mix("result", []byte("other"))
+		q.Join("sub", qsub)
+
+		if p.Equal(q) != 0 {
+			t.Error("joined subprotocol results were not bound")
+		}
+	})
+}
@@ -4,6 +4,7 @@ go 1.26.1
 
 require (
 	github.com/codahale/kt128 v0.0.0-20260614010525-7ad33a4db6bd
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
 	github.com/gtank/ristretto255 v0.2.0
 	github.com/trailofbits/go-fuzz-utils v0.0.0-20250830184917-b61e672bc9ed
 )
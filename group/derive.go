@@ -0,0 +1,26 @@
+package group
+
+import (
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/hazmat/secmem"
+	"github.com/gtank/ristretto255"
+)
+
+// DeriveScalar derives a scalar of g from the protocol, reducing UniformSize bytes derived with the given label (see
+// [Scalar.SetUniformBytes]). The bytes are derived with [thyrse.DeriveArray] and wiped after use, so they never pass
+// through an intermediate heap slice.
+func DeriveScalar(p *thyrse.Protocol, g Group, label string) Scalar {
+	b := thyrse.DeriveArray[[UniformSize]byte](p, label)
+	defer secmem.Wipe(b[:])
+	s, _ := g.NewScalar().SetUniformBytes(b[:])
+	return s
+}
+
+// DeriveRistretto255Scalar is like DeriveScalar for the Ristretto255 group, but returns a github.com/gtank/ristretto255
+// scalar.
+func DeriveRistretto255Scalar(p *thyrse.Protocol, label string) *ristretto255.Scalar {
+	b := thyrse.DeriveArray[[UniformSize]byte](p, label)
+	defer secmem.Wipe(b[:])
+	s, _ := ristretto255.NewScalar().SetUniformBytes(b[:])
+	return s
+}
@@ -0,0 +1,41 @@
+package group_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/gtank/ristretto255"
+)
+
+func TestDeriveScalar(t *testing.T) {
+	newProtocol := func() *thyrse.Protocol {
+		p := thyrse.New("test")
+		p.Mix("key", []byte("secret"))
+		return p
+	}
+
+	want, _ := ristretto255.NewScalar().SetUniformBytes(newProtocol().Derive("scalar", nil, group.UniformSize))
+
+	t.Run("generic", func(t *testing.T) {
+		p := newProtocol()
+		s := group.DeriveScalar(p, group.Ristretto255, "scalar")
+		if got, want := s.Bytes(), want.Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("DeriveScalar() = %x, want %x", got, want)
+		}
+
+		q := newProtocol()
+		q.Derive("scalar", nil, group.UniformSize)
+		if p.Equal(q) != 1 {
+			t.Error("DeriveScalar() diverged from Derive()")
+		}
+	})
+
+	t.Run("ristretto255", func(t *testing.T) {
+		s := group.DeriveRistretto255Scalar(newProtocol(), "scalar")
+		if s.Equal(want) != 1 {
+			t.Errorf("DeriveRistretto255Scalar() = %x, want %x", s.Bytes(), want.Bytes())
+		}
+	})
+}
@@ -0,0 +1,292 @@
+// Package group defines an interface to prime-order groups, so schemes built on them (e.g. the sig package) can be used
+// with groups other than Ristretto255, such as P-256 or a prime-order group built on Ed25519.
+//
+// The interfaces mirror the API of github.com/gtank/ristretto255: methods set the receiver to the result of an
+// operation on their arguments and return it. Scalars and elements from different groups MUST NOT be mixed; methods
+// panic if given a value from another group.
+//
+// Ristretto255 is the default group of every scheme. Its scalars and elements can be converted to and from
+// github.com/gtank/ristretto255 values with Ristretto255Scalar, Ristretto255Element, and the Ristretto255 methods of
+// the values it returns, without copying.
+package group
+
+import (
+	"encoding/binary"
+
+	"github.com/gtank/ristretto255"
+)
+
+// UniformSize is the size, in bytes, of the uniform random input to [Scalar.SetUniformBytes].
+const UniformSize = 64
+
+// A Group is a prime-order group.
+type Group interface {
+	// Name returns the group's name.
+	Name() string
+
+	// ScalarSize returns the size, in bytes, of an encoded scalar.
+	ScalarSize() int
+
+	// ElementSize returns the size, in bytes, of an encoded element.
+	ElementSize() int
+
+	// NewScalar returns a new scalar set to zero.
+	NewScalar() Scalar
+
+	// NewElement returns a new element set to the identity element.
+	NewElement() Element
+}
+
+// A Scalar is an integer modulo the order of a group.
+type Scalar interface {
+	// Add sets the scalar to x + y and returns it.
+	Add(x, y Scalar) Scalar
+
+	// Subtract sets the scalar to x - y and returns it.
+	Subtract(x, y Scalar) Scalar
+
+	// Multiply sets the scalar to x * y and returns it.
+	Multiply(x, y Scalar) Scalar
+
+	// Negate sets the scalar to -x and returns it.
+	Negate(x Scalar) Scalar
+
+	// Invert sets the scalar to the inverse of x and returns it. If x is zero, the result is zero.
+	Invert(x Scalar) Scalar
+
+	// Set sets the scalar to x and returns it.
+	Set(x Scalar) Scalar
+
+	// Equal returns 1 if the scalar is equal to x, and 0 otherwise, in constant time.
+	Equal(x Scalar) int
+
+	// SetUint64 sets the scalar to x, reduced modulo the group order, and returns it.
+	SetUint64(x uint64) Scalar
+
+	// SetUniformBytes sets the scalar to UniformSize uniform random bytes, reduced modulo the group order, and returns
+	// it. Returns an error if b is not UniformSize bytes long.
+	SetUniformBytes(b []byte) (Scalar, error)
+
+	// SetCanonicalBytes sets the scalar to the canonical encoding b and returns it. Returns an error if b is not a
+	// canonical encoding.
+	SetCanonicalBytes(b []byte) (Scalar, error)
+
+	// Bytes returns the canonical encoding of the scalar.
+	Bytes() []byte
+}
+
+// An Element is an element of a group.
+type Element interface {
+	// Add sets the element to p + q and returns it.
+	Add(p, q Element) Element
+
+	// Subtract sets the element to p - q and returns it.
+	Subtract(p, q Element) Element
+
+	// Negate sets the element to -p and returns it.
+	Negate(p Element) Element
+
+	// ScalarMult sets the element to [s]p and returns it.
+	ScalarMult(s Scalar, p Element) Element
+
+	// ScalarBaseMult sets the element to [s]G, where G is the group's generator, and returns it.
+	ScalarBaseMult(s Scalar) Element
+
+	// VarTimeDoubleScalarBaseMult sets the element to [a]A + [b]G, where G is the group's generator, and returns it. It
+	// runs in variable time, so MUST only be used with public inputs.
+	VarTimeDoubleScalarBaseMult(a Scalar, A Element, b Scalar) Element
+
+	// Set sets the element to p and returns it.
+	Set(p Element) Element
+
+	// Equal returns 1 if the element is equal to p, and 0 otherwise, in constant time.
+	Equal(p Element) int
+
+	// SetCanonicalBytes sets the element to the canonical encoding b and returns it. Returns an error if b is not a
+	// canonical encoding.
+	SetCanonicalBytes(b []byte) (Element, error)
+
+	// Bytes returns the canonical encoding of the element.
+	Bytes() []byte
+}
+
+// Ristretto255 is the ristretto255 group (RFC 9496), implemented by github.com/gtank/ristretto255.
+var Ristretto255 Group = ristrettoGroup{}
+
+// Ristretto255Scalar returns s as a Scalar of the Ristretto255 group. The Scalar shares s's memory, so operations on
+// either are visible in both.
+func Ristretto255Scalar(s *ristretto255.Scalar) *RistrettoScalar {
+	return &RistrettoScalar{s}
+}
+
+// Ristretto255Element returns e as an Element of the Ristretto255 group. The Element shares e's memory, so operations
+// on either are visible in both.
+func Ristretto255Element(e *ristretto255.Element) *RistrettoElement {
+	return &RistrettoElement{e}
+}
+
+type ristrettoGroup struct{}
+
+func (ristrettoGroup) Name() string {
+	return "ristretto255"
+}
+
+func (ristrettoGroup) ScalarSize() int {
+	return 32
+}
+
+func (ristrettoGroup) ElementSize() int {
+	return 32
+}
+
+func (ristrettoGroup) NewScalar() Scalar {
+	return Ristretto255Scalar(ristretto255.NewScalar())
+}
+
+func (ristrettoGroup) NewElement() Element {
+	return Ristretto255Element(ristretto255.NewIdentityElement())
+}
+
+// A RistrettoScalar is a Scalar of the Ristretto255 group.
+type RistrettoScalar struct {
+	s *ristretto255.Scalar
+}
+
+// Ristretto255 returns the underlying ristretto255 scalar.
+func (s *RistrettoScalar) Ristretto255() *ristretto255.Scalar {
+	return s.s
+}
+
+func (s *RistrettoScalar) Add(x, y Scalar) Scalar {
+	s.s.Add(rs(x), rs(y))
+	return s
+}
+
+func (s *RistrettoScalar) Subtract(x, y Scalar) Scalar {
+	s.s.Subtract(rs(x), rs(y))
+	return s
+}
+
+func (s *RistrettoScalar) Multiply(x, y Scalar) Scalar {
+	s.s.Multiply(rs(x), rs(y))
+	return s
+}
+
+func (s *RistrettoScalar) Negate(x Scalar) Scalar {
+	s.s.Negate(rs(x))
+	return s
+}
+
+func (s *RistrettoScalar) Invert(x Scalar) Scalar {
+	s.s.Invert(rs(x))
+	return s
+}
+
+func (s *RistrettoScalar) Set(x Scalar) Scalar {
+	s.s.Set(rs(x))
+	return s
+}
+
+func (s *RistrettoScalar) Equal(x Scalar) int {
+	return s.s.Equal(rs(x))
+}
+
+func (s *RistrettoScalar) SetUint64(x uint64) Scalar {
+	var b [32]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	_, _ = s.s.SetCanonicalBytes(b[:])
+	return s
+}
+
+func (s *RistrettoScalar) SetUniformBytes(b []byte) (Scalar, error) {
+	if _, err := s.s.SetUniformBytes(b); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RistrettoScalar) SetCanonicalBytes(b []byte) (Scalar, error) {
+	if _, err := s.s.SetCanonicalBytes(b); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RistrettoScalar) Bytes() []byte {
+	return s.s.Bytes()
+}
+
+// A RistrettoElement is an Element of the Ristretto255 group.
+type RistrettoElement struct {
+	e *ristretto255.Element
+}
+
+// Ristretto255 returns the underlying ristretto255 element.
+func (e *RistrettoElement) Ristretto255() *ristretto255.Element {
+	return e.e
+}
+
+func (e *RistrettoElement) Add(p, q Element) Element {
+	e.e.Add(re(p), re(q))
+	return e
+}
+
+func (e *RistrettoElement) Subtract(p, q Element) Element {
+	e.e.Subtract(re(p), re(q))
+	return e
+}
+
+func (e *RistrettoElement) Negate(p Element) Element {
+	e.e.Negate(re(p))
+	return e
+}
+
+func (e *RistrettoElement) ScalarMult(s Scalar, p Element) Element {
+	e.e.ScalarMult(rs(s), re(p))
+	return e
+}
+
+func (e *RistrettoElement) ScalarBaseMult(s Scalar) Element {
+	e.e.ScalarBaseMult(rs(s))
+	return e
+}
+
+func (e *RistrettoElement) VarTimeDoubleScalarBaseMult(a Scalar, A Element, b Scalar) Element {
+	e.e.VarTimeDoubleScalarBaseMult(rs(a), re(A), rs(b))
+	return e
+}
+
+func (e *RistrettoElement) Set(p Element) Element {
+	e.e.Set(re(p))
+	return e
+}
+
+func (e *RistrettoElement) Equal(p Element) int {
+	return e.e.Equal(re(p))
+}
+
+func (e *RistrettoElement) SetCanonicalBytes(b []byte) (Element, error) {
+	if _, err := e.e.SetCanonicalBytes(b); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *RistrettoElement) Bytes() []byte {
+	return e.e.Bytes()
+}
+
+// rs returns the ristretto255 scalar underlying s. Panics if s is not a Ristretto255 scalar.
+func rs(s Scalar) *ristretto255.Scalar {
+	return s.(*RistrettoScalar).s
+}
+
+// re returns the ristretto255 element underlying e. Panics if e is not a Ristretto255 element.
+func re(e Element) *ristretto255.Element {
+	return e.(*RistrettoElement).e
+}
+
+var (
+	_ Scalar  = (*RistrettoScalar)(nil)
+	_ Element = (*RistrettoElement)(nil)
+)
@@ -0,0 +1,126 @@
+package group_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/gtank/ristretto255"
+)
+
+func TestRistretto255(t *testing.T) {
+	g := group.Ristretto255
+	drbg := testdata.New("thyrse group")
+
+	t.Run("sizes", func(t *testing.T) {
+		if got, want := len(g.NewScalar().Bytes()), g.ScalarSize(); got != want {
+			t.Errorf("len(scalar) = %d, want %d", got, want)
+		}
+		if got, want := len(g.NewElement().Bytes()), g.ElementSize(); got != want {
+			t.Errorf("len(element) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("matches ristretto255", func(t *testing.T) {
+		b := drbg.Data(group.UniformSize)
+		x, err := g.NewScalar().SetUniformBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := ristretto255.NewScalar().SetUniformBytes(b)
+		if !bytes.Equal(x.Bytes(), want.Bytes()) {
+			t.Errorf("SetUniformBytes() = %x, want %x", x.Bytes(), want.Bytes())
+		}
+
+		p := g.NewElement().ScalarBaseMult(x)
+		if got, want := p.Bytes(), ristretto255.NewIdentityElement().ScalarBaseMult(want).Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("ScalarBaseMult() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("algebra", func(t *testing.T) {
+		x, _ := g.NewScalar().SetUniformBytes(drbg.Data(group.UniformSize))
+		y, _ := g.NewScalar().SetUniformBytes(drbg.Data(group.UniformSize))
+
+		// [x]G + [y]G == [x+y]G
+		lhs := g.NewElement().Add(g.NewElement().ScalarBaseMult(x), g.NewElement().ScalarBaseMult(y))
+		rhs := g.NewElement().ScalarBaseMult(g.NewScalar().Add(x, y))
+		if lhs.Equal(rhs) != 1 {
+			t.Error("[x]G + [y]G != [x+y]G")
+		}
+
+		// [x]([y]G) == [xy]G
+		lhs = g.NewElement().ScalarMult(x, g.NewElement().ScalarBaseMult(y))
+		rhs = g.NewElement().ScalarBaseMult(g.NewScalar().Multiply(x, y))
+		if lhs.Equal(rhs) != 1 {
+			t.Error("[x]([y]G) != [xy]G")
+		}
+
+		// x * x^-1 * y == y, -(-x) == x, and x - x == 0.
+		one := g.NewScalar().Multiply(x, g.NewScalar().Invert(x))
+		if g.NewScalar().Multiply(one, y).Equal(y) != 1 {
+			t.Error("x * x^-1 * y != y")
+		}
+		if g.NewScalar().Negate(g.NewScalar().Negate(x)).Equal(x) != 1 {
+			t.Error("-(-x) != x")
+		}
+		if g.NewScalar().Subtract(x, x).Equal(g.NewScalar()) != 1 {
+			t.Error("x - x != 0")
+		}
+		if g.NewScalar().Add(g.NewScalar().SetUint64(2), g.NewScalar().SetUint64(3)).Equal(g.NewScalar().SetUint64(5)) != 1 {
+			t.Error("2 + 3 != 5")
+		}
+		if g.NewScalar().SetUint64(1).Equal(one) != 1 {
+			t.Error("SetUint64(1) != x * x^-1")
+		}
+
+		// [a]A + [b]G
+		A := g.NewElement().ScalarBaseMult(y)
+		lhs = g.NewElement().VarTimeDoubleScalarBaseMult(x, A, y)
+		rhs = g.NewElement().Add(g.NewElement().ScalarMult(x, A), g.NewElement().ScalarBaseMult(y))
+		if lhs.Equal(rhs) != 1 {
+			t.Error("VarTimeDoubleScalarBaseMult() != [a]A + [b]G")
+		}
+		if g.NewElement().Subtract(A, A).Equal(g.NewElement()) != 1 {
+			t.Error("A - A != identity")
+		}
+		if g.NewElement().Add(A, g.NewElement().Negate(A)).Equal(g.NewElement()) != 1 {
+			t.Error("A + -A != identity")
+		}
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		x, _ := g.NewScalar().SetUniformBytes(drbg.Data(group.UniformSize))
+		p := g.NewElement().ScalarBaseMult(x)
+
+		x2, err := g.NewScalar().SetCanonicalBytes(x.Bytes())
+		if err != nil || x2.Equal(x) != 1 {
+			t.Errorf("SetCanonicalBytes(scalar) = %v, %v", x2, err)
+		}
+		p2, err := g.NewElement().SetCanonicalBytes(p.Bytes())
+		if err != nil || p2.Equal(p) != 1 {
+			t.Errorf("SetCanonicalBytes(element) = %v, %v", p2, err)
+		}
+
+		if _, err := g.NewScalar().SetCanonicalBytes(bytes.Repeat([]byte{0xff}, 32)); err == nil {
+			t.Error("SetCanonicalBytes(non-canonical scalar) err = nil")
+		}
+		if _, err := g.NewElement().SetCanonicalBytes(bytes.Repeat([]byte{0xff}, 32)); err == nil {
+			t.Error("SetCanonicalBytes(non-canonical element) err = nil")
+		}
+		if _, err := g.NewScalar().SetUniformBytes(make([]byte, 32)); err == nil {
+			t.Error("SetUniformBytes(short) err = nil")
+		}
+	})
+
+	t.Run("shares memory", func(t *testing.T) {
+		d, q := drbg.KeyPair()
+		if got := group.Ristretto255Scalar(d).Ristretto255(); got != d {
+			t.Error("Ristretto255Scalar() copied the scalar")
+		}
+		if got := group.Ristretto255Element(q).Ristretto255(); got != q {
+			t.Error("Ristretto255Element() copied the element")
+		}
+	})
+}
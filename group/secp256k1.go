@@ -0,0 +1,230 @@
+package group
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Secp256k1 is the secp256k1 elliptic curve group (SEC 2), implemented by github.com/decred/dcrd/dcrec/secp256k1.
+//
+// Scalars are encoded as 32-byte big-endian integers, and SetUniformBytes interprets its input as a 64-byte big-endian
+// integer. Elements are encoded in 33-byte compressed SEC 1 form. The identity element has no SEC 1 encoding of that
+// size: it is encoded as 33 zero bytes, which SetCanonicalBytes rejects.
+//
+// The underlying implementation's scalar multiplications run in variable time, so this group MUST NOT be used where
+// timing side channels are a concern.
+var Secp256k1 Group = secp256k1Group{}
+
+// errInvalidEncoding is returned when a secp256k1 scalar or element encoding is invalid.
+var errInvalidEncoding = errors.New("thyrse/group: invalid secp256k1 encoding")
+
+type secp256k1Group struct{}
+
+func (secp256k1Group) Name() string {
+	return "secp256k1"
+}
+
+func (secp256k1Group) ScalarSize() int {
+	return 32
+}
+
+func (secp256k1Group) ElementSize() int {
+	return 33
+}
+
+func (secp256k1Group) NewScalar() Scalar {
+	return new(secp256k1Scalar)
+}
+
+func (secp256k1Group) NewElement() Element {
+	return new(secp256k1Element)
+}
+
+// A secp256k1Scalar is a Scalar of the Secp256k1 group.
+type secp256k1Scalar struct {
+	s secp256k1.ModNScalar
+}
+
+func (s *secp256k1Scalar) Add(x, y Scalar) Scalar {
+	var r secp256k1.ModNScalar
+	s.s = *r.Add2(ks(x), ks(y))
+	return s
+}
+
+func (s *secp256k1Scalar) Subtract(x, y Scalar) Scalar {
+	var r secp256k1.ModNScalar
+	s.s = *r.NegateVal(ks(y)).Add(ks(x))
+	return s
+}
+
+func (s *secp256k1Scalar) Multiply(x, y Scalar) Scalar {
+	var r secp256k1.ModNScalar
+	s.s = *r.Mul2(ks(x), ks(y))
+	return s
+}
+
+func (s *secp256k1Scalar) Negate(x Scalar) Scalar {
+	var r secp256k1.ModNScalar
+	s.s = *r.NegateVal(ks(x))
+	return s
+}
+
+func (s *secp256k1Scalar) Invert(x Scalar) Scalar {
+	var r secp256k1.ModNScalar
+	s.s = *r.InverseValNonConst(ks(x))
+	return s
+}
+
+func (s *secp256k1Scalar) Set(x Scalar) Scalar {
+	s.s = *ks(x)
+	return s
+}
+
+func (s *secp256k1Scalar) Equal(x Scalar) int {
+	if s.s.Equals(ks(x)) {
+		return 1
+	}
+	return 0
+}
+
+func (s *secp256k1Scalar) SetUint64(x uint64) Scalar {
+	var b [32]byte
+	binary.BigEndian.PutUint64(b[24:], x)
+	s.s.SetBytes(&b)
+	return s
+}
+
+// twoTo256 is 2^256 modulo the order of the Secp256k1 group.
+var twoTo256 = func() (s secp256k1.ModNScalar) {
+	s.SetByteSlice([]byte{
+		0x01, 0x45, 0x51, 0x23, 0x19, 0x50, 0xb7, 0x5f, 0xc4, 0x40, 0x2d, 0xa1, 0x73, 0x2f, 0xc9, 0xbe, 0xbf,
+	})
+	return s
+}()
+
+func (s *secp256k1Scalar) SetUniformBytes(b []byte) (Scalar, error) {
+	if len(b) != UniformSize {
+		return nil, errInvalidEncoding
+	}
+	var hi, lo secp256k1.ModNScalar
+	hi.SetByteSlice(b[:32])
+	lo.SetByteSlice(b[32:])
+	s.s = *hi.Mul(&twoTo256).Add(&lo)
+	return s, nil
+}
+
+func (s *secp256k1Scalar) SetCanonicalBytes(b []byte) (Scalar, error) {
+	var r secp256k1.ModNScalar
+	if len(b) != 32 || r.SetByteSlice(b) {
+		return nil, errInvalidEncoding
+	}
+	s.s = r
+	return s, nil
+}
+
+func (s *secp256k1Scalar) Bytes() []byte {
+	b := s.s.Bytes()
+	return b[:]
+}
+
+// A secp256k1Element is an Element of the Secp256k1 group. Its point is kept in affine form, with X and Y both zero for
+// the identity element.
+type secp256k1Element struct {
+	p secp256k1.JacobianPoint
+}
+
+func (e *secp256k1Element) Add(p, q Element) Element {
+	var r secp256k1.JacobianPoint
+	secp256k1.AddNonConst(kp(p), kp(q), &r)
+	return e.setJacobian(&r)
+}
+
+func (e *secp256k1Element) Subtract(p, q Element) Element {
+	var neg secp256k1Element
+	neg.Negate(q)
+	return e.Add(p, &neg)
+}
+
+func (e *secp256k1Element) Negate(p Element) Element {
+	e.p = *kp(p)
+	if !e.isIdentity() {
+		e.p.Y.Negate(1).Normalize()
+	}
+	return e
+}
+
+func (e *secp256k1Element) ScalarMult(s Scalar, p Element) Element {
+	var r secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(ks(s), kp(p), &r)
+	return e.setJacobian(&r)
+}
+
+func (e *secp256k1Element) ScalarBaseMult(s Scalar) Element {
+	var r secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(ks(s), &r)
+	return e.setJacobian(&r)
+}
+
+func (e *secp256k1Element) VarTimeDoubleScalarBaseMult(a Scalar, A Element, b Scalar) Element {
+	var aA, bG, r secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(ks(a), kp(A), &aA)
+	secp256k1.ScalarBaseMultNonConst(ks(b), &bG)
+	secp256k1.AddNonConst(&aA, &bG, &r)
+	return e.setJacobian(&r)
+}
+
+func (e *secp256k1Element) Set(p Element) Element {
+	e.p = *kp(p)
+	return e
+}
+
+func (e *secp256k1Element) Equal(p Element) int {
+	q := kp(p)
+	if e.p.X.Equals(&q.X) && e.p.Y.Equals(&q.Y) {
+		return 1
+	}
+	return 0
+}
+
+func (e *secp256k1Element) SetCanonicalBytes(b []byte) (Element, error) {
+	if len(b) != 33 || (b[0] != 0x02 && b[0] != 0x03) {
+		return nil, errInvalidEncoding
+	}
+	pk, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, errInvalidEncoding
+	}
+	pk.AsJacobian(&e.p)
+	return e, nil
+}
+
+func (e *secp256k1Element) Bytes() []byte {
+	if e.isIdentity() {
+		return make([]byte, 33)
+	}
+	return secp256k1.NewPublicKey(&e.p.X, &e.p.Y).SerializeCompressed()
+}
+
+// setJacobian sets the element to the point p, converting it to affine form.
+func (e *secp256k1Element) setJacobian(p *secp256k1.JacobianPoint) Element {
+	p.ToAffine()
+	e.p = *p
+	return e
+}
+
+// isIdentity reports whether the element is the identity element.
+func (e *secp256k1Element) isIdentity() bool {
+	return e.p.X.IsZero() && e.p.Y.IsZero()
+}
+
+// ks returns the secp256k1 scalar underlying s. Panics if s is not a Secp256k1 scalar.
+func ks(s Scalar) *secp256k1.ModNScalar {
+	return &s.(*secp256k1Scalar).s
+}
+
+// kp returns the secp256k1 point underlying e. Panics if e is not a Secp256k1 element.
+func kp(e Element) *secp256k1.JacobianPoint {
+	return &e.(*secp256k1Element).p
+}
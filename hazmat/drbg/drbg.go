@@ -0,0 +1,148 @@
+// Package drbg implements a deterministic random bit generator (DRBG) in the style of NIST SP 800-90A, built directly
+// on KT128.
+//
+// This is a low-level construction for users who need an auditable DRBG with explicit instantiate, reseed, and generate
+// functions. Most users should derive pseudorandom output from a thyrse.Protocol instead.
+//
+// The DRBG's internal state is a 32-byte value V. Each function evaluates KT128 with a function-specific customization
+// string over V and the function's inputs, each suffixed with right_encode of its byte length, and reads the new V from
+// the start of the output, followed by any generated bytes. Inputs shorter than the KT128 chunk size (8 KiB) are
+// processed by a single TurboSHAKE128 call. Because V is replaced before any output is returned, a compromise of the
+// state does not reveal previously generated output (backtracking resistance).
+//
+// Prediction resistance is provided by reseeding from an entropy source before each Generate call (see
+// [DRBG.SetPredictionResistance]).
+package drbg
+
+import (
+	"errors"
+	"io"
+
+	"github.com/codahale/kt128"
+	"github.com/codahale/thyrse/hazmat/secmem"
+	"github.com/codahale/thyrse/internal/enc"
+	"github.com/codahale/thyrse/internal/mem"
+)
+
+const (
+	// SecurityStrength is the security strength of the DRBG, in bytes.
+	SecurityStrength = 16
+
+	// MinEntropySize is the minimum size, in bytes, of entropy input to New and Reseed.
+	MinEntropySize = SecurityStrength
+
+	// MaxRequestSize is the maximum number of bytes which may be generated by a single call to Generate.
+	MaxRequestSize = 1 << 16
+
+	// ReseedInterval is the maximum number of Generate calls between reseeds.
+	ReseedInterval = 1 << 32
+)
+
+var (
+	// ErrInsufficientEntropy is returned when entropy input is shorter than MinEntropySize.
+	ErrInsufficientEntropy = errors.New("thyrse/drbg: insufficient entropy")
+
+	// ErrReseedRequired is returned by Generate when ReseedInterval calls have been made since the last reseed.
+	ErrReseedRequired = errors.New("thyrse/drbg: reseed required")
+
+	// ErrRequestTooLarge is returned by Generate when more than MaxRequestSize bytes are requested.
+	ErrRequestTooLarge = errors.New("thyrse/drbg: request too large")
+)
+
+// DRBG is a deterministic random bit generator. It is not safe for concurrent use.
+type DRBG struct {
+	v             [stateSize]byte
+	reseedCounter uint64
+	entropy       io.Reader
+}
+
+// New instantiates a DRBG with the given entropy input, nonce, and personalization string. The entropy input must be at
+// least MinEntropySize bytes.
+func New(entropy, nonce, personalization []byte) (*DRBG, error) {
+	if len(entropy) < MinEntropySize {
+		return nil, ErrInsufficientEntropy
+	}
+
+	d := &DRBG{reseedCounter: 1}
+	d.update(customInstantiate, nil, entropy, nonce, personalization)
+	return d, nil
+}
+
+// SetPredictionResistance configures the DRBG to reseed with MinEntropySize*2 bytes read from r before each call to
+// Generate. Passing nil disables prediction resistance.
+func (d *DRBG) SetPredictionResistance(r io.Reader) {
+	d.entropy = r
+}
+
+// Reseed mixes fresh entropy input and optional additional input into the DRBG's state. The entropy input must be at
+// least MinEntropySize bytes.
+func (d *DRBG) Reseed(entropy, additional []byte) error {
+	if len(entropy) < MinEntropySize {
+		return ErrInsufficientEntropy
+	}
+
+	d.update(customReseed, nil, d.v[:], entropy, additional)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate appends n bytes of pseudorandom output to dst and returns the resulting slice. Optional additional input is
+// mixed into the state before output is generated.
+func (d *DRBG) Generate(dst []byte, n int, additional []byte) ([]byte, error) {
+	if n > MaxRequestSize {
+		return nil, ErrRequestTooLarge
+	}
+
+	if d.entropy != nil {
+		var entropy [MinEntropySize * 2]byte
+		if _, err := io.ReadFull(d.entropy, entropy[:]); err != nil {
+			return nil, err
+		}
+		err := d.Reseed(entropy[:], additional)
+		secmem.Wipe(entropy[:])
+		if err != nil {
+			return nil, err
+		}
+		additional = nil
+	}
+
+	if d.reseedCounter > ReseedInterval {
+		return nil, ErrReseedRequired
+	}
+
+	ret, out := mem.SliceForAppend(dst, n)
+	d.update(customGenerate, out, d.v[:], additional)
+	d.reseedCounter++
+	return ret, nil
+}
+
+// Clear overwrites the DRBG's state with zeros. After Clear, the DRBG must not be used.
+func (d *DRBG) Clear() {
+	secmem.Wipe(d.v[:])
+	d.reseedCounter = ReseedInterval + 1
+	d.entropy = nil
+}
+
+// update replaces V with the first stateSize bytes of KT128(custom, inputs...), then fills out with the bytes which
+// follow. Each input is suffixed with right_encode of its length.
+func (d *DRBG) update(custom string, out []byte, inputs ...[]byte) {
+	h := kt128.New([]byte(custom))
+	var buf [enc.MaxIntSize]byte
+	for _, in := range inputs {
+		_, _ = h.Write(in)
+		_, _ = h.Write(enc.RightEncode(buf[:0], uint64(len(in))))
+	}
+	_, _ = h.Read(d.v[:])
+	if len(out) > 0 {
+		_, _ = h.Read(out)
+	}
+	h.Reset()
+}
+
+const (
+	stateSize = 32
+
+	customInstantiate = "thyrse drbg instantiate"
+	customReseed      = "thyrse drbg reseed"
+	customGenerate    = "thyrse drbg generate"
+)
@@ -0,0 +1,158 @@
+package drbg
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("insufficient entropy", func(t *testing.T) {
+		if _, err := New(make([]byte, MinEntropySize-1), nil, nil); !errors.Is(err, ErrInsufficientEntropy) {
+			t.Errorf("New() err = %v, want %v", err, ErrInsufficientEntropy)
+		}
+	})
+
+	t.Run("personalization", func(t *testing.T) {
+		entropy := bytes.Repeat([]byte{1}, 32)
+		a := generate(t, newDRBG(t, entropy, []byte("nonce"), []byte("a")), 32, nil)
+		b := generate(t, newDRBG(t, entropy, []byte("nonce"), []byte("b")), 32, nil)
+		if bytes.Equal(a, b) {
+			t.Error("different personalization strings produced identical output")
+		}
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		entropy := bytes.Repeat([]byte{1}, 32)
+		a := generate(t, newDRBG(t, entropy, []byte("a"), nil), 32, nil)
+		b := generate(t, newDRBG(t, entropy, []byte("b"), nil), 32, nil)
+		if bytes.Equal(a, b) {
+			t.Error("different nonces produced identical output")
+		}
+	})
+}
+
+func TestDRBG_Generate(t *testing.T) {
+	entropy := bytes.Repeat([]byte{1}, 32)
+
+	t.Run("known answer", func(t *testing.T) {
+		d := newDRBG(t, entropy, []byte("nonce"), []byte("personalization"))
+		generate(t, d, 32, nil)
+		got := generate(t, d, 32, []byte("additional"))
+		if want, _ := hex.DecodeString("f5a48cb7cef4cedaa0802c590e35701447fab73b69c9057f7e44854c2c273106"); !bytes.Equal(got, want) {
+			t.Errorf("Generate() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("sequential outputs differ", func(t *testing.T) {
+		d := newDRBG(t, entropy, nil, nil)
+		if bytes.Equal(generate(t, d, 32, nil), generate(t, d, 32, nil)) {
+			t.Error("sequential Generate calls produced identical output")
+		}
+	})
+
+	t.Run("additional input", func(t *testing.T) {
+		a := generate(t, newDRBG(t, entropy, nil, nil), 32, []byte("a"))
+		b := generate(t, newDRBG(t, entropy, nil, nil), 32, []byte("b"))
+		if bytes.Equal(a, b) {
+			t.Error("different additional input produced identical output")
+		}
+	})
+
+	t.Run("request too large", func(t *testing.T) {
+		d := newDRBG(t, entropy, nil, nil)
+		if _, err := d.Generate(nil, MaxRequestSize+1, nil); !errors.Is(err, ErrRequestTooLarge) {
+			t.Errorf("Generate() err = %v, want %v", err, ErrRequestTooLarge)
+		}
+	})
+
+	t.Run("reseed required", func(t *testing.T) {
+		d := newDRBG(t, entropy, nil, nil)
+		d.reseedCounter = ReseedInterval + 1
+		if _, err := d.Generate(nil, 32, nil); !errors.Is(err, ErrReseedRequired) {
+			t.Errorf("Generate() err = %v, want %v", err, ErrReseedRequired)
+		}
+		if err := d.Reseed(entropy, nil); err != nil {
+			t.Fatal(err)
+		}
+		generate(t, d, 32, nil)
+	})
+
+	t.Run("prediction resistance", func(t *testing.T) {
+		a := newDRBG(t, entropy, nil, nil)
+		a.SetPredictionResistance(bytes.NewReader(bytes.Repeat([]byte{2}, 64)))
+		b := newDRBG(t, entropy, nil, nil)
+		if bytes.Equal(generate(t, a, 32, nil), generate(t, b, 32, nil)) {
+			t.Error("prediction resistance did not reseed")
+		}
+
+		// Two reseeds of 32 bytes each consume the source.
+		generate(t, a, 32, nil)
+		if _, err := a.Generate(nil, 32, nil); err == nil {
+			t.Error("Generate() err = nil, want error from exhausted entropy source")
+		}
+	})
+
+	t.Run("entropy source error", func(t *testing.T) {
+		d := newDRBG(t, entropy, nil, nil)
+		er := &testdata.ErrReader{Err: errors.New("no entropy")}
+		d.SetPredictionResistance(er)
+		if _, err := d.Generate(nil, 32, nil); !errors.Is(err, er.Err) {
+			t.Errorf("Generate() err = %v, want %v", err, er.Err)
+		}
+	})
+}
+
+func TestDRBG_Reseed(t *testing.T) {
+	entropy := bytes.Repeat([]byte{1}, 32)
+
+	t.Run("changes output", func(t *testing.T) {
+		a := newDRBG(t, entropy, nil, nil)
+		b := newDRBG(t, entropy, nil, nil)
+		if err := b.Reseed(bytes.Repeat([]byte{2}, 32), nil); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(generate(t, a, 32, nil), generate(t, b, 32, nil)) {
+			t.Error("Reseed did not change output")
+		}
+	})
+
+	t.Run("insufficient entropy", func(t *testing.T) {
+		d := newDRBG(t, entropy, nil, nil)
+		if err := d.Reseed(nil, nil); !errors.Is(err, ErrInsufficientEntropy) {
+			t.Errorf("Reseed() err = %v, want %v", err, ErrInsufficientEntropy)
+		}
+	})
+}
+
+func TestDRBG_Clear(t *testing.T) {
+	d := newDRBG(t, bytes.Repeat([]byte{1}, 32), nil, nil)
+	d.Clear()
+	if d.v != [stateSize]byte{} {
+		t.Error("state not cleared")
+	}
+	if _, err := d.Generate(nil, 32, nil); !errors.Is(err, ErrReseedRequired) {
+		t.Errorf("Generate() err = %v, want %v", err, ErrReseedRequired)
+	}
+}
+
+func newDRBG(t *testing.T, entropy, nonce, personalization []byte) *DRBG {
+	t.Helper()
+	d, err := New(entropy, nonce, personalization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func generate(t *testing.T, d *DRBG, n int, additional []byte) []byte {
+	t.Helper()
+	out, err := d.Generate(nil, n, additional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
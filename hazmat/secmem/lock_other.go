@@ -0,0 +1,19 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package secmem
+
+import "errors"
+
+// Alloc returns errors.ErrUnsupported, as this platform does not support memory locking. Panics if n is not positive.
+func Alloc(n int) ([]byte, error) {
+	if n <= 0 {
+		panic("thyrse/secmem: invalid allocation size")
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// Free wipes b. Alloc never returns a buffer on this platform.
+func Free(b []byte) error {
+	Wipe(b[:cap(b)])
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package secmem
+
+import (
+	"os"
+	"syscall"
+)
+
+// Alloc returns a zeroed n-byte buffer in memory which is locked into RAM, so it is never written to swap. The buffer
+// occupies its own pages outside the Go heap, and MUST be released with Free. Returns errors.ErrUnsupported on
+// platforms without memory locking, or the system's error if the memory cannot be mapped or locked (e.g. because of a
+// resource limit on locked memory). Panics if n is not positive.
+func Alloc(n int) ([]byte, error) {
+	if n <= 0 {
+		panic("thyrse/secmem: invalid allocation size")
+	}
+
+	page := os.Getpagesize()
+	b, err := syscall.Mmap(-1, 0, (n+page-1)/page*page, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(b); err != nil {
+		_ = syscall.Munmap(b)
+		return nil, err
+	}
+	return b[:n], nil
+}
+
+// Free wipes, unlocks, and releases a buffer returned by Alloc. The buffer MUST NOT be used afterward.
+func Free(b []byte) error {
+	b = b[:cap(b)]
+	Wipe(b)
+	if err := syscall.Munlock(b); err != nil {
+		return err
+	}
+	return syscall.Munmap(b)
+}
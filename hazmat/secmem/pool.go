@@ -0,0 +1,60 @@
+package secmem
+
+import "sync"
+
+// slabSize is the size of the locked memory a Pool maps at a time.
+const slabSize = 64 << 10
+
+// A Pool hands out fixed-size buffers in memory locked into RAM. Rather than mapping and locking pages for each buffer,
+// as Alloc does, it carves them out of larger locked slabs and reuses them once they are returned, so buffers which are
+// allocated often cost neither a system call nor a page of locked memory apiece.
+//
+// Slabs are never released, so the locked memory a Pool holds is its high-water mark. A Pool is safe for concurrent use.
+type Pool struct {
+	size int
+
+	mu   sync.Mutex
+	free [][]byte
+}
+
+// NewPool returns a Pool of n-byte buffers. Panics if n is not positive.
+func NewPool(n int) *Pool {
+	if n <= 0 {
+		panic("thyrse/secmem: invalid allocation size")
+	}
+	return &Pool{size: n}
+}
+
+// Get returns a zeroed buffer from the pool, which MUST be returned with Put rather than Free. If the pool has no free
+// buffers, it allocates a new slab with Alloc, or a single buffer if the slab cannot be locked, and returns Alloc's error
+// if neither can be.
+func (p *Pool) Get() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.free) == 0 {
+		slab, err := Alloc(max(slabSize/p.size, 1) * p.size)
+		if err != nil {
+			if slab, err = Alloc(p.size); err != nil {
+				return nil, err
+			}
+		}
+		for i := 0; i+p.size <= len(slab); i += p.size {
+			p.free = append(p.free, slab[i:i+p.size:i+p.size])
+		}
+	}
+
+	b := p.free[len(p.free)-1]
+	p.free = p.free[:len(p.free)-1]
+	return b, nil
+}
+
+// Put wipes a buffer returned by Get and returns it to the pool. The buffer MUST NOT be used afterward.
+func (p *Pool) Put(b []byte) {
+	b = b[:cap(b)]
+	Wipe(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.free = append(p.free, b)
+}
@@ -0,0 +1,24 @@
+// Package secmem provides utilities for zeroizing sensitive data, such as keys and intermediate secrets, in memory.
+//
+// Go's built-in clear is usually sufficient, but the compiler is free to eliminate a store to memory which is never
+// read again (e.g. a key in a stack-allocated array cleared just before a function returns). The functions in this
+// package are not inlined and keep the wiped memory reachable until the wipe is complete, so the zeroing cannot be
+// removed as a dead store.
+//
+// Zeroization is best-effort: the Go runtime may have copied the data elsewhere (e.g. when growing a stack or a slice),
+// and those copies are not wiped.
+//
+// For secrets which must not be written to swap, Alloc returns buffers in memory locked into RAM, outside the Go heap,
+// which Free wipes and releases. A Pool hands out buffers of a fixed size from shared locked slabs, for secrets which
+// are allocated often.
+package secmem
+
+import "runtime"
+
+// Wipe overwrites b with zeros.
+//
+//go:noinline
+func Wipe(b []byte) {
+	clear(b)
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,98 @@
+package secmem_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/hazmat/secmem"
+)
+
+func TestWipe(t *testing.T) {
+	t.Run("stack array", func(t *testing.T) {
+		var key [32]byte
+		for i := range key {
+			key[i] = byte(i + 1)
+		}
+		secmem.Wipe(key[:])
+		if got, want := key[:], make([]byte, 32); !bytes.Equal(got, want) {
+			t.Errorf("Wipe() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("subslice", func(t *testing.T) {
+		b := bytes.Repeat([]byte{0xff}, 32)
+		secmem.Wipe(b[8:24])
+		want := slices.Concat(bytes.Repeat([]byte{0xff}, 8), make([]byte, 16), bytes.Repeat([]byte{0xff}, 8))
+		if !bytes.Equal(b, want) {
+			t.Errorf("Wipe() = %x, want = %x", b, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		secmem.Wipe(nil)
+	})
+}
+
+func TestAlloc(t *testing.T) {
+	b, err := secmem.Alloc(100)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("memory locking is not supported")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := b, make([]byte, 100); !bytes.Equal(got, want) {
+		t.Errorf("Alloc() = %x, want = %x", got, want)
+	}
+	if got, want := cap(b)%os.Getpagesize(), 0; got != want {
+		t.Errorf("cap(Alloc())%%pagesize = %d, want = %d", got, want)
+	}
+
+	copy(b, "secret")
+	if err := secmem.Free(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPool(t *testing.T) {
+	pool := secmem.NewPool(100)
+	a, err := pool.Get()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("memory locking is not supported")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a, make([]byte, 100); !bytes.Equal(got, want) {
+		t.Errorf("Get() = %x, want = %x", got, want)
+	}
+	if got, want := cap(a), 100; got != want {
+		t.Errorf("cap(Get()) = %d, want = %d", got, want)
+	}
+
+	copy(a, "secret")
+	copy(b, "secret")
+	if b[0] != 's' || a[0] != 's' {
+		t.Error("Get() returned overlapping buffers")
+	}
+
+	pool.Put(a)
+	c, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &c[0] != &a[0] {
+		t.Error("Get() did not reuse a returned buffer")
+	}
+	if got, want := c, make([]byte, 100); !bytes.Equal(got, want) {
+		t.Errorf("Get() = %x, want = %x", got, want)
+	}
+	pool.Put(b)
+	pool.Put(c)
+}
@@ -0,0 +1,70 @@
+// Package shamir implements the Shamir secret sharing shared by the threshold schemes (e.g. frost, thresholdvrf, and
+// quorum) over any prime-order group: deriving a dealer's secret polynomial, evaluating it at participants'
+// identifiers, and the Lagrange coefficients which interpolate shares at zero.
+//
+// Identifiers are 1-based 16-bit integers, so a polynomial can be split into at most MaxShares shares.
+package shamir
+
+import (
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+)
+
+// MaxShares is the maximum number of shares, as identifiers are 16-bit and zero is reserved for the secret.
+const MaxShares = 0xffff
+
+// NewPolynomial derives the threshold coefficients of a secret polynomial of degree threshold-1 from the protocol, with
+// the label "coefficient". The constant term, coeffs[0], is the shared secret.
+func NewPolynomial(p *thyrse.Protocol, g group.Group, threshold int) []group.Scalar {
+	coeffs := make([]group.Scalar, threshold)
+	for i := range coeffs {
+		coeffs[i] = group.DeriveScalar(p, g, "coefficient")
+	}
+	return coeffs
+}
+
+// Eval evaluates the polynomial f(x) = coeffs[0] + coeffs[1]*x + ... + coeffs[t-1]*x^(t-1) using Horner's method.
+func Eval(g group.Group, coeffs []group.Scalar, x uint16) group.Scalar {
+	xScalar := g.NewScalar().SetUint64(uint64(x))
+	result := g.NewScalar().Set(coeffs[len(coeffs)-1])
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		result.Multiply(result, xScalar)
+		result.Add(result, coeffs[i])
+	}
+	return result
+}
+
+// Deal evaluates the polynomial at identifiers 1 through n, returning the shares and their verifying shares [f(i)]G,
+// where shares[i] belongs to the participant with identifier i+1. Panics if n is greater than MaxShares, as identifiers
+// would wrap around to zero and reveal the secret.
+func Deal(g group.Group, coeffs []group.Scalar, n int) (shares []group.Scalar, verifyingShares []group.Element) {
+	if n > MaxShares {
+		panic("thyrse/shamir: too many shares")
+	}
+
+	shares = make([]group.Scalar, n)
+	verifyingShares = make([]group.Element, n)
+	for i := range n {
+		shares[i] = Eval(g, coeffs, uint16(i+1))
+		verifyingShares[i] = g.NewElement().ScalarBaseMult(shares[i])
+	}
+	return shares, verifyingShares
+}
+
+// Lagrange computes the Lagrange interpolation coefficient at x=0 for the given identifier among the identifiers:
+//
+//	λ_i = Π_{j∈S, j≠i} (j / (j - i))
+func Lagrange(g group.Group, identifier uint16, identifiers []uint16) group.Scalar {
+	i := g.NewScalar().SetUint64(uint64(identifier))
+	num := g.NewScalar().SetUint64(1)
+	den := g.NewScalar().SetUint64(1)
+	for _, id := range identifiers {
+		if id == identifier {
+			continue
+		}
+		j := g.NewScalar().SetUint64(uint64(id))
+		num.Multiply(num, j)
+		den.Multiply(den, j.Subtract(j, i))
+	}
+	return num.Multiply(num, den.Invert(den))
+}
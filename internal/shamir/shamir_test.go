@@ -0,0 +1,54 @@
+package shamir
+
+import (
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+)
+
+func TestDeal(t *testing.T) {
+	g := group.Ristretto255
+	p := thyrse.New("test")
+	p.Mix("seed", []byte("dealer"))
+	coeffs := NewPolynomial(p, g, 3)
+	shares, verifyingShares := Deal(g, coeffs, 5)
+
+	t.Run("verifying shares", func(t *testing.T) {
+		for i, share := range shares {
+			if g.NewElement().ScalarBaseMult(share).Equal(verifyingShares[i]) != 1 {
+				t.Errorf("verifyingShares[%d] != [shares[%d]]G", i, i)
+			}
+		}
+	})
+
+	t.Run("interpolation", func(t *testing.T) {
+		for _, ids := range [][]uint16{{1, 2, 3}, {2, 4, 5}, {5, 1, 3}, {1, 2, 3, 4, 5}} {
+			secret := g.NewScalar()
+			for _, id := range ids {
+				secret.Add(secret, g.NewScalar().Multiply(Lagrange(g, id, ids), shares[id-1]))
+			}
+			if secret.Equal(coeffs[0]) != 1 {
+				t.Errorf("shares %v did not interpolate to the secret", ids)
+			}
+		}
+
+		ids := []uint16{1, 2}
+		secret := g.NewScalar()
+		for _, id := range ids {
+			secret.Add(secret, g.NewScalar().Multiply(Lagrange(g, id, ids), shares[id-1]))
+		}
+		if secret.Equal(coeffs[0]) == 1 {
+			t.Error("fewer than threshold shares interpolated to the secret")
+		}
+	})
+
+	t.Run("too many shares", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Deal(MaxShares+1) did not panic")
+			}
+		}()
+		Deal(g, coeffs, MaxShares+1)
+	})
+}
@@ -0,0 +1,31 @@
+package thyrse
+
+// SealMessage encrypts and authenticates a single message with the given key and nonce, authenticates the associated
+// data, and returns the ciphertext with a [TagSize]-byte tag appended. It is equivalent to:
+//
+//	p := New(domain)
+//	p.Mix("key", key)
+//	p.Mix("nonce", nonce)
+//	p.Mix("ad", ad)
+//	ciphertext := p.Seal("message", nil, plaintext)
+//
+// The key MUST be secret and unpredictable, and a nonce MUST NOT be reused with the same key and domain. Messages
+// sealed with SealMessage can be opened with the aead scheme's cipher.AEAD using the same domain and key, and vice
+// versa.
+func SealMessage(domain string, key, nonce, ad, plaintext []byte) []byte {
+	return newMessage(domain, key, nonce, ad).Seal("message", nil, plaintext)
+}
+
+// OpenMessage decrypts and authenticates a ciphertext produced by [SealMessage] with the same domain, key, nonce, and
+// associated data. On failure, returns an error wrapping ErrInvalidCiphertext.
+func OpenMessage(domain string, key, nonce, ad, ciphertext []byte) ([]byte, error) {
+	return newMessage(domain, key, nonce, ad).Open("message", nil, ciphertext)
+}
+
+func newMessage(domain string, key, nonce, ad []byte) *Protocol {
+	p := New(domain)
+	p.Mix("key", key)
+	p.Mix("nonce", nonce)
+	p.Mix("ad", ad)
+	return p
+}
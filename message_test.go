@@ -0,0 +1,51 @@
+package thyrse
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealMessage(t *testing.T) {
+	key, nonce, ad := []byte("my-secret-key"), []byte("actually random"), []byte("some authenticated data")
+	plaintext := []byte("hello world")
+	ciphertext := SealMessage("test.message", key, nonce, ad, plaintext)
+
+	t.Run("transcript", func(t *testing.T) {
+		p := New("test.message")
+		p.Mix("key", key)
+		p.Mix("nonce", nonce)
+		p.Mix("ad", ad)
+		if got, want := ciphertext, p.Seal("message", nil, plaintext); !bytes.Equal(got, want) {
+			t.Errorf("SealMessage() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := OpenMessage("test.message", key, nonce, ad, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := plaintext; !bytes.Equal(got, want) {
+			t.Errorf("OpenMessage() = %q, want = %q", got, want)
+		}
+	})
+
+	for _, c := range []struct {
+		name                       string
+		domain                     string
+		key, nonce, ad, ciphertext []byte
+	}{
+		{"wrong domain", "test.other", key, nonce, ad, ciphertext},
+		{"wrong key", "test.message", []byte("other key"), nonce, ad, ciphertext},
+		{"wrong nonce", "test.message", key, []byte("other nonce"), ad, ciphertext},
+		{"wrong ad", "test.message", key, nonce, []byte("other ad"), ciphertext},
+		{"truncated", "test.message", key, nonce, ad, ciphertext[:TagSize-1]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := OpenMessage(c.domain, c.key, c.nonce, c.ad, c.ciphertext); !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("OpenMessage() err = %v, want = %v", err, ErrInvalidCiphertext)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+//go:build thyrse_experimental
+
+package thyrse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	build := func(pl *Pipeline) *Pipeline {
+		return pl.Mix("key", []byte("secret")).
+			Mix("nonce", []byte("nonce")).
+			Derive("prf", 17).
+			Mix("ad", nil).
+			Seal("message", []byte("hello, world")).
+			Seal("empty", nil).
+			Mix("trailer", []byte("trailer"))
+	}
+	direct := func(p *Protocol, dst []byte) []byte {
+		p.Mix("key", []byte("secret"))
+		p.Mix("nonce", []byte("nonce"))
+		dst = p.Derive("prf", dst, 17)
+		p.Mix("ad", nil)
+		dst = p.Seal("message", dst, []byte("hello, world"))
+		dst = p.Seal("empty", dst, nil)
+		p.Mix("trailer", []byte("trailer"))
+		return dst
+	}
+
+	t.Run("equivalent to direct calls", func(t *testing.T) {
+		p1, p2 := New("test"), New("test")
+		got := build(new(Pipeline)).Run(p1, []byte("prefix"))
+		want := direct(p2, []byte("prefix"))
+		if !bytes.Equal(got, want) {
+			t.Errorf("Run() = %x, want %x", got, want)
+		}
+		if p1.Equal(p2) != 1 {
+			t.Error("Run() and direct calls left different states")
+		}
+	})
+
+	t.Run("auto-ratchet", func(t *testing.T) {
+		p1, p2 := New("test"), New("test")
+		p1.SetAutoRatchet(16)
+		p2.SetAutoRatchet(16)
+		got := build(new(Pipeline)).Run(p1, nil)
+		want := direct(p2, nil)
+		if !bytes.Equal(got, want) {
+			t.Errorf("Run() = %x, want %x", got, want)
+		}
+		if p1.Equal(p2) != 1 {
+			t.Error("Run() and direct calls left different states")
+		}
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		pl := build(new(Pipeline))
+		p := New("test")
+		out := pl.Run(p, nil)
+		pl.Reset()
+		build(pl)
+		out = pl.Run(p, out[:0])
+
+		p2 := New("test")
+		direct(p2, nil)
+		if want := direct(p2, nil); !bytes.Equal(out, want) {
+			t.Errorf("Run() = %x, want %x", out, want)
+		}
+		key, nonce := []byte("secret"), []byte("nonce")
+		if allocs := testing.AllocsPerRun(10, func() {
+			pl.Reset()
+			pl.Mix("key", key).Mix("nonce", nonce).Derive("prf", 16)
+			out = pl.Run(p, out[:0])
+		}); allocs != 0 {
+			t.Errorf("Run() allocs = %v, want 0", allocs)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		p1, p2 := New("test"), New("test")
+		p1.Mix("key", []byte("secret"))
+		p1.Derive("output", nil, 16)
+		p1.Seal("message", nil, make([]byte, 20))
+
+		new(Pipeline).Mix("key", []byte("secret")).Derive("output", 16).Seal("message", make([]byte, 20)).Run(p2, nil)
+		if got, want := p2.Stats(), p1.Stats(); got != want {
+			t.Errorf("Stats() = %+v, want = %+v", got, want)
+		}
+	})
+
+	t.Run("rekey policy", func(t *testing.T) {
+		p, q := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+		p.SetRekeyPolicy(RekeyPolicy{Messages: 1})
+		q.SetRekeyPolicy(RekeyPolicy{Messages: 1})
+
+		p.Seal("message", nil, []byte("hello"))
+		p.Seal("message", nil, []byte("world"))
+		new(Pipeline).Seal("message", []byte("hello")).Seal("message", []byte("world")).Run(q, nil)
+		if p.Equal(q) != 1 {
+			t.Error("pipeline did not apply the rekey policy")
+		}
+	})
+}
+
+func BenchmarkPipeline_Packet(b *testing.B) {
+	nonce, ad, payload := make([]byte, 16), make([]byte, 8), make([]byte, 1200)
+	out := make([]byte, 0, len(payload)+TagSize)
+
+	b.Run("direct", func(b *testing.B) {
+		base := New("bench")
+		b.ReportAllocs()
+		for b.Loop() {
+			p := base.Clone()
+			p.Mix("nonce", nonce)
+			p.Mix("ad", ad)
+			out = p.Seal("packet", out[:0], payload)
+		}
+	})
+
+	b.Run("pipeline", func(b *testing.B) {
+		base := New("bench")
+		var pl Pipeline
+		b.ReportAllocs()
+		for b.Loop() {
+			pl.Reset()
+			out = pl.Mix("nonce", nonce).Mix("ad", ad).Seal("packet", payload).Run(base.Clone(), out[:0])
+		}
+	})
+}
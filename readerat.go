@@ -0,0 +1,81 @@
+package thyrse
+
+import (
+	"errors"
+	"io"
+	"runtime"
+
+	"github.com/codahale/thyrse/internal/enc"
+)
+
+// MixReaderAt absorbs the first size bytes of r into the protocol transcript. It is equivalent to calling
+// [Protocol.Mix] with those bytes, but does not require them to be in memory, which makes it suitable for pre-hashing
+// large files.
+//
+// MixReaderAt parallelizes I/O, not hashing. Sections of r are read concurrently by up to GOMAXPROCS goroutines ahead
+// of the hasher, so slow or high-latency storage (e.g. network file systems or object stores) does not stall
+// absorption. The sections are then absorbed in order by the protocol's single KT128 instance, which uses the SIMD
+// parallelism available on the platform but is not spread across goroutines: the transcript's KT128 leaves span
+// everything absorbed before the label, so hashing sections independently would produce a different transcript than
+// Mix. Callers which need multi-core hashing of very large inputs should hash them with a separate construction and
+// Mix the digest instead.
+//
+// If r returns an error or has fewer than size bytes, MixReaderAt returns the error (io.ErrUnexpectedEOF for a short
+// input) and clears the protocol, as its transcript would otherwise contain an incomplete frame. The protocol MUST NOT
+// be used afterward.
+func (p *Protocol) MixReaderAt(label string, r io.ReaderAt, size int64) error {
+	if size < 0 {
+		panic("thyrse: MixReaderAt size must not be negative")
+	}
+
+	p.writeLabel(label)
+
+	type section struct {
+		buf []byte
+		err error
+	}
+
+	// Start reads in order, bounding the number of sections in flight.
+	done := make(chan struct{})
+	defer close(done)
+	queue := make(chan chan section, runtime.GOMAXPROCS(0))
+	go func() {
+		defer close(queue)
+		for off := int64(0); off < size; off += readerAtChunkSize {
+			ch := make(chan section, 1)
+			select {
+			case queue <- ch:
+			case <-done:
+				return
+			}
+			go func() {
+				buf := make([]byte, min(readerAtChunkSize, size-off))
+				n, err := r.ReadAt(buf, off)
+				if n == len(buf) {
+					err = nil
+				} else if err == nil || errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				ch <- section{buf, err}
+			}()
+		}
+	}()
+
+	// Absorb sections in order as they complete.
+	for ch := range queue {
+		s := <-ch
+		if s.err != nil {
+			p.Clear()
+			return s.err
+		}
+		p.write(s.buf)
+	}
+
+	var buf [enc.MaxIntSize + 1]byte
+	b := enc.RightEncode(buf[:0], uint64(size))
+	b = append(b, opMix)
+	p.write(b)
+	p.stats.Mixes++
+	p.maybeRatchet()
+	return nil
+}
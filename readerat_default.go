@@ -0,0 +1,6 @@
+//go:build !thyrse_lowmem
+
+package thyrse
+
+// readerAtChunkSize is the size of each section read by [Protocol.MixReaderAt].
+const readerAtChunkSize = 1 << 20
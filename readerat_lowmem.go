@@ -0,0 +1,7 @@
+//go:build thyrse_lowmem
+
+package thyrse
+
+// readerAtChunkSize is the size of each section read by [Protocol.MixReaderAt]. With the thyrse_lowmem build tag, it is
+// reduced so that MixReaderAt buffers at most a few KiB per section on memory-constrained devices.
+const readerAtChunkSize = 4 << 10
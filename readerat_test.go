@@ -0,0 +1,53 @@
+package thyrse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+)
+
+func TestMixReaderAt(t *testing.T) {
+	drbg := testdata.New("thyrse mix reader at")
+	data := drbg.Data(3*readerAtChunkSize + 1234)
+
+	for _, size := range []int{0, 1, readerAtChunkSize, len(data)} {
+		p1, p2 := New("test"), New("test")
+		p1.Mix("file", data[:size])
+		if err := p2.MixReaderAt("file", bytes.NewReader(data), int64(size)); err != nil {
+			t.Fatalf("MixReaderAt(%d) err = %v", size, err)
+		}
+		if p1.Equal(p2) != 1 {
+			t.Errorf("MixReaderAt(%d) transcript differs from Mix", size)
+		}
+	}
+
+	t.Run("short input", func(t *testing.T) {
+		p := New("test")
+		err := p.MixReaderAt("file", bytes.NewReader(data[:100]), 200)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("MixReaderAt() err = %v, want = %v", err, io.ErrUnexpectedEOF)
+		}
+		if p.h != nil {
+			t.Error("protocol not cleared after error")
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		want := errors.New("disk on fire")
+		p := New("test")
+		if err := p.MixReaderAt("file", errReaderAt{want}, int64(len(data))); !errors.Is(err, want) {
+			t.Errorf("MixReaderAt() err = %v, want = %v", err, want)
+		}
+	})
+}
+
+type errReaderAt struct {
+	err error
+}
+
+func (e errReaderAt) ReadAt([]byte, int64) (int, error) {
+	return 0, e.err
+}
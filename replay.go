@@ -0,0 +1,123 @@
+package thyrse
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrReplayed is returned by [ReplayWindow] when a sequence number has already been accepted or is too old to be
+// checked.
+var ErrReplayed = errors.New("thyrse: replayed or stale sequence number")
+
+// ReplayWindow provides anti-replay protection for datagrams which may arrive out of order, like the sliding windows of
+// IPsec and DTLS. It tracks the highest sequence number accepted and a bitmap of which of the preceding sequence
+// numbers have been accepted; sequence numbers older than the window are rejected.
+//
+// Senders seal each datagram with [SealDatagram] and a unique, increasing sequence number sent alongside it; receivers
+// open it with [ReplayWindow.OpenDatagram].
+//
+// A ReplayWindow is safe for concurrent use.
+type ReplayWindow struct {
+	mu       sync.Mutex
+	bits     []uint64
+	size     uint64
+	highest  uint64
+	accepted bool
+}
+
+// NewReplayWindow returns a ReplayWindow which accepts sequence numbers up to size positions behind the highest
+// sequence number accepted so far. The size is rounded up to a multiple of 64. Panics if size is not positive.
+func NewReplayWindow(size int) *ReplayWindow {
+	if size <= 0 {
+		panic("thyrse: ReplayWindow size must be positive")
+	}
+
+	n := (size + 63) / 64
+	return &ReplayWindow{bits: make([]uint64, n), size: uint64(n) * 64}
+}
+
+// Check returns ErrReplayed if seq has already been accepted or is too old to be checked, and nil otherwise. It does
+// not modify the window; call [ReplayWindow.Accept] once the datagram has been authenticated.
+func (w *ReplayWindow) Check(seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.check(seq)
+}
+
+// Accept marks seq as accepted, returning ErrReplayed if it has already been accepted or is too old to be checked.
+// Only sequence numbers of authenticated datagrams should be accepted, otherwise an attacker can advance the window
+// and cause legitimate datagrams to be rejected.
+func (w *ReplayWindow) Accept(seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.check(seq); err != nil {
+		return err
+	}
+
+	// Advance the window, clearing the bits of the sequence numbers it passes over.
+	if !w.accepted || seq > w.highest {
+		if !w.accepted || seq-w.highest >= w.size {
+			clear(w.bits)
+		} else {
+			for n := seq - w.highest; n > 0; n-- {
+				w.clearBit(seq - n + 1)
+			}
+		}
+		w.highest, w.accepted = seq, true
+	}
+	w.bits[(seq/64)%uint64(len(w.bits))] |= 1 << (seq % 64)
+	return nil
+}
+
+// OpenDatagram opens a datagram sealed with [SealDatagram] with the given sequence number. The protocol is not
+// modified. The sequence number is checked before and accepted after the datagram is authenticated, so forged
+// datagrams do not advance the window.
+//
+// Returns ErrReplayed if the sequence number has already been accepted or is too old, or an error wrapping
+// ErrInvalidCiphertext if the datagram cannot be authenticated.
+func (w *ReplayWindow) OpenDatagram(p *Protocol, label string, seq uint64, dst, sealed []byte) ([]byte, error) {
+	if err := w.Check(seq); err != nil {
+		return nil, err
+	}
+
+	clone := p.Clone()
+	clone.MixUint64("sequence", seq)
+	plaintext, err := clone.Open(label, dst, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.Accept(seq); err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// SealDatagram seals a datagram with the given sequence number, which must be unique for the protocol's state. The
+// protocol is not modified, so datagrams can be opened independently of one another and in any order.
+func SealDatagram(p *Protocol, label string, seq uint64, dst, plaintext []byte) []byte {
+	clone := p.Clone()
+	clone.MixUint64("sequence", seq)
+	return clone.Seal(label, dst, plaintext)
+}
+
+// check returns ErrReplayed if seq has been accepted or is outside the window.
+func (w *ReplayWindow) check(seq uint64) error {
+	switch {
+	case !w.accepted || seq > w.highest:
+		return nil
+	case w.highest-seq >= w.size:
+		return ErrReplayed
+	case w.bits[(seq/64)%uint64(len(w.bits))]&(1<<(seq%64)) != 0:
+		return ErrReplayed
+	default:
+		return nil
+	}
+}
+
+// clearBit marks seq as not accepted.
+func (w *ReplayWindow) clearBit(seq uint64) {
+	w.bits[(seq/64)%uint64(len(w.bits))] &^= 1 << (seq % 64)
+}
@@ -0,0 +1,141 @@
+package thyrse
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplayWindow(t *testing.T) {
+	t.Run("in order", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		for seq := range uint64(200) {
+			if err := w.Accept(seq); err != nil {
+				t.Fatalf("Accept(%d) = %v, want nil", seq, err)
+			}
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		for _, seq := range []uint64{0, 5, 3} {
+			if err := w.Accept(seq); err != nil {
+				t.Fatalf("Accept(%d) = %v, want nil", seq, err)
+			}
+		}
+		for _, seq := range []uint64{0, 5, 3} {
+			if err := w.Accept(seq); !errors.Is(err, ErrReplayed) {
+				t.Errorf("Accept(%d) = %v, want %v", seq, err, ErrReplayed)
+			}
+		}
+		if err := w.Check(4); err != nil {
+			t.Errorf("Check(4) = %v, want nil", err)
+		}
+	})
+
+	t.Run("out of order within window", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		if err := w.Accept(100); err != nil {
+			t.Fatal(err)
+		}
+		for _, seq := range []uint64{99, 37, 50} {
+			if err := w.Accept(seq); err != nil {
+				t.Errorf("Accept(%d) = %v, want nil", seq, err)
+			}
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		if err := w.Accept(100); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Accept(36); !errors.Is(err, ErrReplayed) {
+			t.Errorf("Accept(36) = %v, want %v", err, ErrReplayed)
+		}
+	})
+
+	t.Run("window advances", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		if err := w.Accept(10); err != nil {
+			t.Fatal(err)
+		}
+		// Advancing by exactly the window's size must not leave sequence number 74's bit set from 10.
+		if err := w.Accept(73); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Accept(74); err != nil {
+			t.Errorf("Accept(74) = %v, want nil", err)
+		}
+		if err := w.Accept(1000); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Accept(999); err != nil {
+			t.Errorf("Accept(999) = %v, want nil", err)
+		}
+	})
+
+	t.Run("maximum sequence number", func(t *testing.T) {
+		w := NewReplayWindow(64)
+		for _, seq := range []uint64{1<<64 - 3, 1<<64 - 1} {
+			if err := w.Accept(seq); err != nil {
+				t.Fatalf("Accept(%d) = %v, want nil", seq, err)
+			}
+		}
+		if err := w.Accept(1<<64 - 1); !errors.Is(err, ErrReplayed) {
+			t.Errorf("Accept() = %v, want %v", err, ErrReplayed)
+		}
+		if err := w.Accept(1<<64 - 2); err != nil {
+			t.Errorf("Accept() = %v, want nil", err)
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("NewReplayWindow(0) did not panic")
+			}
+		}()
+		NewReplayWindow(0)
+	})
+}
+
+func TestOpenDatagram(t *testing.T) {
+	sender, receiver := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+	w := NewReplayWindow(64)
+
+	d1 := SealDatagram(sender, "datagram", 1, nil, []byte("one"))
+	d2 := SealDatagram(sender, "datagram", 2, nil, []byte("two"))
+
+	for _, tc := range []struct {
+		seq      uint64
+		datagram []byte
+		want     string
+	}{{2, d2, "two"}, {1, d1, "one"}} {
+		got, err := w.OpenDatagram(receiver, "datagram", tc.seq, nil, tc.datagram)
+		if err != nil {
+			t.Fatalf("OpenDatagram(%d) err = %v", tc.seq, err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("OpenDatagram(%d) = %q, want %q", tc.seq, got, tc.want)
+		}
+	}
+
+	t.Run("replayed", func(t *testing.T) {
+		if _, err := w.OpenDatagram(receiver, "datagram", 1, nil, d1); !errors.Is(err, ErrReplayed) {
+			t.Errorf("OpenDatagram() err = %v, want %v", err, ErrReplayed)
+		}
+	})
+
+	t.Run("wrong sequence number", func(t *testing.T) {
+		if _, err := w.OpenDatagram(receiver, "datagram", 3, nil, d1); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("OpenDatagram() err = %v, want %v", err, ErrInvalidCiphertext)
+		}
+		if err := w.Check(3); err != nil {
+			t.Errorf("forged datagram advanced the window: Check(3) = %v", err)
+		}
+	})
+
+	if sender.Equal(newKeyed("test", []byte("key"))) != 1 || receiver.Equal(newKeyed("test", []byte("key"))) != 1 {
+		t.Error("SealDatagram or OpenDatagram modified the protocol")
+	}
+}
@@ -0,0 +1,181 @@
+package thyrse
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/codahale/thyrse/hazmat/secmem"
+)
+
+// RollingKey derives a schedule of symmetric keys from a protocol, one for each epoch of a fixed interval of wall-clock
+// time. The previous epoch's key remains available alongside the current one, giving keys an overlapping validity
+// window (e.g. for session ticket or cookie encryption keys, where values encrypted under the previous key should still
+// be accepted).
+//
+// Epochs are counted from the Unix epoch (epoch n begins at n*interval after 1970-01-01T00:00:00Z), so parties which
+// create a RollingKey from identical protocol states with the same interval derive the same key for each epoch,
+// whenever they were created and however long they were idle.
+//
+// Epoch keys are the leaves of a binary tree of secrets rooted in a [Protocol.Derive] output, and only the subtrees
+// covering the current and later epochs are retained. Compromising a RollingKey's state therefore reveals neither the
+// keys of earlier epochs nor the previous key once it has been rolled out. Moving to a new epoch derives at most 64
+// nodes of the tree, no matter how many epochs have elapsed. Superseded keys and secrets are wiped.
+//
+// A RollingKey is safe for concurrent use.
+type RollingKey struct {
+	mu                sync.Mutex
+	label             string
+	size              int
+	interval          time.Duration
+	epoch             uint64
+	nodes             []rollingNode
+	current, previous []byte
+	now               func() time.Time
+}
+
+// RollingKey returns a RollingKey which derives size-byte keys with the given label, rolling to a new key at the start
+// of every interval. The interval is bound into the keys. The current and previous keys are derived immediately.
+//
+// The RollingKey takes ownership of the protocol and clears it, so it MUST NOT be used afterward. Panics if size or
+// interval is not positive.
+func (p *Protocol) RollingKey(label string, size int, interval time.Duration) *RollingKey {
+	return p.rollingKey(label, size, interval, time.Now)
+}
+
+func (p *Protocol) rollingKey(label string, size int, interval time.Duration, now func() time.Time) *RollingKey {
+	if size <= 0 || interval <= 0 {
+		panic("thyrse: RollingKey size and interval must be positive")
+	}
+
+	p.MixUint64("interval", uint64(interval))
+	root := rollingNode{}
+	p.Derive(label, root.secret[:0], len(root.secret))
+	p.Clear()
+
+	k := &RollingKey{label: label, size: size, interval: interval, nodes: []rollingNode{root}, now: now}
+	k.epoch = k.epochAt(now())
+	if k.epoch > 0 {
+		k.previous = k.key(k.epoch - 1)
+	}
+	k.current = k.key(k.epoch)
+	return k
+}
+
+// Current returns a copy of the current epoch's key, first rolling to a new key if a new epoch has begun.
+func (k *RollingKey) Current() []byte {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.update()
+	return bytes.Clone(k.current)
+}
+
+// Previous returns a copy of the previous epoch's key, or nil in the first epoch. It first rolls to a new key if a new
+// epoch has begun.
+func (k *RollingKey) Previous() []byte {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.update()
+	return bytes.Clone(k.previous)
+}
+
+// epochAt returns the number of the epoch containing t. Times before the Unix epoch are in epoch zero.
+func (k *RollingKey) epochAt(t time.Time) uint64 {
+	return uint64(max(t.Sub(time.Unix(0, 0)), 0) / k.interval)
+}
+
+// update moves directly to the current epoch if a new one has begun. If the clock moves backward, the keys are
+// unchanged.
+func (k *RollingKey) update() {
+	e := k.epochAt(k.now())
+	if e <= k.epoch {
+		return
+	}
+
+	secmem.Wipe(k.previous)
+	if e-1 == k.epoch {
+		k.previous = k.current
+	} else {
+		secmem.Wipe(k.current)
+		k.previous = k.key(e - 1)
+	}
+	k.current = k.key(e)
+	k.epoch = e
+}
+
+// key derives the key for epoch e, which must follow every epoch derived so far.
+func (k *RollingKey) key(e uint64) []byte {
+	leaf := k.leaf(e)
+	defer secmem.Wipe(leaf[:])
+
+	p := New("thyrse.rolling-key")
+	defer p.Clear()
+	p.Mix("leaf", leaf[:])
+	return p.Derive(k.label, nil, k.size)
+}
+
+// leaf returns the leaf secret for epoch e, which must follow every epoch derived so far, and discards the subtrees of
+// epochs up to and including e.
+//
+// The nodes form a stack of disjoint subtrees covering every epoch from the next underived one onward, with the
+// subtree of the earliest epochs on top.
+func (k *RollingKey) leaf(e uint64) [32]byte {
+	// Discard subtrees which lie entirely before e.
+	for !k.nodes[len(k.nodes)-1].contains(e) {
+		k.pop()
+	}
+
+	// Split the subtree containing e until its leaf is reached, retaining the subtrees to the right of e.
+	for {
+		n := k.pop()
+		if n.depth == 64 {
+			return n.secret
+		}
+
+		left, right := n.split()
+		secmem.Wipe(n.secret[:])
+		k.nodes = append(k.nodes, right)
+		if right.contains(e) {
+			secmem.Wipe(left.secret[:])
+		} else {
+			k.nodes = append(k.nodes, left)
+		}
+	}
+}
+
+// pop removes the top node from the stack, wiping its place in the stack, and returns it.
+func (k *RollingKey) pop() rollingNode {
+	top := &k.nodes[len(k.nodes)-1]
+	n := *top
+	secmem.Wipe(top.secret[:])
+	k.nodes = k.nodes[:len(k.nodes)-1]
+	return n
+}
+
+// rollingNode is a node in a RollingKey's tree of secrets. It covers the epochs which share the first depth bits of
+// start; leaves have a depth of 64 and cover a single epoch.
+type rollingNode struct {
+	secret [32]byte
+	start  uint64
+	depth  uint8
+}
+
+// contains reports whether the node covers epoch e.
+func (n *rollingNode) contains(e uint64) bool {
+	return n.depth == 0 || e>>(64-n.depth) == n.start>>(64-n.depth)
+}
+
+// split derives the node's two children, which cover the earlier and later halves of its epochs.
+func (n *rollingNode) split() (left, right rollingNode) {
+	p := New("thyrse.rolling-key")
+	defer p.Clear()
+	p.Mix("node", n.secret[:])
+
+	left = rollingNode{start: n.start, depth: n.depth + 1}
+	right = rollingNode{start: n.start | 1<<(63-n.depth), depth: n.depth + 1}
+	p.Derive("left", left.secret[:0], len(left.secret))
+	p.Derive("right", right.secret[:0], len(right.secret))
+	return left, right
+}
@@ -0,0 +1,109 @@
+package thyrse
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRollingKey(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newKey := func(now *time.Time) *RollingKey {
+		p := newKeyed("test", []byte("key"))
+		return p.rollingKey("ticket", 32, time.Hour, func() time.Time { return *now })
+	}
+
+	t.Run("key schedule", func(t *testing.T) {
+		now := start
+		k := newKey(&now)
+		first, previous := k.Current(), k.Previous()
+		if previous == nil || bytes.Equal(first, previous) {
+			t.Fatalf("Previous() = %x, want a distinct key", previous)
+		}
+
+		now = start.Add(59 * time.Minute)
+		if got := k.Current(); !bytes.Equal(got, first) {
+			t.Errorf("Current() rolled early")
+		}
+
+		now = start.Add(time.Hour)
+		if got := k.Current(); bytes.Equal(got, first) {
+			t.Error("Current() did not roll")
+		}
+		if got := k.Previous(); !bytes.Equal(got, first) {
+			t.Errorf("Previous() = %x, want = %x", got, first)
+		}
+	})
+
+	t.Run("aligned to wall clock", func(t *testing.T) {
+		now := start.Add(5 * time.Minute)
+		a := newKey(&now)
+		now = start.Add(55 * time.Minute)
+		b := newKey(&now)
+		if !bytes.Equal(a.Current(), b.Current()) || !bytes.Equal(a.Previous(), b.Previous()) {
+			t.Error("keys created in the same interval differ")
+		}
+
+		now = start.Add(time.Hour + time.Minute)
+		c := newKey(&now)
+		if !bytes.Equal(a.Current(), c.Current()) || !bytes.Equal(b.Previous(), c.Previous()) {
+			t.Error("keys created in different intervals do not agree")
+		}
+	})
+
+	t.Run("catch up", func(t *testing.T) {
+		now := start
+		a := newKey(&now)
+
+		now = start.Add(24*365*time.Hour + time.Minute)
+		b := newKey(&now)
+		if !bytes.Equal(a.Current(), b.Current()) || !bytes.Equal(a.Previous(), b.Previous()) {
+			t.Error("idle key did not jump to the current interval")
+		}
+		if got, want := len(a.nodes), 64; got > want {
+			t.Errorf("len(nodes) = %d, want <= %d", got, want)
+		}
+	})
+
+	t.Run("clock moves backward", func(t *testing.T) {
+		now := start.Add(time.Hour)
+		k := newKey(&now)
+		current := k.Current()
+
+		now = start
+		if got := k.Current(); !bytes.Equal(got, current) {
+			t.Error("Current() changed when the clock moved backward")
+		}
+	})
+
+	t.Run("interval bound", func(t *testing.T) {
+		now := start
+		a := newKey(&now)
+		b := newKeyed("test", []byte("key")).rollingKey("ticket", 32, 2*time.Hour, func() time.Time { return now })
+		if bytes.Equal(a.Current(), b.Current()) {
+			t.Error("keys with different intervals are equal")
+		}
+	})
+
+	t.Run("wipes superseded keys", func(t *testing.T) {
+		now := start
+		k := newKey(&now)
+		previous := k.previous
+
+		now = start.Add(2 * time.Hour)
+		k.Current()
+		if !bytes.Equal(previous, make([]byte, len(previous))) {
+			t.Errorf("superseded key = %x, want zeros", previous)
+		}
+	})
+
+	t.Run("copies", func(t *testing.T) {
+		now := start
+		k := newKey(&now)
+		got := k.Current()
+		clear(got)
+		if bytes.Equal(k.Current(), got) {
+			t.Error("Current() returned the internal key")
+		}
+	})
+}
@@ -4,8 +4,12 @@ package aead
 
 import (
 	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/mem"
 )
 
 // New returns a new cipher.AEAD instance which uses the given domain string and key.
@@ -69,4 +73,100 @@ func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, erro
 	return p.Open("message", dst, ciphertext)
 }
 
-var _ cipher.AEAD = (*aead)(nil)
+// KeyIDSize is the size, in bytes, of the key ID prefixed to ciphertexts by a multi-key AEAD.
+const KeyIDSize = 4
+
+// ErrUnknownKey is returned by a multi-key AEAD's Open method when a ciphertext's key ID is not in its key ring.
+var ErrUnknownKey = errors.New("thyrse/aead: unknown key ID")
+
+// NewMultiKey returns a new cipher.AEAD instance which uses the given domain string and key ring, a map of key IDs to
+// keys, to support key rotation. Ciphertexts are sealed with the key with the current ID and prefixed with that ID;
+// Open uses the key indicated by a ciphertext's prefix, returning ErrUnknownKey if it is not in the key ring. Each key's
+// ID is bound to its ciphertexts, so ciphertexts produced by NewMultiKey cannot be opened by an AEAD from New, or vice
+// versa.
+//
+// The key ring is copied, so rotating keys requires creating a new instance. Panics if nonceSize is less than 16 bytes
+// or if the key ring has no key with the current ID.
+func NewMultiKey(domain string, keys map[uint32][]byte, current uint32, nonceSize int) cipher.AEAD {
+	if _, ok := keys[current]; !ok {
+		panic("thyrse/aead: current key ID not in key ring")
+	}
+
+	ring := make(map[uint32]*aead, len(keys))
+	for id, key := range keys {
+		a := New(domain, key, nonceSize).(*aead)
+		a.p.Mix("key-id", binary.BigEndian.AppendUint32(nil, id))
+		ring[id] = a
+	}
+	return &multiKey{ring: ring, current: current, nonceSize: nonceSize}
+}
+
+type multiKey struct {
+	ring      map[uint32]*aead
+	current   uint32
+	nonceSize int
+}
+
+func (m *multiKey) NonceSize() int {
+	return m.nonceSize
+}
+
+func (m *multiKey) Overhead() int {
+	return KeyIDSize + thyrse.TagSize
+}
+
+// Seal encrypts and authenticates plaintext with the current key, authenticates the additional data and appends the
+// current key ID and the result to dst, returning the updated slice.
+//
+// Panics if len(nonce) != m.NonceSize().
+func (m *multiKey) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, m.current)
+	return m.ring[m.current].Seal(dst, nonce, plaintext, additionalData)
+}
+
+// Open decrypts and authenticates ciphertext with the key indicated by its key ID, authenticates the additional data
+// and, if successful, appends the resulting plaintext to dst, returning the updated slice.
+//
+// Panics if len(nonce) != m.NonceSize().
+func (m *multiKey) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != m.NonceSize() {
+		panic("thyrse/aead: invalid nonce size")
+	}
+	if len(ciphertext) < KeyIDSize {
+		return nil, thyrse.ErrTruncated
+	}
+
+	a, ok := m.ring[binary.BigEndian.Uint32(ciphertext)]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return a.Open(dst, nonce, ciphertext[KeyIDSize:], additionalData)
+}
+
+// SealRandom encrypts and authenticates plaintext with a random nonce read from crypto/rand, authenticates the
+// additional data, and appends the nonce followed by the ciphertext to dst, returning the updated slice. The output is
+// a.NonceSize() + a.Overhead() bytes longer than the plaintext.
+//
+// With a nonce size of at least 16 bytes, as New requires, random nonces can be used for practically unlimited numbers
+// of messages per key without risk of collision.
+func SealRandom(a cipher.AEAD, dst, plaintext, additionalData []byte) []byte {
+	ret, out := mem.SliceForAppend(dst, a.NonceSize())
+	_, _ = rand.Read(out)
+	return a.Seal(ret, out, plaintext, additionalData)
+}
+
+// OpenRandom decrypts and authenticates the output of SealRandom, authenticates the additional data and, if successful,
+// appends the resulting plaintext to dst, returning the updated slice. Returns thyrse.ErrTruncated if the input is
+// shorter than a nonce.
+func OpenRandom(a cipher.AEAD, dst, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < a.NonceSize() {
+		return nil, thyrse.ErrTruncated
+	}
+	nonce, ciphertext := ciphertext[:a.NonceSize()], ciphertext[a.NonceSize():]
+	return a.Open(dst, nonce, ciphertext, additionalData)
+}
+
+var (
+	_ cipher.AEAD = (*aead)(nil)
+	_ cipher.AEAD = (*multiKey)(nil)
+)
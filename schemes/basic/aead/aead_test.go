@@ -2,6 +2,7 @@ package aead_test
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/codahale/thyrse"
@@ -146,6 +147,117 @@ func TestAEAD_Open(t *testing.T) {
 	})
 }
 
+func TestNewMultiKey(t *testing.T) {
+	drbg := testdata.New("thyrse aead multi-key")
+	keys := map[uint32][]byte{1: drbg.Data(32), 2: drbg.Data(32)}
+	nonce := drbg.Data(16)
+	plaintext := []byte("this is a message")
+	ad := []byte("header")
+
+	old := aead.NewMultiKey("com.example.test", keys, 1, 16)
+	current := aead.NewMultiKey("com.example.test", keys, 2, 16)
+	oldCiphertext := old.Seal(nil, nonce, plaintext, ad)
+	ciphertext := current.Seal(nil, nonce, plaintext, ad)
+
+	t.Run("panic on missing current key", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("NewMultiKey() did not panic")
+			}
+		}()
+		aead.NewMultiKey("com.example.test", keys, 3, 16)
+	})
+
+	t.Run("overhead", func(t *testing.T) {
+		if got, want := len(ciphertext), len(plaintext)+current.Overhead(); got != want {
+			t.Errorf("len(Seal()) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("key ID prefix", func(t *testing.T) {
+		if got, want := ciphertext[:aead.KeyIDSize], []byte{0, 0, 0, 2}; !bytes.Equal(got, want) {
+			t.Errorf("key ID = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		got, err := current.Open(nil, nonce, oldCiphertext, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("Open() = %q, want %q", got, plaintext)
+		}
+	})
+
+	t.Run("unknown key ID", func(t *testing.T) {
+		retired := aead.NewMultiKey("com.example.test", map[uint32][]byte{2: keys[2]}, 2, 16)
+		if _, err := retired.Open(nil, nonce, oldCiphertext, ad); !errors.Is(err, aead.ErrUnknownKey) {
+			t.Errorf("Open() err = %v, want %v", err, aead.ErrUnknownKey)
+		}
+	})
+
+	t.Run("modified key ID", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[aead.KeyIDSize-1] = 1
+		if _, err := current.Open(nil, nonce, modified, ad); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("not compatible with New", func(t *testing.T) {
+		single := aead.New("com.example.test", keys[2], 16)
+		if _, err := single.Open(nil, nonce, ciphertext[aead.KeyIDSize:], ad); err == nil {
+			t.Error("Open() succeeded, want error")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := current.Open(nil, nonce, ciphertext[:2], ad); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("Open() err = %v, want %v", err, thyrse.ErrTruncated)
+		}
+	})
+}
+
+func TestSealRandom(t *testing.T) {
+	c := aead.New("com.example.test", testdata.New("aead random").Data(32), 16)
+	plaintext := []byte("Hello, world!")
+	ad := []byte("header data")
+
+	ciphertext := aead.SealRandom(c, []byte("prefix"), plaintext, ad)
+	if got, want := len(ciphertext), len("prefix")+c.NonceSize()+len(plaintext)+c.Overhead(); got != want {
+		t.Errorf("len(SealRandom()) = %d, want %d", got, want)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := aead.OpenRandom(c, nil, ciphertext[len("prefix"):], ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := plaintext; !bytes.Equal(got, want) {
+			t.Errorf("OpenRandom() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unique nonces", func(t *testing.T) {
+		if bytes.Equal(aead.SealRandom(c, nil, plaintext, ad), aead.SealRandom(c, nil, plaintext, ad)) {
+			t.Error("SealRandom() produced identical ciphertexts")
+		}
+	})
+
+	t.Run("wrong additional data", func(t *testing.T) {
+		if _, err := aead.OpenRandom(c, nil, ciphertext[len("prefix"):], []byte("other")); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("OpenRandom() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := aead.OpenRandom(c, nil, make([]byte, c.NonceSize()-1), ad); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("OpenRandom() err = %v, want %v", err, thyrse.ErrTruncated)
+		}
+	})
+}
+
 func FuzzAEAD(f *testing.F) {
 	drbg := testdata.New("thyrse aead fuzz")
 	for range 10 {
@@ -0,0 +1,61 @@
+package aead
+
+import (
+	"crypto/cipher"
+
+	"github.com/codahale/thyrse/schemes/basic/siv"
+)
+
+// sivNonceSize is the size of the fixed, all-zero nonce NewSIV passes to the siv package.
+const sivNonceSize = 16
+
+// NewSIV returns a new deterministic, nonce-misuse resistant cipher.AEAD instance which uses the given domain string
+// and key. It takes no nonce: a synthetic IV is derived from the key, the additional data, and the plaintext, and the
+// plaintext is then encrypted under it. Sealing the same plaintext with the same additional data always produces the
+// same ciphertext, which suits cases like deduplicating storage, where nonce uniqueness can't be guaranteed, but
+// reveals whether two ciphertexts have the same plaintext and additional data. Include a unique value in the additional
+// data where that is unacceptable, or use the siv package directly with a nonce.
+//
+// It is an adapter for the siv package: its ciphertexts are those of siv.New with a 16-byte all-zero nonce. They cannot
+// be opened by an AEAD from New, or vice versa.
+func NewSIV(domain string, key []byte) cipher.AEAD {
+	return &sivAEAD{aead: siv.New(domain, key, sivNonceSize)}
+}
+
+type sivAEAD struct {
+	aead cipher.AEAD
+}
+
+func (s *sivAEAD) NonceSize() int {
+	return 0
+}
+
+func (s *sivAEAD) Overhead() int {
+	return s.aead.Overhead()
+}
+
+// Seal encrypts and authenticates plaintext with a synthetic IV, authenticates the additional data and appends the
+// result to dst, returning the updated slice.
+//
+// Panics if nonce is not empty.
+func (s *sivAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != 0 {
+		panic("thyrse/aead: invalid nonce size")
+	}
+	var zero [sivNonceSize]byte
+	return s.aead.Seal(dst, zero[:], plaintext, additionalData)
+}
+
+// Open decrypts and authenticates ciphertext, authenticates the additional data and, if successful, appends the
+// resulting plaintext to dst, returning the updated slice.
+//
+// Panics if nonce is not empty.
+func (s *sivAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != 0 {
+		panic("thyrse/aead: invalid nonce size")
+	}
+	var zero [sivNonceSize]byte
+	return s.aead.Open(dst, zero[:], ciphertext, additionalData)
+}
+
+var _ cipher.AEAD = (*sivAEAD)(nil)
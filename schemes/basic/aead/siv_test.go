@@ -0,0 +1,102 @@
+package aead_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/aead"
+	"github.com/codahale/thyrse/schemes/basic/siv"
+)
+
+func TestNewSIV(t *testing.T) {
+	drbg := testdata.New("aead siv")
+	key := drbg.Data(32)
+	c := aead.NewSIV("com.example.test", key)
+	plaintext := []byte("Hello, world!")
+	ad := []byte("header data")
+
+	t.Run("sizes", func(t *testing.T) {
+		if got, want := c.NonceSize(), 0; got != want {
+			t.Errorf("NonceSize() = %d, want %d", got, want)
+		}
+		if got, want := c.Overhead(), thyrse.TagSize; got != want {
+			t.Errorf("Overhead() = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ciphertext := c.Seal(nil, nil, plaintext, ad)
+		if got, want := len(ciphertext), len(plaintext)+c.Overhead(); got != want {
+			t.Errorf("len(ciphertext) = %d, want %d", got, want)
+		}
+
+		got, err := c.Open(nil, nil, ciphertext, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("Open() = %q, want %q", got, plaintext)
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a := c.Seal(nil, nil, plaintext, ad)
+		b := aead.NewSIV("com.example.test", key).Seal(nil, nil, plaintext, ad)
+		if !bytes.Equal(a, b) {
+			t.Error("Seal() is not deterministic")
+		}
+
+		if bytes.Equal(a, c.Seal(nil, nil, plaintext, []byte("other data"))) {
+			t.Error("Seal() ignored the additional data")
+		}
+		if bytes.Equal(a, c.Seal(nil, nil, []byte("Hello, world?"), ad)) {
+			t.Error("different plaintexts produced the same ciphertext")
+		}
+	})
+
+	t.Run("siv with a zero nonce", func(t *testing.T) {
+		want := siv.New("com.example.test", key, 16).Seal(nil, make([]byte, 16), plaintext, ad)
+		if got := c.Seal(nil, nil, plaintext, ad); !bytes.Equal(got, want) {
+			t.Errorf("Seal() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("distinct from New", func(t *testing.T) {
+		ciphertext := aead.New("com.example.test", key, 16).Seal(nil, make([]byte, 16), plaintext, ad)
+		if _, err := c.Open(nil, nil, ciphertext, ad); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open() err = %v, want ErrInvalidCiphertext", err)
+		}
+	})
+
+	t.Run("invalid ciphertexts", func(t *testing.T) {
+		ciphertext := c.Seal(nil, nil, plaintext, ad)
+
+		if _, err := c.Open(nil, nil, ciphertext[:thyrse.TagSize-1], ad); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("Open(truncated) err = %v, want ErrTruncated", err)
+		}
+
+		for i := range ciphertext {
+			modified := bytes.Clone(ciphertext)
+			modified[i] ^= 1
+			if _, err := c.Open(nil, nil, modified, ad); !errors.Is(err, thyrse.ErrTagMismatch) {
+				t.Errorf("Open(modified byte %d) err = %v, want ErrTagMismatch", i, err)
+			}
+		}
+
+		if _, err := c.Open(nil, nil, ciphertext, []byte("other data")); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("Open(wrong ad) err = %v, want ErrTagMismatch", err)
+		}
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Seal() did not panic")
+			}
+		}()
+		c.Seal(nil, make([]byte, 16), plaintext, ad)
+	})
+}
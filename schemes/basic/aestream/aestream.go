@@ -9,9 +9,15 @@
 // The reader reads the sealed header, opens it, decodes it into a block length, reads an encrypted block of that
 // length and its authentication tag, then opens the sealed block. When it encounters the empty block, it returns EOF.
 // If the stream terminates before that, an invalid ciphertext error is returned.
+//
+// A writer created with NewIndexedWriter also records an index of checkpoints at block boundaries, which a ReaderAt
+// uses to decrypt from an arbitrary offset without reading the entire stream.
+//
+// AuthWriter and AuthReader use the same block structure to authenticate a stream without encrypting it.
 package aestream
 
 import (
+	"encoding"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -21,15 +27,24 @@ import (
 )
 
 // MaxBlockSize is the maximum size of an aestream block, in bytes. Writes larger than this broken up into blocks of
-// this size.
+// this size. A smaller maximum can be set with WithMaxBlockSize.
 const MaxBlockSize = 1<<16 - 1
 
 // Writer encrypts written data in blocks, ensuring both confidentiality and authenticity.
 type Writer struct {
 	p      *thyrse.Protocol
 	w      io.Writer
+	cfg    config
 	buf    []byte
+	offset int64
+	blocks uint64
 	closed bool
+	err    error
+
+	index           *Index
+	interval        int64
+	nextEntry       int64
+	plaintextOffset int64
 }
 
 // NewWriter wraps the given thyrse.Protocol and io.Writer with a streaming authenticated encryption writer.
@@ -39,23 +54,108 @@ type Writer struct {
 //
 // For maximum throughput and transmission efficiency, the use of a bufio.Writer wrapper is strongly recommended.
 // Unbuffered writes will result in blocks the length of each write, rather than blocks of the maximum size.
-func NewWriter(p *thyrse.Protocol, w io.Writer) *Writer {
+func NewWriter(p *thyrse.Protocol, w io.Writer, opts ...Option) *Writer {
 	return &Writer{
 		p:      p,
 		w:      w,
+		cfg:    newConfig(opts),
 		buf:    make([]byte, 0, 1024),
 		closed: false,
 	}
 }
 
+// ResumeWriter returns a Writer which continues the stream recorded in the given checkpoint, appending blocks to w.
+//
+// The caller is responsible for positioning w at the checkpoint's offset (e.g. by truncating a partially written file to
+// Checkpoint.Offset and seeking to its end). Any ciphertext written after the checkpoint is discarded and must be
+// rewritten. The checkpoint's protocol is used by the writer and MUST NOT be used elsewhere while the writer is open.
+// The options MUST be the same as those of the original writer.
+func ResumeWriter(c Checkpoint, w io.Writer, opts ...Option) *Writer {
+	s := NewWriter(c.Protocol, w, opts...)
+	s.offset = c.Offset
+	s.blocks = c.Blocks
+	return s
+}
+
+// A Checkpoint records the state of a Writer at a block boundary, allowing a partially written stream to be resumed
+// with ResumeWriter without rewriting its existing blocks.
+type Checkpoint struct {
+	// Protocol is an independent copy of the writer's protocol state after the last block was written.
+	Protocol *thyrse.Protocol
+
+	// Offset is the number of bytes of ciphertext written to the underlying writer before the checkpoint.
+	Offset int64
+
+	// Blocks is the number of blocks written before the checkpoint.
+	Blocks uint64
+}
+
+// ErrInvalidCheckpoint is returned when a serialized checkpoint is malformed.
+var ErrInvalidCheckpoint = errors.New("thyrse/aestream: invalid checkpoint")
+
+// MarshalBinary encodes the checkpoint as the ciphertext offset, the block count, and the serialized protocol state, so
+// it can be persisted alongside a partially written stream and restored with Checkpoint.UnmarshalBinary after a crash.
+//
+// The encoding contains the stream's protocol state, which is as sensitive as the key used to encrypt it, and must be
+// stored accordingly (e.g. sealed with a separate key). The writer's protocol must be serializable (see
+// thyrse.Protocol.SetSerializable), or MarshalBinary returns thyrse.ErrNotSerializable.
+func (c Checkpoint) MarshalBinary() ([]byte, error) {
+	state, err := c.Protocol.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, 16+len(state))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.Offset))
+	b = binary.BigEndian.AppendUint64(b, c.Blocks)
+	return append(b, state...), nil
+}
+
+// UnmarshalBinary decodes a checkpoint encoded with MarshalBinary, replacing the receiver's contents. Returns
+// ErrInvalidCheckpoint if data is malformed.
+func (c *Checkpoint) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return ErrInvalidCheckpoint
+	}
+	offset := int64(binary.BigEndian.Uint64(data))
+	if offset < 0 {
+		return ErrInvalidCheckpoint
+	}
+
+	p := new(thyrse.Protocol)
+	if err := p.UnmarshalBinary(data[16:]); err != nil {
+		return ErrInvalidCheckpoint
+	}
+	*c = Checkpoint{Protocol: p, Offset: offset, Blocks: binary.BigEndian.Uint64(data[8:])}
+	return nil
+}
+
+// Checkpoint returns a checkpoint of the stream written so far. Every successful Write ends on a block boundary, so
+// the checkpoint covers all data written.
+//
+// A closed writer cannot be checkpointed, as resuming after the terminal block would produce a stream readers reject.
+// Nor can a writer which has failed to write a block, as its protocol state no longer corresponds to the data written;
+// resume from a checkpoint taken before the failure instead.
+func (s *Writer) Checkpoint() (Checkpoint, error) {
+	if s.closed {
+		return Checkpoint{}, thyrse.ErrClosed
+	}
+	if s.err != nil {
+		return Checkpoint{}, s.err
+	}
+	return Checkpoint{Protocol: s.p.Clone(), Offset: s.offset, Blocks: s.blocks}, nil
+}
+
 func (s *Writer) Write(p []byte) (n int, err error) {
+	if s.closed {
+		return 0, thyrse.ErrClosed
+	}
 	if len(p) == 0 {
 		return 0, nil
 	}
 
 	total := len(p)
 	for len(p) > 0 {
-		blockLen := min(len(p), MaxBlockSize)
+		blockLen := min(len(p), s.cfg.maxBlockSize)
 		err = s.sealAndWrite(p[:blockLen])
 		if err != nil {
 			return total - len(p), err
@@ -81,6 +181,11 @@ func (s *Writer) Close() error {
 }
 
 func (s *Writer) sealAndWrite(p []byte) error {
+	// Mix in the block's associated data, if any.
+	if s.cfg.additionalData != nil {
+		s.p.Mix("block-ad", s.cfg.additionalData(s.blocks))
+	}
+
 	// Encode a header with a 2-byte big endian block length and mask it.
 	s.buf = slices.Grow(s.buf[:0], headerSize+len(p)+thyrse.TagSize)
 	header := binary.BigEndian.AppendUint16(s.buf[:0], uint16(len(p)))
@@ -89,12 +194,21 @@ func (s *Writer) sealAndWrite(p []byte) error {
 	// Seal the block, append it to the header block, and send it.
 	block = s.p.Seal("block", block, p)
 	if _, err := s.w.Write(block); err != nil {
+		s.err = err
 		return err
 	}
+	s.offset += int64(len(block))
+	s.plaintextOffset += int64(len(p))
+	s.blocks++
 
 	// Ratchet for forward secrecy.
 	s.p.Ratchet("block")
 
+	// Record an index entry at the block boundary, unless this is the terminal block.
+	if len(p) > 0 {
+		s.recordIndex()
+	}
+
 	return nil
 }
 
@@ -102,20 +216,27 @@ func (s *Writer) sealAndWrite(p []byte) error {
 type Reader struct {
 	p             *thyrse.Protocol
 	r             io.Reader
+	cfg           config
 	buf, blockBuf []byte
+	blocks        uint64
 	eos           bool
+	err           error
 }
 
 // NewReader wraps the given thyrse.Protocol and io.Reader with a streaming authenticated encryption reader. See
 // the NewWriter documentation for details.
 //
-// If the stream has been modified or truncated, a thyrse.ErrInvalidCiphertext is returned.
+// If the stream has been modified or truncated, an error wrapping thyrse.ErrInvalidCiphertext is returned. After such
+// an error, the reader's transcript has diverged from the writer's, and further reads return
+// thyrse.ErrDesynchronized.
 //
-// The provided thyrse.Protocol MUST NOT be used while the reader is open.
-func NewReader(p *thyrse.Protocol, r io.Reader) *Reader {
+// The options MUST be the same as those of the writer. The provided thyrse.Protocol MUST NOT be used while the reader
+// is open.
+func NewReader(p *thyrse.Protocol, r io.Reader, opts ...Option) *Reader {
 	return &Reader{
 		p:        p,
 		r:        r,
+		cfg:      newConfig(opts),
 		buf:      make([]byte, 0, 1024),
 		blockBuf: nil,
 		eos:      false,
@@ -141,6 +262,16 @@ func (o *Reader) Read(p []byte) (n int, err error) {
 			return 0, io.EOF
 		}
 
+		// If a previous block failed to open, the transcript has diverged.
+		if o.err != nil {
+			return 0, o.err
+		}
+
+		// Mix in the block's associated data, if any.
+		if o.cfg.additionalData != nil {
+			o.p.Mix("block-ad", o.cfg.additionalData(o.blocks))
+		}
+
 		// Read and unmask the header and decode the block length.
 		header, err := o.read(headerSize)
 		if err != nil {
@@ -148,6 +279,10 @@ func (o *Reader) Read(p []byte) (n int, err error) {
 		}
 		header = o.p.Unmask("header", header[:0], header)
 		blockLen := int(binary.BigEndian.Uint16(header))
+		if blockLen > o.cfg.maxBlockSize {
+			o.err = thyrse.ErrDesynchronized
+			return 0, thyrse.ErrTooLarge
+		}
 
 		// Read and open the block.
 		block, err := o.read(blockLen + thyrse.TagSize)
@@ -156,10 +291,12 @@ func (o *Reader) Read(p []byte) (n int, err error) {
 		}
 		block, err = o.p.Open("block", block[:0], block)
 		if err != nil {
+			o.err = thyrse.ErrDesynchronized
 			return 0, err
 		}
 		o.eos = len(block) == 0
 		o.blockBuf = block
+		o.blocks++
 
 		// Ratchet for forward secrecy.
 		o.p.Ratchet("block")
@@ -172,7 +309,7 @@ func (o *Reader) read(n int) ([]byte, error) {
 	_, err := io.ReadFull(o.r, data)
 	if err != nil {
 		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-			return nil, thyrse.ErrInvalidCiphertext
+			return nil, thyrse.ErrTruncated
 		}
 		return nil, err
 	}
@@ -182,6 +319,8 @@ func (o *Reader) read(n int) ([]byte, error) {
 const headerSize = 2
 
 var (
-	_ io.WriteCloser = (*Writer)(nil)
-	_ io.Reader      = (*Reader)(nil)
+	_ io.WriteCloser             = (*Writer)(nil)
+	_ io.Reader                  = (*Reader)(nil)
+	_ encoding.BinaryMarshaler   = Checkpoint{}
+	_ encoding.BinaryUnmarshaler = (*Checkpoint)(nil)
 )
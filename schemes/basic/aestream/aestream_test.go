@@ -117,6 +117,90 @@ func TestWriter_Write(t *testing.T) {
 	})
 }
 
+func TestResumeWriter(t *testing.T) {
+	t.Run("crash and resume", func(t *testing.T) {
+		p1 := thyrse.New("example")
+		p1.SetSerializable(true)
+		p1.Mix("key", []byte("it's a key"))
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewWriter(p1, buf)
+		if _, err := w.Write([]byte("first; ")); err != nil {
+			t.Fatal(err)
+		}
+		c, err := w.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Simulate a crash after writing a block which was not checkpointed, losing the writer.
+		if _, err := w.Write([]byte("lost; ")); err != nil {
+			t.Fatal(err)
+		}
+
+		// Restore the persisted checkpoint and truncate the stream to its offset.
+		var restored aestream.Checkpoint
+		if err := restored.UnmarshalBinary(state); err != nil {
+			t.Fatal(err)
+		}
+		buf.Truncate(int(restored.Offset))
+
+		w = aestream.ResumeWriter(restored, buf)
+		if _, err := w.Write([]byte("second")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		p2 := thyrse.New("example")
+		p2.Mix("key", []byte("it's a key"))
+		b, err := io.ReadAll(aestream.NewReader(p2, bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := string(b), "first; second"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("invalid checkpoint", func(t *testing.T) {
+		var c aestream.Checkpoint
+		for _, data := range [][]byte{nil, make([]byte, 15), make([]byte, 40)} {
+			if err := c.UnmarshalBinary(data); !errors.Is(err, aestream.ErrInvalidCheckpoint) {
+				t.Errorf("UnmarshalBinary(%x) err = %v, want %v", data, err, aestream.ErrInvalidCheckpoint)
+			}
+		}
+	})
+
+	t.Run("closed writer", func(t *testing.T) {
+		w := aestream.NewWriter(thyrse.New("example"), io.Discard)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Checkpoint(); !errors.Is(err, thyrse.ErrClosed) {
+			t.Errorf("Checkpoint() err = %v, want %v", err, thyrse.ErrClosed)
+		}
+		if _, err := w.Write([]byte("more")); !errors.Is(err, thyrse.ErrClosed) {
+			t.Errorf("Write() err = %v, want %v", err, thyrse.ErrClosed)
+		}
+	})
+
+	t.Run("failed writer", func(t *testing.T) {
+		ew := &testdata.ErrWriter{Err: errors.New("write failed")}
+		w := aestream.NewWriter(thyrse.New("example"), ew)
+		_, _ = w.Write([]byte("hello"))
+		if _, err := w.Checkpoint(); !errors.Is(err, ew.Err) {
+			t.Errorf("Checkpoint() err = %v, want %v", err, ew.Err)
+		}
+	})
+}
+
 func TestNewReader(t *testing.T) {
 	t.Run("truncation", func(t *testing.T) {
 		p1 := thyrse.New("example")
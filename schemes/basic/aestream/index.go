@@ -0,0 +1,168 @@
+package aestream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/codahale/thyrse"
+)
+
+// ErrInvalidIndex is returned when a serialized index is malformed.
+var ErrInvalidIndex = errors.New("thyrse/aestream: invalid index")
+
+// An Index records checkpoints of a stream at block boundaries, allowing a ReaderAt to decrypt from the nearest
+// preceding checkpoint instead of from the start of the stream.
+//
+// An index contains the protocol state of the stream at each checkpoint, which is as sensitive as the key used to
+// encrypt it, and must be stored accordingly (e.g. sealed with a separate key).
+type Index struct {
+	// Entries are the index's checkpoints, in stream order.
+	Entries []IndexEntry
+}
+
+// An IndexEntry is a checkpoint of a stream at a block boundary.
+type IndexEntry struct {
+	Checkpoint
+
+	// PlaintextOffset is the number of bytes of plaintext written before the checkpoint.
+	PlaintextOffset int64
+}
+
+// MarshalBinary encodes the index as a sequence of entries, each consisting of the ciphertext offset, the plaintext
+// offset, the block count, and the length-prefixed serialized protocol state. The writer's protocol must be
+// serializable (see thyrse.Protocol.SetSerializable), or MarshalBinary returns thyrse.ErrNotSerializable.
+func (idx *Index) MarshalBinary() ([]byte, error) {
+	var b []byte
+	for _, e := range idx.Entries {
+		state, err := e.Protocol.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint64(b, uint64(e.Offset))
+		b = binary.BigEndian.AppendUint64(b, uint64(e.PlaintextOffset))
+		b = binary.BigEndian.AppendUint64(b, e.Blocks)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(state)))
+		b = append(b, state...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes an index encoded with MarshalBinary, replacing the receiver's entries. Returns
+// ErrInvalidIndex if data is malformed.
+func (idx *Index) UnmarshalBinary(data []byte) error {
+	var entries []IndexEntry
+	for len(data) > 0 {
+		if len(data) < 26 {
+			return ErrInvalidIndex
+		}
+		offset, plaintextOffset := int64(binary.BigEndian.Uint64(data)), int64(binary.BigEndian.Uint64(data[8:]))
+		blocks := binary.BigEndian.Uint64(data[16:])
+		n := int(binary.BigEndian.Uint16(data[24:]))
+		data = data[26:]
+		if len(data) < n || offset < 0 || plaintextOffset < 0 {
+			return ErrInvalidIndex
+		}
+
+		p := new(thyrse.Protocol)
+		if err := p.UnmarshalBinary(data[:n]); err != nil {
+			return ErrInvalidIndex
+		}
+		data = data[n:]
+
+		entries = append(entries, IndexEntry{
+			Checkpoint:      Checkpoint{Protocol: p, Offset: offset, Blocks: blocks},
+			PlaintextOffset: plaintextOffset,
+		})
+	}
+	idx.Entries = entries
+	return nil
+}
+
+// NewIndexedWriter returns a Writer which records an index entry at the first block boundary after each interval bytes
+// of plaintext. The index is available via Writer.Index. Smaller intervals allow faster random access at the cost of a
+// larger index. Panics if interval is not positive.
+func NewIndexedWriter(p *thyrse.Protocol, w io.Writer, interval int64, opts ...Option) *Writer {
+	if interval <= 0 {
+		panic("thyrse/aestream: index interval must be positive")
+	}
+
+	s := NewWriter(p, w, opts...)
+	s.index = new(Index)
+	s.interval = interval
+	s.nextEntry = interval
+	return s
+}
+
+// Index returns the index recorded by a writer created with NewIndexedWriter, or nil if the writer is not indexed. The
+// index is complete once the writer is closed.
+func (s *Writer) Index() *Index {
+	return s.index
+}
+
+// recordIndex appends an index entry if the writer is indexed and has passed the next interval.
+func (s *Writer) recordIndex() {
+	if s.index == nil || s.plaintextOffset < s.nextEntry {
+		return
+	}
+
+	s.index.Entries = append(s.index.Entries, IndexEntry{
+		Checkpoint:      Checkpoint{Protocol: s.p.Clone(), Offset: s.offset, Blocks: s.blocks},
+		PlaintextOffset: s.plaintextOffset,
+	})
+	s.nextEntry = s.plaintextOffset + s.interval
+}
+
+// ReaderAt provides random access to the plaintext of an encrypted stream using an index.
+//
+// Each read starts from the nearest index entry preceding its offset, so only the blocks between that entry and the end
+// of the read are decrypted and authenticated. Reads do not modify the ReaderAt, so it is safe for concurrent use.
+type ReaderAt struct {
+	p    *thyrse.Protocol
+	r    io.ReaderAt
+	idx  *Index
+	opts []Option
+}
+
+// NewReaderAt returns a ReaderAt for the stream in r, which was written by a Writer with the given initial protocol and
+// recorded in the given index. The protocol is used for reads before the index's first entry; it is not modified, and
+// MUST NOT be modified while the ReaderAt is in use. The options MUST be the same as those of the writer.
+//
+// If the stream has been modified or truncated, or does not match the index, reads return an error wrapping
+// thyrse.ErrInvalidCiphertext.
+func NewReaderAt(p *thyrse.Protocol, r io.ReaderAt, idx *Index, opts ...Option) *ReaderAt {
+	return &ReaderAt{p: p, r: r, idx: idx, opts: opts}
+}
+
+// ReadAt reads len(b) bytes of plaintext starting at offset off. It returns io.EOF if fewer bytes are read because the
+// stream ends.
+func (o *ReaderAt) ReadAt(b []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("thyrse/aestream: negative offset")
+	}
+
+	// Find the last entry at or before the offset, falling back to the start of the stream.
+	p, start, plaintextStart, blocks := o.p, int64(0), int64(0), uint64(0)
+	if i := sort.Search(len(o.idx.Entries), func(i int) bool {
+		return o.idx.Entries[i].PlaintextOffset > off
+	}); i > 0 {
+		e := o.idx.Entries[i-1]
+		p, start, plaintextStart, blocks = e.Protocol, e.Offset, e.PlaintextOffset, e.Blocks
+	}
+
+	// Decrypt from the entry, discarding plaintext before the offset.
+	r := NewReader(p.Clone(), io.NewSectionReader(o.r, start, 1<<63-1-start), o.opts...)
+	r.blocks = blocks
+	if _, err := io.CopyN(io.Discard, r, off-plaintextStart); err != nil {
+		return 0, err
+	}
+
+	n, err = io.ReadFull(r, b)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+var _ io.ReaderAt = (*ReaderAt)(nil)
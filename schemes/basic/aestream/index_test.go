@@ -0,0 +1,104 @@
+package aestream_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/aestream"
+)
+
+func TestNewReaderAt(t *testing.T) {
+	newProtocol := func() *thyrse.Protocol {
+		p := thyrse.New("example")
+		p.SetSerializable(true)
+		p.Mix("key", []byte("it's a key"))
+		return p
+	}
+
+	message := testdata.New("thyrse aestream index").Data(100_000)
+	buf := bytes.NewBuffer(nil)
+	w := aestream.NewIndexedWriter(newProtocol(), buf, 10_000)
+	for chunk := range slices.Chunk(message, 3_000) {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := buf.Bytes()
+	idx := w.Index()
+
+	t.Run("entries", func(t *testing.T) {
+		if got, want := len(idx.Entries), 8; got != want {
+			t.Errorf("len(Entries) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("random access", func(t *testing.T) {
+		r := aestream.NewReaderAt(newProtocol(), bytes.NewReader(ciphertext), idx)
+		for _, off := range []int64{0, 1, 2_999, 3_000, 12_345, 50_000, 99_000} {
+			got := make([]byte, 1_000)
+			if _, err := r.ReadAt(got, off); err != nil {
+				t.Fatalf("ReadAt(%d) err = %v", off, err)
+			}
+			if want := message[off : off+1_000]; !bytes.Equal(got, want) {
+				t.Errorf("ReadAt(%d) = %x..., want %x...", off, got[:8], want[:8])
+			}
+		}
+	})
+
+	t.Run("end of stream", func(t *testing.T) {
+		r := aestream.NewReaderAt(newProtocol(), bytes.NewReader(ciphertext), idx)
+		got := make([]byte, 1_000)
+		n, err := r.ReadAt(got, 99_500)
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("ReadAt() err = %v, want %v", err, io.EOF)
+		}
+		if want := message[99_500:]; !bytes.Equal(got[:n], want) {
+			t.Errorf("ReadAt() = %d bytes, want %d", n, len(want))
+		}
+
+		if n, err := r.ReadAt(got, 200_000); n != 0 || !errors.Is(err, io.EOF) {
+			t.Errorf("ReadAt() = %d, %v, want 0, %v", n, err, io.EOF)
+		}
+	})
+
+	t.Run("serialized index", func(t *testing.T) {
+		b, err := idx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var restored aestream.Index
+		if err := restored.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+
+		r := aestream.NewReaderAt(newProtocol(), bytes.NewReader(ciphertext), &restored)
+		got := make([]byte, 1_000)
+		if _, err := r.ReadAt(got, 75_000); err != nil {
+			t.Fatal(err)
+		}
+		if want := message[75_000:76_000]; !bytes.Equal(got, want) {
+			t.Error("ReadAt() with restored index returned wrong plaintext")
+		}
+
+		if err := restored.UnmarshalBinary(b[:len(b)-1]); !errors.Is(err, aestream.ErrInvalidIndex) {
+			t.Errorf("UnmarshalBinary(truncated) err = %v, want %v", err, aestream.ErrInvalidIndex)
+		}
+	})
+
+	t.Run("modified ciphertext", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[len(modified)/2] ^= 1
+		r := aestream.NewReaderAt(newProtocol(), bytes.NewReader(modified), idx)
+		if _, err := r.ReadAt(make([]byte, 1_000), 49_000); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAt() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package aestream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/codahale/thyrse"
+)
+
+// AuthWriter authenticates written data in blocks without encrypting it, ensuring authenticity but not
+// confidentiality. This is useful for data which must remain readable by other tools (e.g. logs which must stay
+// grep-able) but must also be tamper-evident.
+//
+// Like Writer, it breaks the stream into blocks of at most the configured maximum block size and ends it with an empty
+// terminal block. Each block's associated data (if any), 2-byte big endian length header, and contents are mixed into
+// the protocol, after which an empty message is sealed, producing an authentication tag which depends on every block
+// written so far.
+//
+// In interleaved mode, each block is written as its header, its plaintext contents, and its tag. In detached mode, the
+// plaintext is written unmodified to the data writer, and the headers and tags are written to a separate tag writer.
+//
+// If writing a block fails, the error is returned from every later call, as the protocol state no longer corresponds to
+// the data written.
+type AuthWriter struct {
+	p      *thyrse.Protocol
+	w      io.Writer
+	tags   io.Writer
+	cfg    config
+	buf    []byte
+	blocks uint64
+	closed bool
+	err    error
+}
+
+// NewAuthWriter wraps the given thyrse.Protocol and io.Writer with a streaming authentication writer. If tags is nil,
+// the headers and tags are interleaved with the data written to w; otherwise, w receives only the unmodified data and
+// tags receives the headers and tags.
+//
+// The returned AuthWriter MUST be closed for the authenticated stream to be valid. The provided thyrse.Protocol MUST
+// NOT be used while the writer is open.
+func NewAuthWriter(p *thyrse.Protocol, w, tags io.Writer, opts ...Option) *AuthWriter {
+	return &AuthWriter{
+		p:    p,
+		w:    w,
+		tags: tags,
+		cfg:  newConfig(opts),
+		buf:  make([]byte, 0, 1024),
+	}
+}
+
+func (s *AuthWriter) Write(p []byte) (n int, err error) {
+	if s.closed {
+		return 0, thyrse.ErrClosed
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	total := len(p)
+	for len(p) > 0 {
+		blockLen := min(len(p), s.cfg.maxBlockSize)
+		err = s.authAndWrite(p[:blockLen])
+		if err != nil {
+			return total - len(p), err
+		}
+		p = p[blockLen:]
+	}
+
+	return total, nil
+}
+
+// Close ends the stream with a terminal block, ensuring no further writes can be made to the stream.
+func (s *AuthWriter) Close() error {
+	if s.closed {
+		return s.err
+	}
+	s.closed = true
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.authAndWrite(nil)
+}
+
+func (s *AuthWriter) authAndWrite(p []byte) error {
+	// Mix in the block's associated data, if any.
+	if s.cfg.additionalData != nil {
+		s.p.Mix("block-ad", s.cfg.additionalData(s.blocks))
+	}
+
+	// Encode a header with a 2-byte big endian block length and mix it and the block into the protocol.
+	header := binary.BigEndian.AppendUint16(s.buf[:0], uint16(len(p)))
+	s.p.Mix("header", header)
+	s.p.Mix("block", p)
+
+	if s.tags == nil {
+		// Send the header, the block, and the tag.
+		frame := append(header, p...)
+		frame = s.p.Seal("tag", frame, nil)
+		if _, err := s.w.Write(frame); err != nil {
+			s.err = err
+			return err
+		}
+		s.buf = frame[:0]
+	} else {
+		// Send the block unmodified, and the header and tag separately.
+		if _, err := s.w.Write(p); err != nil {
+			s.err = err
+			return err
+		}
+		frame := s.p.Seal("tag", header, nil)
+		if _, err := s.tags.Write(frame); err != nil {
+			s.err = err
+			return err
+		}
+		s.buf = frame[:0]
+	}
+	s.blocks++
+
+	// Ratchet for forward secrecy.
+	s.p.Ratchet("block")
+
+	return nil
+}
+
+// AuthReader verifies data written by an AuthWriter in blocks, ensuring authenticity. See the AuthWriter documentation
+// for details.
+//
+// Each block is verified before any of its data is returned. If the stream has been modified or truncated, an error
+// wrapping thyrse.ErrInvalidCiphertext is returned, after which further reads return thyrse.ErrDesynchronized.
+type AuthReader struct {
+	p             *thyrse.Protocol
+	r             io.Reader
+	tags          io.Reader
+	cfg           config
+	buf, blockBuf []byte
+	blocks        uint64
+	eos           bool
+	err           error
+}
+
+// NewAuthReader wraps the given thyrse.Protocol and io.Reader with a streaming authentication reader. If tags is nil,
+// the stream is read in interleaved mode from r; otherwise, r contains only the data and tags contains the headers and
+// tags written in detached mode.
+//
+// The options MUST be the same as those of the writer. The provided thyrse.Protocol MUST NOT be used while the reader
+// is open.
+func NewAuthReader(p *thyrse.Protocol, r, tags io.Reader, opts ...Option) *AuthReader {
+	return &AuthReader{
+		p:    p,
+		r:    r,
+		tags: tags,
+		cfg:  newConfig(opts),
+		buf:  make([]byte, 0, 1024),
+	}
+}
+
+func (o *AuthReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		// If a verified block is buffered, satisfy the read with that.
+		if len(o.blockBuf) > 0 {
+			n = min(len(o.blockBuf), len(p))
+			copy(p, o.blockBuf[:n])
+			o.blockBuf = o.blockBuf[n:]
+			return n, nil
+		}
+
+		// If the stream is closed, return EOF.
+		if o.eos {
+			return 0, io.EOF
+		}
+
+		// If a previous block failed to verify, the transcript has diverged.
+		if o.err != nil {
+			return 0, o.err
+		}
+
+		// Read the header and decode the block length.
+		tags := o.tags
+		if tags == nil {
+			tags = o.r
+		}
+		var header [headerSize]byte
+		if err := readFull(tags, header[:]); err != nil {
+			return 0, err
+		}
+		blockLen := int(binary.BigEndian.Uint16(header[:]))
+		if blockLen > o.cfg.maxBlockSize {
+			o.err = thyrse.ErrDesynchronized
+			return 0, thyrse.ErrTooLarge
+		}
+
+		// Read the block and the tag.
+		o.buf = slices.Grow(o.buf[:0], blockLen+thyrse.TagSize)
+		block, tag := o.buf[:blockLen], o.buf[blockLen:blockLen+thyrse.TagSize]
+		if err := readFull(o.r, block); err != nil {
+			return 0, err
+		}
+		if err := readFull(tags, tag); err != nil {
+			return 0, err
+		}
+
+		// Verify the tag.
+		if o.cfg.additionalData != nil {
+			o.p.Mix("block-ad", o.cfg.additionalData(o.blocks))
+		}
+		o.p.Mix("header", header[:])
+		o.p.Mix("block", block)
+		if _, err := o.p.Open("tag", nil, tag); err != nil {
+			o.err = thyrse.ErrDesynchronized
+			return 0, err
+		}
+		o.eos = blockLen == 0
+		o.blockBuf = block
+		o.blocks++
+
+		// Ratchet for forward secrecy.
+		o.p.Ratchet("block")
+	}
+}
+
+func readFull(r io.Reader, b []byte) error {
+	if _, err := io.ReadFull(r, b); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return thyrse.ErrTruncated
+		}
+		return err
+	}
+	return nil
+}
+
+var (
+	_ io.WriteCloser = (*AuthWriter)(nil)
+	_ io.Reader      = (*AuthReader)(nil)
+)
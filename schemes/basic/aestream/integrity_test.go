@@ -0,0 +1,198 @@
+package aestream_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/aestream"
+)
+
+func TestNewAuthWriter(t *testing.T) {
+	newProtocol := func() *thyrse.Protocol {
+		p := thyrse.New("example")
+		p.Mix("key", []byte("it's a key"))
+		return p
+	}
+	message := append([]byte("log line one\nlog line two\n"), make([]byte, aestream.MaxBlockSize+10)...)
+
+	t.Run("interleaved", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewAuthWriter(newProtocol(), buf, nil)
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Contains(buf.Bytes(), []byte("log line two")) {
+			t.Error("plaintext not present in authenticated stream")
+		}
+
+		b, err := io.ReadAll(aestream.NewAuthReader(newProtocol(), bytes.NewReader(buf.Bytes()), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b, message; !bytes.Equal(got, want) {
+			t.Errorf("io.ReadAll() = %d bytes, want = %d", len(got), len(want))
+		}
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		data, tags := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+		w := aestream.NewAuthWriter(newProtocol(), data, tags)
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := data.Bytes(), message; !bytes.Equal(got, want) {
+			t.Error("data stream was modified")
+		}
+
+		b, err := io.ReadAll(aestream.NewAuthReader(newProtocol(), bytes.NewReader(data.Bytes()), bytes.NewReader(tags.Bytes())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b, message; !bytes.Equal(got, want) {
+			t.Errorf("io.ReadAll() = %d bytes, want = %d", len(got), len(want))
+		}
+
+		t.Run("modified data", func(t *testing.T) {
+			badData := bytes.Clone(data.Bytes())
+			badData[5] ^= 1
+			r := aestream.NewAuthReader(newProtocol(), bytes.NewReader(badData), bytes.NewReader(tags.Bytes()))
+			if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("io.ReadAll() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+			}
+		})
+
+		t.Run("truncated data", func(t *testing.T) {
+			r := aestream.NewAuthReader(newProtocol(), bytes.NewReader(data.Bytes()[:20]), bytes.NewReader(tags.Bytes()))
+			if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("io.ReadAll() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+			}
+		})
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		w := aestream.NewAuthWriter(newProtocol(), io.Discard, nil)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("more")); !errors.Is(err, thyrse.ErrClosed) {
+			t.Errorf("Write() err = %v, want = %v", err, thyrse.ErrClosed)
+		}
+	})
+
+	t.Run("underlying writer error", func(t *testing.T) {
+		ew := &testdata.ErrWriter{Err: errors.New("write failed")}
+		w := aestream.NewAuthWriter(thyrse.New("example"), ew, nil)
+		if _, err := w.Write([]byte("hello")); !errors.Is(err, ew.Err) {
+			t.Errorf("Write() err = %v, want %v", err, ew.Err)
+		}
+
+		want := ew.Err
+		ew.Err = nil
+		if _, err := w.Write([]byte("hello")); !errors.Is(err, want) {
+			t.Errorf("Write() err = %v, want %v", err, want)
+		}
+		if err := w.Close(); !errors.Is(err, want) {
+			t.Errorf("Close() err = %v, want %v", err, want)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		ad := func(block uint64) []byte { return []byte{byte(block)} }
+		message := make([]byte, 250)
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewAuthWriter(newProtocol(), buf, nil, aestream.WithMaxBlockSize(100), aestream.WithAdditionalData(ad))
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.Len(), 4*(2+thyrse.TagSize)+len(message); got != want {
+			t.Errorf("len(stream) = %d, want %d", got, want)
+		}
+
+		got, err := io.ReadAll(aestream.NewAuthReader(newProtocol(), bytes.NewReader(buf.Bytes()), nil,
+			aestream.WithMaxBlockSize(100), aestream.WithAdditionalData(ad)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("io.ReadAll() = %x, want %x", got, message)
+		}
+
+		r := aestream.NewAuthReader(newProtocol(), bytes.NewReader(buf.Bytes()), nil, aestream.WithMaxBlockSize(50))
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrTooLarge) {
+			t.Errorf("io.ReadAll() err = %v, want %v", err, thyrse.ErrTooLarge)
+		}
+
+		r = aestream.NewAuthReader(newProtocol(), bytes.NewReader(buf.Bytes()), nil, aestream.WithMaxBlockSize(100))
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+}
+
+func TestNewAuthReader(t *testing.T) {
+	p1 := thyrse.New("example")
+	buf := bytes.NewBuffer(nil)
+	w := aestream.NewAuthWriter(p1, buf, nil)
+	if _, err := w.Write([]byte("message")); err != nil {
+		t.Fatal(err)
+	}
+	unterminated := bytes.Clone(buf.Bytes())
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("truncation", func(t *testing.T) {
+		r := aestream.NewAuthReader(thyrse.New("example"), bytes.NewReader(unterminated), nil)
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("modification", func(t *testing.T) {
+		bad := bytes.Clone(buf.Bytes())
+		bad[3] ^= 1
+		r := aestream.NewAuthReader(thyrse.New("example"), bytes.NewReader(bad), nil)
+		b, err := io.ReadAll(r)
+		if !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+		}
+		if len(b) != 0 {
+			t.Errorf("io.ReadAll() = %q, want no unverified data", b)
+		}
+	})
+
+	t.Run("read after failure", func(t *testing.T) {
+		bad := bytes.Clone(buf.Bytes())
+		bad[3] ^= 1
+		r := aestream.NewAuthReader(thyrse.New("example"), bytes.NewReader(bad), nil)
+		if _, err := r.Read(make([]byte, 10)); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrTagMismatch)
+		}
+		if _, err := r.Read(make([]byte, 10)); !errors.Is(err, thyrse.ErrDesynchronized) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrDesynchronized)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		p2 := thyrse.New("example")
+		p2.Mix("key", []byte("a different key"))
+		if _, err := io.ReadAll(aestream.NewAuthReader(p2, bytes.NewReader(buf.Bytes()), nil)); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package aestream
+
+// An Option configures the framing of a stream. Writers and readers of the same stream MUST use the same options.
+type Option func(*config)
+
+// WithMaxBlockSize sets the maximum size of a block, in bytes. Writes larger than this are broken up into blocks of this
+// size, and readers reject larger blocks with thyrse.ErrTooLarge. Smaller blocks reduce latency and the amount of data
+// a reader buffers before authenticating it; larger blocks reduce per-block overhead. Panics if size is not between 1
+// and MaxBlockSize.
+func WithMaxBlockSize(size int) Option {
+	if size < 1 || size > MaxBlockSize {
+		panic("thyrse/aestream: invalid max block size")
+	}
+
+	return func(c *config) {
+		c.maxBlockSize = size
+	}
+}
+
+// WithAdditionalData sets a function which returns associated data for each block, given the block's zero-based
+// sequence number (including the terminal block). The associated data is authenticated but not encrypted or written to
+// the stream, e.g. to bind blocks to file metadata or an external sequence number.
+func WithAdditionalData(f func(block uint64) []byte) Option {
+	return func(c *config) {
+		c.additionalData = f
+	}
+}
+
+type config struct {
+	maxBlockSize   int
+	additionalData func(block uint64) []byte
+}
+
+func newConfig(opts []Option) config {
+	c := config{maxBlockSize: MaxBlockSize}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
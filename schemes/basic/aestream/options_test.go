@@ -0,0 +1,160 @@
+package aestream_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/aestream"
+)
+
+func TestWithMaxBlockSize(t *testing.T) {
+	newProtocol := func() *thyrse.Protocol {
+		p := thyrse.New("example")
+		p.Mix("key", []byte("it's a key"))
+		return p
+	}
+	message := testdata.New("thyrse aestream options").Data(1_000)
+
+	seal := func(opts ...aestream.Option) []byte {
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewWriter(newProtocol(), buf, opts...)
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		ciphertext := seal(aestream.WithMaxBlockSize(100))
+		if got, want := len(ciphertext), 11*(2+thyrse.TagSize)+len(message); got != want {
+			t.Errorf("len(ciphertext) = %d, want %d", got, want)
+		}
+
+		got, err := io.ReadAll(aestream.NewReader(newProtocol(), bytes.NewReader(ciphertext), aestream.WithMaxBlockSize(100)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("io.ReadAll() = %x, want %x", got, message)
+		}
+	})
+
+	t.Run("block too large", func(t *testing.T) {
+		ciphertext := seal()
+		r := aestream.NewReader(newProtocol(), bytes.NewReader(ciphertext), aestream.WithMaxBlockSize(100))
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrTooLarge) {
+			t.Errorf("io.ReadAll() err = %v, want %v", err, thyrse.ErrTooLarge)
+		}
+		if _, err := r.Read(make([]byte, 1)); !errors.Is(err, thyrse.ErrDesynchronized) {
+			t.Errorf("Read() err = %v, want %v", err, thyrse.ErrDesynchronized)
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("WithMaxBlockSize() did not panic")
+			}
+		}()
+		aestream.WithMaxBlockSize(0)
+	})
+}
+
+func TestWithAdditionalData(t *testing.T) {
+	newProtocol := func() *thyrse.Protocol {
+		p := thyrse.New("example")
+		p.Mix("key", []byte("it's a key"))
+		return p
+	}
+	message := testdata.New("thyrse aestream additional data").Data(1_000)
+	additionalData := func(file string) aestream.Option {
+		return aestream.WithAdditionalData(func(block uint64) []byte {
+			return binary.BigEndian.AppendUint64([]byte(file), block)
+		})
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := aestream.NewWriter(newProtocol(), buf, aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+	if _, err := w.Write(message); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := buf.Bytes()
+
+	t.Run("round trip", func(t *testing.T) {
+		r := aestream.NewReader(newProtocol(), bytes.NewReader(ciphertext), aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("io.ReadAll() = %x, want %x", got, message)
+		}
+	})
+
+	t.Run("wrong additional data", func(t *testing.T) {
+		r := aestream.NewReader(newProtocol(), bytes.NewReader(ciphertext), aestream.WithMaxBlockSize(100), additionalData("b.txt"))
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("missing additional data", func(t *testing.T) {
+		r := aestream.NewReader(newProtocol(), bytes.NewReader(ciphertext), aestream.WithMaxBlockSize(100))
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("io.ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewWriter(newProtocol(), buf, aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+		if _, err := w.Write(message[:500]); err != nil {
+			t.Fatal(err)
+		}
+		c, err := w.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w = aestream.ResumeWriter(c, buf, aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+		if _, err := w.Write(message[500:]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), ciphertext) {
+			t.Error("resumed stream differs from original")
+		}
+	})
+
+	t.Run("random access", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		w := aestream.NewIndexedWriter(newProtocol(), buf, 300, aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := aestream.NewReaderAt(newProtocol(), bytes.NewReader(buf.Bytes()), w.Index(), aestream.WithMaxBlockSize(100), additionalData("a.txt"))
+		got := make([]byte, 100)
+		if _, err := r.ReadAt(got, 650); err != nil {
+			t.Fatal(err)
+		}
+		if want := message[650:750]; !bytes.Equal(got, want) {
+			t.Errorf("ReadAt() = %x, want %x", got, want)
+		}
+	})
+}
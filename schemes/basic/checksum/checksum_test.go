@@ -0,0 +1,105 @@
+package checksum_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/checksum"
+)
+
+func TestSum(t *testing.T) {
+	t.Run("KT128", func(t *testing.T) {
+		// RFC 9861 section 5, KT128(M=empty, C=empty, 32).
+		c, err := checksum.Sum("", bytes.NewReader(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := c.String(), "1ac2d450fc3b4205d19da7bfca1b37513c0803577ac7167f06fe2ce1f0ef39e5"; got != want {
+			t.Errorf("Sum() = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("domain separation", func(t *testing.T) {
+		a, _ := checksum.Sum("a", bytes.NewReader([]byte("data")))
+		b, _ := checksum.Sum("b", bytes.NewReader([]byte("data")))
+		if a.Equal(b) {
+			t.Error("checksums with different domains are equal")
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		want := errors.New("read error")
+		if _, err := checksum.Sum("test", &testdata.ErrReader{Err: want}); !errors.Is(err, want) {
+			t.Errorf("Sum() err = %v, want = %v", err, want)
+		}
+	})
+}
+
+func TestSumFile(t *testing.T) {
+	data := testdata.New("checksum").Data(3<<20 + 17)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := checksum.SumFile("test", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := checksum.Sum("test", bytes.NewReader(data)); !got.Equal(want) {
+		t.Errorf("SumFile() = %s, want = %s", got, want)
+	}
+
+	if _, err := checksum.SumFile("test", filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SumFile() err = %v, want = %v", err, os.ErrNotExist)
+	}
+}
+
+func TestKeyedSum(t *testing.T) {
+	data := []byte("data")
+	got, err := checksum.KeyedSum("test", []byte("key"), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("transcript", func(t *testing.T) {
+		c, _ := checksum.Sum("test", bytes.NewReader(data))
+		p := thyrse.New("test")
+		p.Mix("key", []byte("key"))
+		p.Mix("checksum", c[:])
+		if want := p.Derive("keyed-checksum", nil, checksum.Size); !bytes.Equal(got[:], want) {
+			t.Errorf("KeyedSum() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("different keys", func(t *testing.T) {
+		other, _ := checksum.KeyedSum("test", []byte("other"), bytes.NewReader(data))
+		if got.Equal(other) {
+			t.Error("checksums with different keys are equal")
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	c, _ := checksum.Sum("test", bytes.NewReader([]byte("data")))
+	for _, s := range []string{c.String(), c.Base64()} {
+		got, err := checksum.Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) err = %v", s, err)
+		}
+		if !got.Equal(c) {
+			t.Errorf("Parse(%q) = %s, want = %s", s, got, c)
+		}
+	}
+
+	for _, s := range []string{"", c.String()[1:], "z" + c.String()[1:], "!" + c.Base64()[1:]} {
+		if _, err := checksum.Parse(s); !errors.Is(err, checksum.ErrInvalidChecksum) {
+			t.Errorf("Parse(%q) err = %v, want = %v", s, err, checksum.ErrInvalidChecksum)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package commit implements hash commitments using the Thyrse protocol.
+//
+// A party commits to a value by publishing a commitment, and later reveals the value along with the opening, which
+// anyone can check against the commitment with Verify. Commitments are hiding, in that they reveal nothing about the
+// value without the opening, and binding, in that a commitment cannot be opened to a different value. They are suited
+// to auctions, coin flips, and other protocols in which a party must fix a choice before seeing other parties' choices.
+//
+// The transcripts are equivalent to the following:
+//
+//	p := thyrse.New(domain)
+//	p.Mix("rand", rand)
+//	opening := p.Derive("opening", nil, OpeningSize)
+//
+//	p := thyrse.New(domain)
+//	p.Mix("opening", opening)
+//	p.Mix("value", value)
+//	commitment := p.Derive("commitment", nil, Size)
+package commit
+
+import (
+	"github.com/codahale/thyrse"
+)
+
+const (
+	// Size is the length of a commitment in bytes.
+	Size = 32
+
+	// OpeningSize is the length of an opening in bytes.
+	OpeningSize = 32
+)
+
+// Commit returns a commitment to value, using the given domain separation string and random value (which must be at
+// least 32 bytes), and the opening which, along with value, must be revealed to open it.
+//
+// Panics if rand is less than 32 bytes.
+func Commit(domain string, value, rand []byte) (commitment, opening []byte) {
+	if len(rand) < 32 {
+		panic("thyrse/commit: rand must be at least 32 bytes")
+	}
+
+	p := thyrse.New(domain)
+	p.Mix("rand", rand)
+	opening = p.Derive("opening", nil, OpeningSize)
+	return newProtocol(domain, value, opening).Derive("commitment", nil, Size), opening
+}
+
+// Verify returns true if commitment is a commitment to value with the given opening and domain separation string. The
+// comparison is constant-time.
+func Verify(domain string, commitment, value, opening []byte) bool {
+	if len(commitment) != Size {
+		return false
+	}
+	return newProtocol(domain, value, opening).Check("commitment", commitment)
+}
+
+func newProtocol(domain string, value, opening []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("opening", opening)
+	p.Mix("value", value)
+	return p
+}
@@ -0,0 +1,71 @@
+package commit_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/commit"
+)
+
+func TestCommit(t *testing.T) {
+	drbg := testdata.New("commit")
+	value := []byte("bid: 1000")
+	commitment, opening := commit.Commit("commit", value, drbg.Data(32))
+
+	t.Run("transcript", func(t *testing.T) {
+		rand := testdata.New("commit").Data(32)
+		p := thyrse.New("commit")
+		p.Mix("rand", rand)
+		if got, want := opening, p.Derive("opening", nil, commit.OpeningSize); !bytes.Equal(got, want) {
+			t.Errorf("opening = %x, want = %x", got, want)
+		}
+
+		p = thyrse.New("commit")
+		p.Mix("opening", opening)
+		p.Mix("value", value)
+		if got, want := commitment, p.Derive("commitment", nil, commit.Size); !bytes.Equal(got, want) {
+			t.Errorf("commitment = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		if !commit.Verify("commit", commitment, value, opening) {
+			t.Error("Verify() = false, want = true")
+		}
+	})
+
+	t.Run("hiding", func(t *testing.T) {
+		other, _ := commit.Commit("commit", value, drbg.Data(32))
+		if bytes.Equal(commitment, other) {
+			t.Error("commitments to the same value with different rand are equal")
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		domain                     string
+		commitment, value, opening []byte
+	}{
+		"wrong domain":     {"other", commitment, value, opening},
+		"wrong value":      {"commit", commitment, []byte("bid: 1001"), opening},
+		"wrong opening":    {"commit", commitment, value, drbg.Data(commit.OpeningSize)},
+		"short commitment": {"commit", commitment[:commit.Size-1], value, opening},
+		"empty commitment": {"commit", nil, value, opening},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if commit.Verify(tc.domain, tc.commitment, tc.value, tc.opening) {
+				t.Error("Verify() = true, want = false")
+			}
+		})
+	}
+
+	t.Run("short rand", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Commit() with short rand did not panic")
+			}
+		}()
+		commit.Commit("commit", value, make([]byte, 31))
+	})
+}
@@ -0,0 +1,78 @@
+// Package drbg provides a seeded, forkable deterministic random bit generator using the Thyrse protocol.
+//
+// Each read derives output from the protocol, which irreversibly advances its state: compromising the generator's state
+// reveals nothing about previously generated output. Parties which create generators with the same domain and seed, and
+// perform the same sequence of reads, reseeds, and forks, generate the same output.
+//
+// A hedged generator additionally mixes entropy from crypto/rand into its state before each read, so its output remains
+// unpredictable if its seed is weak or reused, and remains unpredictable to an attacker who knows its seed if the
+// system's randomness source is sound.
+//
+// For a construction in the style of NIST SP 800-90A, see the hazmat/drbg package.
+package drbg
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/codahale/thyrse"
+)
+
+// EntropySize is the number of bytes of entropy mixed into a hedged generator's state before each read.
+const EntropySize = 32
+
+// A DRBG is a deterministic random bit generator. It is not safe for concurrent use.
+type DRBG struct {
+	p      *thyrse.Protocol
+	hedged bool
+}
+
+// New returns a DRBG with the given domain separation string and seed. The seed must contain enough entropy for the
+// generator's output to be unpredictable (e.g. 32 uniformly random bytes).
+func New(domain string, seed []byte) *DRBG {
+	p := thyrse.New(domain)
+	p.Mix("seed", seed)
+	return &DRBG{p: p}
+}
+
+// NewHedged returns a DRBG like New, but which mixes EntropySize bytes from crypto/rand into its state before each
+// read. Its output is not deterministic.
+func NewHedged(domain string, seed []byte) *DRBG {
+	d := New(domain, seed)
+	d.hedged = true
+	return d
+}
+
+// Read fills b with pseudorandom data. It always returns len(b), nil.
+func (d *DRBG) Read(b []byte) (n int, err error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	if d.hedged {
+		var entropy [EntropySize]byte
+		if _, err := rand.Read(entropy[:]); err != nil {
+			panic(err)
+		}
+		d.p.Mix("entropy", entropy[:])
+	}
+	d.p.Derive("output", b[:0], len(b))
+	return len(b), nil
+}
+
+// Reseed mixes additional seed material into the generator's state. Subsequent output depends on both the original
+// seed and the new seed material.
+func (d *DRBG) Reseed(seed []byte) {
+	d.p.Mix("reseed", seed)
+}
+
+// Fork returns a new generator whose output is independent of this generator's, for use by a separate component (e.g.
+// a child process or a separate subsystem in a simulation). Forking with the given label also modifies this
+// generator's state, so neither generator's output can be predicted from the other's. A fork of a hedged generator is
+// also hedged.
+func (d *DRBG) Fork(label string) *DRBG {
+	child := d.p.ForkN(label, []byte("child"))[0]
+	return &DRBG{p: child, hedged: d.hedged}
+}
+
+var _ io.Reader = (*DRBG)(nil)
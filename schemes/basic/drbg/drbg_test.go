@@ -0,0 +1,86 @@
+package drbg_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse/schemes/basic/drbg"
+)
+
+func TestDRBG_Read(t *testing.T) {
+	t.Run("known answer", func(t *testing.T) {
+		d := drbg.New("drbg", []byte("seed"))
+		got := make([]byte, 32)
+		if _, err := io.ReadFull(d, got); err != nil {
+			t.Fatal(err)
+		}
+		if want := "1c5a635ae9ae94bedca6ed1fde12e6f12ba0fee5da073d0be4bc05e7562fe7a2"; hex.EncodeToString(got) != want {
+			t.Errorf("Read() = %x, want = %s", got, want)
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a, b := read(drbg.New("drbg", []byte("seed")), 3), read(drbg.New("drbg", []byte("seed")), 3)
+		if !bytes.Equal(a, b) {
+			t.Errorf("Read() = %x, want = %x", a, b)
+		}
+	})
+
+	t.Run("different seeds", func(t *testing.T) {
+		a, b := read(drbg.New("drbg", []byte("seed")), 1), read(drbg.New("drbg", []byte("other seed")), 1)
+		if bytes.Equal(a, b) {
+			t.Error("generators with different seeds produced the same output")
+		}
+	})
+
+	t.Run("successive reads", func(t *testing.T) {
+		d := drbg.New("drbg", []byte("seed"))
+		a, b := read(d, 1), read(d, 1)
+		if bytes.Equal(a, b) {
+			t.Error("successive reads produced the same output")
+		}
+	})
+
+	t.Run("hedged", func(t *testing.T) {
+		a, b := read(drbg.NewHedged("drbg", []byte("seed")), 1), read(drbg.NewHedged("drbg", []byte("seed")), 1)
+		if bytes.Equal(a, b) {
+			t.Error("hedged generators with the same seed produced the same output")
+		}
+	})
+}
+
+func TestDRBG_Reseed(t *testing.T) {
+	a, b := drbg.New("drbg", []byte("seed")), drbg.New("drbg", []byte("seed"))
+	b.Reseed([]byte("more entropy"))
+	if bytes.Equal(read(a, 1), read(b, 1)) {
+		t.Error("reseeded generator produced the same output")
+	}
+}
+
+func TestDRBG_Fork(t *testing.T) {
+	parent, reference := drbg.New("drbg", []byte("seed")), drbg.New("drbg", []byte("seed"))
+	child := parent.Fork("worker")
+
+	p, c, r := read(parent, 1), read(child, 1), read(reference, 1)
+	if bytes.Equal(p, c) {
+		t.Error("parent and child produced the same output")
+	}
+	if bytes.Equal(p, r) {
+		t.Error("fork did not modify the parent's state")
+	}
+
+	otherChild := drbg.New("drbg", []byte("seed")).Fork("worker")
+	if got, want := read(otherChild, 1), c; !bytes.Equal(got, want) {
+		t.Errorf("Fork() output = %x, want = %x", got, want)
+	}
+}
+
+func read(d *drbg.DRBG, n int) []byte {
+	b := make([]byte, 32*n)
+	for i := range n {
+		_, _ = d.Read(b[i*32 : (i+1)*32])
+	}
+	return b
+}
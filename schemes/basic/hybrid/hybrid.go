@@ -0,0 +1,334 @@
+// Package hybrid provides key encapsulation mechanisms (KEMs) which mix their shared secrets into Thyrse transcripts,
+// including a post-quantum hybrid of ML-KEM-768 and X25519.
+//
+// A sender encapsulates a shared secret for a receiver's public key, mixing it into a protocol and returning a
+// ciphertext. The receiver decapsulates the ciphertext with its private key, mixing the same shared secret into its
+// own protocol:
+//
+//	ct, err := hybrid.MLKEM768X25519.Encapsulate(p, pk)
+//	// ...
+//	err := hybrid.MLKEM768X25519.Decapsulate(p, sk, ct)
+//
+// Along with the shared secret, each KEM mixes its name, the receiver's public key, and the ciphertext into the
+// transcript. The hybrid KEM encapsulates with both of its components in turn, so its shared state is secure as long
+// as either ML-KEM-768 or X25519 is.
+//
+// DHKEM builds a KEM from any Diffie-Hellman group, such as those used by the hpke and handshake packages. The hpke
+// package's NewKEMSender and NewKEMReceiver accept any KEM, including the hybrid.
+package hybrid
+
+import (
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/thyrse"
+)
+
+// SeedSize is the size, in bytes, of the uniform random seed from which a KEM derives a key pair.
+const SeedSize = 64
+
+// ErrInvalidKey is returned when a public or private key cannot be decoded.
+var ErrInvalidKey = errors.New("thyrse/hybrid: invalid key")
+
+// A KEM encapsulates shared secrets into Thyrse transcripts. Keys and ciphertexts are passed as their canonical byte
+// encodings.
+type KEM interface {
+	// Name returns the KEM's name, which is mixed into every transcript.
+	Name() string
+
+	// PublicKeySize returns the size, in bytes, of an encoded public key.
+	PublicKeySize() int
+
+	// CiphertextSize returns the size, in bytes, of a ciphertext.
+	CiphertextSize() int
+
+	// DeriveKeyPair derives a private and public key from a SeedSize-byte uniform random seed. Panics if the seed is
+	// not SeedSize bytes long.
+	DeriveKeyPair(seed []byte) (sk, pk []byte)
+
+	// PublicKey returns the public key for the given private key.
+	PublicKey(sk []byte) ([]byte, error)
+
+	// Encapsulate generates a random shared secret for the public key, mixes it into the protocol, and returns the
+	// ciphertext. Returns ErrInvalidKey if the public key is invalid.
+	Encapsulate(p *thyrse.Protocol, pk []byte) ([]byte, error)
+
+	// Decapsulate recovers the shared secret from the ciphertext with the private key and mixes it into the protocol.
+	// Returns ErrInvalidKey if the private key is invalid, or thyrse.ErrInvalidCiphertext if the ciphertext is
+	// malformed.
+	Decapsulate(p *thyrse.Protocol, sk, ct []byte) error
+}
+
+var (
+	// X25519 is a KEM over X25519 (RFC 7748), using ephemeral-static Diffie-Hellman.
+	X25519 = DHKEM(X25519DH)
+
+	// MLKEM768 is ML-KEM-768 (FIPS 203).
+	MLKEM768 KEM = mlkemKEM{}
+
+	// MLKEM768X25519 is a hybrid of ML-KEM-768 and X25519.
+	MLKEM768X25519 KEM = hybridKEM{pq: MLKEM768, t: X25519}
+)
+
+// mix mixes a KEM's name, public key, ciphertext, and shared secret into the protocol.
+func mix(p *thyrse.Protocol, kem KEM, pk, ct, ss []byte) {
+	p.MixString("kem", kem.Name())
+	p.Mix("public-key", pk)
+	p.Mix("ciphertext", ct)
+	p.Mix("shared-secret", ss)
+}
+
+// A DH is a Diffie-Hellman group, from which DHKEM builds a KEM. Keys are passed as their canonical byte encodings.
+type DH interface {
+	// Name returns the group's name, which is mixed into every transcript.
+	Name() string
+
+	// PublicKeySize returns the size, in bytes, of an encoded public key.
+	PublicKeySize() int
+
+	// DeriveKeyPair derives a private and public key from a SeedSize-byte uniform random seed. Panics if the seed is
+	// not SeedSize bytes long.
+	DeriveKeyPair(seed []byte) (sk, pk []byte)
+
+	// PublicKey returns the public key for the given private key.
+	PublicKey(sk []byte) ([]byte, error)
+
+	// DH returns the Diffie-Hellman shared secret of the given private and public keys.
+	DH(sk, pk []byte) ([]byte, error)
+}
+
+// X25519DH is the X25519 Diffie-Hellman function (RFC 7748).
+var X25519DH DH = x25519DH{}
+
+// DHKEM returns a KEM which uses ephemeral-static Diffie-Hellman over the given group. Its ciphertexts are ephemeral
+// public keys.
+func DHKEM(dh DH) KEM {
+	return dhKEM{dh: dh}
+}
+
+type dhKEM struct {
+	dh DH
+}
+
+func (kem dhKEM) Name() string {
+	return kem.dh.Name()
+}
+
+func (kem dhKEM) PublicKeySize() int {
+	return kem.dh.PublicKeySize()
+}
+
+func (kem dhKEM) CiphertextSize() int {
+	return kem.dh.PublicKeySize()
+}
+
+func (kem dhKEM) DeriveKeyPair(seed []byte) (sk, pk []byte) {
+	return kem.dh.DeriveKeyPair(seed)
+}
+
+func (kem dhKEM) PublicKey(sk []byte) ([]byte, error) {
+	return kem.dh.PublicKey(sk)
+}
+
+func (kem dhKEM) Encapsulate(p *thyrse.Protocol, pk []byte) ([]byte, error) {
+	seed := make([]byte, SeedSize)
+	_, _ = rand.Read(seed)
+	skE, ct := kem.dh.DeriveKeyPair(seed)
+	ss, err := kem.dh.DH(skE, pk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	mix(p, kem, pk, ct, ss)
+	return ct, nil
+}
+
+func (kem dhKEM) Decapsulate(p *thyrse.Protocol, sk, ct []byte) error {
+	pk, err := kem.dh.PublicKey(sk)
+	if err != nil {
+		return ErrInvalidKey
+	}
+	ss, err := kem.dh.DH(sk, ct)
+	if err != nil {
+		return thyrse.ErrInvalidCiphertext
+	}
+	mix(p, kem, pk, ct, ss)
+	return nil
+}
+
+type x25519DH struct{}
+
+func (x25519DH) Name() string {
+	return "x25519"
+}
+
+func (x25519DH) PublicKeySize() int {
+	return 32
+}
+
+func (x25519DH) DeriveKeyPair(seed []byte) (sk, pk []byte) {
+	if len(seed) != SeedSize {
+		panic("thyrse/hybrid: invalid seed size")
+	}
+	k, err := ecdh.X25519().NewPrivateKey(seed[:32])
+	if err != nil {
+		panic(err)
+	}
+	return k.Bytes(), k.PublicKey().Bytes()
+}
+
+func (x25519DH) PublicKey(sk []byte) ([]byte, error) {
+	k, err := ecdh.X25519().NewPrivateKey(sk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return k.PublicKey().Bytes(), nil
+}
+
+func (x25519DH) DH(sk, pk []byte) ([]byte, error) {
+	k, err := ecdh.X25519().NewPrivateKey(sk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	q, err := ecdh.X25519().NewPublicKey(pk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	ss, err := k.ECDH(q)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return ss, nil
+}
+
+type mlkemKEM struct{}
+
+func (mlkemKEM) Name() string {
+	return "ml-kem-768"
+}
+
+func (mlkemKEM) PublicKeySize() int {
+	return mlkem.EncapsulationKeySize768
+}
+
+func (mlkemKEM) CiphertextSize() int {
+	return mlkem.CiphertextSize768
+}
+
+func (mlkemKEM) DeriveKeyPair(seed []byte) (sk, pk []byte) {
+	if len(seed) != SeedSize {
+		panic("thyrse/hybrid: invalid seed size")
+	}
+	k, err := mlkem.NewDecapsulationKey768(seed)
+	if err != nil {
+		panic(err)
+	}
+	return k.Bytes(), k.EncapsulationKey().Bytes()
+}
+
+func (mlkemKEM) PublicKey(sk []byte) ([]byte, error) {
+	k, err := mlkem.NewDecapsulationKey768(sk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return k.EncapsulationKey().Bytes(), nil
+}
+
+func (kem mlkemKEM) Encapsulate(p *thyrse.Protocol, pk []byte) ([]byte, error) {
+	ek, err := mlkem.NewEncapsulationKey768(pk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	ss, ct := ek.Encapsulate()
+	mix(p, kem, pk, ct, ss)
+	return ct, nil
+}
+
+func (kem mlkemKEM) Decapsulate(p *thyrse.Protocol, sk, ct []byte) error {
+	k, err := mlkem.NewDecapsulationKey768(sk)
+	if err != nil {
+		return ErrInvalidKey
+	}
+	ss, err := k.Decapsulate(ct)
+	if err != nil {
+		return thyrse.ErrInvalidCiphertext
+	}
+	mix(p, kem, k.EncapsulationKey().Bytes(), ct, ss)
+	return nil
+}
+
+// hybridKEM combines a post-quantum and a traditional KEM. Its private key is its seed, from which the components' key
+// pairs are derived, and its public keys and ciphertexts are the concatenations of the components'.
+type hybridKEM struct {
+	pq, t KEM
+}
+
+func (kem hybridKEM) Name() string {
+	return kem.pq.Name() + "+" + kem.t.Name()
+}
+
+func (kem hybridKEM) PublicKeySize() int {
+	return kem.pq.PublicKeySize() + kem.t.PublicKeySize()
+}
+
+func (kem hybridKEM) CiphertextSize() int {
+	return kem.pq.CiphertextSize() + kem.t.CiphertextSize()
+}
+
+func (kem hybridKEM) DeriveKeyPair(seed []byte) (sk, pk []byte) {
+	if len(seed) != SeedSize {
+		panic("thyrse/hybrid: invalid seed size")
+	}
+	_, pqPK, _, tPK := kem.deriveKeyPairs(seed)
+	return append([]byte(nil), seed...), append(pqPK, tPK...)
+}
+
+func (kem hybridKEM) PublicKey(sk []byte) ([]byte, error) {
+	if len(sk) != SeedSize {
+		return nil, ErrInvalidKey
+	}
+	_, pqPK, _, tPK := kem.deriveKeyPairs(sk)
+	return append(pqPK, tPK...), nil
+}
+
+func (kem hybridKEM) Encapsulate(p *thyrse.Protocol, pk []byte) ([]byte, error) {
+	if len(pk) != kem.PublicKeySize() {
+		return nil, ErrInvalidKey
+	}
+	p.MixString("kem", kem.Name())
+	pqCT, err := kem.pq.Encapsulate(p, pk[:kem.pq.PublicKeySize()])
+	if err != nil {
+		return nil, err
+	}
+	tCT, err := kem.t.Encapsulate(p, pk[kem.pq.PublicKeySize():])
+	if err != nil {
+		return nil, err
+	}
+	return append(pqCT, tCT...), nil
+}
+
+func (kem hybridKEM) Decapsulate(p *thyrse.Protocol, sk, ct []byte) error {
+	if len(sk) != SeedSize {
+		return ErrInvalidKey
+	}
+	if len(ct) != kem.CiphertextSize() {
+		return thyrse.ErrInvalidCiphertext
+	}
+	pqSK, _, tSK, _ := kem.deriveKeyPairs(sk)
+	p.MixString("kem", kem.Name())
+	if err := kem.pq.Decapsulate(p, pqSK, ct[:kem.pq.CiphertextSize()]); err != nil {
+		return err
+	}
+	return kem.t.Decapsulate(p, tSK, ct[kem.pq.CiphertextSize():])
+}
+
+// deriveKeyPairs derives the components' key pairs from the hybrid seed.
+func (kem hybridKEM) deriveKeyPairs(seed []byte) (pqSK, pqPK, tSK, tPK []byte) {
+	p := thyrse.New("thyrse.hybrid")
+	p.MixString("kem", kem.Name())
+	p.Mix("seed", seed)
+	pqSK, pqPK = kem.pq.DeriveKeyPair(p.Derive("pq-seed", nil, SeedSize))
+	tSK, tPK = kem.t.DeriveKeyPair(p.Derive("t-seed", nil, SeedSize))
+	return pqSK, pqPK, tSK, tPK
+}
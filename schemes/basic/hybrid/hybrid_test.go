@@ -0,0 +1,106 @@
+package hybrid_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/hybrid"
+)
+
+func TestKEM(t *testing.T) {
+	for _, kem := range []hybrid.KEM{hybrid.X25519, hybrid.MLKEM768, hybrid.MLKEM768X25519} {
+		t.Run(kem.Name(), func(t *testing.T) {
+			drbg := testdata.New("thyrse hybrid kem " + kem.Name())
+			sk, pk := kem.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+			skX, _ := kem.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+
+			sender := thyrse.New("hybrid")
+			ct, err := kem.Encapsulate(sender, pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := sender.Derive("key", nil, 16)
+
+			t.Run("sizes", func(t *testing.T) {
+				if got, want := len(pk), kem.PublicKeySize(); got != want {
+					t.Errorf("len(pk) = %d, want = %d", got, want)
+				}
+				if got, want := len(ct), kem.CiphertextSize(); got != want {
+					t.Errorf("len(ct) = %d, want = %d", got, want)
+				}
+			})
+
+			t.Run("public key", func(t *testing.T) {
+				got, err := kem.PublicKey(sk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, pk) {
+					t.Errorf("PublicKey() = %x, want = %x", got, pk)
+				}
+			})
+
+			t.Run("decapsulate", func(t *testing.T) {
+				receiver := thyrse.New("hybrid")
+				if err := kem.Decapsulate(receiver, sk, ct); err != nil {
+					t.Fatal(err)
+				}
+				if got := receiver.Derive("key", nil, 16); !bytes.Equal(got, want) {
+					t.Errorf("Derive() = %x, want = %x", got, want)
+				}
+			})
+
+			t.Run("wrong key", func(t *testing.T) {
+				receiver := thyrse.New("hybrid")
+				if err := kem.Decapsulate(receiver, skX, ct); err != nil {
+					t.Fatal(err)
+				}
+				if got := receiver.Derive("key", nil, 16); bytes.Equal(got, want) {
+					t.Error("Decapsulate() with the wrong key derived the same state")
+				}
+			})
+
+			t.Run("truncated ciphertext", func(t *testing.T) {
+				if err := kem.Decapsulate(thyrse.New("hybrid"), sk, ct[:len(ct)-1]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+					t.Errorf("Decapsulate() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+				}
+			})
+
+			t.Run("invalid public key", func(t *testing.T) {
+				if _, err := kem.Encapsulate(thyrse.New("hybrid"), pk[:len(pk)-1]); !errors.Is(err, hybrid.ErrInvalidKey) {
+					t.Errorf("Encapsulate() err = %v, want = %v", err, hybrid.ErrInvalidKey)
+				}
+			})
+		})
+	}
+}
+
+func TestMLKEM768X25519(t *testing.T) {
+	drbg := testdata.New("thyrse hybrid kem combiner")
+	sk, pk := hybrid.MLKEM768X25519.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+	ct, err := hybrid.MLKEM768X25519.Encapsulate(thyrse.New("hybrid"), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modifying either component's ciphertext changes the shared state.
+	for name, i := range map[string]int{"ml-kem": 0, "x25519": len(ct) - 1} {
+		t.Run(name, func(t *testing.T) {
+			a, b := thyrse.New("hybrid"), thyrse.New("hybrid")
+			if err := hybrid.MLKEM768X25519.Decapsulate(a, sk, ct); err != nil {
+				t.Fatal(err)
+			}
+			bad := bytes.Clone(ct)
+			bad[i] ^= 1
+			if err := hybrid.MLKEM768X25519.Decapsulate(b, sk, bad); err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(a.Derive("key", nil, 16), b.Derive("key", nil, 16)) {
+				t.Error("Decapsulate() of a modified ciphertext derived the same state")
+			}
+		})
+	}
+}
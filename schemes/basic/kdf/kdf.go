@@ -0,0 +1,47 @@
+// Package kdf provides an HKDF-style key derivation function using the Thyrse protocol.
+//
+// Like HKDF, derivation is split into an extract step, which condenses input keying material and an optional salt into
+// a Deriver, and an expand step, which derives any number of independent outputs from the Deriver, each bound to an
+// info string and its length. Unlike HKDF, there is no limit on the output length, and each input is mixed into the
+// transcript with its own label, so inputs cannot be confused with one another.
+//
+// The transcripts are equivalent to the following:
+//
+//	p := thyrse.New(domain)
+//	p.Mix("salt", salt)
+//	p.Mix("ikm", ikm)
+//	p.Mix("info", info)
+//	okm := p.Derive("okm", nil, n)
+package kdf
+
+import (
+	"github.com/codahale/thyrse"
+)
+
+// Derive derives n bytes of output keying material from the given input keying material, salt, and info. It is
+// equivalent to Extract(domain, salt, ikm).Expand(info, n).
+func Derive(domain string, ikm, salt, info []byte, n int) []byte {
+	return Extract(domain, salt, ikm).Expand(info, n)
+}
+
+// A Deriver derives multiple independent outputs from a single set of input keying material.
+type Deriver struct {
+	p *thyrse.Protocol
+}
+
+// Extract returns a Deriver for the given salt and input keying material. The salt may be nil.
+func Extract(domain string, salt, ikm []byte) *Deriver {
+	p := thyrse.New(domain)
+	p.Mix("salt", salt)
+	p.Mix("ikm", ikm)
+	return &Deriver{p: p}
+}
+
+// Expand derives n bytes of output keying material bound to the given info. Outputs with different info values or
+// lengths are independent of one another. The Deriver's state is not modified, so Expand may be called any number of
+// times.
+func (d *Deriver) Expand(info []byte, n int) []byte {
+	p := d.p.Clone()
+	p.Mix("info", info)
+	return p.Derive("okm", nil, n)
+}
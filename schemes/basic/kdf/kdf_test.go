@@ -0,0 +1,56 @@
+package kdf_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/basic/kdf"
+)
+
+func TestDerive(t *testing.T) {
+	ikm, salt, info := []byte("input keying material"), []byte("salt"), []byte("encryption key")
+
+	t.Run("transcript", func(t *testing.T) {
+		p := thyrse.New("kdf")
+		p.Mix("salt", salt)
+		p.Mix("ikm", ikm)
+		p.Mix("info", info)
+		if got, want := kdf.Derive("kdf", ikm, salt, info, 32), p.Derive("okm", nil, 32); !bytes.Equal(got, want) {
+			t.Errorf("Derive() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("known answer", func(t *testing.T) {
+		got := hex.EncodeToString(kdf.Derive("kdf", ikm, salt, info, 32))
+		if want := "f96792f1e272294852ce2f2028470672c848e5abd49a7af08fdf7a994a1b2284"; got != want {
+			t.Errorf("Derive() = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("inputs are separated", func(t *testing.T) {
+		a := kdf.Derive("kdf", []byte("ab"), []byte("c"), nil, 32)
+		b := kdf.Derive("kdf", []byte("a"), []byte("bc"), nil, 32)
+		if bytes.Equal(a, b) {
+			t.Error("Derive() outputs for different salt and ikm splits are equal")
+		}
+	})
+}
+
+func TestDeriver_Expand(t *testing.T) {
+	d := kdf.Extract("kdf", []byte("salt"), []byte("input keying material"))
+
+	encKey, macKey := d.Expand([]byte("encryption"), 32), d.Expand([]byte("mac"), 32)
+	if bytes.Equal(encKey, macKey) {
+		t.Error("outputs with different info are equal")
+	}
+
+	if got, want := d.Expand([]byte("encryption"), 32), encKey; !bytes.Equal(got, want) {
+		t.Errorf("Expand() = %x, want = %x", got, want)
+	}
+
+	if got, prefix := d.Expand([]byte("encryption"), 64), encKey; bytes.HasPrefix(got, prefix) {
+		t.Error("outputs with different lengths are related")
+	}
+}
@@ -5,6 +5,9 @@
 //   - 3N "static" nodes from indegree-reduced EGSample (DRSample ∪ Grates)
 //   - 2N "dynamic" challenge-chain nodes with random back-pointers
 //
+// For storing password hashes, CreateHash and VerifyHash encode the parameters of a hash alongside it in the PHC string
+// format, so stored hashes remain verifiable as parameters are changed.
+//
 // [DEGSample]: https://arxiv.org/pdf/2508.06795
 package mhf
 
@@ -13,27 +16,76 @@ import (
 	"fmt"
 	"math"
 	"math/bits"
+	"sync"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/hazmat/secmem"
 )
 
 // Hash calculates a memory-hard hash of the given password and salt using the DEGSample construction with the given
-// cost and salt parameters. It appends n bytes of output to dst and returns the resulting slice.
+// cost and salt parameters and a block size of DefaultBlockSize. It appends n bytes of output to dst and returns the
+// resulting slice.
 //
-// The total memory usage required is 5*2**(cost+10) to perform 7*2**(cost+10) operations.
+// The total memory usage required is 5*2**(cost+10) bytes to perform 7*2**(cost+10) bytes of hashing (see MemoryCost
+// and HashCost).
 //
 // For online operations (i.e., password validation), the cost parameter should be selected so that the total operation
 // takes ~100ms; for offline operations (i.e., password-based encryption), the cost parameter should be selected to
 // fully use all available memory.
 func Hash(domain string, cost uint8, salt, password, dst []byte, n int) []byte {
+	return HashWithBlockSize(domain, cost, DefaultBlockSize, salt, password, dst, n)
+}
+
+// HashWithBlockSize is like Hash, but with a configurable block size. The block size is bound into the hash, so
+// different block sizes produce unrelated outputs. A block size of DefaultBlockSize is equivalent to Hash.
+//
+// Larger blocks reduce the number of nodes in the graph for a given memory cost, trading per-node overhead for coarser
+// memory granularity; smaller blocks increase the number of random accesses, raising the bandwidth an attacker's
+// hardware must sustain.
+//
+// Panics if blockSize is less than MinBlockSize or greater than MaxBlockSize, or if the memory cost does not fit in the
+// platform's address space (e.g. a cost of 22 or more with the default block size on 32-bit platforms).
+func HashWithBlockSize(domain string, cost uint8, blockSize int, salt, password, dst []byte, n int) []byte {
+	return HashParallel(domain, cost, blockSize, 1, salt, password, dst, n)
+}
+
+// HashParallel is like HashWithBlockSize, but splits the static part of the graph into the given number of independent
+// lanes, which are calculated concurrently and combined before the data-dependent challenge chain. The memory cost and
+// hashing cost are the same as for a single lane, but the wall-clock time of the static part is divided by the
+// parallelism given enough cores, much like Argon2's p parameter. The parallelism is bound into the hash, and a
+// parallelism of 1 is equivalent to HashWithBlockSize.
+//
+// Panics if parallelism is not a power of two between 1 and 2**cost, or under the same conditions as HashWithBlockSize.
+func HashParallel(domain string, cost uint8, blockSize, parallelism int, salt, password, dst []byte, n int) []byte {
+	if blockSize < MinBlockSize || blockSize > MaxBlockSize {
+		panic("thyrse/mhf: invalid block size")
+	}
+	if !fitsPlatform(cost, blockSize) {
+		panic("thyrse/mhf: cost too large for platform")
+	}
+	if parallelism < 1 || bits.OnesCount(uint(parallelism)) != 1 || uint64(parallelism) > uint64(1)<<cost {
+		panic("thyrse/mhf: invalid parallelism")
+	}
+
 	// Calculate parameters and allocate memory.
 	N := 1 << cost
 	totalNodes, staticNodes, gratesCols := 5*N, 3*N, numGratesCols(N)
-	blocks := make([][blockSize]byte, totalNodes)
+	blocks := make([][]byte, totalNodes)
+	memory := make([]byte, totalNodes*blockSize)
+	for i := range blocks {
+		blocks[i] = memory[i*blockSize : (i+1)*blockSize : (i+1)*blockSize]
+	}
+	defer secmem.Wipe(memory)
 
 	// Initialize the root protocol and mix in all public parameters.
 	root := thyrse.New(domain)
 	root.Mix("cost", []byte{cost})
+	if blockSize != DefaultBlockSize {
+		root.Mix("block-size", binary.BigEndian.AppendUint32(nil, uint32(blockSize)))
+	}
+	if parallelism > 1 {
+		root.Mix("parallelism", binary.BigEndian.AppendUint32(nil, uint32(parallelism)))
+	}
 	root.Mix("salt", salt)
 
 	// Fork into data-independent and data-dependent branches.
@@ -45,20 +97,30 @@ func Hash(domain string, cost uint8, salt, password, dst []byte, n int) []byte {
 	// ------------------------------------------------------------------
 	// Phase 1: Static part (3N nodes of indegree-reduced EGSample)
 	// ------------------------------------------------------------------
-	// Source node: a hash of all the parameters.
-	dd.Derive("source", blocks[0][:0], blockSize)
-
-	for v := 1; v < staticNodes; v++ {
-		p1, p2 := staticParents(id.Clone(), gratesCols, v)
-		h := dd.Clone()
-		h.Mix("node", binary.AppendUvarint(nil, uint64(v)))
-		if p1 >= 0 {
-			h.Mix("required", blocks[p1][:])
+	if parallelism == 1 {
+		fillStatic(id, dd, gratesCols, blocks[:staticNodes])
+	} else {
+		// Split the static part into lanes of 3N/p contiguous nodes, each an independent EGSample graph on N/p
+		// original nodes. As the lanes are contiguous, the back-pointers of the dynamic phase address the last
+		// sub-node of original node r of the combined graph, as with a single lane.
+		laneNodes := staticNodes / parallelism
+		laneCols := numGratesCols(N / parallelism)
+		lanes := make([]*thyrse.Protocol, parallelism)
+		var wg sync.WaitGroup
+		for l := range lanes {
+			lanes[l] = dd.Clone()
+			lanes[l].Mix("lane", binary.AppendUvarint(nil, uint64(l)))
+			laneID := id.Clone()
+			wg.Go(func() {
+				fillStatic(laneID, lanes[l], laneCols, blocks[l*laneNodes:(l+1)*laneNodes])
+			})
 		}
-		if p2 >= 0 {
-			h.Mix("optional", blocks[p2][:])
+		wg.Wait()
+
+		// Combine the lanes by mixing the last node of each into the data-dependent branch.
+		for l := range lanes {
+			dd.Mix("lane-output", blocks[(l+1)*laneNodes-1])
 		}
-		h.Derive("static", blocks[v][:0], blockSize)
 	}
 
 	// ------------------------------------------------------------------
@@ -80,7 +142,7 @@ func Hash(domain string, cost uint8, salt, password, dst []byte, n int) []byte {
 		prev := v - 1
 
 		h := dd.Clone()
-		h.Mix("prev", blocks[prev][:])
+		h.Mix("prev", blocks[prev])
 
 		// Step 1: Compute static pre-label to discover dynamic edge.
 		var buf [8]byte
@@ -91,14 +153,54 @@ func Hash(domain string, cost uint8, salt, password, dst []byte, n int) []byte {
 		target := 3*r + 2 // last sub-node of original node r
 
 		// Step 3: Compute final dynamic label.
-		h.Mix("back-pointer", blocks[target][:])
+		h.Mix("back-pointer", blocks[target])
 		h.Derive("dynamic", blocks[v][:0], blockSize)
 	}
 
-	dd.Mix("final", blocks[totalNodes-1][:])
+	dd.Mix("final", blocks[totalNodes-1])
 	return dd.Derive("output", dst, n)
 }
 
+// fillStatic calculates the nodes of an indegree-reduced EGSample graph into blocks, using the data-independent
+// protocol to derive its structure and the data-dependent protocol to derive its labels. The source node is derived
+// directly from the data-dependent protocol.
+func fillStatic(id, dd *thyrse.Protocol, gratesCols int, blocks [][]byte) {
+	blockSize := len(blocks[0])
+
+	// Source node: a hash of all the parameters.
+	dd.Derive("source", blocks[0][:0], blockSize)
+
+	for v := 1; v < len(blocks); v++ {
+		p1, p2 := staticParents(id.Clone(), gratesCols, v)
+		h := dd.Clone()
+		h.Mix("node", binary.AppendUvarint(nil, uint64(v)))
+		if p1 >= 0 {
+			h.Mix("required", blocks[p1])
+		}
+		if p2 >= 0 {
+			h.Mix("optional", blocks[p2])
+		}
+		h.Derive("static", blocks[v][:0], blockSize)
+	}
+}
+
+// MemoryCost returns the number of bytes of memory required to calculate a hash with the given cost and block size:
+// 5*2**cost*blockSize.
+func MemoryCost(cost uint8, blockSize int) uint64 {
+	return 5 * (uint64(1) << cost) * uint64(blockSize)
+}
+
+// fitsPlatform reports whether the MemoryCost of the given cost and block size fits in the platform's address space.
+func fitsPlatform(cost uint8, blockSize int) bool {
+	return uint64(blockSize) <= (math.MaxInt/5)>>cost
+}
+
+// HashCost returns the number of bytes hashed to calculate a hash with the given cost and block size:
+// 7*2**cost*blockSize.
+func HashCost(cost uint8, blockSize int) uint64 {
+	return 7 * (uint64(1) << cost) * uint64(blockSize)
+}
+
 // staticParents returns the parent indices (p1, p2) for a node in the indegree-reduced static graph. p2 = -1 means only
 // one parent. If both p1 = p2 = -1, then the node is a source.
 //
@@ -246,9 +348,17 @@ func numGratesCols(n int) int {
 }
 
 const (
-	// blockSize is the label size in bytes.
-	blockSize = 1024
+	// DefaultBlockSize is the block size, in bytes, used by Hash.
+	DefaultBlockSize = 1024
+
+	// MinBlockSize is the minimum block size, in bytes.
+	MinBlockSize = 64
 
+	// MaxBlockSize is the maximum block size, in bytes.
+	MaxBlockSize = 1024 * 1024
+)
+
+const (
 	// epsilon controls the Grates depth-robustness exponent.
 	// Grates(N, Epsilon) is (γN, γ'N^{1-Epsilon})-depth-robust.
 	// Smaller Epsilon → stronger depth guarantee but smaller constant γ'.
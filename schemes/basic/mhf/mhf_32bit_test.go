@@ -0,0 +1,28 @@
+//go:build 386 || arm || mips || mipsle
+
+package mhf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/schemes/basic/mhf"
+)
+
+func TestHash32Bit(t *testing.T) {
+	// 5*2**22*1024 bytes overflows a 32-bit int, so this must panic rather than allocate a truncated buffer.
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Hash() did not panic")
+		}
+	}()
+	mhf.Hash("test", 22, nil, nil, nil, 32)
+}
+
+func TestLimits_Check32Bit(t *testing.T) {
+	// Verifiers must be able to reject such parameters from an encoded hash with an error rather than a panic.
+	limits := mhf.Limits{MaxMemory: mhf.MemoryCost(22, mhf.DefaultBlockSize)}
+	if err := limits.Check(mhf.Params{Cost: 22}); !errors.Is(err, mhf.ErrCostTooHigh) {
+		t.Errorf("Check() = %v, want %v", err, mhf.ErrCostTooHigh)
+	}
+}
@@ -68,6 +68,55 @@ func TestHash(t *testing.T) {
 			t.Errorf("Hash = %x, want != %x", got, want)
 		}
 	})
+
+	t.Run("default block size", func(t *testing.T) {
+		if got, want := mhf.HashWithBlockSize(domain, cost, mhf.DefaultBlockSize, salt, password, nil, n), hash; !bytes.Equal(got, want) {
+			t.Errorf("HashWithBlockSize = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("wrong block size", func(t *testing.T) {
+		if got, want := mhf.HashWithBlockSize(domain, cost, 512, salt, password, nil, n), hash; bytes.Equal(got, want) {
+			t.Errorf("HashWithBlockSize = %x, want != %x", got, want)
+		}
+	})
+}
+
+func TestHashWithBlockSize(t *testing.T) {
+	for _, blockSize := range []int{mhf.MinBlockSize - 1, mhf.MaxBlockSize + 1} {
+		t.Run(fmt.Sprintf("invalid block size %d", blockSize), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("HashWithBlockSize() did not panic")
+				}
+			}()
+			mhf.HashWithBlockSize("test", 4, blockSize, nil, nil, nil, 32)
+		})
+	}
+
+	t.Run("cost too large", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("HashWithBlockSize() did not panic")
+			}
+		}()
+		mhf.HashWithBlockSize("test", 255, mhf.MinBlockSize, nil, nil, nil, 32)
+	})
+}
+
+func TestMemoryCost(t *testing.T) {
+	if got, want := mhf.MemoryCost(10, mhf.DefaultBlockSize), uint64(5*1024*1024); got != want {
+		t.Errorf("MemoryCost(10, 1024) = %d, want = %d", got, want)
+	}
+	if got, want := mhf.MemoryCost(4, 64), uint64(5*16*64); got != want {
+		t.Errorf("MemoryCost(4, 64) = %d, want = %d", got, want)
+	}
+}
+
+func TestHashCost(t *testing.T) {
+	if got, want := mhf.HashCost(10, mhf.DefaultBlockSize), uint64(7*1024*1024); got != want {
+		t.Errorf("HashCost(10, 1024) = %d, want = %d", got, want)
+	}
 }
 
 func FuzzHash(f *testing.F) {
@@ -211,3 +260,45 @@ func FuzzHashEmptyInputs(f *testing.F) {
 		}
 	})
 }
+
+func TestHashParallel(t *testing.T) {
+	salt, password := []byte("salt"), []byte("password")
+
+	t.Run("single lane", func(t *testing.T) {
+		got := mhf.HashParallel("test", 8, mhf.MinBlockSize, 1, salt, password, nil, 32)
+		want := mhf.HashWithBlockSize("test", 8, mhf.MinBlockSize, salt, password, nil, 32)
+		if !bytes.Equal(got, want) {
+			t.Errorf("HashParallel(p=1) = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a := mhf.HashParallel("test", 8, mhf.MinBlockSize, 4, salt, password, nil, 32)
+		b := mhf.HashParallel("test", 8, mhf.MinBlockSize, 4, salt, password, nil, 32)
+		if !bytes.Equal(a, b) {
+			t.Errorf("HashParallel() = %x, then %x", a, b)
+		}
+	})
+
+	t.Run("parallelism is bound", func(t *testing.T) {
+		seen := make(map[string]int)
+		for _, p := range []int{1, 2, 4, 256} {
+			h := string(mhf.HashParallel("test", 8, mhf.MinBlockSize, p, salt, password, nil, 32))
+			if other, ok := seen[h]; ok {
+				t.Errorf("HashParallel(p=%d) == HashParallel(p=%d)", p, other)
+			}
+			seen[h] = p
+		}
+	})
+
+	for _, p := range []int{0, 3, 512} {
+		t.Run(fmt.Sprintf("invalid parallelism %d", p), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("HashParallel() did not panic")
+				}
+			}()
+			mhf.HashParallel("test", 8, mhf.MinBlockSize, p, salt, password, nil, 32)
+		})
+	}
+}
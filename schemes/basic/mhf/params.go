@@ -0,0 +1,161 @@
+package mhf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidHash is returned when an encoded hash is malformed or has unsupported parameters.
+	ErrInvalidHash = errors.New("thyrse/mhf: invalid encoded hash")
+
+	// ErrCostTooHigh is returned when an encoded hash's parameters exceed the verifier's Limits, or require more memory
+	// than the platform can address.
+	ErrCostTooHigh = errors.New("thyrse/mhf: cost too high")
+)
+
+// Limits bound the resources used to calculate a hash whose parameters come from an untrusted source, such as an
+// encoded hash an attacker may have modified.
+type Limits struct {
+	// MaxMemory is the maximum memory cost of a hash (see MemoryCost), in bytes.
+	MaxMemory uint64
+
+	// MaxParallelism is the maximum number of lanes, each of which is calculated on its own goroutine. If zero, only a
+	// single lane is allowed.
+	MaxParallelism int
+}
+
+// Check returns ErrCostTooHigh if calculating a hash with the given parameters would use more memory or lanes than the
+// limits allow, or more memory than the platform can address. The parameters' block size and parallelism must be
+// valid.
+func (l Limits) Check(params Params) error {
+	blockSize := params.blockSize()
+	if !fitsPlatform(params.Cost, blockSize) || uint64(blockSize) > (l.MaxMemory/5)>>params.Cost ||
+		params.parallelism() > max(l.MaxParallelism, 1) {
+		return ErrCostTooHigh
+	}
+	return nil
+}
+
+// Params are the parameters of a hash.
+type Params struct {
+	// Domain is the domain string of the hash. It is not included in encoded hashes, and must be given separately to
+	// VerifyHash.
+	Domain string
+
+	// Cost is the memory cost parameter; see Hash.
+	Cost uint8
+
+	// BlockSize is the block size, in bytes. If zero, DefaultBlockSize is used.
+	BlockSize int
+
+	// Parallelism is the number of lanes; see HashParallel. If zero, a single lane is used.
+	Parallelism int
+
+	// OutputLen is the length of the hash, in bytes.
+	OutputLen int
+}
+
+// Hash calculates a memory-hard hash of the given password and salt with the parameters, appending the output to dst
+// and returning the resulting slice.
+func (p Params) Hash(salt, password, dst []byte) []byte {
+	return HashParallel(p.Domain, p.Cost, p.blockSize(), p.parallelism(), salt, password, dst, p.OutputLen)
+}
+
+// CreateHash hashes the password with the parameters and a random 16-byte salt, returning a self-describing encoded
+// hash in the PHC string format:
+//
+//	$thyrse-mhf$v=1$c=<cost>,b=<block size>,p=<parallelism>$<salt>$<hash>
+//
+// The salt and hash are encoded in unpadded standard base64. The domain is not encoded.
+func CreateHash(params Params, password []byte) string {
+	salt := make([]byte, saltSize)
+	_, _ = rand.Read(salt)
+	hash := params.Hash(salt, password, nil)
+
+	return fmt.Sprintf("$%s$v=%d$c=%d,b=%d,p=%d$%s$%s", phcID, phcVersion, params.Cost, params.blockSize(),
+		params.parallelism(), phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(hash))
+}
+
+// VerifyHash reports whether the password matches the encoded hash, using the parameters encoded in it. Hashes whose
+// parameters exceed the limits are rejected with ErrCostTooHigh before any hashing (see Limits.Check), bounding the
+// memory and goroutines an attacker who can modify stored hashes can consume. Returns ErrInvalidHash if the encoded
+// hash is malformed.
+func VerifyHash(domain string, limits Limits, password []byte, encoded string) (bool, error) {
+	params, salt, hash, err := decodeHash(encoded)
+	if err != nil {
+		return false, err
+	}
+	if err := limits.Check(params); err != nil {
+		return false, err
+	}
+
+	params.Domain = domain
+	return subtle.ConstantTimeCompare(params.Hash(salt, password, nil), hash) == 1, nil
+}
+
+// NeedsRehash reports whether the encoded hash was created with parameters other than the given ones, in which case the
+// password should be rehashed with CreateHash after it has been verified. Malformed hashes need rehashing.
+func NeedsRehash(params Params, encoded string) bool {
+	got, _, hash, err := decodeHash(encoded)
+	return err != nil || got.Cost != params.Cost || got.BlockSize != params.blockSize() ||
+		got.Parallelism != params.parallelism() || len(hash) != params.OutputLen
+}
+
+// decodeHash parses a PHC string created by CreateHash.
+func decodeHash(encoded string) (params Params, salt, hash []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != phcID || parts[2] != "v="+strconv.Itoa(phcVersion) {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var cost, blockSize, parallelism int
+	if _, err := fmt.Sscanf(parts[3], "c=%d,b=%d,p=%d", &cost, &blockSize, &parallelism); err != nil ||
+		parts[3] != fmt.Sprintf("c=%d,b=%d,p=%d", cost, blockSize, parallelism) {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	if cost < 0 || cost > 0xff || blockSize < MinBlockSize || blockSize > MaxBlockSize || parallelism < 1 ||
+		parallelism&(parallelism-1) != 0 || uint64(parallelism) > uint64(1)<<min(cost, 63) {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	salt, err = phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	hash, err = phcEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	return Params{Cost: uint8(cost), BlockSize: blockSize, Parallelism: parallelism, OutputLen: len(hash)}, salt, hash,
+		nil
+}
+
+func (p Params) blockSize() int {
+	if p.BlockSize == 0 {
+		return DefaultBlockSize
+	}
+	return p.BlockSize
+}
+
+func (p Params) parallelism() int {
+	if p.Parallelism == 0 {
+		return 1
+	}
+	return p.Parallelism
+}
+
+const (
+	phcID      = "thyrse-mhf"
+	phcVersion = 1
+	saltSize   = 16
+)
+
+// phcEncoding is the unpadded standard base64 encoding used by the PHC string format.
+var phcEncoding = base64.RawStdEncoding.Strict()
@@ -0,0 +1,138 @@
+package mhf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/codahale/thyrse/schemes/basic/mhf"
+)
+
+func TestParams_Hash(t *testing.T) {
+	params := mhf.Params{Domain: "test", Cost: 6, OutputLen: 32}
+	got := params.Hash([]byte("salt"), []byte("password"), nil)
+	want := mhf.Hash("test", 6, []byte("salt"), []byte("password"), nil, 32)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Hash() = %x, want %x", got, want)
+	}
+}
+
+func TestCreateHash(t *testing.T) {
+	params := mhf.Params{Domain: "test", Cost: 6, BlockSize: mhf.MinBlockSize, Parallelism: 2, OutputLen: 32}
+	encoded := mhf.CreateHash(params, []byte("password"))
+
+	t.Run("format", func(t *testing.T) {
+		if want := "$thyrse-mhf$v=1$c=6,b=64,p=2$"; !strings.HasPrefix(encoded, want) {
+			t.Errorf("CreateHash() = %q, want prefix %q", encoded, want)
+		}
+	})
+
+	t.Run("random salt", func(t *testing.T) {
+		if other := mhf.CreateHash(params, []byte("password")); other == encoded {
+			t.Errorf("CreateHash() = %q twice", encoded)
+		}
+	})
+
+	t.Run("needs rehash", func(t *testing.T) {
+		if mhf.NeedsRehash(params, encoded) {
+			t.Error("NeedsRehash(same params) = true, want false")
+		}
+		stronger := params
+		stronger.Cost++
+		if !mhf.NeedsRehash(stronger, encoded) {
+			t.Error("NeedsRehash(stronger params) = false, want true")
+		}
+		if !mhf.NeedsRehash(params, "garbage") {
+			t.Error("NeedsRehash(garbage) = false, want true")
+		}
+	})
+}
+
+func TestVerifyHash(t *testing.T) {
+	params := mhf.Params{Domain: "test", Cost: 6, OutputLen: 32}
+	encoded := mhf.CreateHash(params, []byte("password"))
+	limits := mhf.Limits{MaxMemory: mhf.MemoryCost(10, mhf.DefaultBlockSize)}
+
+	t.Run("valid", func(t *testing.T) {
+		ok, err := mhf.VerifyHash("test", limits, []byte("password"), encoded)
+		if err != nil || !ok {
+			t.Errorf("VerifyHash() = %v, %v, want true, nil", ok, err)
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		ok, err := mhf.VerifyHash("test", limits, []byte("wrong"), encoded)
+		if err != nil || ok {
+			t.Errorf("VerifyHash() = %v, %v, want false, nil", ok, err)
+		}
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		ok, err := mhf.VerifyHash("other", limits, []byte("password"), encoded)
+		if err != nil || ok {
+			t.Errorf("VerifyHash() = %v, %v, want false, nil", ok, err)
+		}
+	})
+
+	for name, encoded := range map[string]string{
+		"cost":        strings.Replace(encoded, "c=6", "c=11", 1),
+		"huge cost":   strings.Replace(encoded, "c=6", "c=255", 1),
+		"block size":  strings.Replace(encoded, "b=1024", fmt.Sprintf("b=%d", mhf.MaxBlockSize), 1),
+		"parallelism": strings.Replace(encoded, "p=1", "p=2", 1),
+	} {
+		t.Run(name+" too high", func(t *testing.T) {
+			if _, err := mhf.VerifyHash("test", limits, []byte("password"), encoded); !errors.Is(err, mhf.ErrCostTooHigh) {
+				t.Errorf("VerifyHash() err = %v, want %v", err, mhf.ErrCostTooHigh)
+			}
+		})
+	}
+
+	for name, encoded := range map[string]string{
+		"empty":               "",
+		"wrong id":            strings.Replace(encoded, "thyrse-mhf", "argon2id", 1),
+		"wrong version":       strings.Replace(encoded, "v=1", "v=2", 1),
+		"non-canonical param": strings.Replace(encoded, "c=6", "c=06", 1),
+		"invalid block size":  strings.Replace(encoded, "b=1024", "b=1", 1),
+		"invalid parallelism": strings.Replace(encoded, "p=1", "p=3", 1),
+		"invalid base64":      encoded + "!",
+		"missing hash":        encoded[:strings.LastIndex(encoded, "$")+1],
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := mhf.VerifyHash("test", limits, []byte("password"), encoded); !errors.Is(err, mhf.ErrInvalidHash) {
+				t.Errorf("VerifyHash() err = %v, want %v", err, mhf.ErrInvalidHash)
+			}
+		})
+	}
+}
+
+func TestLimits_Check(t *testing.T) {
+	limits := mhf.Limits{MaxMemory: mhf.MemoryCost(10, mhf.DefaultBlockSize), MaxParallelism: 4}
+	for _, tc := range []struct {
+		params mhf.Params
+		want   error
+	}{
+		{mhf.Params{Cost: 10}, nil},
+		{mhf.Params{Cost: 11}, mhf.ErrCostTooHigh},
+		{mhf.Params{Cost: 11, BlockSize: 512}, nil},
+		{mhf.Params{Cost: 10, BlockSize: 2048}, mhf.ErrCostTooHigh},
+		{mhf.Params{Cost: 255}, mhf.ErrCostTooHigh},
+		{mhf.Params{Cost: 10, Parallelism: 4}, nil},
+		{mhf.Params{Cost: 10, Parallelism: 8}, mhf.ErrCostTooHigh},
+		{mhf.Params{Cost: 63, BlockSize: mhf.MaxBlockSize}, mhf.ErrCostTooHigh},
+	} {
+		if got := limits.Check(tc.params); !errors.Is(got, tc.want) {
+			t.Errorf("Check(%+v) = %v, want %v", tc.params, got, tc.want)
+		}
+	}
+
+	t.Run("unlimited memory", func(t *testing.T) {
+		// Even without a memory limit, parameters which the platform cannot address are rejected, not panicked on.
+		unlimited := mhf.Limits{MaxMemory: math.MaxUint64}
+		if got := unlimited.Check(mhf.Params{Cost: 60}); !errors.Is(got, mhf.ErrCostTooHigh) {
+			t.Errorf("Check() = %v, want %v", got, mhf.ErrCostTooHigh)
+		}
+	})
+}
@@ -3,9 +3,17 @@
 // OAE2 allows for secure streaming of data with a fixed block size, providing confidentiality, integrity, and
 // authenticity. It protects against truncation, tampering, and block-reordering attacks by using a stateful
 // cryptographic protocol to authenticate each block in sequence.
+//
+// Streams can alternatively use the nonce-based STREAM construction, selected with WithSTREAM, in which each block is
+// sealed independently with a nonce derived from its position in the stream. Neither construction is interoperable
+// with other stream encryption formats.
+//
+// In either construction, blocks can also be bound to a stream ID, with WithStreamID, and to per-block associated data,
+// with WithBlockAD, so that blocks cannot be spliced between streams which share a key.
 package oae2
 
 import (
+	"encoding/binary"
 	"errors"
 	"io"
 
@@ -19,8 +27,10 @@ import (
 type Writer struct {
 	p         *thyrse.Protocol
 	w         io.Writer
+	cfg       config
 	blockSize int
 	buf       []byte // plaintext accumulator, flushed when it reaches blockSize
+	blocks    int64  // number of blocks flushed to w
 	closed    bool   // true after Close returns, makes Close idempotent
 	err       error  // sticky write error; once set, all further operations fail
 }
@@ -32,25 +42,117 @@ type Writer struct {
 //
 // The returned io.WriteCloser MUST be closed for the encrypted stream to be valid. The provided thyrse.Protocol MUST
 // NOT be used while the writer is open.
-func NewWriter(p *thyrse.Protocol, w io.Writer, blockSize int) *Writer {
+func NewWriter(p *thyrse.Protocol, w io.Writer, blockSize int, opts ...Option) *Writer {
 	if blockSize < 1 {
 		panic("oae2: block size must be at least 1")
 	}
 	return &Writer{
 		p:         p,
 		w:         w,
+		cfg:       newConfig(opts),
 		blockSize: blockSize,
 		buf:       make([]byte, 0, blockSize),
 	}
 }
 
+// ResumeWriter returns a Writer which continues the stream recorded in the given checkpoint, appending blocks of the
+// given size to w. The block size and options must match those used to write the original stream.
+//
+// The caller is responsible for positioning w at the checkpoint's offset (e.g. by truncating a partially written file to
+// Checkpoint.Offset and seeking to its end) and for re-writing any plaintext after Checkpoint.PlaintextOffset. The
+// checkpoint's protocol is used by the writer and MUST NOT be used elsewhere while the writer is open.
+func ResumeWriter(c Checkpoint, w io.Writer, blockSize int, opts ...Option) *Writer {
+	ww := NewWriter(c.Protocol, w, blockSize, opts...)
+	ww.blocks = c.Blocks
+	return ww
+}
+
+// A Checkpoint records the state of a Writer at a block boundary, allowing a partially written stream to be resumed
+// with ResumeWriter without rewriting its existing blocks.
+type Checkpoint struct {
+	// Protocol is an independent copy of the writer's protocol state after the last full block was written.
+	Protocol *thyrse.Protocol
+
+	// Blocks is the number of full blocks written before the checkpoint.
+	Blocks int64
+
+	// Offset is the number of bytes of ciphertext written to the underlying writer before the checkpoint.
+	Offset int64
+
+	// PlaintextOffset is the number of bytes of plaintext covered by the checkpoint.
+	PlaintextOffset int64
+}
+
+// ErrInvalidCheckpoint is returned when a serialized checkpoint is malformed.
+var ErrInvalidCheckpoint = errors.New("oae2: invalid checkpoint")
+
+// MarshalBinary encodes the checkpoint as the block count, the ciphertext offset, the plaintext offset, and the
+// serialized protocol state, so it can be persisted alongside a partially written stream and restored with
+// Checkpoint.UnmarshalBinary after a crash.
+//
+// The encoding contains the stream's protocol state, which is as sensitive as the key used to encrypt it, and must be
+// stored accordingly (e.g. sealed with a separate key). The writer's protocol must be serializable (see
+// thyrse.Protocol.SetSerializable), or MarshalBinary returns thyrse.ErrNotSerializable.
+func (c Checkpoint) MarshalBinary() ([]byte, error) {
+	state, err := c.Protocol.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, 24+len(state))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.Blocks))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.Offset))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.PlaintextOffset))
+	return append(b, state...), nil
+}
+
+// UnmarshalBinary decodes a checkpoint encoded with MarshalBinary, replacing the receiver's contents. Returns
+// ErrInvalidCheckpoint if data is malformed.
+func (c *Checkpoint) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return ErrInvalidCheckpoint
+	}
+	blocks := int64(binary.BigEndian.Uint64(data))
+	offset := int64(binary.BigEndian.Uint64(data[8:]))
+	plaintextOffset := int64(binary.BigEndian.Uint64(data[16:]))
+	if blocks < 0 || offset < 0 || plaintextOffset < 0 {
+		return ErrInvalidCheckpoint
+	}
+
+	p := new(thyrse.Protocol)
+	if err := p.UnmarshalBinary(data[24:]); err != nil {
+		return ErrInvalidCheckpoint
+	}
+	*c = Checkpoint{Protocol: p, Blocks: blocks, Offset: offset, PlaintextOffset: plaintextOffset}
+	return nil
+}
+
+// Checkpoint returns a checkpoint of the stream at the last full block written. Plaintext buffered in a partial block
+// is not covered by the checkpoint and must be re-written after resuming.
+//
+// A closed writer cannot be checkpointed, as resuming after the final block would produce a stream readers reject. Nor
+// can a writer which has failed to write a block; resume from a checkpoint taken before the failure instead.
+func (w *Writer) Checkpoint() (Checkpoint, error) {
+	if w.closed {
+		return Checkpoint{}, thyrse.ErrClosed
+	}
+	if w.err != nil {
+		return Checkpoint{}, w.err
+	}
+	return Checkpoint{
+		Protocol:        w.p.Clone(),
+		Blocks:          w.blocks,
+		Offset:          w.blocks * int64(w.blockSize+thyrse.TagSize),
+		PlaintextOffset: w.blocks * int64(w.blockSize),
+	}, nil
+}
+
 // Write writes data to the underlying io.Writer in buffered blocks.
 //
 // It encrypts and authenticates full blocks of size blockSize. Partial blocks are buffered until enough data is written
 // or Close is called.
 func (w *Writer) Write(data []byte) (n int, err error) {
 	if w.closed {
-		return 0, errors.New("oae2: Writer closed")
+		return 0, thyrse.ErrClosed
 	}
 	if w.err != nil {
 		return 0, w.err
@@ -99,13 +201,14 @@ func (w *Writer) Close() error {
 
 // flushBlock seals the buffer with the given label and writes the ciphertext.
 func (w *Writer) flushBlock(label string) error {
-	ciphertext := w.p.Seal(label, nil, w.buf)
+	ciphertext := w.cfg.segment(w.p, w.blocks, label).Seal(label, nil, w.buf)
 	_, err := w.w.Write(ciphertext)
 	if err != nil {
 		w.err = err
 		return err
 	}
 	w.buf = w.buf[:0]
+	w.blocks++
 	return nil
 }
 
@@ -116,7 +219,11 @@ func (w *Writer) flushBlock(label string) error {
 type Reader struct {
 	p         *thyrse.Protocol
 	r         io.Reader
+	cfg       config
 	blockSize int
+	blocks    int64  // number of blocks opened
+	pos       int64  // plaintext offset of the next byte returned
+	skip      int    // plaintext bytes to discard from the next block after a Seek
 	buf       []byte // decrypted plaintext not yet returned to the caller
 	err       error
 	next      []byte // current ciphertext block buffer (reused across fills)
@@ -129,9 +236,9 @@ type Reader struct {
 //
 // The protocol state provided must be exactly synchronized with the protocol state used to initialize the Writer.
 //
-// If the stream has been modified or truncated, a thyrse.ErrInvalidCiphertext is returned. The provided
-// thyrse.Protocol MUST NOT be used while the reader is open.
-func NewReader(p *thyrse.Protocol, r io.Reader, blockSize int) *Reader {
+// If the stream has been modified or truncated, a thyrse.ErrInvalidCiphertext is returned. The block size and options
+// must match those used by the Writer. The provided thyrse.Protocol MUST NOT be used while the reader is open.
+func NewReader(p *thyrse.Protocol, r io.Reader, blockSize int, opts ...Option) *Reader {
 	if blockSize < 1 {
 		panic("oae2: block size must be at least 1")
 	}
@@ -139,6 +246,7 @@ func NewReader(p *thyrse.Protocol, r io.Reader, blockSize int) *Reader {
 	return &Reader{
 		p:         p,
 		r:         r,
+		cfg:       newConfig(opts),
 		blockSize: blockSize,
 		next:      make([]byte, cipherLen),
 		ahead:     make([]byte, cipherLen),
@@ -158,14 +266,20 @@ func (r *Reader) Read(p []byte) (int, error) {
 	if len(r.buf) > 0 {
 		n := copy(p, r.buf)
 		r.buf = r.buf[n:]
+		r.pos += int64(n)
 		return n, nil
 	}
 	if r.err != nil {
 		return 0, r.err
 	}
 
-	// Decrypt the next block. An error is deferred if plaintext is available.
+	// Decrypt the next block, skipping any plaintext before a sought offset. An error is deferred if plaintext is
+	// available.
 	err := r.fill()
+	if r.skip > 0 {
+		r.buf = r.buf[min(r.skip, len(r.buf)):]
+		r.skip = 0
+	}
 	if err != nil {
 		r.err = err
 		if len(r.buf) == 0 {
@@ -176,12 +290,89 @@ func (r *Reader) Read(p []byte) (int, error) {
 	if len(r.buf) > 0 {
 		n := copy(p, r.buf)
 		r.buf = r.buf[n:]
+		r.pos += int64(n)
 		return n, nil
 	}
 
 	return 0, r.err
 }
 
+// Seek sets the plaintext offset for the next Read, interpreted according to whence, and returns the new offset.
+//
+// Seeking requires a stream written with WithSTREAM, as blocks in the default chained construction can only be opened
+// in order, and an underlying reader which implements io.Seeker and whose offset zero is the start of the stream (e.g.
+// an io.SectionReader). Otherwise, it returns ErrNotSeekable. Only the block containing the new offset is read and
+// authenticated; seeking relative to io.SeekEnd also reads and authenticates the final block to determine the length of
+// the plaintext.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	rs, ok := r.r.(io.Seeker)
+	if !ok || !r.cfg.stream {
+		return 0, ErrNotSeekable
+	}
+
+	// Determine the number of blocks in the stream.
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	cipherLen := int64(r.blockSize + thyrse.TagSize)
+	n := size / cipherLen
+	if n == 0 || size%cipherLen != 0 {
+		return 0, thyrse.ErrTruncated
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		length, err := r.length(rs, n)
+		if err != nil {
+			return 0, err
+		}
+		offset += length
+	default:
+		return 0, errors.New("oae2: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("oae2: negative position")
+	}
+
+	// Position the underlying reader at the block containing the offset. Offsets past the final block read as EOF.
+	block := offset / int64(r.blockSize)
+	r.buf, r.err, r.nextN, r.final = nil, nil, 0, false
+	r.pos, r.skip, r.blocks = offset, int(offset%int64(r.blockSize)), block
+	if block >= n {
+		r.final, r.skip = true, 0
+		return offset, nil
+	}
+	if _, err := rs.Seek(block*cipherLen, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// length returns the plaintext length of a STREAM-mode stream with n blocks by opening its final block.
+func (r *Reader) length(rs io.Seeker, n int64) (int64, error) {
+	cipherLen := int64(r.blockSize + thyrse.TagSize)
+	if _, err := rs.Seek((n-1)*cipherLen, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r.r, r.next[:cipherLen]); err != nil {
+		return 0, err
+	}
+
+	plaintext, err := r.cfg.segment(r.p, n-1, "final").Open("final", nil, r.next[:cipherLen])
+	if err != nil {
+		return 0, err
+	}
+	plaintext, err = unpad(plaintext)
+	if err != nil {
+		return 0, thyrse.ErrInvalidCiphertext
+	}
+	return (n-1)*int64(r.blockSize) + int64(len(plaintext)), nil
+}
+
 // fill decrypts one block from the underlying reader into r.buf.
 func (r *Reader) fill() error {
 	if r.final {
@@ -194,7 +385,7 @@ func (r *Reader) fill() error {
 	if r.nextN == 0 {
 		if _, err := io.ReadFull(r.r, r.next[:cipherLen]); err != nil {
 			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-				return thyrse.ErrInvalidCiphertext
+				return thyrse.ErrTruncated
 			}
 			return err
 		}
@@ -217,7 +408,7 @@ func (r *Reader) fill() error {
 		r.ahead[0] = peek[0]
 		if _, err := io.ReadFull(r.r, r.ahead[1:cipherLen]); err != nil {
 			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-				return thyrse.ErrInvalidCiphertext
+				return thyrse.ErrTruncated
 			}
 			return err
 		}
@@ -230,10 +421,11 @@ func (r *Reader) fill() error {
 		label = "final"
 	}
 
-	plaintext, err := r.p.Open(label, nil, r.next[:r.nextN])
+	plaintext, err := r.cfg.segment(r.p, r.blocks, label).Open(label, nil, r.next[:r.nextN])
 	if err != nil {
 		return err
 	}
+	r.blocks++
 
 	if isFinal {
 		// Strip 0x80 bit padding: scan backwards past zero bytes to find the 0x80 marker. Any other non-zero byte means
@@ -280,7 +472,11 @@ func unpad(plaintext []byte) ([]byte, error) {
 
 var errInvalidPadding = errors.New("invalid padding")
 
+// ErrNotSeekable is returned by Reader.Seek when the stream was not written with WithSTREAM or the underlying reader
+// does not implement io.Seeker.
+var ErrNotSeekable = errors.New("oae2: stream is not seekable")
+
 var (
 	_ io.WriteCloser = (*Writer)(nil)
-	_ io.Reader      = (*Reader)(nil)
+	_ io.ReadSeeker  = (*Reader)(nil)
 )
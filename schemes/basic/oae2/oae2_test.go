@@ -244,6 +244,75 @@ func TestWriter_Close(t *testing.T) {
 	})
 }
 
+func TestResumeWriter(t *testing.T) {
+	t.Run("crash and resume", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := thyrse.New("example")
+		p.SetSerializable(true)
+		w := oae2.NewWriter(p, &buf, 8)
+		if _, err := w.Write([]byte("0123456789abc")); err != nil {
+			t.Fatal(err)
+		}
+		c, err := w.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := c.Blocks, int64(1); got != want {
+			t.Errorf("Blocks = %d, want %d", got, want)
+		}
+		if got, want := c.Offset, int64(8+thyrse.TagSize); got != want {
+			t.Errorf("Offset = %d, want %d", got, want)
+		}
+		if got, want := c.PlaintextOffset, int64(8); got != want {
+			t.Errorf("PlaintextOffset = %d, want %d", got, want)
+		}
+
+		state, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Simulate a crash after writing a block which was not checkpointed, losing the writer.
+		if _, err := w.Write([]byte("lost lost")); err != nil {
+			t.Fatal(err)
+		}
+
+		// Restore the persisted checkpoint and truncate the stream to its offset.
+		var restored oae2.Checkpoint
+		if err := restored.UnmarshalBinary(state); err != nil {
+			t.Fatal(err)
+		}
+		buf.Truncate(int(restored.Offset))
+
+		// Re-write the plaintext after the checkpoint's plaintext offset.
+		w = oae2.ResumeWriter(restored, &buf, 8)
+		if _, err := w.Write([]byte("89abc and more")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := io.ReadAll(oae2.NewReader(thyrse.New("example"), bytes.NewReader(buf.Bytes()), 8))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(b), "0123456789abc and more"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("closed writer", func(t *testing.T) {
+		w := oae2.NewWriter(thyrse.New("example"), io.Discard, 64)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Checkpoint(); err == nil {
+			t.Error("Checkpoint() err = nil, want error")
+		}
+	})
+}
+
 func TestReader_Read(t *testing.T) {
 	t.Run("empty read", func(t *testing.T) {
 		r := oae2.NewReader(thyrse.New("example"), bytes.NewReader(nil), 64)
@@ -409,3 +478,102 @@ func FuzzReader(f *testing.F) {
 		}
 	})
 }
+
+func TestReader_Seek(t *testing.T) {
+	const blockSize = 64
+	input := testdata.New("thyrse oae2 seek").Data(1_000)
+	seal := func(opts ...oae2.Option) []byte {
+		var buf bytes.Buffer
+		w := oae2.NewWriter(thyrse.New("test"), &buf, blockSize, opts...)
+		if _, err := w.Write(input); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	ciphertext := seal(oae2.WithSTREAM())
+
+	t.Run("seek and read", func(t *testing.T) {
+		r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize, oae2.WithSTREAM())
+		for _, off := range []int64{500, 0, 64, 999, 130} {
+			if pos, err := r.Seek(off, io.SeekStart); err != nil || pos != off {
+				t.Fatalf("Seek(%d) = %d, %v", off, pos, err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := input[off:]; !bytes.Equal(got, want) {
+				t.Errorf("ReadAll() after Seek(%d) = %d bytes, want %d", off, len(got), len(want))
+			}
+		}
+	})
+
+	t.Run("whence", func(t *testing.T) {
+		r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize, oae2.WithSTREAM())
+		if got, err := r.Seek(0, io.SeekEnd); err != nil || got != int64(len(input)) {
+			t.Fatalf("Seek(0, io.SeekEnd) = %d, %v, want %d", got, err, len(input))
+		}
+		if got, err := r.Seek(-100, io.SeekEnd); err != nil || got != int64(len(input)-100) {
+			t.Fatalf("Seek(-100, io.SeekEnd) = %d, %v, want %d", got, err, len(input)-100)
+		}
+
+		buf := make([]byte, 10)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := r.Seek(20, io.SeekCurrent); err != nil || got != int64(len(input)-70) {
+			t.Fatalf("Seek(20, io.SeekCurrent) = %d, %v, want %d", got, err, len(input)-70)
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		if want := input[len(input)-70 : len(input)-60]; !bytes.Equal(buf, want) {
+			t.Errorf("Read() = %x, want %x", buf, want)
+		}
+	})
+
+	t.Run("past end", func(t *testing.T) {
+		r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize, oae2.WithSTREAM())
+		if _, err := r.Seek(5_000, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Read(make([]byte, 10)); !errors.Is(err, io.EOF) {
+			t.Errorf("Read() err = %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("modified block", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[3*(blockSize+thyrse.TagSize)+1] ^= 1
+		r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(modified), blockSize, oae2.WithSTREAM())
+		if _, err := r.Seek(5*blockSize, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(r); err != nil {
+			t.Errorf("ReadAll() after modified block err = %v", err)
+		}
+		if _, err := r.Seek(3*blockSize, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("chained mode", func(t *testing.T) {
+		r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(seal()), blockSize)
+		if _, err := r.Seek(100, io.SeekStart); !errors.Is(err, oae2.ErrNotSeekable) {
+			t.Errorf("Seek() err = %v, want %v", err, oae2.ErrNotSeekable)
+		}
+	})
+
+	t.Run("unseekable reader", func(t *testing.T) {
+		r := oae2.NewReader(thyrse.New("test"), io.MultiReader(bytes.NewReader(ciphertext)), blockSize, oae2.WithSTREAM())
+		if _, err := r.Seek(100, io.SeekStart); !errors.Is(err, oae2.ErrNotSeekable) {
+			t.Errorf("Seek() err = %v, want %v", err, oae2.ErrNotSeekable)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package oae2
+
+import (
+	"encoding/binary"
+
+	"github.com/codahale/thyrse"
+)
+
+// An Option configures a stream. Writers and readers of the same stream MUST use the same options.
+type Option func(*config)
+
+// WithSTREAM selects the nonce-based STREAM construction of Hoang, Reyhanitabar, and Rogaway instead of the default
+// chained construction.
+//
+// In the default construction, each block is sealed with the protocol state left by the previous block. With STREAM,
+// the protocol is never advanced; each block is sealed with an independent copy of it into which a segment nonce of the
+// block's 8-byte big endian index and a 1-byte last-block flag has been mixed. Blocks can therefore be opened
+// independently of one another, while the nonces still prevent them from being reordered, dropped, or truncated. The
+// framing and padding of blocks are unchanged.
+//
+// This is the STREAM construction, not a STREAM wire format: blocks are sealed with thyrse.Protocol rather than a
+// nonce-based AEAD such as AES-GCM or ChaCha20-Poly1305, and the nonce encoding, block framing, and key derivation are
+// specific to this package. Streams written with WithSTREAM cannot be read by other STREAM implementations (e.g. Tink's
+// streaming AEADs or age's payload format), nor can this package read theirs.
+func WithSTREAM() Option {
+	return func(c *config) {
+		c.stream = true
+	}
+}
+
+// WithStreamID binds every block of the stream to the given stream ID, so that blocks cannot be spliced between
+// streams which share a key or protocol state but have different IDs (e.g. the files of a backup, each encrypted with
+// its path as its ID). Without it, only the protocol state distinguishes one stream from another.
+func WithStreamID(id []byte) Option {
+	return func(c *config) {
+		c.streamID = id
+	}
+}
+
+// WithBlockAD binds each block of the stream to associated data returned by ad for the block's zero-based index (e.g.
+// the block's offset within a file or a record ID). The associated data is authenticated but not encrypted, and is not
+// included in the stream, so readers must supply the same function. A block opened with different associated data
+// fails authentication.
+func WithBlockAD(ad func(block int64) []byte) Option {
+	return func(c *config) {
+		c.blockAD = ad
+	}
+}
+
+type config struct {
+	stream   bool
+	streamID []byte
+	blockAD  func(block int64) []byte
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// segment returns the protocol used to seal or open the block with the given index and label. In STREAM mode, this is
+// a copy of p with the segment nonce mixed in; otherwise, it is p itself. The stream ID and the block's associated data,
+// if any, are then mixed in.
+func (c config) segment(p *thyrse.Protocol, index int64, label string) *thyrse.Protocol {
+	if c.stream {
+		var nonce [9]byte
+		binary.BigEndian.PutUint64(nonce[:], uint64(index))
+		if label == "final" {
+			nonce[8] = 1
+		}
+		p = p.Clone()
+		p.Mix("nonce", nonce[:])
+	}
+
+	if c.streamID != nil {
+		p.Mix("stream-id", c.streamID)
+	}
+	if c.blockAD != nil {
+		p.Mix("ad", c.blockAD(index))
+	}
+	return p
+}
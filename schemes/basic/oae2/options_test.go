@@ -0,0 +1,194 @@
+package oae2_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/oae2"
+)
+
+func TestWithSTREAM(t *testing.T) {
+	const blockSize = 64
+	input := testdata.New("thyrse oae2 stream").Data(1_000)
+	seal := func(opts ...oae2.Option) []byte {
+		var buf bytes.Buffer
+		w := oae2.NewWriter(thyrse.New("test"), &buf, blockSize, opts...)
+		if _, err := w.Write(input); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	open := func(ciphertext []byte, opts ...oae2.Option) ([]byte, error) {
+		return io.ReadAll(oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize, opts...))
+	}
+	ciphertext := seal(oae2.WithSTREAM())
+	cipherLen := blockSize + thyrse.TagSize
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := open(ciphertext, oae2.WithSTREAM())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, input) {
+			t.Errorf("ReadAll() = %x, want %x", got, input)
+		}
+	})
+
+	t.Run("differs from chained mode", func(t *testing.T) {
+		chained := seal()
+		if got, want := len(ciphertext), len(chained); got != want {
+			t.Errorf("len(ciphertext) = %d, want %d", got, want)
+		}
+		if bytes.Equal(ciphertext, chained) {
+			t.Error("STREAM ciphertext equals chained ciphertext")
+		}
+		if _, err := open(ciphertext); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("reordered blocks", func(t *testing.T) {
+		blocks := slices.Collect(slices.Chunk(bytes.Clone(ciphertext), cipherLen))
+		blocks[0], blocks[1] = blocks[1], blocks[0]
+		if _, err := open(slices.Concat(blocks...), oae2.WithSTREAM()); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("truncated at block boundary", func(t *testing.T) {
+		if _, err := open(ciphertext[:len(ciphertext)-cipherLen], oae2.WithSTREAM()); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := oae2.NewWriter(thyrse.New("test"), &buf, blockSize, oae2.WithSTREAM())
+		if _, err := w.Write(input[:500]); err != nil {
+			t.Fatal(err)
+		}
+		c, err := w.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Truncate(int(c.Offset))
+		w = oae2.ResumeWriter(c, &buf, blockSize, oae2.WithSTREAM())
+		if _, err := w.Write(input[c.PlaintextOffset:]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), ciphertext) {
+			t.Error("resumed stream differs from original")
+		}
+	})
+}
+
+func TestWithStreamID(t *testing.T) {
+	const blockSize = 64
+	input := testdata.New("thyrse oae2 stream id").Data(1_000)
+	cipherLen := blockSize + thyrse.TagSize
+
+	for name, mode := range map[string][]oae2.Option{"chained": nil, "STREAM": {oae2.WithSTREAM()}} {
+		t.Run(name, func(t *testing.T) {
+			seal := func(id string) []byte {
+				var buf bytes.Buffer
+				w := oae2.NewWriter(thyrse.New("test"), &buf, blockSize, append(mode, oae2.WithStreamID([]byte(id)))...)
+				if _, err := w.Write(input); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				return buf.Bytes()
+			}
+			open := func(ciphertext []byte, id string) ([]byte, error) {
+				r := oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize,
+					append(mode, oae2.WithStreamID([]byte(id)))...)
+				return io.ReadAll(r)
+			}
+			a, b := seal("a"), seal("b")
+
+			got, err := open(a, "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, input) {
+				t.Errorf("ReadAll() = %x, want %x", got, input)
+			}
+
+			if _, err := open(a, "b"); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("ReadAll() with wrong ID err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+			}
+
+			spliced := slices.Concat(a[:cipherLen], b[cipherLen:])
+			if _, err := open(spliced, "a"); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("ReadAll() of spliced streams err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestWithBlockAD(t *testing.T) {
+	const blockSize = 64
+	input := testdata.New("thyrse oae2 block ad").Data(1_000)
+	offsets := oae2.WithBlockAD(func(block int64) []byte {
+		return binary.BigEndian.AppendUint64(nil, uint64(block*blockSize))
+	})
+
+	for name, mode := range map[string][]oae2.Option{"chained": nil, "STREAM": {oae2.WithSTREAM()}} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := oae2.NewWriter(thyrse.New("test"), &buf, blockSize, append(mode, offsets)...)
+			if _, err := w.Write(input[:500]); err != nil {
+				t.Fatal(err)
+			}
+			c, err := w.Checkpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf.Truncate(int(c.Offset))
+			w = oae2.ResumeWriter(c, &buf, blockSize, append(mode, offsets)...)
+			if _, err := w.Write(input[c.PlaintextOffset:]); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			ciphertext := buf.Bytes()
+
+			got, err := io.ReadAll(oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize,
+				append(mode, offsets)...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, input) {
+				t.Errorf("ReadAll() = %x, want %x", got, input)
+			}
+
+			wrong := oae2.WithBlockAD(func(block int64) []byte {
+				return binary.BigEndian.AppendUint64(nil, uint64(block*blockSize+1))
+			})
+			_, err = io.ReadAll(oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize,
+				append(mode, wrong)...))
+			if !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("ReadAll() with wrong AD err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+			}
+
+			_, err = io.ReadAll(oae2.NewReader(thyrse.New("test"), bytes.NewReader(ciphertext), blockSize, mode...))
+			if !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Errorf("ReadAll() without AD err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package pwenc
+
+import (
+	"math/bits"
+
+	"github.com/codahale/thyrse/schemes/basic/mhf"
+)
+
+// An Option configures the mhf parameters used to derive the key for a new ciphertext. The parameters are recorded in
+// the header, so decrypters need no options.
+type Option func(*config)
+
+// WithBlockSize sets the mhf block size (see mhf.HashWithBlockSize). Panics if size is less than mhf.MinBlockSize or
+// greater than mhf.MaxBlockSize.
+func WithBlockSize(size int) Option {
+	if size < mhf.MinBlockSize || size > mhf.MaxBlockSize {
+		panic("thyrse/pwenc: invalid block size")
+	}
+
+	return func(c *config) {
+		c.blockSize = size
+	}
+}
+
+// WithParallelism sets the mhf parallelism (see mhf.HashParallel). Panics if parallelism is not a power of two between 1
+// and 2**15, or, when the ciphertext is encrypted, if it is greater than 2**cost.
+func WithParallelism(parallelism int) Option {
+	if parallelism < 1 || parallelism > maxParallelism || bits.OnesCount(uint(parallelism)) != 1 {
+		panic("thyrse/pwenc: invalid parallelism")
+	}
+
+	return func(c *config) {
+		c.parallelism = parallelism
+	}
+}
+
+type config struct {
+	blockSize, parallelism int
+}
+
+func newConfig(opts []Option) config {
+	c := config{blockSize: mhf.DefaultBlockSize, parallelism: 1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// maxParallelism is the largest parallelism which can be encoded in a header.
+const maxParallelism = 1 << 15
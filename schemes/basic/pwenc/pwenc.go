@@ -0,0 +1,164 @@
+// Package pwenc implements password-based encryption using mhf and Thyrse.
+//
+// A key is derived from the password and a random salt with mhf, and the data is sealed with a protocol keyed with it.
+// Every ciphertext begins with a self-describing header:
+//
+//	version (1B) || cost (1B) || block size (4B BE) || parallelism (2B BE) || salt (16B)
+//
+// The header is mixed into the protocol, so it cannot be modified without detection, and it records everything needed
+// to decrypt the ciphertext given the domain and password, so the mhf parameters can be raised for new ciphertexts
+// without affecting existing ones.
+//
+// As the header is read before it can be authenticated, decrypters bound the memory and parallelism it may demand with
+// an mhf.Limits, and reject headers which exceed them before deriving a key.
+//
+// Encrypt and Decrypt handle data in memory; NewWriter and NewReader handle streams using aestream.
+package pwenc
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/hazmat/secmem"
+	"github.com/codahale/thyrse/schemes/basic/aestream"
+	"github.com/codahale/thyrse/schemes/basic/mhf"
+)
+
+const (
+	// HeaderSize is the size, in bytes, of the header which begins every ciphertext.
+	HeaderSize = 8 + saltSize
+
+	// Overhead is the length, in bytes, of the additional data added to a plaintext by Encrypt.
+	Overhead = HeaderSize + thyrse.TagSize
+
+	// Version is the version of the header format.
+	Version = 1
+
+	saltSize = 16
+	keySize  = 32
+)
+
+var (
+	// ErrUnsupportedVersion is returned when a ciphertext's header has an unknown version.
+	ErrUnsupportedVersion = errors.New("thyrse/pwenc: unsupported version")
+
+	// ErrInvalidHeader is returned when a ciphertext's header has mhf parameters which are out of range.
+	ErrInvalidHeader = errors.New("thyrse/pwenc: invalid header")
+
+	// ErrCostTooHigh is returned when a ciphertext's mhf parameters exceed the decrypter's limits.
+	ErrCostTooHigh = errors.New("thyrse/pwenc: cost too high")
+)
+
+// Encrypt encrypts the plaintext with a key derived from the password with the given mhf cost and a random salt,
+// returning the header followed by the sealed plaintext. Panics if the options' parallelism is greater than 2**cost.
+func Encrypt(domain string, cost uint8, password, plaintext []byte, opts ...Option) []byte {
+	header := newHeader(cost, newConfig(opts))
+	return newProtocol(domain, header, password).Seal("message", header, plaintext)
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt with the password. Ciphertexts whose mhf parameters exceed limits
+// are rejected with ErrCostTooHigh before deriving a key, bounding the resources an attacker who can modify ciphertexts
+// can consume. Returns an error wrapping thyrse.ErrInvalidCiphertext if the password is incorrect or the ciphertext has
+// been modified.
+func Decrypt(domain string, limits mhf.Limits, password, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead {
+		return nil, thyrse.ErrTruncated
+	}
+	header := ciphertext[:HeaderSize]
+	if err := checkHeader(header, limits); err != nil {
+		return nil, err
+	}
+	return newProtocol(domain, header, password).Open("message", nil, ciphertext[HeaderSize:])
+}
+
+// NewWriter writes a header to w and returns an aestream.Writer which encrypts data written to it with a key derived
+// from the password with the given mhf cost and a random salt. The writer MUST be closed for the stream to be valid.
+// Panics if the options' parallelism is greater than 2**cost.
+func NewWriter(domain string, cost uint8, password []byte, w io.Writer, opts ...Option) (*aestream.Writer, error) {
+	header := newHeader(cost, newConfig(opts))
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return aestream.NewWriter(newProtocol(domain, header, password), w), nil
+}
+
+// NewReader reads a header from r and returns an aestream.Reader which decrypts the stream written by a Writer created
+// with NewWriter. Streams whose mhf parameters exceed limits are rejected with ErrCostTooHigh. If the password is
+// incorrect or the stream has been modified, reads return an error wrapping thyrse.ErrInvalidCiphertext.
+func NewReader(domain string, limits mhf.Limits, password []byte, r io.Reader) (*aestream.Reader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, thyrse.ErrTruncated
+		}
+		return nil, err
+	}
+	if err := checkHeader(header, limits); err != nil {
+		return nil, err
+	}
+	return aestream.NewReader(newProtocol(domain, header, password), r), nil
+}
+
+// newHeader returns a header with the given cost, the configured block size and parallelism, and a random salt.
+func newHeader(cost uint8, c config) []byte {
+	if uint64(c.parallelism) > uint64(1)<<min(cost, 63) {
+		panic("thyrse/pwenc: invalid parallelism")
+	}
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, Version, cost)
+	header = binary.BigEndian.AppendUint32(header, uint32(c.blockSize))
+	header = binary.BigEndian.AppendUint16(header, uint16(c.parallelism))
+	header = header[:HeaderSize]
+	_, _ = rand.Read(header[8:])
+	return header
+}
+
+// parseHeader returns the mhf parameters recorded in the header, or an error if it has an unsupported version or
+// parameters which are out of range.
+func parseHeader(header []byte) (mhf.Params, error) {
+	if header[0] != Version {
+		return mhf.Params{}, ErrUnsupportedVersion
+	}
+
+	params := mhf.Params{
+		Cost:        header[1],
+		BlockSize:   int(binary.BigEndian.Uint32(header[2:])),
+		Parallelism: int(binary.BigEndian.Uint16(header[6:])),
+		OutputLen:   keySize,
+	}
+	if params.BlockSize < mhf.MinBlockSize || params.BlockSize > mhf.MaxBlockSize || params.Parallelism < 1 ||
+		bits.OnesCount(uint(params.Parallelism)) != 1 || uint64(params.Parallelism) > uint64(1)<<min(params.Cost, 63) {
+		return mhf.Params{}, ErrInvalidHeader
+	}
+	return params, nil
+}
+
+// checkHeader returns an error if the header is invalid or its mhf parameters exceed limits.
+func checkHeader(header []byte, limits mhf.Limits) error {
+	params, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+	if limits.Check(params) != nil {
+		return ErrCostTooHigh
+	}
+	return nil
+}
+
+// newProtocol returns a protocol keyed with the header and a key derived from the password with the header's mhf
+// parameters and salt. The header must be valid.
+func newProtocol(domain string, header, password []byte) *thyrse.Protocol {
+	params, _ := parseHeader(header)
+	params.Domain = domain
+	key := params.Hash(header[8:], password, nil)
+	p := thyrse.New(domain)
+	p.Mix("header", header)
+	p.Mix("key", key)
+	secmem.Wipe(key)
+	return p
+}
@@ -0,0 +1,184 @@
+package pwenc_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/basic/mhf"
+	"github.com/codahale/thyrse/schemes/basic/pwenc"
+)
+
+var (
+	limits    = mhf.Limits{MaxMemory: mhf.MemoryCost(10, mhf.DefaultBlockSize)}
+	lowLimits = mhf.Limits{MaxMemory: mhf.MemoryCost(5, mhf.DefaultBlockSize)}
+)
+
+func TestEncrypt(t *testing.T) {
+	password, plaintext := []byte("hunter2"), []byte("this is a secret")
+	ciphertext := pwenc.Encrypt("test", 6, password, plaintext)
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := pwenc.Decrypt("test", limits, password, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+		}
+	})
+
+	t.Run("length", func(t *testing.T) {
+		if got, want := len(ciphertext), len(plaintext)+pwenc.Overhead; got != want {
+			t.Errorf("len(Encrypt()) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		if got, want := ciphertext[:8], []byte{pwenc.Version, 6, 0, 0, 4, 0, 0, 1}; !bytes.Equal(got, want) {
+			t.Errorf("header = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("random salt", func(t *testing.T) {
+		if other := pwenc.Encrypt("test", 6, password, plaintext); bytes.Equal(other, ciphertext) {
+			t.Error("Encrypt() produced identical ciphertexts")
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		if _, err := pwenc.Decrypt("test", limits, []byte("hunter3"), ciphertext); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Decrypt() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("modified header", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[10] ^= 1
+		if _, err := pwenc.Decrypt("test", limits, password, modified); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Decrypt() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[0] = 2
+		if _, err := pwenc.Decrypt("test", limits, password, modified); !errors.Is(err, pwenc.ErrUnsupportedVersion) {
+			t.Errorf("Decrypt() err = %v, want %v", err, pwenc.ErrUnsupportedVersion)
+		}
+	})
+
+	t.Run("cost too high", func(t *testing.T) {
+		if _, err := pwenc.Decrypt("test", lowLimits, password, ciphertext); !errors.Is(err, pwenc.ErrCostTooHigh) {
+			t.Errorf("Decrypt() err = %v, want %v", err, pwenc.ErrCostTooHigh)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, params := range [][]byte{
+			{0, 0, 0, 32, 0, 1},   // block size below minimum
+			{0, 0x20, 0, 0, 0, 1}, // block size above maximum
+			{0, 0, 4, 0, 0, 0},    // zero parallelism
+			{0, 0, 4, 0, 0, 3},    // parallelism not a power of two
+			{0, 0, 4, 0, 0, 128},  // parallelism greater than 2**cost
+		} {
+			modified := bytes.Clone(ciphertext)
+			copy(modified[2:], params)
+			if _, err := pwenc.Decrypt("test", limits, password, modified); !errors.Is(err, pwenc.ErrInvalidHeader) {
+				t.Errorf("Decrypt(%x) err = %v, want %v", params, err, pwenc.ErrInvalidHeader)
+			}
+		}
+	})
+
+	t.Run("parallelism too high", func(t *testing.T) {
+		ciphertext := pwenc.Encrypt("test", 6, password, plaintext, pwenc.WithParallelism(4))
+		if _, err := pwenc.Decrypt("test", limits, password, ciphertext); !errors.Is(err, pwenc.ErrCostTooHigh) {
+			t.Errorf("Decrypt() err = %v, want %v", err, pwenc.ErrCostTooHigh)
+		}
+
+		got, err := pwenc.Decrypt("test", mhf.Limits{MaxMemory: limits.MaxMemory, MaxParallelism: 4}, password, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+		}
+	})
+
+	t.Run("block size", func(t *testing.T) {
+		ciphertext := pwenc.Encrypt("test", 6, password, plaintext, pwenc.WithBlockSize(256))
+		got, err := pwenc.Decrypt("test", limits, password, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+		}
+
+		large := pwenc.Encrypt("test", 6, password, plaintext, pwenc.WithBlockSize(32<<10))
+		if _, err := pwenc.Decrypt("test", limits, password, large); !errors.Is(err, pwenc.ErrCostTooHigh) {
+			t.Errorf("Decrypt() err = %v, want %v", err, pwenc.ErrCostTooHigh)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := pwenc.Decrypt("test", limits, password, ciphertext[:pwenc.Overhead-1]); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("Decrypt() err = %v, want %v", err, thyrse.ErrTruncated)
+		}
+	})
+}
+
+func TestNewWriter(t *testing.T) {
+	password, plaintext := []byte("hunter2"), bytes.Repeat([]byte("streaming secret "), 10_000)
+
+	var buf bytes.Buffer
+	w, err := pwenc.NewWriter("test", 6, password, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := buf.Bytes()
+
+	t.Run("round trip", func(t *testing.T) {
+		r, err := pwenc.NewReader("test", limits, password, bytes.NewReader(ciphertext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Error("ReadAll() did not return the plaintext")
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		r, err := pwenc.NewReader("test", limits, []byte("hunter3"), bytes.NewReader(ciphertext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(r); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("ReadAll() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("cost too high", func(t *testing.T) {
+		if _, err := pwenc.NewReader("test", lowLimits, password, bytes.NewReader(ciphertext)); !errors.Is(err, pwenc.ErrCostTooHigh) {
+			t.Errorf("NewReader() err = %v, want %v", err, pwenc.ErrCostTooHigh)
+		}
+	})
+
+	t.Run("truncated header", func(t *testing.T) {
+		if _, err := pwenc.NewReader("test", limits, password, bytes.NewReader(ciphertext[:5])); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("NewReader() err = %v, want %v", err, thyrse.ErrTruncated)
+		}
+	})
+}
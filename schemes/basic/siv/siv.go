@@ -6,9 +6,9 @@ package siv
 
 import (
 	"crypto/cipher"
-	"crypto/subtle"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/hazmat/secmem"
 )
 
 // New returns a new cipher.AEAD instance which uses the given domain string and key.
@@ -74,7 +74,7 @@ func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, erro
 	}
 
 	if len(ciphertext) < thyrse.TagSize {
-		return nil, thyrse.ErrInvalidCiphertext
+		return nil, thyrse.ErrTruncated
 	}
 
 	ciphertext, receivedTag := ciphertext[:len(ciphertext)-thyrse.TagSize], ciphertext[len(ciphertext)-thyrse.TagSize:]
@@ -91,10 +91,9 @@ func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, erro
 	plaintext := ret[len(dst):]
 
 	auth.Mix("message", plaintext)
-	expectedTag := auth.Derive("tag", nil, thyrse.TagSize)
-	if subtle.ConstantTimeCompare(expectedTag, receivedTag) == 0 {
-		clear(plaintext)
-		return nil, thyrse.ErrInvalidCiphertext
+	if !auth.Check("tag", receivedTag) {
+		secmem.Wipe(plaintext)
+		return nil, thyrse.ErrTagMismatch
 	}
 
 	return ret, nil
@@ -0,0 +1,65 @@
+// Package timekey derives short-lived keys and tokens bound to coarse windows of time, in the manner of TOTP.
+//
+// Both parties share a long-term key and agree on a window length. Time is divided into numbered windows of that length,
+// counted from the Unix epoch, and each token is derived from the key, the number of the window it was issued in, and a
+// context (e.g. a TURN username, a URL and method, or a cache entry's name). A token is valid only for its context, and
+// only while its window or one adjacent to it is current, which allows for clock skew between the parties and for
+// tokens issued near the end of a window. Tokens issued for one window reveal nothing about those of other windows.
+//
+// The window length is not bound into tokens, so applications which use several lengths with the same key should
+// include the length in the domain separation string.
+//
+// The transcript is equivalent to the following:
+//
+//	p := thyrse.New(domain)
+//	p.Mix("key", key)
+//	p.MixUint64("window", window)
+//	p.Mix("context", context)
+//	token := p.Derive("token", nil, n)
+package timekey
+
+import (
+	"crypto/subtle"
+	"math"
+	"time"
+
+	"github.com/codahale/thyrse"
+)
+
+// Window returns the number of the window of the given length which contains t. Windows are counted from the Unix
+// epoch, and times before the Unix epoch are in window zero.
+//
+// Panics if length is not positive.
+func Window(t time.Time, length time.Duration) uint64 {
+	if length <= 0 {
+		panic("thyrse/timekey: window length must be positive")
+	}
+	return uint64(max(t.Sub(time.Unix(0, 0)), 0) / length)
+}
+
+// DeriveWindow returns an n-byte token derived from the key for the given window and context.
+func DeriveWindow(domain string, key []byte, window uint64, context []byte, n int) []byte {
+	p := thyrse.New(domain)
+	p.Mix("key", key)
+	p.MixUint64("window", window)
+	p.Mix("context", context)
+	return p.Derive("token", nil, n)
+}
+
+// VerifyWindow returns true if token was derived from the key for the given context and for the given window or one
+// adjacent to it. The comparison is constant-time, and does not reveal which window matched. Returns false if token is
+// empty.
+func VerifyWindow(domain string, key []byte, window uint64, context, token []byte) bool {
+	if len(token) == 0 {
+		return false
+	}
+
+	ok := 0
+	for w := max(window, 1) - 1; ; w++ {
+		ok |= subtle.ConstantTimeCompare(DeriveWindow(domain, key, w, context, len(token)), token)
+		if w == window+1 || w == math.MaxUint64 {
+			break
+		}
+	}
+	return ok == 1
+}
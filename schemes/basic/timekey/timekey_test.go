@@ -0,0 +1,94 @@
+package timekey_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/timekey"
+)
+
+func TestWindow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := timekey.Window(start, time.Minute)
+	if got, want := timekey.Window(start.Add(59*time.Second), time.Minute), w; got != want {
+		t.Errorf("Window(+59s) = %d, want = %d", got, want)
+	}
+	if got, want := timekey.Window(start.Add(time.Minute), time.Minute), w+1; got != want {
+		t.Errorf("Window(+1m) = %d, want = %d", got, want)
+	}
+	if got, want := timekey.Window(time.Unix(-100, 0), time.Minute), uint64(0); got != want {
+		t.Errorf("Window(before epoch) = %d, want = %d", got, want)
+	}
+}
+
+func TestDeriveWindow(t *testing.T) {
+	key := testdata.New("timekey").Data(32)
+	context := []byte("turn:alice")
+	token := timekey.DeriveWindow("timekey", key, 100, context, 20)
+
+	t.Run("transcript", func(t *testing.T) {
+		p := thyrse.New("timekey")
+		p.Mix("key", key)
+		p.MixUint64("window", 100)
+		p.Mix("context", context)
+		if got, want := token, p.Derive("token", nil, 20); !bytes.Equal(got, want) {
+			t.Errorf("token = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("windows", func(t *testing.T) {
+		for _, tc := range []struct {
+			window uint64
+			want   bool
+		}{
+			{98, false},
+			{99, true},
+			{100, true},
+			{101, true},
+			{102, false},
+		} {
+			if got := timekey.VerifyWindow("timekey", key, tc.window, context, token); got != tc.want {
+				t.Errorf("VerifyWindow(window=%d) = %v, want = %v", tc.window, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("wrong context", func(t *testing.T) {
+		if timekey.VerifyWindow("timekey", key, 100, []byte("turn:bob"), token) {
+			t.Error("token verified for another context")
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		if timekey.VerifyWindow("timekey", []byte("other"), 100, context, token) {
+			t.Error("token verified with another key")
+		}
+	})
+
+	t.Run("modified token", func(t *testing.T) {
+		bad := bytes.Clone(token)
+		bad[0] ^= 1
+		if timekey.VerifyWindow("timekey", key, 100, context, bad) {
+			t.Error("modified token verified")
+		}
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		if timekey.VerifyWindow("timekey", key, 100, context, nil) {
+			t.Error("empty token verified")
+		}
+	})
+
+	t.Run("edges", func(t *testing.T) {
+		for _, w := range []uint64{0, math.MaxUint64} {
+			token := timekey.DeriveWindow("timekey", key, w, context, 20)
+			if !timekey.VerifyWindow("timekey", key, w, context, token) {
+				t.Errorf("token for window %d did not verify", w)
+			}
+		}
+	})
+}
@@ -35,7 +35,7 @@ const (
 func NewInitiator(p *thyrse.Protocol, local *ristretto255.Scalar, remote *ristretto255.Element) *State {
 	send, recv := p.Fork("role", []byte("initiator"), []byte("responder"))
 	s := &State{
-		localPriv: local,
+		localPriv: ristretto255.NewScalar().Set(local),
 		localPub:  ristretto255.NewIdentityElement().ScalarBaseMult(local),
 		remotePub: remote,
 		send:      send,
@@ -54,7 +54,7 @@ func NewInitiator(p *thyrse.Protocol, local *ristretto255.Scalar, remote *ristre
 func NewResponder(p *thyrse.Protocol, local *ristretto255.Scalar, remote *ristretto255.Element) *State {
 	recv, send := p.Fork("role", []byte("initiator"), []byte("responder"))
 	s := &State{
-		localPriv: local,
+		localPriv: ristretto255.NewScalar().Set(local),
 		localPub:  ristretto255.NewIdentityElement().ScalarBaseMult(local),
 		remotePub: remote,
 		send:      send,
@@ -109,7 +109,7 @@ func (s *State) Ratchet() {
 // ratchet steps as needed.
 func (s *State) ReceiveMessage(ciphertext []byte) ([]byte, error) {
 	if len(ciphertext) < Overhead {
-		return nil, thyrse.ErrInvalidCiphertext
+		return nil, thyrse.ErrTruncated
 	}
 	header := ciphertext[:headerSize]
 	msg := ciphertext[headerSize:]
@@ -171,7 +171,7 @@ func (s *State) advanceRecvChain(targetN uint32) error {
 		return nil
 	}
 	if targetN-s.recvN > MaxSkip {
-		return thyrse.ErrInvalidCiphertext
+		return thyrse.ErrTooLarge
 	}
 	for s.recvN < targetN {
 		s.recv.Mix("n", binary.LittleEndian.AppendUint32(nil, s.recvN))
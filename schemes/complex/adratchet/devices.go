@@ -0,0 +1,104 @@
+package adratchet
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/gtank/ristretto255"
+)
+
+var (
+	// ErrUnknownDevice is returned when a device ID is not in a Devices registry.
+	ErrUnknownDevice = errors.New("thyrse/adratchet: unknown device")
+
+	// ErrDuplicateDevice is returned when adding a device ID which is already in a Devices registry.
+	ErrDuplicateDevice = errors.New("thyrse/adratchet: duplicate device")
+)
+
+// DeviceProtocol returns the per-device protocol for the device with the given ID, derived from a clone of the shared
+// root protocol. The peer's device passes it to NewResponder (or NewInitiator) to establish its end of the session.
+func DeviceProtocol(root *thyrse.Protocol, id string) *thyrse.Protocol {
+	p := root.Clone()
+	p.MixString("device", id)
+	return p
+}
+
+// Devices maintains parallel double ratchets with each of a peer's devices. Every session is derived from a shared root
+// protocol and separated by device ID, so compromising one device's session does not affect the others.
+type Devices struct {
+	root      *thyrse.Protocol
+	local     *ristretto255.Scalar
+	initiator bool
+	states    map[string]*State
+}
+
+// NewDevices returns an empty device registry. Each device's session is derived from the given root protocol (see
+// DeviceProtocol) with the given local private key, in the initiator role if initiator is true and the responder role
+// otherwise.
+func NewDevices(root *thyrse.Protocol, local *ristretto255.Scalar, initiator bool) *Devices {
+	return &Devices{
+		root:      root.Clone(),
+		local:     local,
+		initiator: initiator,
+		states:    make(map[string]*State),
+	}
+}
+
+// Add creates a session with the device with the given ID and public key and returns its state. Returns
+// ErrDuplicateDevice if the device has already been added.
+func (d *Devices) Add(id string, remote *ristretto255.Element) (*State, error) {
+	if _, ok := d.states[id]; ok {
+		return nil, ErrDuplicateDevice
+	}
+
+	p := DeviceProtocol(d.root, id)
+	var s *State
+	if d.initiator {
+		s = NewInitiator(p, d.local, remote)
+	} else {
+		s = NewResponder(p, d.local, remote)
+	}
+	d.states[id] = s
+	return s, nil
+}
+
+// Remove deletes the session with the device with the given ID, if any.
+func (d *Devices) Remove(id string) {
+	delete(d.states, id)
+}
+
+// Device returns the session state for the device with the given ID, or nil if the device is unknown.
+func (d *Devices) Device(id string) *State {
+	return d.states[id]
+}
+
+// IDs returns the IDs of all registered devices in sorted order.
+func (d *Devices) IDs() []string {
+	ids := make([]string, 0, len(d.states))
+	for id := range d.states {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// SendToAll encrypts the given plaintext separately for every registered device and returns the ciphertexts, keyed by
+// device ID.
+func (d *Devices) SendToAll(plaintext []byte) map[string][]byte {
+	out := make(map[string][]byte, len(d.states))
+	for id, s := range d.states {
+		out[id] = s.SendMessage(plaintext)
+	}
+	return out
+}
+
+// ReceiveMessage decrypts a ciphertext from the device with the given ID. Returns ErrUnknownDevice if the device is not
+// registered.
+func (d *Devices) ReceiveMessage(id string, ciphertext []byte) ([]byte, error) {
+	s, ok := d.states[id]
+	if !ok {
+		return nil, ErrUnknownDevice
+	}
+	return s.ReceiveMessage(ciphertext)
+}
@@ -0,0 +1,97 @@
+package adratchet_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/adratchet"
+)
+
+func TestDevices(t *testing.T) {
+	drbg := testdata.New("thyrse async double ratchet devices test")
+	dA, qA := drbg.KeyPair()
+	dPhone, qPhone := drbg.KeyPair()
+	dLaptop, qLaptop := drbg.KeyPair()
+
+	root := thyrse.New("test")
+	root.Mix("shared key", []byte("secret"))
+
+	alice := adratchet.NewDevices(root, dA, true)
+	if _, err := alice.Add("phone", qPhone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.Add("laptop", qLaptop); err != nil {
+		t.Fatal(err)
+	}
+
+	phone := adratchet.NewResponder(adratchet.DeviceProtocol(root, "phone"), dPhone, qA)
+	laptop := adratchet.NewResponder(adratchet.DeviceProtocol(root, "laptop"), dLaptop, qA)
+
+	t.Run("IDs", func(t *testing.T) {
+		if got, want := alice.IDs(), []string{"laptop", "phone"}; !slices.Equal(got, want) {
+			t.Errorf("IDs() = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("send to all", func(t *testing.T) {
+		msgs := alice.SendToAll([]byte("hello everyone"))
+		if got, want := len(msgs), 2; got != want {
+			t.Fatalf("len(SendToAll()) = %d, want = %d", got, want)
+		}
+		if bytes.Equal(msgs["phone"], msgs["laptop"]) {
+			t.Error("devices received identical ciphertexts")
+		}
+
+		for id, s := range map[string]*adratchet.State{"phone": phone, "laptop": laptop} {
+			v, err := s.ReceiveMessage(msgs[id])
+			if err != nil {
+				t.Fatalf("%s: ReceiveMessage() err = %v", id, err)
+			}
+			if got, want := string(v), "hello everyone"; got != want {
+				t.Errorf("%s: ReceiveMessage() = %q, want = %q", id, got, want)
+			}
+		}
+
+		// A failed receive can advance the ratchet, so check cross-device delivery against a throwaway session.
+		other := adratchet.NewResponder(adratchet.DeviceProtocol(root, "laptop"), dLaptop, qA)
+		if _, err := other.ReceiveMessage(msgs["phone"]); err == nil {
+			t.Error("laptop decrypted the phone's message")
+		}
+	})
+
+	t.Run("receive", func(t *testing.T) {
+		v, err := alice.ReceiveMessage("laptop", laptop.SendMessage([]byte("reply")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(v), "reply"; got != want {
+			t.Errorf("ReceiveMessage() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		if _, err := alice.ReceiveMessage("tablet", make([]byte, adratchet.Overhead)); !errors.Is(err, adratchet.ErrUnknownDevice) {
+			t.Errorf("ReceiveMessage() err = %v, want = %v", err, adratchet.ErrUnknownDevice)
+		}
+	})
+
+	t.Run("duplicate device", func(t *testing.T) {
+		if _, err := alice.Add("phone", qPhone); !errors.Is(err, adratchet.ErrDuplicateDevice) {
+			t.Errorf("Add() err = %v, want = %v", err, adratchet.ErrDuplicateDevice)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		alice.Remove("phone")
+		if alice.Device("phone") != nil {
+			t.Error("Device(phone) != nil after Remove")
+		}
+		if got, want := len(alice.SendToAll([]byte("bye"))), 1; got != want {
+			t.Errorf("len(SendToAll()) = %d, want = %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package adratchet
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/hazmat/secmem"
+	"github.com/gtank/ristretto255"
+)
+
+// ErrInvalidState is returned by State.UnmarshalBinary and OpenState when a serialized state is malformed, or when a
+// sealed state cannot be decrypted.
+var ErrInvalidState = errors.New("thyrse/adratchet: invalid state")
+
+const (
+	stateVersion   = 1
+	stateNonceSize = 16
+)
+
+// MarshalBinary returns a serialized copy of the ratchet state, which can be restored with State.UnmarshalBinary, e.g.
+// to persist a long-lived session across process restarts.
+//
+// The serialized state contains the local private key and the chain states, and must be stored as securely as any
+// key. Use SealState to encrypt it. The base protocol given to NewInitiator or NewResponder must have been made
+// serializable (see thyrse.Protocol.SetSerializable), or MarshalBinary returns thyrse.ErrNotSerializable.
+//
+// Layout, with integers in little-endian order:
+//
+//	version (1B) || local private key (32B) || remote public key (32B) || sendN (4B) || recvN (4B) || prevSendN (4B) ||
+//	send chain || recv chain || skipped count (4B) || { public key (32B) || n (4B) || chain }...
+//
+// where each chain is a 2-byte length followed by the serialized thyrse.Protocol.
+func (s *State) MarshalBinary() ([]byte, error) {
+	b := []byte{stateVersion}
+	b = append(b, s.localPriv.Bytes()...)
+	b = append(b, s.remotePub.Bytes()...)
+	b = binary.LittleEndian.AppendUint32(b, s.sendN)
+	b = binary.LittleEndian.AppendUint32(b, s.recvN)
+	b = binary.LittleEndian.AppendUint32(b, s.prevSendN)
+
+	var err error
+	if b, err = appendProtocol(b, s.send); err != nil {
+		return nil, err
+	}
+	if b, err = appendProtocol(b, s.recv); err != nil {
+		return nil, err
+	}
+
+	// Sort the skipped message keys so the serialized state is deterministic.
+	keys := slices.SortedFunc(maps.Keys(s.skipped), func(a, b skippedKey) int {
+		return cmp.Or(bytes.Compare(a.pub[:], b.pub[:]), cmp.Compare(a.n, b.n))
+	})
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(keys)))
+	for _, k := range keys {
+		b = append(b, k.pub[:]...)
+		b = binary.LittleEndian.AppendUint32(b, k.n)
+		if b, err = appendProtocol(b, s.skipped[k]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores a ratchet state serialized by State.MarshalBinary, replacing the receiver's state. Returns
+// ErrInvalidState if data is malformed.
+func (s *State) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+32+32+4+4+4 || data[0] != stateVersion {
+		return ErrInvalidState
+	}
+	localPriv, _ := ristretto255.NewScalar().SetCanonicalBytes(data[1:33])
+	remotePub, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(data[33:65])
+	if localPriv == nil || remotePub == nil {
+		return ErrInvalidState
+	}
+	sendN := binary.LittleEndian.Uint32(data[65:])
+	recvN := binary.LittleEndian.Uint32(data[69:])
+	prevSendN := binary.LittleEndian.Uint32(data[73:])
+	data = data[77:]
+
+	send, data, err := readProtocol(data)
+	if err != nil {
+		return err
+	}
+	recv, data, err := readProtocol(data)
+	if err != nil {
+		return err
+	}
+
+	if len(data) < 4 {
+		return ErrInvalidState
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	if n > len(data)/(32+4+2) {
+		return ErrInvalidState
+	}
+	skipped := make(map[skippedKey]*thyrse.Protocol, n)
+	for range n {
+		if len(data) < 32+4 {
+			return ErrInvalidState
+		}
+		k := skippedKey{pub: [32]byte(data[:32]), n: binary.LittleEndian.Uint32(data[32:])}
+		if skipped[k], data, err = readProtocol(data[36:]); err != nil {
+			return err
+		}
+	}
+	if len(data) != 0 {
+		return ErrInvalidState
+	}
+
+	*s = State{
+		localPriv: localPriv,
+		localPub:  ristretto255.NewIdentityElement().ScalarBaseMult(localPriv),
+		remotePub: remotePub,
+		send:      send,
+		recv:      recv,
+		sendN:     sendN,
+		recvN:     recvN,
+		prevSendN: prevSendN,
+		skipped:   skipped,
+	}
+	return nil
+}
+
+// SealState serializes the ratchet state with State.MarshalBinary and encrypts it with the given key, which should be
+// at least 32 bytes of uniform data.
+func (s *State) SealState(key []byte) ([]byte, error) {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(b)
+
+	// Generate a random nonce so the same key can seal many states.
+	nonce := make([]byte, stateNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return newStateProtocol(key, nonce).Seal("state", nonce, b), nil
+}
+
+// OpenState decrypts and restores a ratchet state sealed by State.SealState with the given key. Returns ErrInvalidState
+// if the sealed state is malformed or cannot be decrypted with the key.
+func OpenState(key, sealed []byte) (*State, error) {
+	if len(sealed) < stateNonceSize+thyrse.TagSize {
+		return nil, ErrInvalidState
+	}
+	b, err := newStateProtocol(key, sealed[:stateNonceSize]).Open("state", nil, sealed[stateNonceSize:])
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	defer secmem.Wipe(b)
+
+	s := new(State)
+	if err := s.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Wipe zeroizes the local private key, the sending and receiving chains, and the skipped message chains. After Wipe,
+// the state must not be used.
+func (s *State) Wipe() {
+	s.localPriv.Zero()
+	s.send.Clear()
+	s.recv.Clear()
+	for k, p := range s.skipped {
+		p.Clear()
+		delete(s.skipped, k)
+	}
+	s.sendN, s.recvN, s.prevSendN = 0, 0, 0
+}
+
+// newStateProtocol returns a protocol for sealing or opening a state with the given key and nonce.
+func newStateProtocol(key, nonce []byte) *thyrse.Protocol {
+	p := thyrse.New("thyrse.adratchet.state")
+	p.Mix("key", key)
+	p.Mix("nonce", nonce)
+	return p
+}
+
+// appendProtocol appends the length-prefixed serialized protocol state to b.
+func appendProtocol(b []byte, p *thyrse.Protocol) ([]byte, error) {
+	state, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(state)))
+	return append(b, state...), nil
+}
+
+// readProtocol reads a length-prefixed serialized protocol state from data, returning the protocol and the remaining
+// data.
+func readProtocol(data []byte) (*thyrse.Protocol, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, ErrInvalidState
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	data = data[2:]
+	if len(data) < n {
+		return nil, nil, ErrInvalidState
+	}
+
+	p := new(thyrse.Protocol)
+	if err := p.UnmarshalBinary(data[:n]); err != nil {
+		return nil, nil, ErrInvalidState
+	}
+	return p, data[n:], nil
+}
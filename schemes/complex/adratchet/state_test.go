@@ -0,0 +1,138 @@
+package adratchet_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/adratchet"
+)
+
+func TestState_MarshalBinary(t *testing.T) {
+	drbg := testdata.New("thyrse async double ratchet state test")
+	dA, qA := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+
+	p := thyrse.New("test")
+	p.SetSerializable(true)
+	p.Mix("shared key", []byte("secret"))
+
+	a := adratchet.NewInitiator(p.Clone(), dA, qB)
+	b := adratchet.NewResponder(p.Clone(), dB, qA)
+
+	// Bea receives the third of Alice's messages, leaving two skipped message chains in her state.
+	var msgs [][]byte
+	for i := range 3 {
+		msgs = append(msgs, a.SendMessage([]byte{byte(i)}))
+	}
+	if _, err := b.ReceiveMessage(msgs[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		data, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := new(adratchet.State)
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		// The restored state can receive skipped messages and reply.
+		for i, msg := range msgs[:2] {
+			v, err := restored.ReceiveMessage(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := v, []byte{byte(i)}; !bytes.Equal(got, want) {
+				t.Errorf("ReceiveMessage() = %v, want = %v", got, want)
+			}
+		}
+		v, err := a.ReceiveMessage(restored.SendMessage([]byte("reply")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, []byte("reply"); !bytes.Equal(got, want) {
+			t.Errorf("ReceiveMessage() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		data, err := a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, bad := range [][]byte{nil, data[:len(data)-1], append(data, 0)} {
+			if err := new(adratchet.State).UnmarshalBinary(bad); !errors.Is(err, adratchet.ErrInvalidState) {
+				t.Errorf("UnmarshalBinary() err = %v, want = %v", err, adratchet.ErrInvalidState)
+			}
+		}
+	})
+}
+
+func TestState_SealState(t *testing.T) {
+	drbg := testdata.New("thyrse async double ratchet seal test")
+	dA, qA := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	key := drbg.Data(32)
+
+	p := thyrse.New("test")
+	p.SetSerializable(true)
+	p.Mix("shared key", []byte("secret"))
+
+	a := adratchet.NewInitiator(p.Clone(), dA, qB)
+	b := adratchet.NewResponder(p.Clone(), dB, qA)
+
+	sealed, err := a.SealState(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		restored, err := adratchet.OpenState(key, sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := b.ReceiveMessage(restored.SendMessage([]byte("restored")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, []byte("restored"); !bytes.Equal(got, want) {
+			t.Errorf("ReceiveMessage() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		if _, err := adratchet.OpenState(drbg.Data(32), sealed); !errors.Is(err, adratchet.ErrInvalidState) {
+			t.Errorf("OpenState() err = %v, want = %v", err, adratchet.ErrInvalidState)
+		}
+	})
+
+	t.Run("randomized", func(t *testing.T) {
+		again, err := a.SealState(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(sealed, again) {
+			t.Error("SealState() returned the same sealed state twice")
+		}
+	})
+}
+
+func TestState_Wipe(t *testing.T) {
+	drbg := testdata.New("thyrse async double ratchet wipe test")
+	dA, _ := drbg.KeyPair()
+	_, qB := drbg.KeyPair()
+	want := dA.Bytes()
+
+	p := thyrse.New("test")
+	a := adratchet.NewResponder(p, dA, qB)
+	a.Wipe()
+
+	if got := dA.Bytes(); !bytes.Equal(got, want) {
+		t.Error("Wipe() modified the caller's private key")
+	}
+}
@@ -0,0 +1,77 @@
+// Package attest binds remote attestation evidence (e.g. an SGX or SEV-SNP quote) into a thyrse.Protocol transcript.
+//
+// A relying party and an enclave which share a transcript (e.g. from a handshake) bind the enclave's attestation to it
+// in three steps:
+//
+//  1. The enclave calls ReportData and requests a quote from its platform with the result as the quote's report data,
+//     which binds the quote to the transcript.
+//  2. The enclave sends the quote to the relying party.
+//  3. Both parties call Bind with the quote. The relying party supplies a Verifier which checks the quote's signature
+//     chain, measurement, and policy; the enclave, which trusts its own quote, may supply one which always approves.
+//
+// Bind checks the quote's report data against the transcript, calls the Verifier, and only then mixes the quote into
+// the transcript. If either check fails, the protocol is cleared, so no session keys can be derived from an unverified
+// transcript.
+package attest
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/codahale/thyrse"
+)
+
+// ReportDataSize is the size, in bytes, of the report data returned by ReportData. It matches the size of the report
+// data field of SGX and SEV-SNP quotes.
+const ReportDataSize = 64
+
+// ErrReportDataMismatch is returned by Bind when a quote's report data does not match the transcript.
+var ErrReportDataMismatch = errors.New("thyrse/attest: report data mismatch")
+
+// A Quote is a piece of attestation evidence, decoded into the fields which are bound into the transcript.
+type Quote struct {
+	Platform    string // The attestation platform (e.g. "sgx" or "sev-snp").
+	Measurement []byte // The measurement of the attested code (e.g. MRENCLAVE or the SEV-SNP launch measurement).
+	Policy      []byte // The platform policy the code runs under (e.g. SGX attributes or the SEV-SNP guest policy).
+	ReportData  []byte // The report data included in the quote, which must be the output of ReportData.
+	Evidence    []byte // The raw quote, including its signature and certificate chain.
+}
+
+// A Verifier approves or rejects a quote, e.g. by validating its signature chain and comparing its measurement and
+// policy to expected values. It returns nil if the quote is approved.
+type Verifier func(q *Quote) error
+
+// ReportData returns the report data which binds a quote to the current state of the protocol. The protocol's state is
+// not modified.
+func ReportData(p *thyrse.Protocol) []byte {
+	return p.Clone().Derive("report data", nil, ReportDataSize)
+}
+
+// MixQuote mixes the quote's fields into the protocol, each with a separate label.
+func MixQuote(p *thyrse.Protocol, q *Quote) {
+	p.MixString("platform", q.Platform)
+	p.Mix("measurement", q.Measurement)
+	p.Mix("policy", q.Policy)
+	p.Mix("report data", q.ReportData)
+	p.Mix("evidence", q.Evidence)
+}
+
+// Bind checks that the quote's report data matches ReportData(p), calls verify, and if both succeed, mixes the quote into
+// the protocol with MixQuote.
+//
+// If the report data does not match, ErrReportDataMismatch is returned. If verify returns an error, that error is
+// returned. In either case, the protocol is cleared and MUST NOT be used afterward.
+func Bind(p *thyrse.Protocol, q *Quote, verify Verifier) error {
+	if subtle.ConstantTimeCompare(q.ReportData, ReportData(p)) != 1 {
+		p.Clear()
+		return ErrReportDataMismatch
+	}
+
+	if err := verify(q); err != nil {
+		p.Clear()
+		return err
+	}
+
+	MixQuote(p, q)
+	return nil
+}
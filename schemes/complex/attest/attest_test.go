@@ -0,0 +1,98 @@
+package attest_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/attest"
+)
+
+func TestBind(t *testing.T) {
+	measurement := []byte("expected measurement")
+	verify := func(q *attest.Quote) error {
+		if !bytes.Equal(q.Measurement, measurement) {
+			return errors.New("untrusted measurement")
+		}
+		return nil
+	}
+	newQuote := func(p *thyrse.Protocol) *attest.Quote {
+		return &attest.Quote{
+			Platform:    "sgx",
+			Measurement: measurement,
+			Policy:      []byte("debug=false"),
+			ReportData:  attest.ReportData(p),
+			Evidence:    []byte("signed quote"),
+		}
+	}
+	newSession := func() *thyrse.Protocol {
+		p := thyrse.New("attest")
+		p.Mix("handshake", []byte("shared transcript"))
+		return p
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		enclave, relyingParty := newSession(), newSession()
+		q := newQuote(enclave)
+
+		if err := attest.Bind(enclave, q, func(*attest.Quote) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if err := attest.Bind(relyingParty, q, verify); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := relyingParty.Derive("session key", nil, 16), enclave.Derive("session key", nil, 16); !bytes.Equal(got, want) {
+			t.Errorf("Derive() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("report data is bound to the transcript", func(t *testing.T) {
+		other := thyrse.New("attest")
+		other.Mix("handshake", []byte("another transcript"))
+
+		if err := attest.Bind(newSession(), newQuote(other), verify); !errors.Is(err, attest.ErrReportDataMismatch) {
+			t.Errorf("Bind() err = %v, want = %v", err, attest.ErrReportDataMismatch)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		p := newSession()
+		q := newQuote(p)
+		q.Measurement = []byte("malicious measurement")
+
+		if err := attest.Bind(p, q, verify); err == nil {
+			t.Fatal("Bind() err = nil, want error")
+		}
+		defer func() {
+			if recover() == nil {
+				t.Error("Derive() on a rejected protocol did not panic")
+			}
+		}()
+		p.Derive("session key", nil, 16)
+	})
+
+	t.Run("quote fields are bound", func(t *testing.T) {
+		p1, p2 := newSession(), newSession()
+		q1, q2 := newQuote(p1), newQuote(p2)
+		q2.Policy = []byte("debug=true")
+
+		attest.MixQuote(p1, q1)
+		attest.MixQuote(p2, q2)
+		if p1.Equal(p2) == 1 {
+			t.Error("protocols with different quotes are equal")
+		}
+	})
+}
+
+func TestReportData(t *testing.T) {
+	p := thyrse.New("attest")
+	before := p.Clone()
+	if got, want := len(attest.ReportData(p)), attest.ReportDataSize; got != want {
+		t.Errorf("len(ReportData()) = %d, want = %d", got, want)
+	}
+	if p.Equal(before) != 1 {
+		t.Error("ReportData() modified the protocol")
+	}
+}
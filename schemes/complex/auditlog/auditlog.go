@@ -0,0 +1,391 @@
+// Package auditlog implements an append-only, externally verifiable audit log of the operations performed by a
+// thyrse.Protocol.
+//
+// A Protocol wraps a thyrse.Protocol and, for every finalizing operation (Derive, Ratchet, Mask, Unmask, Seal, Open),
+// appends a Record of the operation, its label, and a commitment to the resulting protocol state to a caller-provided
+// io.Writer. Every record is also absorbed into a separate audit transcript. Periodically, a checkpoint containing a
+// Schnorr signature (see [sig.Sign]) over the audit transcript is appended. When the log is closed, a final checkpoint
+// is appended, signed under a distinct label, so a verifier holding the signer's public key can confirm that the
+// sequence of records is complete, ordered, and unmodified, and that the log was not cut back to an earlier checkpoint.
+//
+// The commitment is derived from a clone of the protocol state under a dedicated label. It binds the record to the
+// protocol's state without revealing the chain value itself, which would allow anyone reading the log to recover the
+// protocol's outputs.
+//
+// Applications and other schemes can also append their own events, with arbitrary data, to a Log with [Log.Event].
+// Events are covered by the same checkpoints as operation records.
+package auditlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+	"github.com/gtank/ristretto255"
+)
+
+// CommitmentSize is the size, in bytes, of a record's state commitment.
+const CommitmentSize = 32
+
+// ErrInvalidLog is returned by Verify when a log is malformed, truncated, has an invalid checkpoint signature, or does
+// not end with a final checkpoint.
+var ErrInvalidLog = errors.New("thyrse/auditlog: invalid log")
+
+// Op identifies the finalizing operation a Record describes.
+type Op byte
+
+// Finalizing operations recorded by a Protocol.
+const (
+	OpDerive Op = iota + 1
+	OpRatchet
+	OpMask
+	OpUnmask
+	OpSeal
+	OpOpen
+	OpEvent
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpDerive:
+		return "Derive"
+	case OpRatchet:
+		return "Ratchet"
+	case OpMask:
+		return "Mask"
+	case OpUnmask:
+		return "Unmask"
+	case OpSeal:
+		return "Seal"
+	case OpOpen:
+		return "Open"
+	case OpEvent:
+		return "Event"
+	default:
+		return "Op(" + strconv.Itoa(int(op)) + ")"
+	}
+}
+
+// A Record describes a single finalizing operation or event.
+type Record struct {
+	Seq        uint64               // The 0-based position of the record in the log.
+	Op         Op                   // The operation performed, or OpEvent.
+	Label      string               // The operation's or event's label.
+	Commitment [CommitmentSize]byte // A commitment to the protocol state after the operation; zero for events.
+	Data       []byte               // The event's data; nil for operations.
+}
+
+// A Log appends records and signed checkpoints to an underlying io.Writer.
+type Log struct {
+	domain   string
+	d        *ristretto255.Scalar
+	w        io.Writer
+	interval int
+	audit    *thyrse.Protocol
+	seq      uint64
+	pending  int
+	err      error
+}
+
+// NewLog returns a Log which writes records to w and signs a checkpoint with the private key d after every interval
+// records. The domain separates both the audit transcript and the checkpoint signatures.
+//
+// Panics if interval is less than 1.
+func NewLog(domain string, d *ristretto255.Scalar, w io.Writer, interval int) *Log {
+	if interval < 1 {
+		panic("thyrse/auditlog: interval must be at least 1")
+	}
+	return &Log{
+		domain:   domain,
+		d:        d,
+		w:        w,
+		interval: interval,
+		audit:    newAudit(domain),
+	}
+}
+
+// Checkpoint signs the audit transcript and appends the signature to the log. It is a no-op if no records have been
+// appended since the last checkpoint.
+func (l *Log) Checkpoint() error {
+	if l.err != nil {
+		return l.err
+	}
+	if l.pending == 0 {
+		return nil
+	}
+	return l.sign(entryCheckpoint)
+}
+
+// Close appends a final checkpoint covering all records in the log. A log is only verifiable once it has been closed;
+// after Close, all operations on the log return thyrse.ErrClosed.
+func (l *Log) Close() error {
+	if l.err != nil {
+		return l.err
+	}
+	if err := l.sign(entryFinal); err != nil {
+		return err
+	}
+	l.err = thyrse.ErrClosed
+	return nil
+}
+
+// sign signs the audit transcript for a checkpoint of the given kind and appends the signature to the log.
+func (l *Log) sign(kind byte) error {
+	var r [64]byte
+	if _, err := rand.Read(r[:]); err != nil {
+		panic(err)
+	}
+	signature, err := sig.Sign(l.domain, l.d, r[:], bytes.NewReader(checkpointDigest(l.audit, kind)))
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.w.Write(append([]byte{kind}, signature...)); err != nil {
+		l.err = err
+		return err
+	}
+	l.pending = 0
+	return nil
+}
+
+// Event appends a record of an application-defined event with the given label and data to the log.
+func (l *Log) Event(label string, data []byte) error {
+	if uint64(len(data)) > math.MaxUint32 {
+		panic("thyrse/auditlog: event data too long")
+	}
+	return l.write(entryEvent, &Record{Seq: l.seq, Op: OpEvent, Label: label, Data: data})
+}
+
+func (l *Log) append(op Op, label string, p *thyrse.Protocol) error {
+	rec := Record{
+		Seq:        l.seq,
+		Op:         op,
+		Label:      label,
+		Commitment: thyrse.DeriveArray[[CommitmentSize]byte](p.Clone(), "audit-commitment"),
+	}
+	return l.write(entryRecord, &rec)
+}
+
+func (l *Log) write(kind byte, rec *Record) error {
+	if l.err != nil {
+		return l.err
+	}
+	if len(rec.Label) > math.MaxUint16 {
+		panic("thyrse/auditlog: label too long")
+	}
+
+	entry := appendRecord([]byte{kind}, rec)
+	l.audit.Mix("record", entry)
+	if _, err := l.w.Write(entry); err != nil {
+		l.err = err
+		return err
+	}
+	l.seq++
+	l.pending++
+
+	if l.pending >= l.interval {
+		return l.Checkpoint()
+	}
+	return nil
+}
+
+// Protocol wraps a thyrse.Protocol, recording each finalizing operation in a Log.
+//
+// Errors writing to the log are returned from each operation; once an error has occurred, it is returned from all
+// subsequent operations. The operation itself is always performed before the record is written.
+type Protocol struct {
+	p   *thyrse.Protocol
+	log *Log
+}
+
+// Wrap returns a Protocol which records the operations performed on p in log. The wrapped thyrse.Protocol MUST NOT be
+// used directly while it is wrapped.
+func Wrap(p *thyrse.Protocol, log *Log) *Protocol {
+	return &Protocol{p: p, log: log}
+}
+
+// Mix calls [thyrse.Protocol.Mix]. Mix is not a finalizing operation and is not recorded on its own; its input is bound
+// into the commitment of the next recorded operation.
+func (a *Protocol) Mix(label string, data []byte) {
+	a.p.Mix(label, data)
+}
+
+// Derive calls [thyrse.Protocol.Derive] and records the operation.
+func (a *Protocol) Derive(label string, dst []byte, outputLen int) ([]byte, error) {
+	out := a.p.Derive(label, dst, outputLen)
+	return out, a.log.append(OpDerive, label, a.p)
+}
+
+// Ratchet calls [thyrse.Protocol.Ratchet] and records the operation.
+func (a *Protocol) Ratchet(label string) error {
+	a.p.Ratchet(label)
+	return a.log.append(OpRatchet, label, a.p)
+}
+
+// Mask calls [thyrse.Protocol.Mask] and records the operation.
+func (a *Protocol) Mask(label string, dst, plaintext []byte) ([]byte, error) {
+	out := a.p.Mask(label, dst, plaintext)
+	return out, a.log.append(OpMask, label, a.p)
+}
+
+// Unmask calls [thyrse.Protocol.Unmask] and records the operation.
+func (a *Protocol) Unmask(label string, dst, ciphertext []byte) ([]byte, error) {
+	out := a.p.Unmask(label, dst, ciphertext)
+	return out, a.log.append(OpUnmask, label, a.p)
+}
+
+// Seal calls [thyrse.Protocol.Seal] and records the operation.
+func (a *Protocol) Seal(label string, dst, plaintext []byte) ([]byte, error) {
+	out := a.p.Seal(label, dst, plaintext)
+	return out, a.log.append(OpSeal, label, a.p)
+}
+
+// Open calls [thyrse.Protocol.Open] and records the operation. Failed opens are recorded as well, since they advance
+// the protocol's state.
+func (a *Protocol) Open(label string, dst, sealed []byte) ([]byte, error) {
+	out, openErr := a.p.Open(label, dst, sealed)
+	if err := a.log.append(OpOpen, label, a.p); err != nil {
+		return nil, err
+	}
+	return out, openErr
+}
+
+// Verify reads a log written by a Log with the given domain and the signer's public key q, returning the records it
+// contains. The log must end with the final checkpoint written by [Log.Close], and every checkpoint signature must be
+// valid; if the log is malformed, truncated, or has been modified, ErrInvalidLog is returned.
+//
+// Event data is read incrementally, so a corrupted length cannot force a large allocation, but the records returned
+// are held in memory. Callers verifying logs from untrusted sources should bound the size of r (e.g. with
+// io.LimitReader).
+func Verify(domain string, q *ristretto255.Element, r io.Reader) ([]Record, error) {
+	audit := newAudit(domain)
+	var records []Record
+	closed := false
+
+	for {
+		var kind [1]byte
+		if _, err := io.ReadFull(r, kind[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, readErr(err)
+		}
+
+		// Nothing may follow the final checkpoint.
+		if closed {
+			return nil, ErrInvalidLog
+		}
+
+		switch kind[0] {
+		case entryRecord, entryEvent:
+			var header [8 + 1 + 2]byte
+			if _, err := io.ReadFull(r, header[:]); err != nil {
+				return nil, readErr(err)
+			}
+			label := make([]byte, binary.BigEndian.Uint16(header[9:]))
+			if _, err := io.ReadFull(r, label); err != nil {
+				return nil, readErr(err)
+			}
+			rec := Record{
+				Seq:   binary.BigEndian.Uint64(header[:8]),
+				Op:    Op(header[8]),
+				Label: string(label),
+			}
+			if (kind[0] == entryEvent) != (rec.Op == OpEvent) {
+				return nil, ErrInvalidLog
+			}
+			if kind[0] == entryEvent {
+				var size [4]byte
+				if _, err := io.ReadFull(r, size[:]); err != nil {
+					return nil, readErr(err)
+				}
+				var data bytes.Buffer
+				if _, err := io.CopyN(&data, r, int64(binary.BigEndian.Uint32(size[:]))); err != nil {
+					return nil, readErr(err)
+				}
+				rec.Data = data.Bytes()
+			} else if _, err := io.ReadFull(r, rec.Commitment[:]); err != nil {
+				return nil, readErr(err)
+			}
+			if rec.Seq != uint64(len(records)) {
+				return nil, ErrInvalidLog
+			}
+			audit.Mix("record", appendRecord([]byte{kind[0]}, &rec))
+			records = append(records, rec)
+		case entryCheckpoint, entryFinal:
+			signature := make([]byte, sig.Size)
+			if _, err := io.ReadFull(r, signature); err != nil {
+				return nil, readErr(err)
+			}
+			valid, err := sig.Verify(domain, q, signature, bytes.NewReader(checkpointDigest(audit, kind[0])))
+			if err != nil {
+				return nil, err
+			}
+			if !valid {
+				return nil, ErrInvalidLog
+			}
+			closed = kind[0] == entryFinal
+		default:
+			return nil, ErrInvalidLog
+		}
+	}
+
+	// A log without a final checkpoint is unclosed or has been cut back to an earlier checkpoint, and records after
+	// the last checkpoint are unauthenticated.
+	if !closed {
+		return nil, ErrInvalidLog
+	}
+
+	return records, nil
+}
+
+func newAudit(domain string) *thyrse.Protocol {
+	audit := thyrse.New(domain)
+	audit.MixString("purpose", "audit log")
+	return audit
+}
+
+// checkpointDigest returns a digest of the audit transcript so far for a checkpoint of the given kind, without
+// advancing it. Final checkpoints are derived under a distinct label, so an intermediate checkpoint signature cannot
+// stand in for a final one.
+func checkpointDigest(audit *thyrse.Protocol, kind byte) []byte {
+	label := "checkpoint"
+	if kind == entryFinal {
+		label = "final-checkpoint"
+	}
+	return audit.Clone().Derive(label, nil, 32)
+}
+
+// appendRecord appends the encoding of rec to b: seq (8 bytes, big endian), op (1 byte), label length (2 bytes, big
+// endian), label, and either the commitment or, for events, the data length (4 bytes, big endian) and data.
+func appendRecord(b []byte, rec *Record) []byte {
+	b = binary.BigEndian.AppendUint64(b, rec.Seq)
+	b = append(b, byte(rec.Op))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(rec.Label)))
+	b = append(b, rec.Label...)
+	if rec.Op == OpEvent {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(rec.Data)))
+		return append(b, rec.Data...)
+	}
+	return append(b, rec.Commitment[:]...)
+}
+
+func readErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrInvalidLog
+	}
+	return err
+}
+
+const (
+	entryRecord     = 0x01
+	entryCheckpoint = 0x02
+	entryEvent      = 0x03
+	entryFinal      = 0x04
+)
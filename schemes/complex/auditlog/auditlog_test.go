@@ -0,0 +1,226 @@
+package auditlog_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/auditlog"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+)
+
+func TestVerify(t *testing.T) {
+	drbg := testdata.New("thyrse audit log")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+
+	record := func(interval int) []byte {
+		buf := bytes.NewBuffer(nil)
+		log := auditlog.NewLog("audit", d, buf, interval)
+		p := auditlog.Wrap(thyrse.New("example"), log)
+		p.Mix("key", []byte("a secret key"))
+		if _, err := p.Derive("first", nil, 16); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Ratchet("second"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Seal("third", nil, []byte("a message")); err != nil {
+			t.Fatal(err)
+		}
+		if err := log.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		for _, interval := range []int{1, 2, 10} {
+			records, err := auditlog.Verify("audit", q, bytes.NewReader(record(interval)))
+			if err != nil {
+				t.Fatalf("interval=%d: Verify() err = %v", interval, err)
+			}
+
+			var got []string
+			for _, r := range records {
+				got = append(got, fmt.Sprintf("%d:%s:%s", r.Seq, r.Op, r.Label))
+			}
+			if got, want := fmt.Sprint(got), "[0:Derive:first 1:Ratchet:second 2:Seal:third]"; got != want {
+				t.Errorf("interval=%d: records = %s, want = %s", interval, got, want)
+			}
+		}
+	})
+
+	t.Run("deterministic commitments", func(t *testing.T) {
+		r1, _ := auditlog.Verify("audit", q, bytes.NewReader(record(1)))
+		r2, _ := auditlog.Verify("audit", q, bytes.NewReader(record(10)))
+		for i := range r1 {
+			if r1[i].Commitment != r2[i].Commitment {
+				t.Errorf("record %d commitment = %x, want = %x", i, r1[i].Commitment, r2[i].Commitment)
+			}
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		if _, err := auditlog.Verify("audit", qX, bytes.NewReader(record(2))); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		if _, err := auditlog.Verify("other", q, bytes.NewReader(record(2))); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("modified record", func(t *testing.T) {
+		log := record(10)
+		log[20] ^= 1
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(log)); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		log := record(10)
+		for _, n := range []int{1, 10, 64} {
+			if _, err := auditlog.Verify("audit", q, bytes.NewReader(log[:len(log)-n])); !errors.Is(err, auditlog.ErrInvalidLog) {
+				t.Errorf("Verify(truncated %d) err = %v, want = %v", n, err, auditlog.ErrInvalidLog)
+			}
+		}
+	})
+
+	t.Run("cut back to checkpoint", func(t *testing.T) {
+		log := record(1)
+		cut := len(log) - 1 - sig.Size
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(log[:cut])); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("entry after close", func(t *testing.T) {
+		log := record(1)
+		log = append(log, log[len(log)-1-sig.Size:]...)
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(log)); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("unclosed", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		log := auditlog.NewLog("audit", d, buf, 10)
+		p := auditlog.Wrap(thyrse.New("example"), log)
+		_ = p.Ratchet("unsigned")
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(buf.Bytes())); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+}
+
+func TestLog_Event(t *testing.T) {
+	drbg := testdata.New("thyrse audit log")
+	d, q := drbg.KeyPair()
+
+	buf := bytes.NewBuffer(nil)
+	log := auditlog.NewLog("audit", d, buf, 10)
+	p := auditlog.Wrap(thyrse.New("example"), log)
+	if err := log.Event("login", []byte("alice")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Ratchet("ratchet"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Event("logout", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		records, err := auditlog.Verify("audit", q, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, r := range records {
+			got = append(got, fmt.Sprintf("%d:%s:%s:%s", r.Seq, r.Op, r.Label, r.Data))
+		}
+		if got, want := fmt.Sprint(got), "[0:Event:login:alice 1:Ratchet:ratchet: 2:Event:logout:]"; got != want {
+			t.Errorf("records = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("modified data", func(t *testing.T) {
+		log := bytes.Clone(buf.Bytes())
+		log[1+8+1+2+len("login")+4] ^= 1
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(log)); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("oversized data length", func(t *testing.T) {
+		log := bytes.Clone(buf.Bytes())
+		copy(log[1+8+1+2+len("login"):], []byte{0xff, 0xff, 0xff, 0xff})
+		if _, err := auditlog.Verify("audit", q, bytes.NewReader(log)); !errors.Is(err, auditlog.ErrInvalidLog) {
+			t.Errorf("Verify() err = %v, want = %v", err, auditlog.ErrInvalidLog)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		if err := log.Event("late", nil); !errors.Is(err, thyrse.ErrClosed) {
+			t.Errorf("Event() err = %v, want = %v", err, thyrse.ErrClosed)
+		}
+		if err := log.Close(); !errors.Is(err, thyrse.ErrClosed) {
+			t.Errorf("Close() err = %v, want = %v", err, thyrse.ErrClosed)
+		}
+	})
+}
+
+func TestProtocol(t *testing.T) {
+	drbg := testdata.New("thyrse audit log")
+	d, _ := drbg.KeyPair()
+
+	t.Run("transparent", func(t *testing.T) {
+		log := auditlog.NewLog("audit", d, bytes.NewBuffer(nil), 1)
+		p := auditlog.Wrap(thyrse.New("example"), log)
+		p.Mix("key", []byte("a secret key"))
+		got, err := p.Seal("message", nil, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plain := thyrse.New("example")
+		plain.Mix("key", []byte("a secret key"))
+		if want := plain.Seal("message", nil, []byte("hello")); !bytes.Equal(got, want) {
+			t.Errorf("Seal() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("failed open", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		log := auditlog.NewLog("audit", d, buf, 1)
+		p := auditlog.Wrap(thyrse.New("example"), log)
+		if _, err := p.Open("message", nil, make([]byte, 40)); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+		}
+		if buf.Len() == 0 {
+			t.Error("failed Open was not recorded")
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		log := auditlog.NewLog("audit", d, &testdata.ErrWriter{Err: errors.New("broken")}, 1)
+		p := auditlog.Wrap(thyrse.New("example"), log)
+		if err := p.Ratchet("one"); err == nil {
+			t.Error("Ratchet() err = nil, want error")
+		}
+		if _, err := p.Derive("two", nil, 8); err == nil {
+			t.Error("Derive() err = nil, want sticky error")
+		}
+	})
+}
@@ -0,0 +1,118 @@
+// Package beacon implements verification of a distributed randomness beacon in the style of [drand].
+//
+// A beacon publishes a sequence of rounds, each carrying a Schnorr signature (see [sig.Sign]) by a group key over a
+// message derived from the round number and, in chained mode, the previous round's signature. The group key is
+// typically held by a threshold of participants in the frost scheme, so no single operator can produce a round
+// alone. Application randomness for each round is derived from the verified signature via a Thyrse transcript.
+//
+// Unlike BLS signatures, Schnorr signatures are not unique: a threshold of colluding signers can produce many valid
+// signatures for the same round and publish the one whose randomness they prefer. Applications which require
+// unbiasable output against a colluding threshold should combine the beacon with a commit-reveal scheme or a VRF.
+//
+// The Verifier is transport-agnostic; callers fetch rounds over whatever channel the beacon uses and pass them in.
+//
+// [drand]: https://drand.love
+package beacon
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+	"github.com/gtank/ristretto255"
+)
+
+// MessageSize is the size, in bytes, of the message signed for each round.
+const MessageSize = 32
+
+var (
+	// ErrInvalidRound is returned when a round's signature is invalid.
+	ErrInvalidRound = errors.New("thyrse/beacon: invalid round")
+
+	// ErrBrokenChain is returned in chained mode when a round's previous signature does not match the signature of the
+	// previously verified round.
+	ErrBrokenChain = errors.New("thyrse/beacon: broken chain")
+)
+
+// A Round is a single beacon output.
+type Round struct {
+	Number            uint64 // The round number, starting at 1.
+	PreviousSignature []byte // The signature of the previous round in chained mode; empty otherwise.
+	Signature         []byte // The group's signature of the round's message.
+}
+
+// Message returns the message a beacon signs for the given round number and previous signature (which is empty for
+// unchained beacons and for the first round of a chained beacon).
+func Message(domain string, number uint64, previousSignature []byte) []byte {
+	p := thyrse.New(domain)
+	p.MixUint64("round", number)
+	p.Mix("previous-signature", previousSignature)
+	return p.Derive("message", nil, MessageSize)
+}
+
+// Randomness returns n bytes of application randomness for the given round, separated by the given purpose label. The
+// round must be verified before its randomness is used.
+func Randomness(domain string, r Round, purpose string, n int) []byte {
+	p := thyrse.New(domain)
+	p.MixUint64("round", r.Number)
+	p.Mix("signature", r.Signature)
+	p.MixString("purpose", purpose)
+	return p.Derive("randomness", nil, n)
+}
+
+// A Verifier verifies rounds from a single beacon.
+type Verifier struct {
+	domain   string
+	groupKey *ristretto255.Element
+	chained  bool
+	last     Round
+}
+
+// NewVerifier returns a Verifier for the beacon with the given domain and group public key. If chained is true, each
+// round's message includes the previous round's signature, and consecutive rounds passed to Verify are checked for
+// continuity.
+func NewVerifier(domain string, groupKey *ristretto255.Element, chained bool) *Verifier {
+	return &Verifier{domain: domain, groupKey: groupKey, chained: chained}
+}
+
+// Verify checks the round's signature. In chained mode, if the round immediately follows the most recently verified
+// round, its previous signature must match that round's signature; otherwise, ErrBrokenChain is returned.
+func (v *Verifier) Verify(r Round) error {
+	if r.Number == 0 {
+		return ErrInvalidRound
+	}
+
+	prev := r.PreviousSignature
+	if !v.chained {
+		prev = nil
+	} else if v.last.Number != 0 && r.Number == v.last.Number+1 {
+		if subtle.ConstantTimeCompare(prev, v.last.Signature) != 1 {
+			return ErrBrokenChain
+		}
+	}
+
+	msg := Message(v.domain, r.Number, prev)
+	valid, err := sig.Verify(v.domain, v.groupKey, r.Signature, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidRound
+	}
+
+	if r.Number > v.last.Number {
+		v.last = Round{Number: r.Number, Signature: bytes.Clone(r.Signature)}
+	}
+	return nil
+}
+
+// Randomness verifies the round and returns n bytes of application randomness for it, separated by the given purpose
+// label.
+func (v *Verifier) Randomness(r Round, purpose string, n int) ([]byte, error) {
+	if err := v.Verify(r); err != nil {
+		return nil, err
+	}
+	return Randomness(v.domain, r, purpose, n), nil
+}
@@ -0,0 +1,176 @@
+package beacon_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/beacon"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+	"github.com/gtank/ristretto255"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	drbg := testdata.New("thyrse beacon")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+
+	rounds := chain(t, drbg, d, 3)
+
+	t.Run("chained", func(t *testing.T) {
+		v := beacon.NewVerifier("beacon", q, true)
+		for _, r := range rounds {
+			if err := v.Verify(r); err != nil {
+				t.Fatalf("round %d: Verify() err = %v", r.Number, err)
+			}
+		}
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		v := beacon.NewVerifier("beacon", q, true)
+		for _, i := range []int{2, 0, 1} {
+			if err := v.Verify(rounds[i]); err != nil {
+				t.Fatalf("round %d: Verify() err = %v", rounds[i].Number, err)
+			}
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		v := beacon.NewVerifier("beacon", qX, true)
+		if err := v.Verify(rounds[0]); !errors.Is(err, beacon.ErrInvalidRound) {
+			t.Errorf("Verify() err = %v, want = %v", err, beacon.ErrInvalidRound)
+		}
+	})
+
+	t.Run("wrong number", func(t *testing.T) {
+		v := beacon.NewVerifier("beacon", q, true)
+		r := rounds[1]
+		r.Number = 7
+		if err := v.Verify(r); !errors.Is(err, beacon.ErrInvalidRound) {
+			t.Errorf("Verify() err = %v, want = %v", err, beacon.ErrInvalidRound)
+		}
+	})
+
+	t.Run("round zero", func(t *testing.T) {
+		v := beacon.NewVerifier("beacon", q, true)
+		r := rounds[0]
+		r.Number = 0
+		if err := v.Verify(r); !errors.Is(err, beacon.ErrInvalidRound) {
+			t.Errorf("Verify() err = %v, want = %v", err, beacon.ErrInvalidRound)
+		}
+	})
+
+	t.Run("broken chain", func(t *testing.T) {
+		// Sign a valid round 2 which chains from a different round 1.
+		forked := rounds[1]
+		forked.PreviousSignature = bytes.Repeat([]byte{1}, sig.Size)
+		forked.Signature = sign(t, drbg, d, beacon.Message("beacon", 2, forked.PreviousSignature))
+
+		v := beacon.NewVerifier("beacon", q, true)
+		if err := v.Verify(forked); err != nil {
+			t.Fatalf("Verify(forked) err = %v", err)
+		}
+
+		v = beacon.NewVerifier("beacon", q, true)
+		if err := v.Verify(rounds[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.Verify(forked); !errors.Is(err, beacon.ErrBrokenChain) {
+			t.Errorf("Verify() err = %v, want = %v", err, beacon.ErrBrokenChain)
+		}
+	})
+
+	t.Run("unchained", func(t *testing.T) {
+		r := beacon.Round{Number: 9, Signature: sign(t, drbg, d, beacon.Message("beacon", 9, nil))}
+		v := beacon.NewVerifier("beacon", q, false)
+		if err := v.Verify(r); err != nil {
+			t.Errorf("Verify() err = %v", err)
+		}
+	})
+}
+
+func TestVerifier_Randomness(t *testing.T) {
+	drbg := testdata.New("thyrse beacon")
+	d, q := drbg.KeyPair()
+	rounds := chain(t, drbg, d, 2)
+
+	v := beacon.NewVerifier("beacon", q, true)
+	r1, err := v.Randomness(rounds[0], "lottery", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := v.Randomness(rounds[1], "lottery", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(r1, r2) {
+		t.Error("rounds produced identical randomness")
+	}
+
+	if got, want := beacon.Randomness("beacon", rounds[0], "election", 32), r1; bytes.Equal(got, want) {
+		t.Errorf("Randomness(election) = %x, want != %x", got, want)
+	}
+
+	bad := rounds[0]
+	bad.Signature = rounds[1].Signature
+	if _, err := v.Randomness(bad, "lottery", 32); err == nil {
+		t.Error("Randomness() err = nil, want error")
+	}
+}
+
+func TestFROST(t *testing.T) {
+	drbg := testdata.New("thyrse beacon frost")
+	groupKey, signers, _, err := frost.KeyGen("beacon-keygen", 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := beacon.Message("beacon", 1, nil)
+	nonces := make([]frost.Nonce, 2)
+	commitments := make([]frost.Commitment, 2)
+	for i := range 2 {
+		nonces[i], commitments[i] = signers[i].Commit(drbg.Data(64))
+	}
+	shares := make([][]byte, 2)
+	for i := range 2 {
+		shares[i], err = signers[i].Sign("beacon", nonces[i], msg, commitments)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	signature, err := frost.Aggregate("beacon", groupKey, msg, commitments, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := beacon.NewVerifier("beacon", groupKey, true)
+	if err := v.Verify(beacon.Round{Number: 1, Signature: signature}); err != nil {
+		t.Errorf("Verify() err = %v", err)
+	}
+}
+
+func chain(t *testing.T, drbg *testdata.DRBG, d *ristretto255.Scalar, n int) []beacon.Round {
+	t.Helper()
+
+	var rounds []beacon.Round
+	var prev []byte
+	for i := range uint64(n) {
+		r := beacon.Round{Number: i + 1, PreviousSignature: prev}
+		r.Signature = sign(t, drbg, d, beacon.Message("beacon", r.Number, prev))
+		rounds = append(rounds, r)
+		prev = r.Signature
+	}
+	return rounds
+}
+
+func sign(t *testing.T, drbg *testdata.DRBG, d *ristretto255.Scalar, msg []byte) []byte {
+	t.Helper()
+
+	signature, err := sig.Sign("beacon", d, drbg.Data(64), bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
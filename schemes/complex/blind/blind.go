@@ -0,0 +1,228 @@
+// Package blind implements [Abe-Okamoto] partially blind Schnorr signatures using Ristretto255 and Thyrse.
+//
+// A user obtains a signature from a signer over a message the signer never sees, bound to public information (e.g. an
+// expiration epoch or token type) which both parties agree on. The signer cannot link a signature to the session which
+// produced it, making this suitable for privacy-preserving token issuance.
+//
+// Signing takes three moves: the signer calls Commit and sends the commitment to the user, the user calls Blind and
+// sends the challenge to the signer, and the signer responds with the Respond function returned by Commit. The user
+// then calls the Finalize function returned by Blind to produce a signature which anyone can check with Verify.
+// Signatures are not compatible with package sig.
+//
+// Unlike plain blind Schnorr signatures, which are vulnerable to the ROS attack when the signer runs many sessions
+// concurrently, the Abe-Okamoto scheme is secure with concurrent sessions in the algebraic group model.
+//
+// [Abe-Okamoto]: https://www.iacr.org/archive/crypto2000/18800272/18800272.pdf
+package blind
+
+import (
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/gtank/ristretto255"
+)
+
+const (
+	// CommitmentSize is the length of a signer's commitment in bytes.
+	CommitmentSize = 64
+
+	// ChallengeSize is the length of a user's blinded challenge in bytes.
+	ChallengeSize = 32
+
+	// ResponseSize is the length of a signer's response in bytes.
+	ResponseSize = 128
+
+	// Size is the length of a signature in bytes.
+	Size = 128
+)
+
+var (
+	// ErrInvalidMessage is returned when a commitment, challenge, or response is malformed or invalid.
+	ErrInvalidMessage = errors.New("thyrse/blind: invalid message")
+
+	// ErrSessionUsed is returned when a signer's Respond function is called more than once.
+	ErrSessionUsed = errors.New("thyrse/blind: session already used")
+)
+
+// Respond is a callback function to be called by the signer when the user's challenge is received.
+type Respond = func(challenge []byte) ([]byte, error)
+
+// Finalize is a callback function to be called by the user when the signer's response is received.
+type Finalize = func(response []byte) ([]byte, error)
+
+// Commit begins a signing session as the signer, using the given domain separation string, private key, public
+// information, and random value (which must be exactly 64 bytes). It returns a Respond function and a commitment to be
+// sent to the user. When the Respond function is called with the user's challenge, it returns a response to be sent to
+// the user.
+//
+// The Respond function can only be called once; answering two challenges with the same commitment reveals the
+// signer's private key. Subsequent calls return ErrSessionUsed.
+//
+// Panics if rand is not exactly 64 bytes.
+func Commit(domain string, d *ristretto255.Scalar, info, rand []byte) (respond Respond, commitment []byte) {
+	if len(rand) != 64 {
+		panic("thyrse/blind: rand must be 64 bytes")
+	}
+
+	q := ristretto255.NewIdentityElement().ScalarBaseMult(d)
+	p, z := newProtocol(domain, q, info)
+
+	// Derive the session's secret scalars from the private key and the random value, hedging against a weak source of
+	// randomness.
+	p.Mix("signer-private", d.Bytes())
+	p.Mix("rand", rand)
+	u := deriveScalar(p, "u")
+	s := deriveScalar(p, "s")
+	y := deriveScalar(p, "y")
+
+	// Calculate the commitment points a = [u]G and b = [s]G + [y]Z.
+	a := ristretto255.NewIdentityElement().ScalarBaseMult(u)
+	b := ristretto255.NewIdentityElement().ScalarMult(y, z)
+	b = b.Add(b, ristretto255.NewIdentityElement().ScalarBaseMult(s))
+	commitment = append(a.Bytes(), b.Bytes()...)
+
+	used := false
+	return func(challenge []byte) ([]byte, error) {
+		if used {
+			return nil, ErrSessionUsed
+		}
+		used = true
+
+		if len(challenge) != ChallengeSize {
+			return nil, ErrInvalidMessage
+		}
+		ch, _ := ristretto255.NewScalar().SetCanonicalBytes(challenge)
+		if ch == nil {
+			return nil, ErrInvalidMessage
+		}
+
+		// Split the challenge into c = ch - y, and calculate r = u - c*d.
+		c := ristretto255.NewScalar().Subtract(ch, y)
+		r := ristretto255.NewScalar().Multiply(c, d)
+		r = r.Subtract(u, r)
+
+		out := append(r.Bytes(), c.Bytes()...)
+		out = append(out, s.Bytes()...)
+		return append(out, y.Bytes()...), nil
+	}, commitment
+}
+
+// Blind continues a signing session as the user, using the given domain separation string, signer's public key, public
+// information, message, random value (which must be exactly 64 bytes), and the signer's commitment. It returns a
+// Finalize function and a blinded challenge to be sent to the signer. When the Finalize function is called with the
+// signer's response, it returns a signature of the message and public information.
+//
+// Returns ErrInvalidMessage if the commitment is invalid, or if the Finalize function is called with an invalid
+// response.
+//
+// Panics if rand is not exactly 64 bytes.
+func Blind(domain string, q *ristretto255.Element, info, message, rand, commitment []byte) (finalize Finalize, challenge []byte, err error) {
+	if len(rand) != 64 {
+		panic("thyrse/blind: rand must be 64 bytes")
+	}
+
+	if len(commitment) != CommitmentSize {
+		return nil, nil, ErrInvalidMessage
+	}
+	a, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(commitment[:32])
+	b, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(commitment[32:])
+	if a == nil || b == nil {
+		return nil, nil, ErrInvalidMessage
+	}
+
+	p, z := newProtocol(domain, q, info)
+
+	// Derive the blinding scalars from the random value.
+	blinding := p.Clone()
+	blinding.Mix("rand", rand)
+	t1 := deriveScalar(blinding, "t1")
+	t2 := deriveScalar(blinding, "t2")
+	t3 := deriveScalar(blinding, "t3")
+	t4 := deriveScalar(blinding, "t4")
+	g := ristretto255.NewGeneratorElement()
+
+	// Blind the commitment points: α = a + [t1]G + [t2]Q and β = b + [t3]G + [t4]Z.
+	alpha := ristretto255.NewIdentityElement().MultiScalarMult([]*ristretto255.Scalar{t1, t2}, []*ristretto255.Element{g, q})
+	alpha = alpha.Add(alpha, a)
+	beta := ristretto255.NewIdentityElement().MultiScalarMult([]*ristretto255.Scalar{t3, t4}, []*ristretto255.Element{g, z})
+	beta = beta.Add(beta, b)
+
+	// Calculate the signature's challenge scalar and blind it: e = ε - t2 - t4.
+	epsilon := challengeScalar(p, message, alpha, beta)
+	e := ristretto255.NewScalar().Subtract(epsilon, t2)
+	e = e.Subtract(e, t4)
+
+	return func(response []byte) ([]byte, error) {
+		if len(response) != ResponseSize {
+			return nil, ErrInvalidMessage
+		}
+		r, _ := ristretto255.NewScalar().SetCanonicalBytes(response[:32])
+		c, _ := ristretto255.NewScalar().SetCanonicalBytes(response[32:64])
+		s, _ := ristretto255.NewScalar().SetCanonicalBytes(response[64:96])
+		d, _ := ristretto255.NewScalar().SetCanonicalBytes(response[96:])
+		if r == nil || c == nil || s == nil || d == nil {
+			return nil, ErrInvalidMessage
+		}
+
+		// Check that c + d = e, a = [r]G + [c]Q, and b = [s]G + [d]Z.
+		if ristretto255.NewScalar().Add(c, d).Equal(e) != 1 ||
+			ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(c, q, r).Equal(a) != 1 ||
+			ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(d, z, s).Equal(b) != 1 {
+			return nil, ErrInvalidMessage
+		}
+
+		// Unblind the response: ρ = r + t1, ω = c + t2, σ = s + t3, δ = d + t4.
+		sig := ristretto255.NewScalar().Add(r, t1).Bytes()
+		sig = append(sig, ristretto255.NewScalar().Add(c, t2).Bytes()...)
+		sig = append(sig, ristretto255.NewScalar().Add(s, t3).Bytes()...)
+		return append(sig, ristretto255.NewScalar().Add(d, t4).Bytes()...), nil
+	}, e.Bytes(), nil
+}
+
+// Verify uses the given Ristretto255 public key and signature to verify a message and public information. Returns true
+// if and only if the signature was produced by a signing session with the holder of the signer's private key.
+func Verify(domain string, q *ristretto255.Element, info, message, sig []byte) bool {
+	if len(sig) != Size {
+		return false
+	}
+	rho, _ := ristretto255.NewScalar().SetCanonicalBytes(sig[:32])
+	omega, _ := ristretto255.NewScalar().SetCanonicalBytes(sig[32:64])
+	sigma, _ := ristretto255.NewScalar().SetCanonicalBytes(sig[64:96])
+	delta, _ := ristretto255.NewScalar().SetCanonicalBytes(sig[96:])
+	if rho == nil || omega == nil || sigma == nil || delta == nil {
+		return false
+	}
+
+	// Calculate the expected commitment points α = [ρ]G + [ω]Q and β = [σ]G + [δ]Z, and check that ω + δ is the
+	// challenge scalar.
+	p, z := newProtocol(domain, q, info)
+	alpha := ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(omega, q, rho)
+	beta := ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(delta, z, sigma)
+	return ristretto255.NewScalar().Add(omega, delta).Equal(challengeScalar(p, message, alpha, beta)) == 1
+}
+
+// newProtocol returns a protocol with the signer's public key and the public information mixed in, and the element Z
+// derived from the public information.
+func newProtocol(domain string, q *ristretto255.Element, info []byte) (*thyrse.Protocol, *ristretto255.Element) {
+	p := thyrse.New(domain)
+	p.Mix("signer", q.Bytes())
+	p.Mix("info", info)
+	z, _ := ristretto255.NewIdentityElement().SetUniformBytes(p.Derive("info-element", nil, 64))
+	return p, z
+}
+
+// challengeScalar derives the challenge scalar for the message and commitment points from a clone of the protocol.
+func challengeScalar(p *thyrse.Protocol, message []byte, alpha, beta *ristretto255.Element) *ristretto255.Scalar {
+	p = p.Clone()
+	p.Mix("message", message)
+	p.Mix("alpha", alpha.Bytes())
+	p.Mix("beta", beta.Bytes())
+	return deriveScalar(p, "challenge")
+}
+
+// deriveScalar derives a uniform scalar from the protocol.
+func deriveScalar(p *thyrse.Protocol, label string) *ristretto255.Scalar {
+	s := group.DeriveRistretto255Scalar(p, label)
+	return s
+}
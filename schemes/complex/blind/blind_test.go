@@ -0,0 +1,126 @@
+package blind_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/blind"
+)
+
+func TestBlind(t *testing.T) {
+	drbg := testdata.New("thyrse blind signature")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+	info := []byte("epoch 42")
+	message := []byte("this is a token")
+
+	respond, commitment := blind.Commit("blind", d, info, drbg.Data(64))
+	finalize, challenge, err := blind.Blind("blind", q, info, message, drbg.Data(64), commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := respond(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := finalize(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		if !blind.Verify("blind", q, info, message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+
+	t.Run("unlinkable", func(t *testing.T) {
+		for _, b := range [][]byte{commitment, challenge, response} {
+			for i := 0; i+32 <= len(b); i += 32 {
+				if bytes.Contains(signature, b[i:i+32]) {
+					t.Error("signature contains a value from the signing session")
+				}
+			}
+		}
+	})
+
+	t.Run("wrong signer", func(t *testing.T) {
+		if blind.Verify("blind", qX, info, message, signature) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+
+	t.Run("wrong info", func(t *testing.T) {
+		if blind.Verify("blind", q, []byte("epoch 43"), message, signature) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+
+	t.Run("wrong message", func(t *testing.T) {
+		if blind.Verify("blind", q, info, []byte("this is another token"), signature) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+
+	t.Run("modified signature", func(t *testing.T) {
+		for i := range blind.Size / 32 {
+			bad := slices.Clone(signature)
+			bad[i*32] ^= 1
+			if blind.Verify("blind", q, info, message, bad) {
+				t.Errorf("Verify() = true for modified scalar %d, want false", i)
+			}
+		}
+	})
+
+	t.Run("short signature", func(t *testing.T) {
+		if blind.Verify("blind", q, info, message, signature[:blind.Size-1]) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+
+	t.Run("session reuse", func(t *testing.T) {
+		if _, err := respond(challenge); !errors.Is(err, blind.ErrSessionUsed) {
+			t.Errorf("respond() err = %v, want %v", err, blind.ErrSessionUsed)
+		}
+	})
+}
+
+func TestBlind_InvalidMessages(t *testing.T) {
+	drbg := testdata.New("thyrse blind signature")
+	d, q := drbg.KeyPair()
+	info := []byte("epoch 42")
+	message := []byte("this is a token")
+
+	t.Run("invalid commitment", func(t *testing.T) {
+		_, commitment := blind.Commit("blind", d, info, drbg.Data(64))
+		_, _, err := blind.Blind("blind", q, info, message, drbg.Data(64), commitment[:blind.CommitmentSize-1])
+		if !errors.Is(err, blind.ErrInvalidMessage) {
+			t.Errorf("Blind() err = %v, want %v", err, blind.ErrInvalidMessage)
+		}
+	})
+
+	t.Run("invalid challenge", func(t *testing.T) {
+		respond, _ := blind.Commit("blind", d, info, drbg.Data(64))
+		if _, err := respond(bytes.Repeat([]byte{0xff}, blind.ChallengeSize)); !errors.Is(err, blind.ErrInvalidMessage) {
+			t.Errorf("respond() err = %v, want %v", err, blind.ErrInvalidMessage)
+		}
+	})
+
+	t.Run("wrong info", func(t *testing.T) {
+		respond, commitment := blind.Commit("blind", d, []byte("epoch 43"), drbg.Data(64))
+		finalize, challenge, err := blind.Blind("blind", q, info, message, drbg.Data(64), commitment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := respond(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := finalize(response); !errors.Is(err, blind.ErrInvalidMessage) {
+			t.Errorf("finalize() err = %v, want %v", err, blind.ErrInvalidMessage)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+// Package dleq implements non-interactive proofs of discrete logarithm equality (DLEQ) using Thyrse.
+//
+// A proof shows that the prover knows a scalar x such that A = [x]G and B = [x]H, for public elements G, A, H, and B,
+// without revealing x, e.g. that a key rotation or a verifiable random function output was computed with the key
+// whose public key is A. The batched form proves B_i = [x]H_i for several pairs at once, with a proof the same size as
+// for one pair, by proving the statement for a random linear combination of the pairs with weights derived from the
+// transcript.
+//
+// The package-level functions use Ristretto255. ForGroup returns the same scheme over any prime-order group. Proofs
+// are bound to a domain and to every element of the statement.
+package dleq
+
+import (
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/gtank/ristretto255"
+)
+
+// ProofSize is the length of a Ristretto255 proof in bytes.
+const ProofSize = 64
+
+// Prove returns a proof that A = [x]G and B = [x]H, where A and B are computed from x. The rand parameter should
+// contain at least 64 bytes of random data; the proof's nonce is derived from x, the statement, and the random data,
+// protecting against both nonce reuse and weak randomness.
+func Prove(domain string, x *ristretto255.Scalar, g, h *ristretto255.Element, rand []byte) []byte {
+	return ProveBatch(domain, x, g, []*ristretto255.Element{h}, rand)
+}
+
+// Verify returns true if the proof shows that A = [x]G and B = [x]H for some scalar x.
+func Verify(domain string, g, a, h, b *ristretto255.Element, proof []byte) bool {
+	return VerifyBatch(domain, g, a, []*ristretto255.Element{h}, []*ristretto255.Element{b}, proof)
+}
+
+// ProveBatch returns a proof that A = [x]G and B_i = [x]H_i for every H_i in h, where A and B_i are computed from x.
+// The rand parameter is as for Prove.
+func ProveBatch(domain string, x *ristretto255.Scalar, g *ristretto255.Element, h []*ristretto255.Element, rand []byte) []byte {
+	return ristretto.ProveBatch(domain, group.Ristretto255Scalar(x), group.Ristretto255Element(g), ristrettoElements(h),
+		rand)
+}
+
+// VerifyBatch returns true if the proof shows that A = [x]G and B_i = [x]H_i for every pair of H_i in h and B_i in b,
+// for some scalar x. Returns false if h and b have different lengths.
+func VerifyBatch(domain string, g, a *ristretto255.Element, h, b []*ristretto255.Element, proof []byte) bool {
+	return ristretto.VerifyBatch(domain, group.Ristretto255Element(g), group.Ristretto255Element(a), ristrettoElements(h),
+		ristrettoElements(b), proof)
+}
+
+// ristretto is the scheme over Ristretto255 used by the package-level functions.
+var ristretto = ForGroup(group.Ristretto255)
+
+// A Scheme is the DLEQ proof scheme over a prime-order group. Its methods behave like the package-level functions of
+// the same names, with the group's scalars and elements. Proofs are the encoded challenge scalar followed by the
+// encoded response scalar.
+type Scheme struct {
+	g group.Group
+}
+
+// ForGroup returns the DLEQ proof scheme over the given group.
+func ForGroup(g group.Group) Scheme {
+	return Scheme{g: g}
+}
+
+// ProofSize returns the length of a proof in bytes.
+func (sc Scheme) ProofSize() int {
+	return 2 * sc.g.ScalarSize()
+}
+
+// Prove returns a proof that A = [x]G and B = [x]H, where A and B are computed from x.
+func (sc Scheme) Prove(domain string, x group.Scalar, g, h group.Element, rand []byte) []byte {
+	return sc.ProveBatch(domain, x, g, []group.Element{h}, rand)
+}
+
+// Verify returns true if the proof shows that A = [x]G and B = [x]H for some scalar x.
+func (sc Scheme) Verify(domain string, g, a, h, b group.Element, proof []byte) bool {
+	return sc.VerifyBatch(domain, g, a, []group.Element{h}, []group.Element{b}, proof)
+}
+
+// ProveBatch returns a proof that A = [x]G and B_i = [x]H_i for every H_i in h, where A and B_i are computed from x.
+func (sc Scheme) ProveBatch(domain string, x group.Scalar, g group.Element, h []group.Element, rand []byte) []byte {
+	a := sc.g.NewElement().ScalarMult(x, g)
+	b := make([]group.Element, len(h))
+	for i := range h {
+		b[i] = sc.g.NewElement().ScalarMult(x, h[i])
+	}
+
+	p, m, _ := sc.statement(domain, g, a, h, b)
+
+	// Derive the nonce from the statement, the secret, and the random data.
+	prover, verifier := p.Fork("role", []byte("prover"), []byte("verifier"))
+	prover.Mix("secret", x.Bytes())
+	prover.Mix("hedged-rand", rand)
+	r := group.DeriveScalar(prover, sc.g, "nonce")
+
+	// Commit to [r]G and [r]M, and derive the challenge.
+	c := sc.challenge(verifier, sc.g.NewElement().ScalarMult(r, g), sc.g.NewElement().ScalarMult(r, m))
+
+	// s = r - c*x
+	s := sc.g.NewScalar().Subtract(r, sc.g.NewScalar().Multiply(c, x))
+	return append(c.Bytes(), s.Bytes()...)
+}
+
+// VerifyBatch returns true if the proof shows that A = [x]G and B_i = [x]H_i for every pair of H_i in h and B_i in b,
+// for some scalar x. Returns false if h and b have different lengths.
+func (sc Scheme) VerifyBatch(domain string, g, a group.Element, h, b []group.Element, proof []byte) bool {
+	n := sc.g.ScalarSize()
+	if len(h) != len(b) || len(proof) != 2*n {
+		return false
+	}
+
+	c, err := sc.g.NewScalar().SetCanonicalBytes(proof[:n])
+	if err != nil {
+		return false
+	}
+	s, err := sc.g.NewScalar().SetCanonicalBytes(proof[n:])
+	if err != nil {
+		return false
+	}
+
+	p, m, z := sc.statement(domain, g, a, h, b)
+	_, verifier := p.Fork("role", []byte("prover"), []byte("verifier"))
+
+	// Recompute the commitments [s]G + [c]A = [r]G and [s]M + [c]Z = [r]M, and compare the challenge they produce.
+	t1 := sc.g.NewElement().Add(sc.g.NewElement().ScalarMult(s, g), sc.g.NewElement().ScalarMult(c, a))
+	t2 := sc.g.NewElement().Add(sc.g.NewElement().ScalarMult(s, m), sc.g.NewElement().ScalarMult(c, z))
+	return sc.challenge(verifier, t1, t2).Equal(c) == 1
+}
+
+// statement returns a protocol bound to the statement, and the combinations M = Σ[d_i]H_i and Z = Σ[d_i]B_i of its
+// pairs, with weights d_i derived from the statement.
+func (sc Scheme) statement(domain string, g, a group.Element, h, b []group.Element) (p *thyrse.Protocol, m, z group.Element) {
+	p = thyrse.New(domain)
+	p.MixString("group", sc.g.Name())
+	p.Mix("g", g.Bytes())
+	p.Mix("a", a.Bytes())
+	p.MixUint64("pairs", uint64(len(h)))
+	for i := range h {
+		p.Mix("h", h[i].Bytes())
+		p.Mix("b", b[i].Bytes())
+	}
+
+	weights := p.Clone()
+	m, z = sc.g.NewElement(), sc.g.NewElement()
+	for i := range h {
+		d := group.DeriveScalar(weights, sc.g, "weight")
+		m.Add(m, sc.g.NewElement().ScalarMult(d, h[i]))
+		z.Add(z, sc.g.NewElement().ScalarMult(d, b[i]))
+	}
+	return p, m, z
+}
+
+// challenge mixes the commitments into the verifier and derives the challenge scalar.
+func (sc Scheme) challenge(verifier *thyrse.Protocol, t1, t2 group.Element) group.Scalar {
+	verifier.Mix("t1", t1.Bytes())
+	verifier.Mix("t2", t2.Bytes())
+	c := group.DeriveScalar(verifier, sc.g, "challenge")
+	return c
+}
+
+// ristrettoElements returns the elements as Elements of the Ristretto255 group.
+func ristrettoElements(elements []*ristretto255.Element) []group.Element {
+	out := make([]group.Element, len(elements))
+	for i, e := range elements {
+		out[i] = group.Ristretto255Element(e)
+	}
+	return out
+}
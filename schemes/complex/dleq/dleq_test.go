@@ -0,0 +1,148 @@
+package dleq_test
+
+import (
+	"testing"
+
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/dleq"
+	"github.com/gtank/ristretto255"
+)
+
+func TestProve(t *testing.T) {
+	drbg := testdata.New("thyrse dleq")
+	x, a := drbg.KeyPair()
+	_, h := drbg.KeyPair()
+	g := ristretto255.NewGeneratorElement()
+	b := ristretto255.NewIdentityElement().ScalarMult(x, h)
+
+	proof := dleq.Prove("example", x, g, h, drbg.Data(64))
+	if got, want := len(proof), dleq.ProofSize; got != want {
+		t.Fatalf("len(proof) = %d, want = %d", got, want)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		if !dleq.Verify("example", g, a, h, b, proof) {
+			t.Error("valid proof did not verify")
+		}
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		if dleq.Verify("other", g, a, h, b, proof) {
+			t.Error("proof verified in another domain")
+		}
+	})
+
+	t.Run("unequal logarithms", func(t *testing.T) {
+		_, other := drbg.KeyPair()
+		if dleq.Verify("example", g, a, h, other, proof) {
+			t.Error("proof verified for a different B")
+		}
+	})
+
+	t.Run("swapped statement", func(t *testing.T) {
+		if dleq.Verify("example", h, b, g, a, proof) {
+			t.Error("proof verified with the pairs swapped")
+		}
+	})
+
+	t.Run("modified proof", func(t *testing.T) {
+		for i := range proof {
+			bad := append([]byte(nil), proof...)
+			bad[i] ^= 1
+			if dleq.Verify("example", g, a, h, b, bad) {
+				t.Errorf("proof modified at byte %d verified", i)
+			}
+		}
+	})
+
+	t.Run("truncated proof", func(t *testing.T) {
+		if dleq.Verify("example", g, a, h, b, proof[:dleq.ProofSize-1]) {
+			t.Error("truncated proof verified")
+		}
+	})
+
+	t.Run("hedged", func(t *testing.T) {
+		if other := dleq.Prove("example", x, g, h, drbg.Data(64)); string(other) == string(proof) {
+			t.Error("proofs with different randomness are equal")
+		}
+	})
+}
+
+func TestProveBatch(t *testing.T) {
+	drbg := testdata.New("thyrse dleq batch")
+	x, a := drbg.KeyPair()
+	g := ristretto255.NewGeneratorElement()
+
+	h := make([]*ristretto255.Element, 4)
+	b := make([]*ristretto255.Element, len(h))
+	for i := range h {
+		_, h[i] = drbg.KeyPair()
+		b[i] = ristretto255.NewIdentityElement().ScalarMult(x, h[i])
+	}
+
+	proof := dleq.ProveBatch("example", x, g, h, drbg.Data(64))
+	if got, want := len(proof), dleq.ProofSize; got != want {
+		t.Fatalf("len(proof) = %d, want = %d", got, want)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		if !dleq.VerifyBatch("example", g, a, h, b, proof) {
+			t.Error("valid proof did not verify")
+		}
+	})
+
+	t.Run("one bad pair", func(t *testing.T) {
+		bad := append([]*ristretto255.Element(nil), b...)
+		_, bad[2] = drbg.KeyPair()
+		if dleq.VerifyBatch("example", g, a, h, bad, proof) {
+			t.Error("proof verified with one bad pair")
+		}
+	})
+
+	t.Run("reordered pairs", func(t *testing.T) {
+		h2 := []*ristretto255.Element{h[1], h[0], h[2], h[3]}
+		b2 := []*ristretto255.Element{b[1], b[0], b[2], b[3]}
+		if dleq.VerifyBatch("example", g, a, h2, b2, proof) {
+			t.Error("proof verified with the pairs reordered")
+		}
+	})
+
+	t.Run("subset", func(t *testing.T) {
+		if dleq.VerifyBatch("example", g, a, h[:3], b[:3], proof) {
+			t.Error("proof verified for a subset of the pairs")
+		}
+	})
+
+	t.Run("mismatched lengths", func(t *testing.T) {
+		if dleq.VerifyBatch("example", g, a, h, b[:3], proof) {
+			t.Error("proof verified with mismatched lengths")
+		}
+	})
+}
+
+func TestForGroup(t *testing.T) {
+	drbg := testdata.New("thyrse dleq group")
+	sc := dleq.ForGroup(group.Ristretto255)
+	if got, want := sc.ProofSize(), dleq.ProofSize; got != want {
+		t.Fatalf("ProofSize() = %d, want = %d", got, want)
+	}
+
+	x, a := drbg.KeyPair()
+	_, h := drbg.KeyPair()
+	g := ristretto255.NewGeneratorElement()
+	b := ristretto255.NewIdentityElement().ScalarMult(x, h)
+
+	// Proofs from the generic scheme verify with the package-level functions, and vice versa.
+	proof := sc.Prove("example", group.Ristretto255Scalar(x), group.Ristretto255Element(g),
+		group.Ristretto255Element(h), drbg.Data(64))
+	if !dleq.Verify("example", g, a, h, b, proof) {
+		t.Error("generic proof did not verify")
+	}
+
+	proof = dleq.Prove("example", x, g, h, drbg.Data(64))
+	if !sc.Verify("example", group.Ristretto255Element(g), group.Ristretto255Element(a),
+		group.Ristretto255Element(h), group.Ristretto255Element(b), proof) {
+		t.Error("proof did not verify with the generic scheme")
+	}
+}
@@ -0,0 +1,204 @@
+// Package bip340 implements FROST threshold signatures over secp256k1 which are BIP340 Schnorr signatures, verifiable
+// by any BIP340 implementation (e.g. for Bitcoin Taproot key path spends with an untweaked key).
+//
+// It shares the frost package's signing protocol, instantiated with [group.Secp256k1], with two differences required
+// for compatibility. First, the challenge is the BIP340 tagged SHA-256 hash of the group commitment, the group key, and
+// the message, rather than being derived from a Thyrse transcript, and is not bound to a domain. Second, BIP340 public
+// keys and group commitments are x-only points with an even y coordinate: KeyGen negates the shares if needed so the
+// group key has an even y coordinate, and signers negate their nonces when the group commitment does not. Key
+// generation, nonce derivation, and binding factors still use Thyrse transcripts bound to a domain.
+//
+// Points are encoded in compressed SEC 1 form, except for the group key and signatures, which use BIP340's x-only
+// encodings. Errors are those of the frost package.
+package bip340
+
+import (
+	"crypto/sha256"
+	"slices"
+
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+	"github.com/codahale/thyrse/schemes/complex/frost/internal/core"
+)
+
+const (
+	// SignatureSize is the size of a BIP340 signature in bytes.
+	SignatureSize = 64
+
+	// PublicKeySize is the size of a BIP340 x-only public key in bytes.
+	PublicKeySize = 32
+
+	// ShareSize is the size of a signature share in bytes.
+	ShareSize = 32
+
+	// PointSize is the size of a compressed point (a commitment or verifying share) in bytes.
+	PointSize = 33
+)
+
+// suite is FROST over secp256k1 with BIP340 challenges and even y coordinates.
+var suite = &core.Suite{
+	Group:        group.Secp256k1,
+	BindingLabel: "frost-bip340-binding",
+	Challenge: func(_ string, groupKey, message []byte, r group.Element) group.Scalar {
+		return challenge(r.Bytes()[1:], groupKey, message)
+	},
+	Negate: hasOddY,
+}
+
+// A Signer holds the secret key material for a single FROST participant.
+type Signer struct {
+	domain         string
+	identifier     uint16
+	signingShare   group.Scalar
+	verifyingShare []byte
+	groupKey       []byte
+}
+
+// Identifier returns the signer's 1-based identifier.
+func (s *Signer) Identifier() uint16 {
+	return s.identifier
+}
+
+// VerifyingShare returns the signer's compressed verifying share (public key corresponding to their signing share).
+func (s *Signer) VerifyingShare() []byte {
+	return s.verifyingShare
+}
+
+// GroupKey returns the group's x-only BIP340 public key.
+func (s *Signer) GroupKey() []byte {
+	return s.groupKey
+}
+
+// A Nonce holds the ephemeral secret nonces for a single signing round. Each Nonce must be used exactly once and then
+// discarded.
+type Nonce struct {
+	nonce core.Nonce
+}
+
+// A Commitment is the public counterpart of a [Nonce], broadcast to all participants before signing. Its Hiding and
+// Binding fields are 33-byte compressed points.
+type Commitment = core.Commitment
+
+// KeyGen performs trusted-dealer key generation for a threshold-of-maxSigners FROST scheme. It returns the group's
+// x-only public key, the signers, and their compressed verifying shares.
+//
+// Identifiers are 1-based: signers[i] has identifier i+1. The threshold must be at least 2 and at most maxSigners, and
+// maxSigners must be at most 65535. rand must contain at least 64 bytes of uniform randomness.
+func KeyGen(domain string, maxSigners, threshold int, rand []byte) ([]byte, []Signer, [][]byte, error) {
+	y, shares, vss, err := suite.KeyGen(domain, maxSigners, threshold, rand)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	groupKey := y.Bytes()[1:]
+	signers := make([]Signer, maxSigners)
+	verifyingShares := make([][]byte, maxSigners)
+	for i := range maxSigners {
+		verifyingShares[i] = vss[i].Bytes()
+		signers[i] = Signer{
+			domain:         domain,
+			identifier:     uint16(i + 1),
+			signingShare:   shares[i],
+			verifyingShare: verifyingShares[i],
+			groupKey:       groupKey,
+		}
+	}
+
+	return groupKey, signers, verifyingShares, nil
+}
+
+// Commit generates a nonce pair and its public commitment for a signing round. The rand parameter should contain at
+// least 64 bytes of random data; the nonces are derived deterministically from the signer's share and the random data,
+// providing hedged nonce generation that protects against both nonce reuse and weak randomness.
+func (s *Signer) Commit(rand []byte) (Nonce, Commitment) {
+	n, c := suite.Commit(s.domain, s.identifier, s.signingShare, rand)
+	return Nonce{nonce: n}, c
+}
+
+// Sign produces a signature share for the given message. The commitments slice must contain the commitments of all
+// participants in this signing round, including this signer's own commitment. The nonce must be the same one returned
+// by [Signer.Commit] for this round and must not be reused.
+func (s *Signer) Sign(domain string, nonce Nonce, message []byte, commitments []Commitment) ([]byte, error) {
+	return suite.Sign(domain, s.groupKey, s.identifier, s.signingShare, nonce.nonce, message, commitments)
+}
+
+// Aggregate combines the signature shares from a threshold of signers into a final BIP340 signature. The commitments
+// must be the same set used during signing, and sigShares[i] must correspond to commitments[i] (after sorting by
+// identifier).
+func Aggregate(domain string, groupKey, message []byte, commitments []Commitment, sigShares [][]byte) ([]byte, error) {
+	if len(groupKey) != PublicKeySize {
+		return nil, frost.ErrInvalidParameters
+	}
+
+	r, z, err := suite.Aggregate(domain, groupKey, message, commitments, sigShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Concat(r.Bytes()[1:], z.Bytes()), nil
+}
+
+// Verify checks a BIP340 signature against an x-only public key and message, as specified by BIP340.
+func Verify(publicKey, message, signature []byte) bool {
+	if len(signature) != SignatureSize {
+		return false
+	}
+
+	pk, ok := liftX(publicKey)
+	if !ok {
+		return false
+	}
+
+	s, err := group.Secp256k1.NewScalar().SetCanonicalBytes(signature[32:])
+	if err != nil {
+		return false
+	}
+
+	// R = [s]G - [e]P, which must be the point with an x coordinate of r and an even y coordinate.
+	e := challenge(signature[:32], publicKey, message)
+	r := group.Secp256k1.NewElement().VarTimeDoubleScalarBaseMult(e.Negate(e), pk, s)
+	return slices.Equal(r.Bytes(), slices.Concat([]byte{0x02}, signature[:32]))
+}
+
+// VerifyShare checks an individual signature share against the signer's compressed verifying share. This can be used
+// to identify which participant produced an invalid share before aggregation.
+func VerifyShare(domain string, verifyingShare, groupKey []byte, identifier uint16, message []byte, commitments []Commitment, sigShare []byte) bool {
+	y, err := group.Secp256k1.NewElement().SetCanonicalBytes(verifyingShare)
+	if err != nil || len(groupKey) != PublicKeySize {
+		return false
+	}
+
+	return suite.VerifyShare(domain, y, groupKey, identifier, message, commitments, sigShare)
+}
+
+// challengeTag is the SHA-256 hash of the BIP340 challenge tag, "BIP0340/challenge".
+var challengeTag = sha256.Sum256([]byte("BIP0340/challenge"))
+
+// challenge returns the BIP340 challenge, the tagged hash of the x coordinate of R, the x-only public key, and the
+// message, reduced modulo the group order.
+func challenge(rx, publicKey, message []byte) group.Scalar {
+	h := sha256.New()
+	h.Write(challengeTag[:])
+	h.Write(challengeTag[:])
+	h.Write(rx)
+	h.Write(publicKey)
+	h.Write(message)
+
+	// The hash is reduced as the low half of a 64-byte big endian integer.
+	e, _ := group.Secp256k1.NewScalar().SetUniformBytes(h.Sum(make([]byte, 32)))
+	return e
+}
+
+// hasOddY reports whether a point has an odd y coordinate, and so is not a valid BIP340 public key or R.
+func hasOddY(e group.Element) bool {
+	return e.Bytes()[0] == 0x03
+}
+
+// liftX returns the point with the given x coordinate and an even y coordinate, as specified by BIP340.
+func liftX(b []byte) (group.Element, bool) {
+	if len(b) != PublicKeySize {
+		return nil, false
+	}
+	p, err := group.Secp256k1.NewElement().SetCanonicalBytes(slices.Concat([]byte{0x02}, b))
+	return p, err == nil
+}
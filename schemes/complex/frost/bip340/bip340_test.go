@@ -0,0 +1,210 @@
+package bip340_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+	"github.com/codahale/thyrse/schemes/complex/frost/bip340"
+)
+
+const (
+	signDomain = "frost-bip340-test"
+	kgDomain   = "frost-bip340-keygen"
+)
+
+// sign runs a signing round with the given signers and returns the commitments, shares, and aggregated signature.
+func sign(t *testing.T, drbg *testdata.DRBG, groupKey, message []byte, signers []bip340.Signer) ([]bip340.Commitment, [][]byte, []byte) {
+	t.Helper()
+
+	nonces := make([]bip340.Nonce, len(signers))
+	commitments := make([]bip340.Commitment, len(signers))
+	for i := range signers {
+		nonces[i], commitments[i] = signers[i].Commit(drbg.Data(64))
+	}
+
+	shares := make([][]byte, len(signers))
+	for i := range signers {
+		share, err := signers[i].Sign(signDomain, nonces[i], message, commitments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = share
+	}
+
+	signature, err := bip340.Aggregate(signDomain, groupKey, message, commitments, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commitments, shares, signature
+}
+
+func TestKeyGen(t *testing.T) {
+	drbg := testdata.New("frost bip340 keygen")
+
+	t.Run("valid 3-of-5", func(t *testing.T) {
+		groupKey, signers, verifyingShares, err := bip340.KeyGen(kgDomain, 5, 3, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := len(groupKey), bip340.PublicKeySize; got != want {
+			t.Errorf("len(groupKey) = %d, want %d", got, want)
+		}
+
+		for i, s := range signers {
+			if got, want := s.Identifier(), uint16(i+1); got != want {
+				t.Errorf("signer[%d].Identifier() = %d, want %d", i, got, want)
+			}
+			if !bytes.Equal(s.GroupKey(), groupKey) {
+				t.Errorf("signer[%d].GroupKey() does not match group key", i)
+			}
+			if !bytes.Equal(s.VerifyingShare(), verifyingShares[i]) {
+				t.Errorf("signer[%d].VerifyingShare() does not match verifying share", i)
+			}
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, params := range [][3]int{{5, 1, 64}, {2, 3, 64}, {5, 3, 32}, {0x10000, 3, 64}} {
+			if _, _, _, err := bip340.KeyGen(kgDomain, params[0], params[1], drbg.Data(params[2])); !errors.Is(err, frost.ErrInvalidParameters) {
+				t.Errorf("KeyGen(%v) err = %v, want ErrInvalidParameters", params, err)
+			}
+		}
+	})
+}
+
+func TestSignAndVerify(t *testing.T) {
+	drbg := testdata.New("frost bip340 sign")
+	message := []byte("a taproot spend")
+
+	// Cover group keys and group commitments with both y coordinate parities.
+	for i := range 8 {
+		groupKey, signers, verifyingShares, err := bip340.KeyGen(kgDomain, 5, 3, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		subset := []bip340.Signer{signers[4], signers[0], signers[2]}
+		commitments, shares, signature := sign(t, drbg, groupKey, message, subset)
+
+		if !bip340.Verify(groupKey, message, signature) {
+			t.Fatalf("key %d: Verify() = false, want true", i)
+		}
+		if bip340.Verify(groupKey, []byte("another spend"), signature) {
+			t.Errorf("key %d: Verify(wrong message) = true, want false", i)
+		}
+
+		for j, s := range subset {
+			if !bip340.VerifyShare(signDomain, verifyingShares[s.Identifier()-1], groupKey, s.Identifier(), message, commitments, shares[j]) {
+				t.Errorf("key %d: VerifyShare(signer %d) = false, want true", i, s.Identifier())
+			}
+		}
+		if bip340.VerifyShare(signDomain, verifyingShares[1], groupKey, subset[0].Identifier(), message, commitments, shares[0]) {
+			t.Errorf("key %d: VerifyShare(wrong verifying share) = true, want false", i)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	drbg := testdata.New("frost bip340 aggregate")
+	message := []byte("message")
+	groupKey, signers, _, err := bip340.KeyGen(kgDomain, 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitments, shares, _ := sign(t, drbg, groupKey, message, signers[:2])
+
+	t.Run("wrong share count", func(t *testing.T) {
+		if _, err := bip340.Aggregate(signDomain, groupKey, message, commitments, shares[:1]); !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("Aggregate() err = %v, want ErrInvalidParameters", err)
+		}
+	})
+
+	t.Run("invalid share", func(t *testing.T) {
+		bad := [][]byte{shares[0], bytes.Repeat([]byte{0xff}, bip340.ShareSize)}
+		if _, err := bip340.Aggregate(signDomain, groupKey, message, commitments, bad); !errors.Is(err, frost.ErrInvalidShare) {
+			t.Errorf("Aggregate() err = %v, want ErrInvalidShare", err)
+		}
+	})
+
+	t.Run("invalid commitment", func(t *testing.T) {
+		bad := []bip340.Commitment{commitments[0], {Identifier: 2, Hiding: []byte{2}, Binding: commitments[1].Binding}}
+		if _, err := bip340.Aggregate(signDomain, groupKey, message, bad, shares); !errors.Is(err, frost.ErrInvalidCommitment) {
+			t.Errorf("Aggregate() err = %v, want ErrInvalidCommitment", err)
+		}
+	})
+
+	t.Run("invalid share contribution", func(t *testing.T) {
+		bad := [][]byte{shares[0], shares[0]}
+		signature, err := bip340.Aggregate(signDomain, groupKey, message, commitments, bad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bip340.Verify(groupKey, message, signature) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+}
+
+func TestSign(t *testing.T) {
+	drbg := testdata.New("frost bip340 errors")
+	_, signers, _, err := bip340.KeyGen(kgDomain, 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n0, c0 := signers[0].Commit(drbg.Data(64))
+	_, c1 := signers[1].Commit(drbg.Data(64))
+
+	if _, err := signers[0].Sign(signDomain, n0, nil, []bip340.Commitment{c1}); !errors.Is(err, frost.ErrMissingSigner) {
+		t.Errorf("Sign(missing signer) err = %v, want ErrMissingSigner", err)
+	}
+	if _, err := signers[0].Sign(signDomain, n0, nil, []bip340.Commitment{c0, c0}); !errors.Is(err, frost.ErrDuplicateIdentifier) {
+		t.Errorf("Sign(duplicate) err = %v, want ErrDuplicateIdentifier", err)
+	}
+}
+
+// TestVerify checks Verify against test vectors from BIP340.
+func TestVerify(t *testing.T) {
+	for _, tc := range []struct {
+		publicKey, message, signature string
+		valid                         bool
+	}{
+		{
+			publicKey: "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			message:   "0000000000000000000000000000000000000000000000000000000000000000",
+			signature: "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+			valid:     true,
+		},
+		{
+			publicKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			message:   "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			signature: "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+			valid:     true,
+		},
+		{
+			// Public key not on the curve.
+			publicKey: "EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+			message:   "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			signature: "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			valid:     false,
+		},
+		{
+			// Invalid signature.
+			publicKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			message:   "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			signature: "1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD",
+			valid:     false,
+		},
+	} {
+		publicKey, _ := hex.DecodeString(tc.publicKey)
+		message, _ := hex.DecodeString(tc.message)
+		signature, _ := hex.DecodeString(tc.signature)
+		if got := bip340.Verify(publicKey, message, signature); got != tc.valid {
+			t.Errorf("Verify(%s, %s, %s) = %v, want %v", tc.publicKey, tc.message, tc.signature, got, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package frost
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/codahale/thyrse/schemes/complex/frost/internal/core"
+	"github.com/gtank/ristretto255"
+)
+
+// ErrMissingShare is returned by Coordinator.Signature when a committed signer has not sent a signature share.
+var ErrMissingShare = errors.New("frost: missing signature share")
+
+// A Coordinator drives a single FROST signing round for a message, collecting the signers' commitments and signature
+// shares, verifying each share as it arrives, and aggregating them into a signature.
+//
+// A round proceeds as follows:
+//
+//  1. Each participating signer calls Signer.Commit and sends its Commitment, which the coordinator adds with
+//     AddCommitment.
+//  2. The coordinator calls Commitments to end the first round and sends the list to each signer, along with the
+//     message.
+//  3. Each signer calls Signer.Sign and sends its share, which the coordinator adds with AddShare.
+//  4. The coordinator calls Signature to aggregate the shares.
+//
+// Invalid shares are reported as a CulpritError identifying the misbehaving signers, who should be excluded from
+// future rounds. A Coordinator is not safe for concurrent use.
+type Coordinator struct {
+	domain          string
+	groupKey        *ristretto255.Element
+	verifyingShares []*ristretto255.Element
+	threshold       int
+	message         []byte
+	commitments     []Commitment
+	shares          map[uint16][]byte
+	culprits        []uint16
+	closed          bool
+}
+
+// NewCoordinator returns a Coordinator for signing the message with the given group. The verifying shares are as
+// returned by KeyGen: verifyingShares[i] belongs to the signer with identifier i+1.
+func NewCoordinator(domain string, groupKey *ristretto255.Element, verifyingShares []*ristretto255.Element, threshold int, message []byte) *Coordinator {
+	return &Coordinator{
+		domain:          domain,
+		groupKey:        groupKey,
+		verifyingShares: verifyingShares,
+		threshold:       threshold,
+		message:         message,
+		shares:          make(map[uint16][]byte),
+	}
+}
+
+// AddCommitment adds a signer's commitment to the first round. Returns ErrInvalidCommitment if the commitment cannot
+// be decoded or is from an unknown signer, ErrDuplicateIdentifier if the signer has already committed, or
+// ErrInvalidRound if the first round has ended.
+func (c *Coordinator) AddCommitment(commitment Commitment) error {
+	if c.closed {
+		return ErrInvalidRound
+	}
+	if commitment.Identifier == 0 || int(commitment.Identifier) > len(c.verifyingShares) {
+		return ErrInvalidCommitment
+	}
+	for _, b := range [][]byte{commitment.Hiding, commitment.Binding} {
+		if e, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(b); e == nil {
+			return ErrInvalidCommitment
+		}
+	}
+	if slices.ContainsFunc(c.commitments, func(other Commitment) bool {
+		return other.Identifier == commitment.Identifier
+	}) {
+		return ErrDuplicateIdentifier
+	}
+
+	c.commitments = append(c.commitments, commitment)
+	return nil
+}
+
+// Commitments ends the first round and returns the commitment list to send to the signers, sorted by identifier.
+// Returns ErrInvalidParameters if fewer than threshold signers have committed.
+func (c *Coordinator) Commitments() ([]Commitment, error) {
+	if len(c.commitments) < c.threshold {
+		return nil, ErrInvalidParameters
+	}
+
+	c.closed = true
+	c.commitments = core.SortCommitments(c.commitments)
+	return slices.Clone(c.commitments), nil
+}
+
+// AddShare verifies a signer's signature share and adds it to the second round. Returns a CulpritError with
+// ErrInvalidShare identifying the signer if the share is invalid, ErrMissingSigner if the signer has not committed,
+// ErrDuplicateIdentifier if the signer has already sent a share, or ErrInvalidRound if the first round has not ended.
+func (c *Coordinator) AddShare(identifier uint16, share []byte) error {
+	if !c.closed {
+		return ErrInvalidRound
+	}
+	if !slices.ContainsFunc(c.commitments, func(commitment Commitment) bool {
+		return commitment.Identifier == identifier
+	}) {
+		return ErrMissingSigner
+	}
+	if _, ok := c.shares[identifier]; ok || slices.Contains(c.culprits, identifier) {
+		return ErrDuplicateIdentifier
+	}
+
+	if !VerifyShare(c.domain, c.verifyingShares[identifier-1], c.groupKey, identifier, c.message, c.commitments, share) {
+		c.culprits = append(c.culprits, identifier)
+		return &CulpritError{Err: ErrInvalidShare, Culprits: []uint16{identifier}}
+	}
+	c.shares[identifier] = slices.Clone(share)
+	return nil
+}
+
+// Signature aggregates the signature shares into a signature. Returns a CulpritError with ErrInvalidShare identifying
+// every signer who sent an invalid share, ErrMissingShare if any committed signer has not sent a share, or
+// ErrInvalidRound if the first round has not ended.
+func (c *Coordinator) Signature() ([]byte, error) {
+	if !c.closed {
+		return nil, ErrInvalidRound
+	}
+	if len(c.culprits) > 0 {
+		return nil, &CulpritError{Err: ErrInvalidShare, Culprits: slices.Sorted(slices.Values(c.culprits))}
+	}
+	if len(c.shares) != len(c.commitments) {
+		return nil, ErrMissingShare
+	}
+
+	shares := make([][]byte, len(c.commitments))
+	for i, commitment := range c.commitments {
+		shares[i] = c.shares[commitment.Identifier]
+	}
+	return Aggregate(c.domain, c.groupKey, c.message, c.commitments, shares)
+}
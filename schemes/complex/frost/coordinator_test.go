@@ -0,0 +1,153 @@
+package frost_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+)
+
+func TestCoordinator(t *testing.T) {
+	drbg := testdata.New("frost coordinator")
+	groupKey, signers, verifyingShares, err := frost.KeyGen(kgDomain, 5, 3, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("coordinated")
+
+	// commit runs the first round with the given signers.
+	commit := func(t *testing.T, c *frost.Coordinator, group []int) ([]frost.Nonce, []frost.Commitment) {
+		t.Helper()
+
+		nonces := make([]frost.Nonce, len(group))
+		for i, idx := range group {
+			var commitment frost.Commitment
+			nonces[i], commitment = signers[idx].Commit(drbg.Data(64))
+			if err := c.AddCommitment(commitment); err != nil {
+				t.Fatal(err)
+			}
+		}
+		commitments, err := c.Commitments()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nonces, commitments
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		c := frost.NewCoordinator(signDomain, groupKey, verifyingShares, 3, message)
+		group := []int{4, 0, 2}
+		nonces, commitments := commit(t, c, group)
+		if !slices.IsSortedFunc(commitments, func(a, b frost.Commitment) int {
+			return int(a.Identifier) - int(b.Identifier)
+		}) {
+			t.Error("Commitments() are not sorted")
+		}
+
+		for i, idx := range group {
+			share, err := signers[idx].Sign(signDomain, nonces[i], message, commitments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.AddShare(signers[idx].Identifier(), share); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		signature, err := c.Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !frost.Verify(signDomain, groupKey, message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+
+	t.Run("misbehaving signer", func(t *testing.T) {
+		c := frost.NewCoordinator(signDomain, groupKey, verifyingShares, 3, message)
+		group := []int{0, 1, 2}
+		nonces, commitments := commit(t, c, group)
+
+		for i, idx := range group {
+			share, err := signers[idx].Sign(signDomain, nonces[i], message, commitments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if idx == 1 {
+				share[0] ^= 1
+			}
+
+			err = c.AddShare(signers[idx].Identifier(), share)
+			if idx == 1 && !errors.Is(err, frost.ErrInvalidShare) {
+				t.Errorf("AddShare() err = %v, want %v", err, frost.ErrInvalidShare)
+			} else if idx != 1 && err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := c.Signature()
+		var culpritErr *frost.CulpritError
+		if !errors.As(err, &culpritErr) || !errors.Is(err, frost.ErrInvalidShare) {
+			t.Fatalf("Signature() err = %v, want %v", err, frost.ErrInvalidShare)
+		}
+		if got, want := culpritErr.Culprits, []uint16{2}; !slices.Equal(got, want) {
+			t.Errorf("Culprits = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("missing share", func(t *testing.T) {
+		c := frost.NewCoordinator(signDomain, groupKey, verifyingShares, 3, message)
+		nonces, commitments := commit(t, c, []int{0, 1, 2})
+		share, err := signers[0].Sign(signDomain, nonces[0], message, commitments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddShare(1, share); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddShare(1, share); !errors.Is(err, frost.ErrDuplicateIdentifier) {
+			t.Errorf("AddShare() err = %v, want %v", err, frost.ErrDuplicateIdentifier)
+		}
+		if _, err := c.Signature(); !errors.Is(err, frost.ErrMissingShare) {
+			t.Errorf("Signature() err = %v, want %v", err, frost.ErrMissingShare)
+		}
+	})
+
+	t.Run("rounds out of order", func(t *testing.T) {
+		c := frost.NewCoordinator(signDomain, groupKey, verifyingShares, 3, message)
+		if err := c.AddShare(1, make([]byte, frost.ShareSize)); !errors.Is(err, frost.ErrInvalidRound) {
+			t.Errorf("AddShare() err = %v, want %v", err, frost.ErrInvalidRound)
+		}
+		if _, err := c.Signature(); !errors.Is(err, frost.ErrInvalidRound) {
+			t.Errorf("Signature() err = %v, want %v", err, frost.ErrInvalidRound)
+		}
+
+		_, commitment := signers[3].Commit(drbg.Data(64))
+		commit(t, c, []int{0, 1, 2})
+		if err := c.AddCommitment(commitment); !errors.Is(err, frost.ErrInvalidRound) {
+			t.Errorf("AddCommitment() err = %v, want %v", err, frost.ErrInvalidRound)
+		}
+		if err := c.AddShare(4, make([]byte, frost.ShareSize)); !errors.Is(err, frost.ErrMissingSigner) {
+			t.Errorf("AddShare() err = %v, want %v", err, frost.ErrMissingSigner)
+		}
+	})
+
+	t.Run("invalid commitments", func(t *testing.T) {
+		c := frost.NewCoordinator(signDomain, groupKey, verifyingShares, 3, message)
+		_, commitment := signers[0].Commit(drbg.Data(64))
+		if err := c.AddCommitment(commitment); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddCommitment(commitment); !errors.Is(err, frost.ErrDuplicateIdentifier) {
+			t.Errorf("AddCommitment() err = %v, want %v", err, frost.ErrDuplicateIdentifier)
+		}
+		if err := c.AddCommitment(frost.Commitment{Identifier: 6, Hiding: commitment.Hiding, Binding: commitment.Binding}); !errors.Is(err, frost.ErrInvalidCommitment) {
+			t.Errorf("AddCommitment() err = %v, want %v", err, frost.ErrInvalidCommitment)
+		}
+		if _, err := c.Commitments(); !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("Commitments() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+}
@@ -0,0 +1,335 @@
+package frost
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/shamir"
+	"github.com/gtank/ristretto255"
+)
+
+var (
+	// ErrInvalidProof is returned when a DKG participant's proof of knowledge of their secret does not verify.
+	ErrInvalidProof = errors.New("frost: invalid proof of knowledge")
+
+	// ErrInvalidSecretShare is returned when a DKG participant's secret share does not match their commitments.
+	ErrInvalidSecretShare = errors.New("frost: invalid secret share")
+
+	// ErrInvalidRound is returned when a DKG participant's or a coordinator's methods are called out of order.
+	ErrInvalidRound = errors.New("frost: round out of order")
+)
+
+// A CulpritError is returned when messages from specific participants fail verification. In a DKG, participants with
+// invalid proofs of knowledge can be disqualified immediately; participants with invalid secret shares should be
+// accused with a complaint (see ResolveComplaint).
+type CulpritError struct {
+	Err      error    // The reason verification failed, e.g. ErrInvalidProof or ErrInvalidShare.
+	Culprits []uint16 // The identifiers of the participants whose messages failed verification.
+}
+
+func (e *CulpritError) Error() string {
+	return fmt.Sprintf("%v: participants %v", e.Err, e.Culprits)
+}
+
+func (e *CulpritError) Unwrap() error {
+	return e.Err
+}
+
+// A Round1Package is broadcast by each DKG participant to all others in the first round.
+type Round1Package struct {
+	Identifier  uint16
+	Commitments [][]byte // threshold 32-byte canonical element encodings of the polynomial coefficient commitments.
+	Proof       []byte   // 64-byte proof of knowledge of the secret.
+}
+
+// A Round2Package is sent privately by each DKG participant to each other participant in the second round. It contains
+// the receiver's secret share and MUST be sent over a confidential, authenticated channel.
+type Round2Package struct {
+	Sender   uint16
+	Receiver uint16
+	Share    []byte // 32-byte canonical scalar encoding.
+}
+
+// A DKGParticipant holds the state of a single participant in a Pedersen distributed key generation, which produces
+// FROST signers without a trusted dealer: the group's private key is never held by any single party.
+//
+// Each participant:
+//
+//  1. Calls NewDKGParticipant and broadcasts the returned Round1Package.
+//  2. Calls Round2 with all participants' Round1Packages, and sends each returned Round2Package to its receiver.
+//  3. Calls Finalize with the Round2Packages it received, producing its Signer.
+//
+// If Finalize returns a CulpritError with ErrInvalidSecretShare, the participant broadcasts a complaint against each
+// culprit, who responds by publishing the Round2Package it sent (see Reveal); everyone then calls ResolveComplaint to
+// determine which party is at fault.
+type DKGParticipant struct {
+	domain                string
+	identifier            uint16
+	maxSigners, threshold int
+	coeffs                []group.Scalar
+	round1                []Round1Package
+}
+
+// NewDKGParticipant begins a threshold-of-maxSigners distributed key generation for the participant with the given
+// 1-based identifier, returning the participant's state and its Round1Package. rand must contain at least 64 bytes of
+// uniform randomness.
+func NewDKGParticipant(domain string, identifier uint16, maxSigners, threshold int, rand []byte) (*DKGParticipant, Round1Package, error) {
+	if threshold < 2 || maxSigners < threshold || maxSigners > 0xffff || identifier == 0 ||
+		int(identifier) > maxSigners || len(rand) < 64 {
+		return nil, Round1Package{}, ErrInvalidParameters
+	}
+
+	// Derive the polynomial coefficients and proof nonce from the randomness.
+	p := newDKG(domain, maxSigners, threshold)
+	dealer, _ := p.Fork("process", []byte("dealer"), []byte("proof"))
+	dealer.Mix("identifier", binary.BigEndian.AppendUint16(nil, identifier))
+	dealer.Mix("rand", rand)
+	coeffs := shamir.NewPolynomial(dealer, group.Ristretto255, threshold)
+	commitments := make([][]byte, threshold)
+	for i, c := range coeffs {
+		commitments[i] = group.Ristretto255.NewElement().ScalarBaseMult(c).Bytes()
+	}
+	k := group.DeriveRistretto255Scalar(dealer, "proof-nonce")
+
+	// Prove knowledge of the secret a_0: R = [k]G, mu = k + a_0*c.
+	r := ristretto255.NewIdentityElement().ScalarBaseMult(k)
+	c := proofChallenge(domain, maxSigners, threshold, identifier, commitments[0], r.Bytes())
+	mu := ristretto255.NewScalar().Multiply(ristrettoScalar(coeffs[0]), c)
+	mu.Add(mu, k)
+
+	participant := &DKGParticipant{
+		domain:     domain,
+		identifier: identifier,
+		maxSigners: maxSigners,
+		threshold:  threshold,
+		coeffs:     coeffs,
+	}
+	return participant, Round1Package{
+		Identifier:  identifier,
+		Commitments: commitments,
+		Proof:       slices.Concat(r.Bytes(), mu.Bytes()),
+	}, nil
+}
+
+// Round2 verifies every participant's Round1Package, including the participant's own, and returns a Round2Package for
+// each other participant. Returns a CulpritError with ErrInvalidProof identifying any participants whose proofs of
+// knowledge do not verify, or ErrInvalidParameters if the packages are malformed or not from exactly every participant.
+func (p *DKGParticipant) Round2(round1 []Round1Package) ([]Round2Package, error) {
+	if p.round1 != nil {
+		return nil, ErrInvalidRound
+	}
+
+	sorted, err := checkRound1(p.domain, p.maxSigners, p.threshold, round1)
+	if err != nil {
+		return nil, err
+	}
+	p.round1 = sorted
+
+	packages := make([]Round2Package, 0, p.maxSigners-1)
+	for _, pkg := range sorted {
+		if pkg.Identifier != p.identifier {
+			revealed, _ := p.Reveal(pkg.Identifier)
+			packages = append(packages, revealed)
+		}
+	}
+	return packages, nil
+}
+
+// Reveal returns the Round2Package the participant sends to the receiver, which it publishes in response to a complaint
+// by the receiver. Returns ErrInvalidParameters if the receiver is not another participant in the key generation, as
+// evaluating the secret polynomial at any other point (e.g. zero) would reveal information about the participant's
+// secret.
+func (p *DKGParticipant) Reveal(receiver uint16) (Round2Package, error) {
+	if receiver == 0 || int(receiver) > p.maxSigners || receiver == p.identifier {
+		return Round2Package{}, ErrInvalidParameters
+	}
+
+	return Round2Package{
+		Sender:   p.identifier,
+		Receiver: receiver,
+		Share:    shamir.Eval(group.Ristretto255, p.coeffs, receiver).Bytes(),
+	}, nil
+}
+
+// Finalize verifies the Round2Packages sent to the participant by every other participant and returns the
+// participant's Signer and the verifying shares of all participants, where verifyingShares[i] belongs to the
+// participant with identifier i+1. Returns a CulpritError with ErrInvalidSecretShare identifying any participants whose
+// shares do not match their commitments.
+//
+// The participant retains its secret polynomial so it can respond to complaints, and should be discarded once the key
+// generation has completed without any.
+func (p *DKGParticipant) Finalize(round2 []Round2Package) (*Signer, []*ristretto255.Element, error) {
+	if p.round1 == nil {
+		return nil, nil, ErrInvalidRound
+	}
+	if len(round2) != p.maxSigners-1 {
+		return nil, nil, ErrInvalidParameters
+	}
+
+	// Sum the secret shares, starting with the participant's own, checking each against its sender's commitments.
+	signingShare := ristrettoScalar(shamir.Eval(group.Ristretto255, p.coeffs, p.identifier))
+	seen := make([]bool, p.maxSigners+1)
+	seen[p.identifier] = true
+	var culprits []uint16
+	for _, pkg := range round2 {
+		if pkg.Receiver != p.identifier || pkg.Sender == 0 || int(pkg.Sender) > p.maxSigners || seen[pkg.Sender] {
+			return nil, nil, ErrInvalidParameters
+		}
+		seen[pkg.Sender] = true
+
+		share, ok := verifySecretShare(p.round1[pkg.Sender-1], pkg)
+		if !ok {
+			culprits = append(culprits, pkg.Sender)
+			continue
+		}
+		signingShare.Add(signingShare, share)
+	}
+	if len(culprits) > 0 {
+		slices.Sort(culprits)
+		return nil, nil, &CulpritError{Err: ErrInvalidSecretShare, Culprits: culprits}
+	}
+
+	// Calculate the group key and every participant's verifying share from the commitments.
+	groupKey, verifyingShares := groupVerifyingShares(p.round1, p.maxSigners)
+
+	return &Signer{
+		domain:         p.domain,
+		identifier:     p.identifier,
+		signingShare:   signingShare,
+		verifyingShare: verifyingShares[p.identifier-1],
+		groupKey:       groupKey,
+	}, verifyingShares, nil
+}
+
+// ResolveComplaint determines which party is at fault when the accuser complains that the secret share it received
+// from the accused does not match the accused's commitments. The revealed package is the Round2Package published by
+// the accused in response to the complaint (see DKGParticipant.Reveal). Returns the identifier of the accused if the
+// revealed share is invalid, or the identifier of the accuser if it is valid, in which case the complaint was false.
+//
+// The faulty party should be disqualified and the key generation restarted without them.
+func ResolveComplaint(domain string, maxSigners, threshold int, round1 []Round1Package, accuser uint16, revealed Round2Package) (uint16, error) {
+	sorted, err := checkRound1(domain, maxSigners, threshold, round1)
+	if err != nil {
+		return 0, err
+	}
+	if revealed.Receiver != accuser || revealed.Sender == accuser || revealed.Sender == 0 ||
+		int(revealed.Sender) > maxSigners {
+		return 0, ErrInvalidParameters
+	}
+
+	if _, ok := verifySecretShare(sorted[revealed.Sender-1], revealed); !ok {
+		return revealed.Sender, nil
+	}
+	return accuser, nil
+}
+
+// checkRound1 returns the Round1Packages sorted by identifier if there is exactly one well-formed package from each
+// participant and each proof of knowledge verifies.
+func checkRound1(domain string, maxSigners, threshold int, round1 []Round1Package) ([]Round1Package, error) {
+	if len(round1) != maxSigners {
+		return nil, ErrInvalidParameters
+	}
+	sorted := slices.SortedFunc(slices.Values(round1), func(a, b Round1Package) int {
+		return int(a.Identifier) - int(b.Identifier)
+	})
+
+	var culprits []uint16
+	for i, pkg := range sorted {
+		if int(pkg.Identifier) != i+1 || len(pkg.Commitments) != threshold || len(pkg.Proof) != 64 {
+			return nil, ErrInvalidParameters
+		}
+		if !verifyProof(domain, maxSigners, threshold, pkg) {
+			culprits = append(culprits, pkg.Identifier)
+		}
+	}
+	if len(culprits) > 0 {
+		return nil, &CulpritError{Err: ErrInvalidProof, Culprits: culprits}
+	}
+	return sorted, nil
+}
+
+// verifyProof checks a Round1Package's proof of knowledge of its secret: [mu]G == R + [c]C_0.
+func verifyProof(domain string, maxSigners, threshold int, pkg Round1Package) bool {
+	c0, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(pkg.Commitments[0])
+	r, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(pkg.Proof[:32])
+	mu, _ := ristretto255.NewScalar().SetCanonicalBytes(pkg.Proof[32:])
+	if c0 == nil || r == nil || mu == nil {
+		return false
+	}
+	for _, commitment := range pkg.Commitments[1:] {
+		if e, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(commitment); e == nil {
+			return false
+		}
+	}
+
+	c := proofChallenge(domain, maxSigners, threshold, pkg.Identifier, pkg.Commitments[0], pkg.Proof[:32])
+	expected := ristretto255.NewIdentityElement().ScalarMult(c, c0)
+	expected.Add(expected, r)
+	return ristretto255.NewIdentityElement().ScalarBaseMult(mu).Equal(expected) == 1
+}
+
+// verifySecretShare checks a secret share against its sender's commitments: [f(i)]G == Σ [i^k]C_k.
+func verifySecretShare(sender Round1Package, pkg Round2Package) (*ristretto255.Scalar, bool) {
+	share, _ := ristretto255.NewScalar().SetCanonicalBytes(pkg.Share)
+	if share == nil {
+		return nil, false
+	}
+
+	expected := evalCommitments(sender.Commitments, pkg.Receiver)
+	return share, ristretto255.NewIdentityElement().ScalarBaseMult(share).Equal(expected) == 1
+}
+
+// groupVerifyingShares returns the group key Σ C_j0 and each participant's verifying share Σ_j Σ_k [i^k]C_jk.
+func groupVerifyingShares(round1 []Round1Package, maxSigners int) (*ristretto255.Element, []*ristretto255.Element) {
+	groupKey := ristretto255.NewIdentityElement()
+	for _, pkg := range round1 {
+		c0, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(pkg.Commitments[0])
+		groupKey.Add(groupKey, c0)
+	}
+
+	verifyingShares := make([]*ristretto255.Element, maxSigners)
+	for i := range verifyingShares {
+		vs := ristretto255.NewIdentityElement()
+		for _, pkg := range round1 {
+			vs.Add(vs, evalCommitments(pkg.Commitments, uint16(i+1)))
+		}
+		verifyingShares[i] = vs
+	}
+	return groupKey, verifyingShares
+}
+
+// evalCommitments evaluates the committed polynomial Σ [x^k]C_k using Horner's method. The commitments must be valid
+// element encodings.
+func evalCommitments(commitments [][]byte, x uint16) *ristretto255.Element {
+	xScalar := ristrettoScalar(group.Ristretto255.NewScalar().SetUint64(uint64(x)))
+	result := ristretto255.NewIdentityElement()
+	for _, commitment := range slices.Backward(commitments) {
+		c, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(commitment)
+		result.ScalarMult(xScalar, result)
+		result.Add(result, c)
+	}
+	return result
+}
+
+// proofChallenge derives the challenge scalar for a participant's proof of knowledge of their secret.
+func proofChallenge(domain string, maxSigners, threshold int, identifier uint16, c0, r []byte) *ristretto255.Scalar {
+	p := newDKG(domain, maxSigners, threshold)
+	_, proof := p.Fork("process", []byte("dealer"), []byte("proof"))
+	proof.Mix("identifier", binary.BigEndian.AppendUint16(nil, identifier))
+	proof.Mix("secret-commitment", c0)
+	proof.Mix("proof-commitment", r)
+	c := group.DeriveRistretto255Scalar(proof, "challenge")
+	return c
+}
+
+// newDKG returns a protocol with the DKG parameters mixed in.
+func newDKG(domain string, maxSigners, threshold int) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.MixUint64("frost-dkg-max-signers", uint64(maxSigners))
+	p.MixUint64("frost-dkg-threshold", uint64(threshold))
+	return p
+}
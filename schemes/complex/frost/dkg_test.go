@@ -0,0 +1,244 @@
+package frost_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+	"github.com/gtank/ristretto255"
+)
+
+const dkgDomain = "frost-dkg"
+
+// runDKGRound1 starts a DKG for n participants and returns their states and Round1Packages.
+func runDKGRound1(t *testing.T, n, threshold int) ([]*frost.DKGParticipant, []frost.Round1Package) {
+	t.Helper()
+
+	drbg := testdata.New("frost dkg")
+	participants := make([]*frost.DKGParticipant, n)
+	round1 := make([]frost.Round1Package, n)
+	for i := range participants {
+		var err error
+		participants[i], round1[i], err = frost.NewDKGParticipant(dkgDomain, uint16(i+1), n, threshold, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return participants, round1
+}
+
+// runDKGRound2 runs the second round and returns the Round2Packages received by each participant.
+func runDKGRound2(t *testing.T, participants []*frost.DKGParticipant, round1 []frost.Round1Package) [][]frost.Round2Package {
+	t.Helper()
+
+	received := make([][]frost.Round2Package, len(participants))
+	for _, p := range participants {
+		packages, err := p.Round2(round1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkg := range packages {
+			received[pkg.Receiver-1] = append(received[pkg.Receiver-1], pkg)
+		}
+	}
+	return received
+}
+
+func TestDKG(t *testing.T) {
+	participants, round1 := runDKGRound1(t, 5, 3)
+	received := runDKGRound2(t, participants, round1)
+
+	signers := make([]*frost.Signer, len(participants))
+	var verifyingShares []*ristretto255.Element
+	for i, p := range participants {
+		var (
+			vs  []*ristretto255.Element
+			err error
+		)
+		signers[i], vs, err = p.Finalize(received[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyingShares == nil {
+			verifyingShares = vs
+		}
+		for j := range vs {
+			if vs[j].Equal(verifyingShares[j]) != 1 {
+				t.Errorf("participant %d disagrees on verifying share %d", i+1, j+1)
+			}
+		}
+	}
+
+	t.Run("consistent group key", func(t *testing.T) {
+		for i, s := range signers {
+			if s.GroupKey().Equal(signers[0].GroupKey()) != 1 {
+				t.Errorf("signer[%d].GroupKey() does not match", i)
+			}
+			if s.VerifyingShare().Equal(verifyingShares[i]) != 1 {
+				t.Errorf("signer[%d].VerifyingShare() does not match", i)
+			}
+		}
+	})
+
+	t.Run("threshold signature", func(t *testing.T) {
+		drbg := testdata.New("frost dkg sign")
+		message := []byte("signed without a dealer")
+		group := []*frost.Signer{signers[0], signers[2], signers[4]}
+
+		nonces := make([]frost.Nonce, len(group))
+		commitments := make([]frost.Commitment, len(group))
+		for i, s := range group {
+			nonces[i], commitments[i] = s.Commit(drbg.Data(64))
+		}
+		shares := make([][]byte, len(group))
+		for i, s := range group {
+			var err error
+			shares[i], err = s.Sign(signDomain, nonces[i], message, commitments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !frost.VerifyShare(signDomain, verifyingShares[s.Identifier()-1], s.GroupKey(), s.Identifier(), message, commitments, shares[i]) {
+				t.Errorf("VerifyShare(%d) = false, want true", s.Identifier())
+			}
+		}
+
+		signature, err := frost.Aggregate(signDomain, signers[0].GroupKey(), message, commitments, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !frost.Verify(signDomain, signers[0].GroupKey(), message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+
+	t.Run("round out of order", func(t *testing.T) {
+		if _, err := participants[0].Round2(round1); !errors.Is(err, frost.ErrInvalidRound) {
+			t.Errorf("Round2() err = %v, want %v", err, frost.ErrInvalidRound)
+		}
+		p, _, _ := frost.NewDKGParticipant(dkgDomain, 1, 5, 3, make([]byte, 64))
+		if _, _, err := p.Finalize(received[0]); !errors.Is(err, frost.ErrInvalidRound) {
+			t.Errorf("Finalize() err = %v, want %v", err, frost.ErrInvalidRound)
+		}
+	})
+}
+
+func TestNewDKGParticipant(t *testing.T) {
+	for name, args := range map[string]struct {
+		identifier            uint16
+		maxSigners, threshold int
+		randLen               int
+	}{
+		"threshold too low":       {1, 5, 1, 64},
+		"threshold too high":      {1, 2, 3, 64},
+		"zero identifier":         {0, 5, 3, 64},
+		"identifier too high":     {6, 5, 3, 64},
+		"insufficient randomness": {1, 5, 3, 32},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := frost.NewDKGParticipant(dkgDomain, args.identifier, args.maxSigners, args.threshold, make([]byte, args.randLen))
+			if !errors.Is(err, frost.ErrInvalidParameters) {
+				t.Errorf("NewDKGParticipant() err = %v, want %v", err, frost.ErrInvalidParameters)
+			}
+		})
+	}
+}
+
+func TestDKGParticipant_Round2(t *testing.T) {
+	t.Run("invalid proof", func(t *testing.T) {
+		participants, round1 := runDKGRound1(t, 4, 2)
+		round1[2].Proof = slices.Clone(round1[1].Proof)
+
+		_, err := participants[0].Round2(round1)
+		var culpritErr *frost.CulpritError
+		if !errors.As(err, &culpritErr) || !errors.Is(err, frost.ErrInvalidProof) {
+			t.Fatalf("Round2() err = %v, want %v", err, frost.ErrInvalidProof)
+		}
+		if got, want := culpritErr.Culprits, []uint16{3}; !slices.Equal(got, want) {
+			t.Errorf("Culprits = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("missing participant", func(t *testing.T) {
+		participants, round1 := runDKGRound1(t, 4, 2)
+		if _, err := participants[0].Round2(round1[:3]); !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("Round2() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("wrong number of commitments", func(t *testing.T) {
+		participants, round1 := runDKGRound1(t, 4, 2)
+		round1[1].Commitments = round1[1].Commitments[:1]
+		if _, err := participants[0].Round2(round1); !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("Round2() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+}
+
+func TestResolveComplaint(t *testing.T) {
+	participants, round1 := runDKGRound1(t, 4, 2)
+	received := runDKGRound2(t, participants, round1)
+
+	// Participant 2 sends participant 1 a corrupted share.
+	corrupted := ristretto255.NewScalar()
+	for i, pkg := range received[0] {
+		if pkg.Sender == 2 {
+			received[0][i].Share = corrupted.Bytes()
+		}
+	}
+
+	_, _, err := participants[0].Finalize(received[0])
+	var culpritErr *frost.CulpritError
+	if !errors.As(err, &culpritErr) || !errors.Is(err, frost.ErrInvalidSecretShare) {
+		t.Fatalf("Finalize() err = %v, want %v", err, frost.ErrInvalidSecretShare)
+	}
+	if got, want := culpritErr.Culprits, []uint16{2}; !slices.Equal(got, want) {
+		t.Fatalf("Culprits = %v, want %v", got, want)
+	}
+
+	reveal := func(t *testing.T) frost.Round2Package {
+		t.Helper()
+		revealed, err := participants[1].Reveal(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revealed
+	}
+
+	t.Run("accused at fault", func(t *testing.T) {
+		revealed := reveal(t)
+		revealed.Share = corrupted.Bytes()
+		faulty, err := frost.ResolveComplaint(dkgDomain, 4, 2, round1, 1, revealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := faulty, uint16(2); got != want {
+			t.Errorf("ResolveComplaint() = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("false accusation", func(t *testing.T) {
+		faulty, err := frost.ResolveComplaint(dkgDomain, 4, 2, round1, 1, reveal(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := faulty, uint16(1); got != want {
+			t.Errorf("ResolveComplaint() = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("mismatched package", func(t *testing.T) {
+		if _, err := frost.ResolveComplaint(dkgDomain, 4, 2, round1, 3, reveal(t)); !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("ResolveComplaint() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("invalid receiver", func(t *testing.T) {
+		for _, receiver := range []uint16{0, 2, 5} {
+			if _, err := participants[1].Reveal(receiver); !errors.Is(err, frost.ErrInvalidParameters) {
+				t.Errorf("Reveal(%d) err = %v, want %v", receiver, err, frost.ErrInvalidParameters)
+			}
+		}
+	})
+}
@@ -2,6 +2,7 @@ package frost_test
 
 import (
 	"bytes"
+	"errors"
 	"slices"
 	"strings"
 	"testing"
@@ -73,6 +74,13 @@ func TestKeyGen(t *testing.T) {
 			t.Error("KeyGen() err = nil, want error")
 		}
 	})
+
+	t.Run("too many signers", func(t *testing.T) {
+		_, _, _, err := frost.KeyGen(kgDomain, 0x10000, 3, drbg.Data(64))
+		if err == nil {
+			t.Error("KeyGen() err = nil, want error")
+		}
+	})
 }
 
 func TestSignAndVerify(t *testing.T) {
@@ -428,6 +436,82 @@ func TestAggregateErrors(t *testing.T) {
 	})
 }
 
+func TestCommitmentListDigest(t *testing.T) {
+	drbg := testdata.New("frost commitment list digest")
+	message := []byte("this is a test message")
+
+	groupKey, signers, _, err := frost.KeyGen(kgDomain, 5, 3, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonces := make([]frost.Nonce, 3)
+	commitments := make([]frost.Commitment, 3)
+	for i := range 3 {
+		nonces[i], commitments[i] = signers[i].Commit(drbg.Data(64))
+	}
+
+	digest, err := frost.CommitmentListDigest(signDomain, commitments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("order independent", func(t *testing.T) {
+		got, err := frost.CommitmentListDigest(signDomain, []frost.Commitment{commitments[2], commitments[0], commitments[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := digest; !bytes.Equal(got, want) {
+			t.Errorf("CommitmentListDigest() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("equivocation", func(t *testing.T) {
+		_, other := signers[3].Commit(drbg.Data(64))
+		got, err := frost.CommitmentListDigest(signDomain, []frost.Commitment{commitments[0], commitments[1], other})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(got, digest) {
+			t.Error("different commitment lists have the same digest")
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := frost.CommitmentListDigest(signDomain, []frost.Commitment{commitments[0], commitments[0]})
+		if !errors.Is(err, frost.ErrDuplicateIdentifier) {
+			t.Errorf("CommitmentListDigest() err = %v, want %v", err, frost.ErrDuplicateIdentifier)
+		}
+
+		_, err = frost.CommitmentListDigest(signDomain, []frost.Commitment{{Identifier: 1, Hiding: make([]byte, 31)}})
+		if !errors.Is(err, frost.ErrInvalidCommitment) {
+			t.Errorf("CommitmentListDigest() err = %v, want %v", err, frost.ErrInvalidCommitment)
+		}
+	})
+
+	t.Run("sign with digest", func(t *testing.T) {
+		_, err := signers[0].SignWithDigest(signDomain, nonces[0], message, commitments, make([]byte, frost.CommitmentListDigestSize))
+		if !errors.Is(err, frost.ErrCommitmentListMismatch) {
+			t.Errorf("SignWithDigest() err = %v, want %v", err, frost.ErrCommitmentListMismatch)
+		}
+
+		shares := make([][]byte, 3)
+		for i := range 3 {
+			shares[i], err = signers[i].SignWithDigest(signDomain, nonces[i], message, commitments, digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		signature, err := frost.Aggregate(signDomain, groupKey, message, commitments, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !frost.Verify(signDomain, groupKey, message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+}
+
 func TestDeterministicKeyGen(t *testing.T) {
 	seed := testdata.New("frost deterministic").Data(64)
 
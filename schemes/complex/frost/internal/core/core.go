@@ -0,0 +1,303 @@
+// Package core implements the FROST signing protocol over any prime-order group, shared by the frost package and its
+// variants (e.g. bip340): trusted-dealer key generation, hedged nonce commitments, binding factors, signature shares,
+// and their aggregation and verification.
+//
+// A Suite fixes the group and the parts of the protocol which variants need to change: the transcript label for the
+// binding factors, the Schnorr challenge, and whether group keys and group commitments are normalized by negation (as
+// BIP340 does to give them even y coordinates).
+package core
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/shamir"
+)
+
+var (
+	// ErrInvalidParameters is returned for invalid keygen or signing parameters.
+	ErrInvalidParameters = errors.New("frost: invalid parameters")
+
+	// ErrInvalidCommitment is returned when a commitment cannot be decoded.
+	ErrInvalidCommitment = errors.New("frost: invalid commitment")
+
+	// ErrInvalidShare is returned when a signature share cannot be decoded.
+	ErrInvalidShare = errors.New("frost: invalid share")
+
+	// ErrMissingSigner is returned when the signer's identifier is not found in the commitment list.
+	ErrMissingSigner = errors.New("frost: signer not in commitment list")
+
+	// ErrDuplicateIdentifier is returned when duplicate signer identifiers are detected in the commitment list.
+	ErrDuplicateIdentifier = errors.New("frost: duplicate identifier in commitments")
+)
+
+// A Suite is an instantiation of FROST.
+type Suite struct {
+	// Group is the group keys, nonces, and signatures are computed in.
+	Group group.Group
+
+	// BindingLabel is the label the group key is mixed into the binding factor transcript with.
+	BindingLabel string
+
+	// Challenge returns the Schnorr challenge for the encoded group key, the message, and the group commitment R.
+	Challenge func(domain string, groupKey, message []byte, r group.Element) group.Scalar
+
+	// Negate, if not nil, reports whether a group key or group commitment must be negated to be valid for the suite.
+	// KeyGen then negates the secret polynomial, and signers their nonces.
+	Negate func(e group.Element) bool
+}
+
+// A Nonce holds the ephemeral secret nonces for a single signing round.
+type Nonce struct {
+	hiding, binding group.Scalar
+}
+
+// A Commitment is the public counterpart of a Nonce, broadcast to all participants before signing.
+type Commitment struct {
+	Identifier uint16
+	Hiding     []byte // Canonical encoding of the hiding nonce commitment.
+	Binding    []byte // Canonical encoding of the binding nonce commitment.
+}
+
+// KeyGen performs trusted-dealer key generation for a threshold-of-maxSigners scheme, returning the group key, the
+// signing shares, and their verifying shares, where shares[i] belongs to the signer with identifier i+1.
+func (s *Suite) KeyGen(domain string, maxSigners, threshold int, rand []byte) (groupKey group.Element, shares []group.Scalar, verifyingShares []group.Element, err error) {
+	if threshold < 2 || maxSigners < threshold || maxSigners > shamir.MaxShares || len(rand) < 64 {
+		return nil, nil, nil, ErrInvalidParameters
+	}
+
+	// Derive polynomial coefficients deterministically from the seed.
+	p := thyrse.New(domain)
+	keygen, _ := p.Fork("process", []byte("keygen"), []byte("commitment"))
+	keygen.Mix("seed", rand)
+	coeffs := shamir.NewPolynomial(keygen, s.Group, threshold)
+
+	// The group public key is [a_0]G where a_0 is the secret. If it must be negated, negate the polynomial, which
+	// negates the group key and every share.
+	groupKey = s.Group.NewElement().ScalarBaseMult(coeffs[0])
+	if s.Negate != nil && s.Negate(groupKey) {
+		for _, c := range coeffs {
+			c.Negate(c)
+		}
+		groupKey.Negate(groupKey)
+	}
+
+	shares, verifyingShares = shamir.Deal(s.Group, coeffs, maxSigners)
+	return groupKey, shares, verifyingShares, nil
+}
+
+// Commit generates a nonce pair and its public commitment for a signing round. The nonces are derived from the
+// signer's share and the random data, so they are hedged against both nonce reuse and weak randomness.
+func (s *Suite) Commit(domain string, identifier uint16, signingShare group.Scalar, rand []byte) (Nonce, Commitment) {
+	x := thyrse.New(domain)
+
+	_, c := x.Fork("process", []byte("keygen"), []byte("commitment"))
+	c.Mix("signing-share", signingShare.Bytes())
+	c.Mix("rand", rand)
+
+	n := Nonce{
+		hiding:  group.DeriveScalar(c, s.Group, "hiding-nonce"),
+		binding: group.DeriveScalar(c, s.Group, "binding-nonce"),
+	}
+	return n, Commitment{
+		Identifier: identifier,
+		Hiding:     s.Group.NewElement().ScalarBaseMult(n.hiding).Bytes(),
+		Binding:    s.Group.NewElement().ScalarBaseMult(n.binding).Bytes(),
+	}
+}
+
+// Sign produces the signature share of the signer with the given identifier and signing share. The commitments must
+// include the signer's own.
+func (s *Suite) Sign(domain string, groupKey []byte, identifier uint16, signingShare group.Scalar, nonce Nonce, message []byte, commitments []Commitment) ([]byte, error) {
+	sorted := SortCommitments(commitments)
+	if err := validateCommitments(sorted, identifier); err != nil {
+		return nil, err
+	}
+
+	r, err := s.newRound(domain, groupKey, message, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	// z_i = ±(d_i + (e_i * rho_i)) + (lambda_i * s_i * c), negating the nonces if R was negated.
+	g := s.Group
+	z := g.NewScalar().Multiply(nonce.binding, r.bindingFactors[identifier])
+	z.Add(z, nonce.hiding)
+	if r.negated {
+		z.Negate(z)
+	}
+	lambdaSC := g.NewScalar().Multiply(shamir.Lagrange(g, identifier, r.identifiers), signingShare)
+	lambdaSC.Multiply(lambdaSC, r.challenge)
+	z.Add(z, lambdaSC)
+
+	return z.Bytes(), nil
+}
+
+// Aggregate combines the signature shares into the group commitment R and the response z, the two halves of a Schnorr
+// signature. sigShares[i] must correspond to commitments[i] after sorting by identifier.
+func (s *Suite) Aggregate(domain string, groupKey, message []byte, commitments []Commitment, sigShares [][]byte) (group.Element, group.Scalar, error) {
+	sorted := SortCommitments(commitments)
+	if len(sorted) != len(sigShares) {
+		return nil, nil, ErrInvalidParameters
+	}
+
+	r, err := s.newRound(domain, groupKey, message, sorted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sum the signature shares: z = Σ z_i.
+	z := s.Group.NewScalar()
+	for _, share := range sigShares {
+		zi, err := s.Group.NewScalar().SetCanonicalBytes(share)
+		if err != nil {
+			return nil, nil, ErrInvalidShare
+		}
+		z.Add(z, zi)
+	}
+
+	return r.commitment, z, nil
+}
+
+// VerifyShare checks a signature share against the signer's verifying share.
+func (s *Suite) VerifyShare(domain string, verifyingShare group.Element, groupKey []byte, identifier uint16, message []byte, commitments []Commitment, sigShare []byte) bool {
+	sorted := SortCommitments(commitments)
+
+	zi, err := s.Group.NewScalar().SetCanonicalBytes(sigShare)
+	if err != nil {
+		return false
+	}
+
+	r, err := s.newRound(domain, groupKey, message, sorted)
+	if err != nil {
+		return false
+	}
+
+	i := slices.IndexFunc(sorted, func(c Commitment) bool { return c.Identifier == identifier })
+	if i < 0 {
+		return false
+	}
+
+	// Verify: [z_i]G == ±(D_i + [rho_i]E_i) + [c * lambda_i]Y_i
+	g := s.Group
+	commitPoint, _ := s.commitmentShare(sorted[i], r.bindingFactors[identifier])
+	if r.negated {
+		commitPoint.Negate(commitPoint)
+	}
+	cLambda := g.NewScalar().Multiply(r.challenge, shamir.Lagrange(g, identifier, r.identifiers))
+	expected := g.NewElement().Add(commitPoint, g.NewElement().ScalarMult(cLambda, verifyingShare))
+
+	return g.NewElement().ScalarBaseMult(zi).Equal(expected) == 1
+}
+
+// BindingFactors derives a binding factor for each participant from the unified transcript. Because the commitments
+// are sorted by identifier (a total ordering), binding factors are derived independently using the same protocol state
+// via cloning to align with the FROST security proof.
+func (s *Suite) BindingFactors(domain string, groupKey, message []byte, sorted []Commitment) (map[uint16]group.Scalar, error) {
+	p := thyrse.New(domain)
+	p.Mix(s.BindingLabel, groupKey)
+	p.Mix("message", message)
+	for _, c := range sorted {
+		if len(c.Hiding) != s.Group.ElementSize() || len(c.Binding) != s.Group.ElementSize() {
+			return nil, ErrInvalidCommitment
+		}
+		p.Mix("identifier", binary.BigEndian.AppendUint16(nil, c.Identifier))
+		p.Mix("hiding", c.Hiding)
+		p.Mix("binding", c.Binding)
+	}
+
+	factors := make(map[uint16]group.Scalar, len(sorted))
+	for _, c := range sorted {
+		bp := p.Clone()
+		bp.Mix("binding-participant", binary.BigEndian.AppendUint16(nil, c.Identifier))
+		factors[c.Identifier] = group.DeriveScalar(bp, s.Group, "binding-factor")
+	}
+
+	return factors, nil
+}
+
+// SortCommitments returns a copy of the commitments sorted by identifier.
+func SortCommitments(commitments []Commitment) []Commitment {
+	sorted := slices.Clone(commitments)
+	slices.SortFunc(sorted, func(a, b Commitment) int {
+		return cmp.Compare(a.Identifier, b.Identifier)
+	})
+
+	return sorted
+}
+
+// A round holds the values shared by every participant in a signing round.
+type round struct {
+	identifiers    []uint16
+	bindingFactors map[uint16]group.Scalar
+	commitment     group.Element // The group commitment R, after any negation.
+	negated        bool          // Whether R was negated.
+	challenge      group.Scalar
+}
+
+// newRound computes the binding factors, group commitment, and challenge for sorted commitments.
+func (s *Suite) newRound(domain string, groupKey, message []byte, sorted []Commitment) (*round, error) {
+	bindingFactors, err := s.BindingFactors(domain, groupKey, message, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	// R = Σ(D_i + [rho_i]E_i)
+	r := &round{bindingFactors: bindingFactors, commitment: s.Group.NewElement()}
+	for _, c := range sorted {
+		contribution, err := s.commitmentShare(c, bindingFactors[c.Identifier])
+		if err != nil {
+			return nil, err
+		}
+		r.commitment.Add(r.commitment, contribution)
+		r.identifiers = append(r.identifiers, c.Identifier)
+	}
+
+	// Honest commitments only sum to the identity with negligible probability, and it has no encoding in some groups.
+	if r.commitment.Equal(s.Group.NewElement()) == 1 {
+		return nil, ErrInvalidCommitment
+	}
+	if s.Negate != nil && s.Negate(r.commitment) {
+		r.commitment.Negate(r.commitment)
+		r.negated = true
+	}
+
+	r.challenge = s.Challenge(domain, groupKey, message, r.commitment)
+	return r, nil
+}
+
+// commitmentShare decodes a participant's commitment and returns D_i + [rho_i]E_i.
+func (s *Suite) commitmentShare(c Commitment, rho group.Scalar) (group.Element, error) {
+	hiding, err := s.Group.NewElement().SetCanonicalBytes(c.Hiding)
+	if err != nil {
+		return nil, ErrInvalidCommitment
+	}
+	binding, err := s.Group.NewElement().SetCanonicalBytes(c.Binding)
+	if err != nil {
+		return nil, ErrInvalidCommitment
+	}
+
+	return hiding.Add(hiding, binding.ScalarMult(rho, binding)), nil
+}
+
+// validateCommitments checks for duplicate identifiers and verifies the signer is in the list.
+func validateCommitments(sorted []Commitment, signerID uint16) error {
+	found := false
+	for i, c := range sorted {
+		if c.Identifier == signerID {
+			found = true
+		}
+		if i > 0 && sorted[i-1].Identifier == c.Identifier {
+			return ErrDuplicateIdentifier
+		}
+	}
+	if !found {
+		return ErrMissingSigner
+	}
+
+	return nil
+}
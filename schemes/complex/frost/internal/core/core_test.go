@@ -0,0 +1,92 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/testdata"
+)
+
+func TestSuite(t *testing.T) {
+	for _, g := range []group.Group{group.Ristretto255, group.Secp256k1} {
+		t.Run(g.Name(), func(t *testing.T) {
+			s := &Suite{
+				Group:        g,
+				BindingLabel: "test-binding",
+				Challenge: func(domain string, groupKey, message []byte, r group.Element) group.Scalar {
+					p := thyrse.New(domain)
+					p.Mix("key", groupKey)
+					p.Mix("message", message)
+					p.Mix("commitment", r.Bytes())
+					return group.DeriveScalar(p, g, "challenge")
+				},
+				// Negate elements whose encodings are greater than their negations', to exercise negation in both groups.
+				Negate: func(e group.Element) bool {
+					return bytes.Compare(e.Bytes(), g.NewElement().Negate(e).Bytes()) > 0
+				},
+			}
+			drbg := testdata.New("frost core " + g.Name())
+
+			y, shares, verifyingShares, err := s.KeyGen("keygen", 5, 3, drbg.Data(64))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Negate(y) {
+				t.Error("KeyGen() returned a group key which must be negated")
+			}
+			groupKey := y.Bytes()
+
+			// Sign with several subsets, so R is negated in some rounds and not others.
+			for _, ids := range [][]uint16{{1, 2, 3}, {2, 4, 5}, {5, 1, 3}, {1, 2, 3, 4, 5}} {
+				nonces := make([]Nonce, len(ids))
+				commitments := make([]Commitment, len(ids))
+				for i, id := range ids {
+					nonces[i], commitments[i] = s.Commit("keygen", id, shares[id-1], drbg.Data(64))
+				}
+
+				sigShares := make([][]byte, len(ids))
+				for i, id := range ids {
+					sigShares[i], err = s.Sign("sign", groupKey, id, shares[id-1], nonces[i], []byte("message"), commitments)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !s.VerifyShare("sign", verifyingShares[id-1], groupKey, id, []byte("message"), commitments, sigShares[i]) {
+						t.Errorf("VerifyShare(%d) = false, want true", id)
+					}
+				}
+
+				// Aggregate wants the shares in identifier order.
+				sorted := make([][]byte, len(ids))
+				for i, c := range SortCommitments(commitments) {
+					for j, id := range ids {
+						if id == c.Identifier {
+							sorted[i] = sigShares[j]
+						}
+					}
+				}
+				r, z, err := s.Aggregate("sign", groupKey, []byte("message"), commitments, sorted)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// [z]G == R + [c]Y
+				c := s.Challenge("sign", groupKey, []byte("message"), r)
+				if g.NewElement().ScalarBaseMult(z).Equal(g.NewElement().Add(r, g.NewElement().ScalarMult(c, y))) != 1 {
+					t.Errorf("signature by %v does not verify", ids)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		s := &Suite{Group: group.Ristretto255}
+		for _, params := range [][3]int{{5, 1, 64}, {2, 3, 64}, {5, 3, 32}, {0x10000, 3, 64}} {
+			if _, _, _, err := s.KeyGen("keygen", params[0], params[1], make([]byte, params[2])); !errors.Is(err, ErrInvalidParameters) {
+				t.Errorf("KeyGen(%v) err = %v, want ErrInvalidParameters", params, err)
+			}
+		}
+	})
+}
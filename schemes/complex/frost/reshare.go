@@ -0,0 +1,187 @@
+package frost
+
+import (
+	"encoding/binary"
+	"maps"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/shamir"
+	"github.com/gtank/ristretto255"
+)
+
+// ReshareParams describe a resharing of a group's key from its current signers to a new set of signers.
+type ReshareParams struct {
+	// GroupKey is the group's public verifying key, which is unchanged by resharing.
+	GroupKey *ristretto255.Element
+
+	// VerifyingShares are the current signers' verifying shares, where VerifyingShares[i] belongs to the signer with
+	// identifier i+1.
+	VerifyingShares []*ristretto255.Element
+
+	// Dealers are the identifiers of the current signers taking part in the resharing. There must be at least as many
+	// dealers as the current threshold.
+	Dealers []uint16
+
+	// MaxSigners and Threshold are the parameters of the new set of signers, which have identifiers 1 to MaxSigners.
+	MaxSigners, Threshold int
+}
+
+// Reshare deals the signer's share of the group key to a new set of signers, allowing a quorum of the current signers
+// to change the threshold or the set of signers without changing the group key. Each dealer broadcasts the returned
+// Round1Package and sends each Round2Package to its receiver over a confidential, authenticated channel; each new
+// signer then calls FinalizeReshare.
+//
+// Once resharing completes, the current signers MUST discard their Signers, as any threshold of current shares can
+// still produce signatures. rand must contain at least 64 bytes of uniform randomness.
+func (s *Signer) Reshare(params ReshareParams, rand []byte) (Round1Package, []Round2Package, error) {
+	if err := checkReshareParams(params); err != nil || len(rand) < 64 || !slices.Contains(params.Dealers, s.identifier) {
+		return Round1Package{}, nil, ErrInvalidParameters
+	}
+
+	// Deal a random polynomial whose constant term is the signer's Lagrange-weighted share, so the constant terms of
+	// all dealers' polynomials sum to the group's private key.
+	p := newReshare(s.domain, params)
+	p.Mix("signing-share", s.signingShare.Bytes())
+	p.Mix("rand", rand)
+	g := group.Ristretto255
+	coeffs := make([]group.Scalar, params.Threshold)
+	coeffs[0] = g.NewScalar().Multiply(shamir.Lagrange(g, s.identifier, params.Dealers), group.Ristretto255Scalar(s.signingShare))
+	for i := 1; i < len(coeffs); i++ {
+		coeffs[i] = group.DeriveScalar(p, g, "coefficient")
+	}
+
+	commitments := make([][]byte, len(coeffs))
+	for i, c := range coeffs {
+		commitments[i] = g.NewElement().ScalarBaseMult(c).Bytes()
+	}
+	packages := make([]Round2Package, params.MaxSigners)
+	for i := range packages {
+		receiver := uint16(i + 1)
+		packages[i] = Round2Package{Sender: s.identifier, Receiver: receiver, Share: shamir.Eval(g, coeffs, receiver).Bytes()}
+	}
+
+	return Round1Package{Identifier: s.identifier, Commitments: commitments}, packages, nil
+}
+
+// Refresh is like Reshare, but re-randomizes the shares of the current signers without changing the threshold or the
+// set of signers. This limits the window an attacker has to compromise a threshold of shares: shares from before a
+// refresh cannot be combined with shares from after it.
+func (s *Signer) Refresh(verifyingShares []*ristretto255.Element, dealers []uint16, threshold int, rand []byte) (Round1Package, []Round2Package, error) {
+	return s.Reshare(ReshareParams{
+		GroupKey:        s.groupKey,
+		VerifyingShares: verifyingShares,
+		Dealers:         dealers,
+		MaxSigners:      len(verifyingShares),
+		Threshold:       threshold,
+	}, rand)
+}
+
+// FinalizeReshare verifies the packages sent by every dealer to the new signer with the given identifier and returns
+// the new Signer and the verifying shares of all new signers, where verifyingShares[i] belongs to the signer with
+// identifier i+1.
+//
+// Returns a CulpritError with ErrInvalidCommitment identifying any dealers whose polynomials are not consistent with
+// their current verifying shares, or with ErrInvalidSecretShare identifying any dealers whose shares do not match their
+// commitments. Returns ErrInvalidParameters if the packages are malformed or not from exactly every dealer, or if the
+// dealers cannot reconstruct the group key (e.g. because there are fewer of them than the current threshold).
+func FinalizeReshare(domain string, params ReshareParams, identifier uint16, round1 []Round1Package, round2 []Round2Package) (*Signer, []*ristretto255.Element, error) {
+	if err := checkReshareParams(params); err != nil || identifier == 0 || int(identifier) > params.MaxSigners ||
+		len(round1) != len(params.Dealers) || len(round2) != len(params.Dealers) {
+		return nil, nil, ErrInvalidParameters
+	}
+
+	// Check that each dealer's polynomial has their Lagrange-weighted share as its constant term: C_0 = [λ_j]Y_j.
+	byDealer := make(map[uint16]Round1Package, len(round1))
+	var culprits []uint16
+	for _, pkg := range round1 {
+		if !slices.Contains(params.Dealers, pkg.Identifier) || len(pkg.Commitments) != params.Threshold {
+			return nil, nil, ErrInvalidParameters
+		}
+		if _, ok := byDealer[pkg.Identifier]; ok {
+			return nil, nil, ErrInvalidParameters
+		}
+		byDealer[pkg.Identifier] = pkg
+
+		for _, commitment := range pkg.Commitments {
+			if e, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(commitment); e == nil {
+				return nil, nil, ErrInvalidCommitment
+			}
+		}
+		lambda := ristrettoScalar(shamir.Lagrange(group.Ristretto255, pkg.Identifier, params.Dealers))
+		expected := ristretto255.NewIdentityElement().ScalarMult(lambda, params.VerifyingShares[pkg.Identifier-1])
+		if c0, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(pkg.Commitments[0]); c0.Equal(expected) != 1 {
+			culprits = append(culprits, pkg.Identifier)
+		}
+	}
+	if len(culprits) > 0 {
+		slices.Sort(culprits)
+		return nil, nil, &CulpritError{Err: ErrInvalidCommitment, Culprits: culprits}
+	}
+
+	// Sum the shares, checking each against its dealer's commitments.
+	signingShare := ristretto255.NewScalar()
+	seen := make(map[uint16]bool, len(round2))
+	for _, pkg := range round2 {
+		dealer, ok := byDealer[pkg.Sender]
+		if !ok || pkg.Receiver != identifier || seen[pkg.Sender] {
+			return nil, nil, ErrInvalidParameters
+		}
+		seen[pkg.Sender] = true
+
+		share, ok := verifySecretShare(dealer, pkg)
+		if !ok {
+			culprits = append(culprits, pkg.Sender)
+			continue
+		}
+		signingShare.Add(signingShare, share)
+	}
+	if len(culprits) > 0 {
+		slices.Sort(culprits)
+		return nil, nil, &CulpritError{Err: ErrInvalidSecretShare, Culprits: culprits}
+	}
+
+	// Calculate the group key and the new verifying shares from the commitments. If the dealers' constant terms do not
+	// sum to the group key, there were too few dealers.
+	groupKey, verifyingShares := groupVerifyingShares(slices.Collect(maps.Values(byDealer)), params.MaxSigners)
+	if groupKey.Equal(params.GroupKey) != 1 {
+		return nil, nil, ErrInvalidParameters
+	}
+
+	return &Signer{
+		domain:         domain,
+		identifier:     identifier,
+		signingShare:   signingShare,
+		verifyingShare: verifyingShares[identifier-1],
+		groupKey:       groupKey,
+	}, verifyingShares, nil
+}
+
+// checkReshareParams returns ErrInvalidParameters if the resharing parameters are invalid.
+func checkReshareParams(params ReshareParams) error {
+	if params.GroupKey == nil || params.Threshold < 2 || params.MaxSigners < params.Threshold ||
+		params.MaxSigners > 0xffff || len(params.Dealers) < 2 {
+		return ErrInvalidParameters
+	}
+	for i, id := range params.Dealers {
+		if id == 0 || int(id) > len(params.VerifyingShares) || slices.Contains(params.Dealers[:i], id) {
+			return ErrInvalidParameters
+		}
+	}
+	return nil
+}
+
+// newReshare returns a protocol with the resharing parameters mixed in.
+func newReshare(domain string, params ReshareParams) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("frost-reshare", params.GroupKey.Bytes())
+	p.MixUint64("max-signers", uint64(params.MaxSigners))
+	p.MixUint64("threshold", uint64(params.Threshold))
+	dealers := make([]byte, 0, 2*len(params.Dealers))
+	for _, id := range params.Dealers {
+		dealers = binary.BigEndian.AppendUint16(dealers, id)
+	}
+	p.Mix("dealers", dealers)
+	return p
+}
@@ -0,0 +1,225 @@
+package frost_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+	"github.com/gtank/ristretto255"
+)
+
+// thresholdSign produces a FROST signature of the message with the given signers.
+func thresholdSign(t *testing.T, signers []*frost.Signer, message []byte) []byte {
+	t.Helper()
+
+	drbg := testdata.New("frost threshold sign")
+	nonces := make([]frost.Nonce, len(signers))
+	commitments := make([]frost.Commitment, len(signers))
+	for i, s := range signers {
+		nonces[i], commitments[i] = s.Commit(drbg.Data(64))
+	}
+	shares := make([][]byte, len(signers))
+	for i, s := range signers {
+		var err error
+		shares[i], err = s.Sign(signDomain, nonces[i], message, commitments)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	signature, err := frost.Aggregate(signDomain, signers[0].GroupKey(), message, commitments, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+// reshare runs a resharing with the given dealers and returns the new signers and verifying shares.
+func reshare(t *testing.T, dealers []*frost.Signer, params frost.ReshareParams) ([]*frost.Signer, []*ristretto255.Element, error) {
+	t.Helper()
+
+	drbg := testdata.New("frost reshare")
+	round1 := make([]frost.Round1Package, len(dealers))
+	received := make([][]frost.Round2Package, params.MaxSigners)
+	for i, d := range dealers {
+		var (
+			packages []frost.Round2Package
+			err      error
+		)
+		round1[i], packages, err = d.Reshare(params, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkg := range packages {
+			received[pkg.Receiver-1] = append(received[pkg.Receiver-1], pkg)
+		}
+	}
+
+	signers := make([]*frost.Signer, params.MaxSigners)
+	var verifyingShares []*ristretto255.Element
+	for i := range signers {
+		var err error
+		signers[i], verifyingShares, err = frost.FinalizeReshare(kgDomain, params, uint16(i+1), round1, received[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return signers, verifyingShares, nil
+}
+
+func TestReshare(t *testing.T) {
+	drbg := testdata.New("frost reshare keygen")
+	groupKey, old, oldVerifyingShares, err := frost.KeyGen(kgDomain, 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("same key, new shares")
+
+	t.Run("change threshold and signers", func(t *testing.T) {
+		signers, verifyingShares, err := reshare(t, []*frost.Signer{&old[0], &old[2]}, frost.ReshareParams{
+			GroupKey:        groupKey,
+			VerifyingShares: oldVerifyingShares,
+			Dealers:         []uint16{1, 3},
+			MaxSigners:      5,
+			Threshold:       3,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, s := range signers {
+			if s.GroupKey().Equal(groupKey) != 1 {
+				t.Errorf("signer[%d].GroupKey() changed", i)
+			}
+			if s.VerifyingShare().Equal(verifyingShares[i]) != 1 {
+				t.Errorf("signer[%d].VerifyingShare() does not match", i)
+			}
+		}
+
+		signature := thresholdSign(t, []*frost.Signer{signers[1], signers[3], signers[4]}, message)
+		if !frost.Verify(signDomain, groupKey, message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+
+	t.Run("insufficient dealers", func(t *testing.T) {
+		groupKey5, signers5, verifyingShares5, err := frost.KeyGen(kgDomain, 5, 3, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = reshare(t, []*frost.Signer{&signers5[0], &signers5[1]}, frost.ReshareParams{
+			GroupKey:        groupKey5,
+			VerifyingShares: verifyingShares5,
+			Dealers:         []uint16{1, 2},
+			MaxSigners:      3,
+			Threshold:       2,
+		})
+		if !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("FinalizeReshare() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("dealer not in params", func(t *testing.T) {
+		_, _, err := old[1].Reshare(frost.ReshareParams{
+			GroupKey:        groupKey,
+			VerifyingShares: oldVerifyingShares,
+			Dealers:         []uint16{1, 3},
+			MaxSigners:      3,
+			Threshold:       2,
+		}, drbg.Data(64))
+		if !errors.Is(err, frost.ErrInvalidParameters) {
+			t.Errorf("Reshare() err = %v, want %v", err, frost.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("inconsistent dealer", func(t *testing.T) {
+		params := frost.ReshareParams{
+			GroupKey:        groupKey,
+			VerifyingShares: oldVerifyingShares,
+			Dealers:         []uint16{1, 2},
+			MaxSigners:      3,
+			Threshold:       2,
+		}
+		round1 := make([]frost.Round1Package, 2)
+		var received []frost.Round2Package
+		for i, d := range []*frost.Signer{&old[0], &old[1]} {
+			var packages []frost.Round2Package
+			round1[i], packages, err = d.Reshare(params, drbg.Data(64))
+			if err != nil {
+				t.Fatal(err)
+			}
+			received = append(received, packages[0])
+		}
+
+		// Dealer 2 deals a polynomial with a constant term other than its weighted share.
+		round1[1].Commitments[0] = round1[0].Commitments[0]
+
+		_, _, err := frost.FinalizeReshare(kgDomain, params, 1, round1, received)
+		var culpritErr *frost.CulpritError
+		if !errors.As(err, &culpritErr) || !errors.Is(err, frost.ErrInvalidCommitment) {
+			t.Fatalf("FinalizeReshare() err = %v, want %v", err, frost.ErrInvalidCommitment)
+		}
+		if got, want := culpritErr.Culprits, []uint16{2}; !slices.Equal(got, want) {
+			t.Errorf("Culprits = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	drbg := testdata.New("frost refresh keygen")
+	groupKey, old, oldVerifyingShares, err := frost.KeyGen(kgDomain, 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd := testdata.New("frost refresh")
+	round1 := make([]frost.Round1Package, 2)
+	received := make([][]frost.Round2Package, 3)
+	for i, d := range []*frost.Signer{&old[0], &old[1]} {
+		var packages []frost.Round2Package
+		round1[i], packages, err = d.Refresh(oldVerifyingShares, []uint16{1, 2}, 2, rd.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkg := range packages {
+			received[pkg.Receiver-1] = append(received[pkg.Receiver-1], pkg)
+		}
+	}
+
+	params := frost.ReshareParams{
+		GroupKey:        groupKey,
+		VerifyingShares: oldVerifyingShares,
+		Dealers:         []uint16{1, 2},
+		MaxSigners:      3,
+		Threshold:       2,
+	}
+	refreshed := make([]*frost.Signer, 3)
+	for i := range refreshed {
+		refreshed[i], _, err = frost.FinalizeReshare(kgDomain, params, uint16(i+1), round1, received[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(refreshed[i].VerifyingShare().Bytes(), oldVerifyingShares[i].Bytes()) {
+			t.Errorf("signer %d's share was not refreshed", i+1)
+		}
+	}
+
+	message := []byte("refreshed")
+	t.Run("refreshed signers", func(t *testing.T) {
+		signature := thresholdSign(t, []*frost.Signer{refreshed[0], refreshed[2]}, message)
+		if !frost.Verify(signDomain, groupKey, message, signature) {
+			t.Error("Verify() = false, want true")
+		}
+	})
+
+	t.Run("old and refreshed signers", func(t *testing.T) {
+		signature := thresholdSign(t, []*frost.Signer{&old[0], refreshed[2]}, message)
+		if frost.Verify(signDomain, groupKey, message, signature) {
+			t.Error("Verify() = true, want false")
+		}
+	})
+}
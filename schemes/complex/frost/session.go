@@ -0,0 +1,176 @@
+package frost
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/auditlog"
+	"github.com/codahale/thyrse/schemes/complex/frost/internal/core"
+	"github.com/gtank/ristretto255"
+)
+
+// SessionEventLabel is the auditlog event label of a recorded signing session.
+const SessionEventLabel = "frost-session"
+
+// ErrInvalidSession is returned by ParseSession when a session record is malformed.
+var ErrInvalidSession = errors.New("frost: invalid session record")
+
+// A Session records a single signing round: the participants' commitments and binding factors, their signature shares
+// and whether each verified, and the aggregate signature, if any.
+type Session struct {
+	MessageDigest [32]byte      // A digest of the signed message (see SessionRecorder).
+	Participants  []Participant // The participants, sorted by identifier.
+	Signature     []byte        // The aggregate signature, or nil if any share was invalid.
+}
+
+// A Participant records a single signer's contribution to a signing round.
+type Participant struct {
+	Identifier    uint16
+	Hiding        []byte // The hiding nonce commitment.
+	Binding       []byte // The binding nonce commitment.
+	BindingFactor []byte // The participant's binding factor.
+	Share         []byte // The participant's signature share.
+	Valid         bool   // Whether the share verified against the participant's verifying share.
+}
+
+// A SessionRecorder aggregates signature shares like Aggregate, verifying each share and recording the signing round
+// as an event in an auditlog.Log, so the contributors to each threshold signature can later be reconstructed from a
+// signed, append-only record.
+//
+// Messages are recorded as a digest rather than in full: the digest is derived from a Thyrse transcript with the
+// session's domain and the message mixed in under the label "message".
+type SessionRecorder struct {
+	domain          string
+	groupKey        *ristretto255.Element
+	verifyingShares []*ristretto255.Element
+	log             *auditlog.Log
+}
+
+// NewSessionRecorder returns a SessionRecorder for the given group, recording sessions to log. The verifying shares
+// are as returned by KeyGen: verifyingShares[i] belongs to the signer with identifier i+1.
+func NewSessionRecorder(domain string, groupKey *ristretto255.Element, verifyingShares []*ristretto255.Element, log *auditlog.Log) *SessionRecorder {
+	return &SessionRecorder{domain: domain, groupKey: groupKey, verifyingShares: verifyingShares, log: log}
+}
+
+// Aggregate verifies each signature share, records the session, and returns the aggregate signature. If any share is
+// invalid, the session is recorded without a signature and ErrInvalidShare is returned. Malformed commitments are
+// rejected before anything is recorded. Errors writing to the log are returned as-is.
+func (r *SessionRecorder) Aggregate(message []byte, commitments []Commitment, sigShares [][]byte) ([]byte, error) {
+	sorted := core.SortCommitments(commitments)
+	if len(sorted) != len(sigShares) {
+		return nil, ErrInvalidParameters
+	}
+
+	bindingFactors, err := suite.BindingFactors(r.domain, r.groupKey.Bytes(), message, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Session{Participants: make([]Participant, len(sorted))}
+	p := thyrse.New(r.domain)
+	p.Mix("message", message)
+	s.MessageDigest = thyrse.DeriveArray[[32]byte](p, "message-digest")
+
+	valid := true
+	for i, c := range sorted {
+		part := Participant{
+			Identifier:    c.Identifier,
+			Hiding:        c.Hiding,
+			Binding:       c.Binding,
+			BindingFactor: bindingFactors[c.Identifier].Bytes(),
+			Share:         make([]byte, ShareSize),
+		}
+		if len(sigShares[i]) == ShareSize {
+			copy(part.Share, sigShares[i])
+			if id := int(c.Identifier); id >= 1 && id <= len(r.verifyingShares) {
+				part.Valid = VerifyShare(r.domain, r.verifyingShares[id-1], r.groupKey, c.Identifier, message, sorted, sigShares[i])
+			}
+		}
+		valid = valid && part.Valid
+		s.Participants[i] = part
+	}
+
+	if valid {
+		s.Signature, err = Aggregate(r.domain, r.groupKey, message, sorted, sigShares)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.log.Event(SessionEventLabel, appendSession(nil, &s)); err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidShare
+	}
+	return s.Signature, nil
+}
+
+// ParseSession decodes the data of a session event recorded by a SessionRecorder.
+func ParseSession(data []byte) (*Session, error) {
+	if len(data) < 32+2 {
+		return nil, ErrInvalidSession
+	}
+
+	var s Session
+	copy(s.MessageDigest[:], data)
+	n := int(binary.BigEndian.Uint16(data[32:]))
+	data = data[34:]
+
+	if len(data) < n*participantSize+1 {
+		return nil, ErrInvalidSession
+	}
+	s.Participants = make([]Participant, n)
+	for i := range s.Participants {
+		b := data[:participantSize]
+		if b[2+32*4] > 1 {
+			return nil, ErrInvalidSession
+		}
+		s.Participants[i] = Participant{
+			Identifier:    binary.BigEndian.Uint16(b),
+			Hiding:        b[2:34:34],
+			Binding:       b[34:66:66],
+			BindingFactor: b[66:98:98],
+			Share:         b[98:130:130],
+			Valid:         b[130] == 1,
+		}
+		data = data[participantSize:]
+	}
+
+	switch {
+	case data[0] == 0 && len(data) == 1:
+	case data[0] == 1 && len(data) == 1+SignatureSize:
+		s.Signature = data[1:]
+	default:
+		return nil, ErrInvalidSession
+	}
+	return &s, nil
+}
+
+// appendSession appends the encoding of s to b: the message digest, the participant count (2 bytes, big endian), each
+// participant's identifier (2 bytes, big endian), commitments, binding factor, share, and validity (1 byte), and a
+// signature flag (1 byte) followed by the signature, if any.
+func appendSession(b []byte, s *Session) []byte {
+	b = append(b, s.MessageDigest[:]...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s.Participants)))
+	for _, p := range s.Participants {
+		b = binary.BigEndian.AppendUint16(b, p.Identifier)
+		b = append(b, p.Hiding...)
+		b = append(b, p.Binding...)
+		b = append(b, p.BindingFactor...)
+		b = append(b, p.Share...)
+		if p.Valid {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+	}
+	if s.Signature == nil {
+		return append(b, 0)
+	}
+	b = append(b, 1)
+	return append(b, s.Signature...)
+}
+
+const participantSize = 2 + 32 + 32 + 32 + ShareSize + 1
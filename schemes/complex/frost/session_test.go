@@ -0,0 +1,115 @@
+package frost_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/auditlog"
+	"github.com/codahale/thyrse/schemes/complex/frost"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	drbg := testdata.New("frost session recorder")
+	groupKey, signers, verifyingShares, err := frost.KeyGen(kgDomain, 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dLog, qLog := drbg.KeyPair()
+	message := []byte("transfer 100 coins")
+
+	round := func() ([]frost.Commitment, [][]byte) {
+		participants := []frost.Signer{signers[0], signers[2]}
+		nonces := make([]frost.Nonce, len(participants))
+		commitments := make([]frost.Commitment, len(participants))
+		for i, s := range participants {
+			nonces[i], commitments[i] = s.Commit(drbg.Data(64))
+		}
+		shares := make([][]byte, len(participants))
+		for i, s := range participants {
+			shares[i], err = s.Sign(signDomain, nonces[i], message, commitments)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return commitments, shares
+	}
+
+	buf := bytes.NewBuffer(nil)
+	log := auditlog.NewLog("frost-audit", dLog, buf, 1)
+	r := frost.NewSessionRecorder(signDomain, groupKey, verifyingShares, log)
+
+	commitments, shares := round()
+	signature, err := r.Aggregate(message, commitments, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !frost.Verify(signDomain, groupKey, message, signature) {
+		t.Fatal("recorded aggregate signature is invalid")
+	}
+
+	commitments, shares = round()
+	shares[1][0] ^= 1
+	if _, err := r.Aggregate(message, commitments, shares); !errors.Is(err, frost.ErrInvalidShare) {
+		t.Fatalf("Aggregate() err = %v, want = %v", err, frost.ErrInvalidShare)
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := auditlog.Verify("frost-audit", qLog, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(records), 2; got != want {
+		t.Fatalf("len(records) = %d, want = %d", got, want)
+	}
+
+	t.Run("successful session", func(t *testing.T) {
+		s, err := frost.ParseSession(records[0].Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := records[0].Label, frost.SessionEventLabel; got != want {
+			t.Errorf("Label = %q, want = %q", got, want)
+		}
+		if !bytes.Equal(s.Signature, signature) {
+			t.Errorf("Signature = %x, want = %x", s.Signature, signature)
+		}
+		var ids []uint16
+		for _, p := range s.Participants {
+			ids = append(ids, p.Identifier)
+			if !p.Valid {
+				t.Errorf("participant %d: Valid = false, want true", p.Identifier)
+			}
+		}
+		if got, want := ids, []uint16{1, 3}; !slices.Equal(got, want) {
+			t.Errorf("identifiers = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("failed session", func(t *testing.T) {
+		s, err := frost.ParseSession(records[1].Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Signature != nil {
+			t.Errorf("Signature = %x, want nil", s.Signature)
+		}
+		if !s.Participants[0].Valid || s.Participants[1].Valid {
+			t.Errorf("validity = [%v %v], want = [true false]", s.Participants[0].Valid, s.Participants[1].Valid)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		data := records[0].Data
+		for _, bad := range [][]byte{nil, data[:40], data[:len(data)-1], append(bytes.Clone(data), 0)} {
+			if _, err := frost.ParseSession(bad); !errors.Is(err, frost.ErrInvalidSession) {
+				t.Errorf("ParseSession(%d bytes) err = %v, want = %v", len(bad), err, frost.ErrInvalidSession)
+			}
+		}
+	})
+}
@@ -0,0 +1,314 @@
+// Package handshake implements interactive, Noise-style handshakes on top of a thyrse.Protocol.
+//
+// A handshake follows one of a fixed set of patterns from the [Noise Protocol Framework]: NN, NK, XX, and IK. Each
+// message in a pattern is a sequence of tokens, which are processed with a single Thyrse transcript shared by both
+// parties:
+//
+//   - e: the sender generates an ephemeral key pair and sends its public key, which is mixed into the transcript.
+//   - s: the sender seals its static public key and sends the ciphertext.
+//   - ee, es, se, ss: both parties calculate the corresponding Diffie-Hellman shared secret and mix it into the
+//     transcript.
+//
+// Each message ends with a sealed payload, which may be empty. Because every token is processed on the same
+// transcript, each message is bound to the pattern, the prologue, and every previous message. Payloads and static keys
+// are confidential only after a shared secret has been mixed in; a payload is always authenticated by its tag.
+//
+// When the final message has been processed, the transcript is forked into a pair of transport protocols, one for each
+// direction.
+//
+// [Noise Protocol Framework]: https://noiseprotocol.org/noise.html
+package handshake
+
+import (
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/hpke"
+)
+
+// ErrInvalidHandshake is returned when a handshake message is malformed or fails to authenticate.
+var ErrInvalidHandshake = errors.New("thyrse/handshake: invalid handshake")
+
+// A Pattern is a handshake pattern, which determines the messages exchanged and the keys each party must have.
+type Pattern int
+
+const (
+	// NN is an unauthenticated handshake:
+	//
+	//	-> e
+	//	<- e, ee
+	NN Pattern = iota
+
+	// NK authenticates the responder, whose static public key the initiator knows in advance:
+	//
+	//	<- s
+	//	...
+	//	-> e, es
+	//	<- e, ee
+	NK
+
+	// XX mutually authenticates both parties, which exchange static public keys during the handshake:
+	//
+	//	-> e
+	//	<- e, ee, s, es
+	//	-> s, se
+	XX
+
+	// IK mutually authenticates both parties; the initiator knows the responder's static public key in advance and
+	// sends its own in the first message:
+	//
+	//	<- s
+	//	...
+	//	-> e, es, s, ss
+	//	<- e, ee, se
+	IK
+)
+
+// String returns the pattern's name.
+func (p Pattern) String() string {
+	switch p {
+	case NN:
+		return "NN"
+	case NK:
+		return "NK"
+	case XX:
+		return "XX"
+	case IK:
+		return "IK"
+	default:
+		return "invalid"
+	}
+}
+
+// Config is the configuration for one party's side of a handshake.
+type Config struct {
+	// KEM is the Diffie-Hellman group used for all keys.
+	KEM hpke.KEM
+
+	// Pattern is the handshake pattern.
+	Pattern Pattern
+
+	// Initiator is true if the party sends the first message.
+	Initiator bool
+
+	// StaticKey is the party's static private key. It is required by the responder for NK, and by both parties for XX
+	// and IK.
+	StaticKey []byte
+
+	// RemoteStaticKey is the other party's static public key, if known in advance. It is required by the initiator for
+	// NK and IK.
+	RemoteStaticKey []byte
+
+	// Prologue is optional data which both parties must agree on (e.g. a negotiated protocol version).
+	Prologue []byte
+}
+
+// A Handshake is one party's state in an in-progress handshake.
+type Handshake struct {
+	p         *thyrse.Protocol
+	kem       hpke.KEM
+	initiator bool
+	messages  [][]token
+	n         int
+
+	s, e   []byte // local static and ephemeral private keys
+	pkS    []byte // local static public key
+	rs, re []byte // remote static and ephemeral public keys
+}
+
+// New begins a handshake with the given domain separation string and configuration. The seed must be hpke.SeedSize
+// bytes of uniform random data, from which the party's ephemeral key pair is derived.
+//
+// Panics if the configuration lacks a key the pattern requires.
+func New(domain string, cfg Config, seed []byte) (*Handshake, error) {
+	messages, ok := patterns[cfg.Pattern]
+	if !ok {
+		panic("thyrse/handshake: invalid pattern")
+	}
+	needS := cfg.Pattern == XX || cfg.Pattern == IK || (cfg.Pattern == NK && !cfg.Initiator)
+	if needS && cfg.StaticKey == nil {
+		panic("thyrse/handshake: pattern requires a static key")
+	}
+	preRS := cfg.Pattern == NK || cfg.Pattern == IK
+	if preRS && cfg.Initiator && cfg.RemoteStaticKey == nil {
+		panic("thyrse/handshake: pattern requires a remote static key")
+	}
+
+	h := &Handshake{kem: cfg.KEM, initiator: cfg.Initiator, messages: messages, s: cfg.StaticKey}
+	h.e, _ = cfg.KEM.DeriveKeyPair(seed)
+	if cfg.StaticKey != nil {
+		var err error
+		if h.pkS, err = cfg.KEM.PublicKey(cfg.StaticKey); err != nil {
+			return nil, err
+		}
+	}
+
+	h.p = thyrse.New(domain)
+	h.p.MixString("pattern", cfg.Pattern.String())
+	h.p.MixString("kem", cfg.KEM.Name())
+	h.p.Mix("prologue", cfg.Prologue)
+
+	// Mix in the responder's static key, which is known to the initiator in advance.
+	if preRS {
+		if cfg.Initiator {
+			h.rs = cfg.RemoteStaticKey
+			h.p.Mix("s", h.rs)
+		} else {
+			h.p.Mix("s", h.pkS)
+		}
+	}
+
+	return h, nil
+}
+
+// WriteMessage writes the next handshake message with the given payload, appends it to dst, and returns the resulting
+// slice.
+//
+// Panics if it is not the party's turn to send a message, or if the handshake is complete.
+func (h *Handshake) WriteMessage(dst, payload []byte) ([]byte, error) {
+	if h.Complete() || h.isInitiatorTurn() != h.initiator {
+		panic("thyrse/handshake: unexpected WriteMessage")
+	}
+
+	for _, t := range h.messages[h.n] {
+		switch t {
+		case tokenE:
+			pkE, err := h.kem.PublicKey(h.e)
+			if err != nil {
+				return nil, err
+			}
+			h.p.Mix("e", pkE)
+			dst = append(dst, pkE...)
+		case tokenS:
+			dst = h.p.Seal("s", dst, h.pkS)
+		default:
+			if err := h.dh(t); err != nil {
+				return nil, err
+			}
+		}
+	}
+	h.n++
+
+	return h.p.Seal("payload", dst, payload), nil
+}
+
+// ReadMessage reads the next handshake message, appends its payload to dst, and returns the resulting slice. Returns
+// ErrInvalidHandshake if the message is malformed or cannot be authenticated, after which the handshake cannot
+// continue.
+//
+// Panics if it is not the party's turn to receive a message, or if the handshake is complete.
+func (h *Handshake) ReadMessage(dst, msg []byte) ([]byte, error) {
+	if h.Complete() || h.isInitiatorTurn() == h.initiator {
+		panic("thyrse/handshake: unexpected ReadMessage")
+	}
+
+	size := h.kem.PublicKeySize()
+	for _, t := range h.messages[h.n] {
+		switch t {
+		case tokenE:
+			if len(msg) < size {
+				return nil, ErrInvalidHandshake
+			}
+			h.re, msg = msg[:size:size], msg[size:]
+			h.p.Mix("e", h.re)
+		case tokenS:
+			if len(msg) < size+thyrse.TagSize {
+				return nil, ErrInvalidHandshake
+			}
+			rs, err := h.p.Open("s", nil, msg[:size+thyrse.TagSize])
+			if err != nil {
+				return nil, ErrInvalidHandshake
+			}
+			h.rs, msg = rs, msg[size+thyrse.TagSize:]
+		default:
+			if err := h.dh(t); err != nil {
+				return nil, ErrInvalidHandshake
+			}
+		}
+	}
+	h.n++
+
+	payload, err := h.p.Open("payload", dst, msg)
+	if err != nil {
+		return nil, ErrInvalidHandshake
+	}
+	return payload, nil
+}
+
+// Complete returns true if all handshake messages have been written or read.
+func (h *Handshake) Complete() bool {
+	return h.n == len(h.messages)
+}
+
+// RemoteStaticKey returns the other party's static public key, or nil if the pattern does not authenticate it. For
+// patterns in which it is sent during the handshake, it is only available once the corresponding message has been
+// read.
+func (h *Handshake) RemoteStaticKey() []byte {
+	return h.rs
+}
+
+// Transport returns a pair of protocols for sending and receiving messages after the handshake is complete. The
+// initiator's send protocol is the responder's receive protocol, and vice versa. The Handshake MUST NOT be used
+// afterward.
+//
+// Panics if the handshake is not complete.
+func (h *Handshake) Transport() (send, recv *thyrse.Protocol) {
+	if !h.Complete() {
+		panic("thyrse/handshake: handshake not complete")
+	}
+
+	initiator, responder := h.p.Fork("transport", []byte("initiator"), []byte("responder"))
+	if h.initiator {
+		return initiator, responder
+	}
+	return responder, initiator
+}
+
+// dh calculates the shared secret for the given DH token and mixes it into the transcript.
+func (h *Handshake) dh(t token) error {
+	// Tokens name the initiator's key first; swap roles for the responder.
+	var sk, pk []byte
+	switch {
+	case t == tokenEE:
+		sk, pk = h.e, h.re
+	case t == tokenSS:
+		sk, pk = h.s, h.rs
+	case (t == tokenES) == h.initiator:
+		sk, pk = h.e, h.rs
+	default:
+		sk, pk = h.s, h.re
+	}
+
+	ss, err := h.kem.DH(sk, pk)
+	if err != nil {
+		return err
+	}
+	h.p.Mix(t.String(), ss)
+	return nil
+}
+
+func (h *Handshake) isInitiatorTurn() bool {
+	return h.n%2 == 0
+}
+
+type token byte
+
+const (
+	tokenE token = iota
+	tokenS
+	tokenEE
+	tokenES
+	tokenSE
+	tokenSS
+)
+
+func (t token) String() string {
+	return [...]string{"e", "s", "ee", "es", "se", "ss"}[t]
+}
+
+var patterns = map[Pattern][][]token{
+	NN: {{tokenE}, {tokenE, tokenEE}},
+	NK: {{tokenE, tokenES}, {tokenE, tokenEE}},
+	XX: {{tokenE}, {tokenE, tokenEE, tokenS, tokenES}, {tokenS, tokenSE}},
+	IK: {{tokenE, tokenES, tokenS, tokenSS}, {tokenE, tokenEE, tokenSE}},
+}
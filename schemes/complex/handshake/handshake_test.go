@@ -0,0 +1,164 @@
+package handshake_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/handshake"
+	"github.com/codahale/thyrse/schemes/complex/hpke"
+)
+
+func TestHandshake(t *testing.T) {
+	for _, kem := range []hpke.KEM{hpke.Ristretto255, hpke.X25519} {
+		for _, pattern := range []handshake.Pattern{handshake.NN, handshake.NK, handshake.XX, handshake.IK} {
+			t.Run(kem.Name()+"/"+pattern.String(), func(t *testing.T) {
+				drbg := testdata.New("thyrse handshake " + kem.Name() + " " + pattern.String())
+				iS, iP := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+				rS, rP := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+
+				iCfg := handshake.Config{KEM: kem, Pattern: pattern, Initiator: true, Prologue: []byte("v1")}
+				rCfg := handshake.Config{KEM: kem, Pattern: pattern, Prologue: []byte("v1")}
+				if pattern == handshake.XX || pattern == handshake.IK {
+					iCfg.StaticKey = iS
+				}
+				if pattern != handshake.NN {
+					rCfg.StaticKey = rS
+				}
+				if pattern == handshake.NK || pattern == handshake.IK {
+					iCfg.RemoteStaticKey = rP
+				}
+
+				initiator, responder := newPair(t, iCfg, rCfg, drbg)
+				runHandshake(t, initiator, responder)
+
+				if rCfg.StaticKey != nil {
+					if got, want := initiator.RemoteStaticKey(), rP; !bytes.Equal(got, want) {
+						t.Errorf("initiator.RemoteStaticKey() = %x, want = %x", got, want)
+					}
+				}
+				if iCfg.StaticKey != nil {
+					if got, want := responder.RemoteStaticKey(), iP; !bytes.Equal(got, want) {
+						t.Errorf("responder.RemoteStaticKey() = %x, want = %x", got, want)
+					}
+				}
+
+				iSend, iRecv := initiator.Transport()
+				rSend, rRecv := responder.Transport()
+				for _, c := range []struct {
+					name       string
+					send, recv *thyrse.Protocol
+				}{{"initiator to responder", iSend, rRecv}, {"responder to initiator", rSend, iRecv}} {
+					got, err := c.recv.Open("message", nil, c.send.Seal("message", nil, []byte(c.name)))
+					if err != nil {
+						t.Fatalf("%s: Open() err = %v", c.name, err)
+					}
+					if want := []byte(c.name); !bytes.Equal(got, want) {
+						t.Errorf("%s: Open() = %q, want = %q", c.name, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestHandshake_ReadMessage(t *testing.T) {
+	kem := hpke.Ristretto255
+	drbg := testdata.New("thyrse handshake read")
+	_, rP := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+	rS, _ := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+
+	t.Run("mismatched prologue", func(t *testing.T) {
+		initiator, responder := newPair(t,
+			handshake.Config{KEM: kem, Pattern: handshake.NN, Initiator: true, Prologue: []byte("v1")},
+			handshake.Config{KEM: kem, Pattern: handshake.NN, Prologue: []byte("v2")},
+			drbg)
+		msg, err := initiator.WriteMessage(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := responder.ReadMessage(nil, msg); !errors.Is(err, handshake.ErrInvalidHandshake) {
+			t.Errorf("ReadMessage() err = %v, want = %v", err, handshake.ErrInvalidHandshake)
+		}
+	})
+
+	t.Run("wrong responder key", func(t *testing.T) {
+		initiator, responder := newPair(t,
+			handshake.Config{KEM: kem, Pattern: handshake.NK, Initiator: true, RemoteStaticKey: rP},
+			handshake.Config{KEM: kem, Pattern: handshake.NK, StaticKey: rS},
+			drbg)
+		msg, err := initiator.WriteMessage(nil, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := responder.ReadMessage(nil, msg); !errors.Is(err, handshake.ErrInvalidHandshake) {
+			t.Errorf("ReadMessage() err = %v, want = %v", err, handshake.ErrInvalidHandshake)
+		}
+	})
+
+	t.Run("modified message", func(t *testing.T) {
+		initiator, responder := newPair(t,
+			handshake.Config{KEM: kem, Pattern: handshake.NN, Initiator: true},
+			handshake.Config{KEM: kem, Pattern: handshake.NN},
+			drbg)
+		msg, err := initiator.WriteMessage(nil, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg[len(msg)-1] ^= 1
+		if _, err := responder.ReadMessage(nil, msg); !errors.Is(err, handshake.ErrInvalidHandshake) {
+			t.Errorf("ReadMessage() err = %v, want = %v", err, handshake.ErrInvalidHandshake)
+		}
+	})
+
+	t.Run("short message", func(t *testing.T) {
+		_, responder := newPair(t,
+			handshake.Config{KEM: kem, Pattern: handshake.NN, Initiator: true},
+			handshake.Config{KEM: kem, Pattern: handshake.NN},
+			drbg)
+		if _, err := responder.ReadMessage(nil, make([]byte, 10)); !errors.Is(err, handshake.ErrInvalidHandshake) {
+			t.Errorf("ReadMessage() err = %v, want = %v", err, handshake.ErrInvalidHandshake)
+		}
+	})
+}
+
+func newPair(t *testing.T, iCfg, rCfg handshake.Config, drbg *testdata.DRBG) (initiator, responder *handshake.Handshake) {
+	t.Helper()
+
+	initiator, err := handshake.New("handshake", iCfg, drbg.Data(hpke.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err = handshake.New("handshake", rCfg, drbg.Data(hpke.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return initiator, responder
+}
+
+func runHandshake(t *testing.T, initiator, responder *handshake.Handshake) {
+	t.Helper()
+
+	sender, receiver := initiator, responder
+	for i := 0; !initiator.Complete(); i++ {
+		payload := []byte{byte(i)}
+		msg, err := sender.WriteMessage(nil, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := receiver.ReadMessage(nil, msg)
+		if err != nil {
+			t.Fatalf("message %d: ReadMessage() err = %v", i, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("message %d: payload = %x, want = %x", i, got, payload)
+		}
+		sender, receiver = receiver, sender
+	}
+
+	if !responder.Complete() {
+		t.Error("responder.Complete() = false, want true")
+	}
+}
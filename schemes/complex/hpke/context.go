@@ -0,0 +1,197 @@
+package hpke
+
+import (
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/basic/hybrid"
+	"github.com/gtank/ristretto255"
+)
+
+// SeedSize is the size, in bytes, of the uniform random seed from which a KEM derives a key pair.
+const SeedSize = hybrid.SeedSize
+
+// ErrInvalidKey is returned when a public or private key cannot be decoded. It is the same error as
+// hybrid.ErrInvalidKey.
+var ErrInvalidKey = hybrid.ErrInvalidKey
+
+// A KEM is a Diffie-Hellman group used to encapsulate keys for a Sender and Receiver. Keys are passed as their
+// canonical byte encodings.
+//
+// Any KEM can also be used with NewKEMSender and NewKEMReceiver by wrapping it with hybrid.DHKEM.
+type KEM = hybrid.DH
+
+var (
+	// Ristretto255 is a KEM over the Ristretto255 group, compatible with the keys used by Seal and Open.
+	Ristretto255 KEM = ristrettoKEM{}
+
+	// X25519 is a KEM over X25519 (RFC 7748).
+	X25519 = hybrid.X25519DH
+)
+
+// A Sender encrypts a sequence of messages to a single receiver under one encapsulated key.
+//
+// Messages are sealed with a single Thyrse transcript, so each message's ciphertext depends on every previous message.
+// The receiver must open messages in the order they were sealed; messages which are lost, reordered, or modified
+// cause all subsequent messages to fail to open.
+type Sender struct {
+	p *thyrse.Protocol
+}
+
+// NewSender creates a Sender for the owner of the public key pkR, returning it along with the encapsulated key, which
+// the receiver passes to NewReceiver. The seed must be SeedSize bytes of uniform random data, from which an ephemeral
+// key pair is derived.
+//
+// If skS is non-nil, the sender is authenticated with the static private key skS, and the receiver must pass the
+// corresponding public key to NewReceiver (auth mode). Otherwise, the sender is anonymous (base mode).
+func NewSender(domain string, kem KEM, pkR, skS, seed []byte) (*Sender, []byte, error) {
+	skE, enc := kem.DeriveKeyPair(seed)
+	ssE, err := kem.DH(skE, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pkS, ssS []byte
+	if skS != nil {
+		if pkS, err = kem.PublicKey(skS); err != nil {
+			return nil, nil, err
+		}
+		if ssS, err = kem.DH(skS, pkR); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &Sender{p: keySchedule(domain, kem, pkS, pkR, enc, ssE, ssS)}, enc, nil
+}
+
+// Seal encrypts the plaintext, appends the ciphertext to dst, and returns the resulting slice. The ciphertext is
+// thyrse.TagSize bytes longer than the plaintext.
+func (s *Sender) Seal(dst, plaintext []byte) []byte {
+	return s.p.Seal("message", dst, plaintext)
+}
+
+// A Receiver decrypts a sequence of messages encrypted by a Sender. See Sender for ordering requirements.
+type Receiver struct {
+	p *thyrse.Protocol
+}
+
+// NewReceiver creates a Receiver with the private key skR for the encapsulated key enc returned by NewSender. If pkS
+// is non-nil, the sender must have authenticated with the corresponding private key (auth mode).
+//
+// Returns thyrse.ErrInvalidCiphertext if the encapsulated key is invalid.
+func NewReceiver(domain string, kem KEM, skR, pkS, enc []byte) (*Receiver, error) {
+	pkR, err := kem.PublicKey(skR)
+	if err != nil {
+		return nil, err
+	}
+	ssE, err := kem.DH(skR, enc)
+	if err != nil {
+		return nil, thyrse.ErrInvalidCiphertext
+	}
+
+	var ssS []byte
+	if pkS != nil {
+		if ssS, err = kem.DH(skR, pkS); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Receiver{p: keySchedule(domain, kem, pkS, pkR, enc, ssE, ssS)}, nil
+}
+
+// Open decrypts and authenticates the next message, appends the plaintext to dst, and returns the resulting slice.
+// Returns thyrse.ErrInvalidCiphertext if the message is invalid, after which the Receiver cannot open further messages.
+func (r *Receiver) Open(dst, ciphertext []byte) ([]byte, error) {
+	return r.p.Open("message", dst, ciphertext)
+}
+
+// NewKEMSender creates a Sender in base mode for the owner of the public key pkR, encapsulating a shared secret with
+// the given hybrid.KEM (e.g. hybrid.MLKEM768X25519, for confidentiality against quantum adversaries). It returns the
+// Sender along with the encapsulated key, which the receiver passes to NewKEMReceiver. The KEM draws its own
+// randomness.
+//
+// KEMs provide no equivalent of the static Diffie-Hellman exchange which authenticates the sender in auth mode, so
+// senders created with NewKEMSender are anonymous.
+func NewKEMSender(domain string, kem hybrid.KEM, pkR []byte) (*Sender, []byte, error) {
+	p := kemKeySchedule(domain, kem, pkR)
+	enc, err := kem.Encapsulate(p, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Sender{p: p}, enc, nil
+}
+
+// NewKEMReceiver creates a Receiver with the private key skR for the encapsulated key enc returned by NewKEMSender.
+//
+// Returns thyrse.ErrInvalidCiphertext if the encapsulated key is invalid.
+func NewKEMReceiver(domain string, kem hybrid.KEM, skR, enc []byte) (*Receiver, error) {
+	pkR, err := kem.PublicKey(skR)
+	if err != nil {
+		return nil, err
+	}
+	p := kemKeySchedule(domain, kem, pkR)
+	if err := kem.Decapsulate(p, skR, enc); err != nil {
+		return nil, err
+	}
+	return &Receiver{p: p}, nil
+}
+
+func kemKeySchedule(domain string, kem hybrid.KEM, pkR []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.MixString("kem", kem.Name())
+	p.MixBool("auth", false)
+	p.Mix("receiver", pkR)
+	return p
+}
+
+func keySchedule(domain string, kem KEM, pkS, pkR, enc, ssE, ssS []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.MixString("kem", kem.Name())
+	p.MixBool("auth", pkS != nil)
+	if pkS != nil {
+		p.Mix("sender", pkS)
+	}
+	p.Mix("receiver", pkR)
+	p.Mix("ephemeral", enc)
+	p.Mix("ephemeral ecdh", ssE)
+	if pkS != nil {
+		p.Mix("static ecdh", ssS)
+	}
+	return p
+}
+
+type ristrettoKEM struct{}
+
+func (ristrettoKEM) Name() string {
+	return "ristretto255"
+}
+
+func (ristrettoKEM) PublicKeySize() int {
+	return 32
+}
+
+func (ristrettoKEM) DeriveKeyPair(seed []byte) (sk, pk []byte) {
+	d, err := ristretto255.NewScalar().SetUniformBytes(seed)
+	if err != nil {
+		panic(err)
+	}
+	return d.Bytes(), ristretto255.NewIdentityElement().ScalarBaseMult(d).Bytes()
+}
+
+func (ristrettoKEM) PublicKey(sk []byte) ([]byte, error) {
+	d, err := ristretto255.NewScalar().SetCanonicalBytes(sk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return ristretto255.NewIdentityElement().ScalarBaseMult(d).Bytes(), nil
+}
+
+func (ristrettoKEM) DH(sk, pk []byte) ([]byte, error) {
+	d, err := ristretto255.NewScalar().SetCanonicalBytes(sk)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	q, err := ristretto255.NewIdentityElement().SetCanonicalBytes(pk)
+	if err != nil || q.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, ErrInvalidKey
+	}
+	return ristretto255.NewIdentityElement().ScalarMult(d, q).Bytes(), nil
+}
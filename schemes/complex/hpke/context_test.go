@@ -0,0 +1,171 @@
+package hpke_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/hybrid"
+	"github.com/codahale/thyrse/schemes/complex/hpke"
+)
+
+func TestSender(t *testing.T) {
+	for _, kem := range []hpke.KEM{hpke.Ristretto255, hpke.X25519} {
+		t.Run(kem.Name(), func(t *testing.T) {
+			drbg := testdata.New("thyrse hpke context " + kem.Name())
+			skR, pkR := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+			skS, pkS := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+			_, pkX := kem.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+			messages := [][]byte{[]byte("one"), []byte("two"), nil, []byte("four")}
+
+			for _, mode := range []struct {
+				name     string
+				skS, pkS []byte
+			}{{"base", nil, nil}, {"auth", skS, pkS}} {
+				t.Run(mode.name, func(t *testing.T) {
+					s, enc, err := hpke.NewSender("hpke", kem, pkR, mode.skS, drbg.Data(hpke.SeedSize))
+					if err != nil {
+						t.Fatal(err)
+					}
+					var ciphertexts [][]byte
+					for _, m := range messages {
+						ciphertexts = append(ciphertexts, s.Seal(nil, m))
+					}
+
+					r, err := hpke.NewReceiver("hpke", kem, skR, mode.pkS, enc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for i, ct := range ciphertexts {
+						got, err := r.Open(nil, ct)
+						if err != nil {
+							t.Fatalf("Open(%d) err = %v", i, err)
+						}
+						if want := messages[i]; !bytes.Equal(got, want) {
+							t.Errorf("Open(%d) = %q, want = %q", i, got, want)
+						}
+					}
+
+					t.Run("out of order", func(t *testing.T) {
+						r, err := hpke.NewReceiver("hpke", kem, skR, mode.pkS, enc)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if _, err := r.Open(nil, ciphertexts[1]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+							t.Errorf("Open() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+						}
+					})
+
+					t.Run("wrong sender", func(t *testing.T) {
+						r, err := hpke.NewReceiver("hpke", kem, skR, pkX, enc)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if _, err := r.Open(nil, ciphertexts[0]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+							t.Errorf("Open() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+						}
+					})
+				})
+			}
+
+			t.Run("invalid encapsulated key", func(t *testing.T) {
+				if _, err := hpke.NewReceiver("hpke", kem, skR, nil, make([]byte, 7)); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+					t.Errorf("NewReceiver() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+				}
+			})
+
+			t.Run("invalid public key", func(t *testing.T) {
+				if _, _, err := hpke.NewSender("hpke", kem, make([]byte, 7), nil, drbg.Data(hpke.SeedSize)); !errors.Is(err, hpke.ErrInvalidKey) {
+					t.Errorf("NewSender() err = %v, want = %v", err, hpke.ErrInvalidKey)
+				}
+			})
+		})
+	}
+
+	t.Run("ristretto255 interoperates with Seal keys", func(t *testing.T) {
+		drbg := testdata.New("thyrse hpke context interop")
+		dR, qR := drbg.KeyPair()
+
+		s, enc, err := hpke.NewSender("hpke", hpke.Ristretto255, qR.Bytes(), nil, drbg.Data(hpke.SeedSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := hpke.NewReceiver("hpke", hpke.Ristretto255, dR.Bytes(), nil, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Open(nil, s.Seal(nil, []byte("hello"))); err != nil {
+			t.Errorf("Open() err = %v", err)
+		}
+	})
+}
+
+func TestKEMSender(t *testing.T) {
+	for _, kem := range []hybrid.KEM{hybrid.X25519, hybrid.MLKEM768X25519, hybrid.DHKEM(hpke.Ristretto255)} {
+		t.Run(kem.Name(), func(t *testing.T) {
+			drbg := testdata.New("thyrse hpke kem context " + kem.Name())
+			skR, pkR := kem.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+			skX, _ := kem.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+
+			s, enc, err := hpke.NewKEMSender("hpke", kem, pkR)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ciphertext := s.Seal(nil, []byte("hello"))
+
+			t.Run("round trip", func(t *testing.T) {
+				r, err := hpke.NewKEMReceiver("hpke", kem, skR, enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := r.Open(nil, ciphertext)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := []byte("hello"); !bytes.Equal(got, want) {
+					t.Errorf("Open() = %q, want = %q", got, want)
+				}
+			})
+
+			t.Run("wrong receiver", func(t *testing.T) {
+				r, err := hpke.NewKEMReceiver("hpke", kem, skX, enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := r.Open(nil, ciphertext); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+					t.Errorf("Open() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+				}
+			})
+
+			t.Run("invalid encapsulated key", func(t *testing.T) {
+				if _, err := hpke.NewKEMReceiver("hpke", kem, skR, enc[:len(enc)-1]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+					t.Errorf("NewKEMReceiver() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+				}
+			})
+
+			t.Run("invalid public key", func(t *testing.T) {
+				if _, _, err := hpke.NewKEMSender("hpke", kem, pkR[:len(pkR)-1]); !errors.Is(err, hpke.ErrInvalidKey) {
+					t.Errorf("NewKEMSender() err = %v, want = %v", err, hpke.ErrInvalidKey)
+				}
+			})
+		})
+	}
+
+	t.Run("distinct from DH key schedule", func(t *testing.T) {
+		drbg := testdata.New("thyrse hpke kem context dh")
+		skR, pkR := hpke.X25519.DeriveKeyPair(drbg.Data(hpke.SeedSize))
+		s, enc, err := hpke.NewKEMSender("hpke", hybrid.DHKEM(hpke.X25519), pkR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := hpke.NewReceiver("hpke", hpke.X25519, skR, nil, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Open(nil, s.Seal(nil, []byte("hello"))); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open() err = %v, want = %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+}
@@ -48,7 +48,7 @@ func Seal(domain string, qR *ristretto255.Element, dS *ristretto255.Scalar, rand
 // Open decrypts the ciphertext produced by Seal.
 func Open(domain string, dR *ristretto255.Scalar, qS *ristretto255.Element, ciphertext []byte) ([]byte, error) {
 	if len(ciphertext) < Overhead {
-		return nil, thyrse.ErrInvalidCiphertext
+		return nil, thyrse.ErrTruncated
 	}
 
 	qE, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(ciphertext[:32])
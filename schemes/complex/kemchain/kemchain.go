@@ -0,0 +1,155 @@
+// Package kemchain implements a one-directional ratcheted KEM scheme, in which a sender encrypts a sequence of messages
+// (e.g. log entries or telemetry) to a receiver's static public key while the receiver is offline.
+//
+// The sender and receiver share a single chain: a protocol bound to the domain, the KEM, and the receiver's public key.
+// The first message of each epoch carries a KEM ciphertext, and the sender mixes a fresh shared secret encapsulated to
+// the receiver into the chain before sealing it. Every message is sealed with [thyrse.Protocol.Seal] and the chain is
+// then ratcheted with [thyrse.Protocol.Ratchet], so compromising the sender's state reveals no earlier messages, and
+// reveals later messages only until the start of the next epoch. Unlike a double ratchet, the receiver never replies;
+// only its static private key, which can decrypt every message, is needed to follow the chain.
+//
+// Messages MUST be opened in the order they were sealed. The chain binds every message to all of those before it, so a
+// receiver detects dropped, reordered, or truncated messages as a failure to open.
+package kemchain
+
+import (
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/basic/hybrid"
+)
+
+// DefaultEpochMessages is the number of messages in each epoch unless WithEpochMessages is given.
+const DefaultEpochMessages = 1024
+
+// Overhead is the size, in bytes, that a message adds to its plaintext, not counting the KEM ciphertext at the start of
+// each epoch.
+const Overhead = 1 + thyrse.TagSize
+
+// ErrInvalidMessage is returned when a message is malformed.
+var ErrInvalidMessage = errors.New("thyrse/kemchain: invalid message")
+
+const (
+	chainMessage byte = iota
+	epochMessage
+)
+
+// A Sender seals messages to a receiver's public key.
+type Sender struct {
+	kem   hybrid.KEM
+	pk    []byte
+	cfg   config
+	chain *thyrse.Protocol
+	n     uint64
+}
+
+// NewSender returns a Sender which seals messages in the given domain to the receiver's public key. Returns
+// hybrid.ErrInvalidKey if the public key is the wrong size.
+func NewSender(domain string, kem hybrid.KEM, pk []byte, opts ...Option) (*Sender, error) {
+	if len(pk) != kem.PublicKeySize() {
+		return nil, hybrid.ErrInvalidKey
+	}
+
+	return &Sender{kem: kem, pk: pk, cfg: newConfig(opts), chain: newChain(domain, kem, pk)}, nil
+}
+
+// Seal appends the next message in the chain, containing the sealed plaintext, to dst and returns the resulting slice.
+// If the message starts a new epoch, it also contains a KEM ciphertext. Returns hybrid.ErrInvalidKey if encapsulation
+// to the receiver's public key fails.
+func (s *Sender) Seal(dst, plaintext []byte) ([]byte, error) {
+	if s.n%s.cfg.epochMessages == 0 {
+		ct, err := s.kem.Encapsulate(s.chain, s.pk)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, epochMessage)
+		dst = append(dst, ct...)
+	} else {
+		dst = append(dst, chainMessage)
+	}
+	s.n++
+
+	dst = s.chain.Seal("message", dst, plaintext)
+	s.chain.Ratchet("message")
+	return dst, nil
+}
+
+// A Receiver opens the messages sealed by a Sender.
+type Receiver struct {
+	kem   hybrid.KEM
+	sk    []byte
+	chain *thyrse.Protocol
+	keyed bool
+	err   error
+}
+
+// NewReceiver returns a Receiver which opens messages in the given domain with the receiver's private key. Returns
+// hybrid.ErrInvalidKey if the private key is invalid.
+func NewReceiver(domain string, kem hybrid.KEM, sk []byte) (*Receiver, error) {
+	pk, err := kem.PublicKey(sk)
+	if err != nil {
+		return nil, hybrid.ErrInvalidKey
+	}
+
+	return &Receiver{kem: kem, sk: sk, chain: newChain(domain, kem, pk)}, nil
+}
+
+// Open opens the next message in the chain, appends its plaintext to dst, and returns the resulting slice.
+//
+// Returns ErrInvalidMessage if the message is malformed, or thyrse.ErrInvalidCiphertext (or a more specific error
+// wrapping it) if it cannot be opened. After any error, the Receiver's chain has diverged from the Sender's, and
+// subsequent calls return thyrse.ErrDesynchronized.
+func (r *Receiver) Open(dst, message []byte) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	dst, err := r.open(dst, message)
+	if err != nil {
+		r.err = thyrse.ErrDesynchronized
+		return nil, err
+	}
+	return dst, nil
+}
+
+func (r *Receiver) open(dst, message []byte) ([]byte, error) {
+	if len(message) < Overhead {
+		return nil, ErrInvalidMessage
+	}
+
+	switch kind, rest := message[0], message[1:]; kind {
+	case chainMessage:
+		// The initial chain is public, so the first message must start an epoch.
+		if !r.keyed {
+			return nil, ErrInvalidMessage
+		}
+		message = rest
+	case epochMessage:
+		size := r.kem.CiphertextSize()
+		if len(rest) < size+thyrse.TagSize {
+			return nil, ErrInvalidMessage
+		}
+		if err := r.kem.Decapsulate(r.chain, r.sk, rest[:size]); err != nil {
+			return nil, err
+		}
+		message = rest[size:]
+		r.keyed = true
+	default:
+		return nil, ErrInvalidMessage
+	}
+
+	dst, err := r.chain.Open("message", dst, message)
+	if err != nil {
+		return nil, err
+	}
+	r.chain.Ratchet("message")
+	return dst, nil
+}
+
+// newChain returns the initial state of the chain for the domain, KEM, and receiver's public key.
+func newChain(domain string, kem hybrid.KEM, pk []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.MixString("kem", kem.Name())
+	p.Mix("receiver", pk)
+	return p
+}
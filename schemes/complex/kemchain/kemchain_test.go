@@ -0,0 +1,147 @@
+package kemchain_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/basic/hybrid"
+	"github.com/codahale/thyrse/schemes/complex/kemchain"
+)
+
+func newPair(t *testing.T, opts ...kemchain.Option) (*kemchain.Sender, *kemchain.Receiver) {
+	t.Helper()
+
+	drbg := testdata.New("thyrse kemchain")
+	sk, pk := hybrid.X25519.DeriveKeyPair(drbg.Data(hybrid.SeedSize))
+	s, err := kemchain.NewSender("kemchain", hybrid.X25519, pk, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := kemchain.NewReceiver("kemchain", hybrid.X25519, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, r
+}
+
+// seal seals n messages and returns them.
+func seal(t *testing.T, s *kemchain.Sender, n int) [][]byte {
+	t.Helper()
+
+	messages := make([][]byte, n)
+	for i := range messages {
+		m, err := s.Seal(nil, fmt.Appendf(nil, "message %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages[i] = m
+	}
+	return messages
+}
+
+func TestKEMChain(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		s, r := newPair(t, kemchain.WithEpochMessages(3))
+		for i, m := range seal(t, s, 10) {
+			if got, want := len(m)-len(fmt.Sprintf("message %d", i)), kemchain.Overhead; i%3 == 0 {
+				want += hybrid.X25519.CiphertextSize()
+				if got != want {
+					t.Errorf("overhead of message %d = %d, want = %d", i, got, want)
+				}
+			} else if got != want {
+				t.Errorf("overhead of message %d = %d, want = %d", i, got, want)
+			}
+
+			plaintext, err := r.Open(nil, m)
+			if err != nil {
+				t.Fatalf("Open(message %d) err = %v", i, err)
+			}
+			if got, want := string(plaintext), fmt.Sprintf("message %d", i); got != want {
+				t.Errorf("Open(message %d) = %q, want = %q", i, got, want)
+			}
+		}
+	})
+
+	t.Run("distinct messages", func(t *testing.T) {
+		s, _ := newPair(t)
+		a, err := s.Seal(nil, []byte("same"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := s.Seal(nil, []byte("same"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := len("same") + thyrse.TagSize
+		if bytes.Equal(a[len(a)-n:], b[len(b)-n:]) {
+			t.Error("identical plaintexts sealed identically")
+		}
+	})
+
+	t.Run("dropped message", func(t *testing.T) {
+		s, r := newPair(t)
+		messages := seal(t, s, 3)
+		if _, err := r.Open(nil, messages[0]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Open(nil, messages[2]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open(skipped) err = %v, want = ErrInvalidCiphertext", err)
+		}
+		if _, err := r.Open(nil, messages[1]); !errors.Is(err, thyrse.ErrDesynchronized) {
+			t.Errorf("Open(after failure) err = %v, want = ErrDesynchronized", err)
+		}
+	})
+
+	t.Run("modified message", func(t *testing.T) {
+		s, r := newPair(t)
+		m := seal(t, s, 1)[0]
+		m[len(m)-1] ^= 1
+		if _, err := r.Open(nil, m); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open(modified) err = %v, want = ErrInvalidCiphertext", err)
+		}
+	})
+
+	t.Run("unkeyed chain", func(t *testing.T) {
+		s, r := newPair(t)
+		m := seal(t, s, 2)[1]
+		if _, err := r.Open(nil, m); !errors.Is(err, kemchain.ErrInvalidMessage) {
+			t.Errorf("Open(chain message first) err = %v, want = ErrInvalidMessage", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, m := range [][]byte{
+			nil,
+			make([]byte, kemchain.Overhead-1),
+			append([]byte{2}, make([]byte, 64)...),
+			append([]byte{1}, make([]byte, 20)...),
+		} {
+			_, r := newPair(t)
+			if _, err := r.Open(nil, m); !errors.Is(err, kemchain.ErrInvalidMessage) {
+				t.Errorf("Open(%x) err = %v, want = ErrInvalidMessage", m, err)
+			}
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		s, _ := newPair(t)
+		sk, _ := hybrid.X25519.DeriveKeyPair(testdata.New("thyrse kemchain other").Data(hybrid.SeedSize))
+		r, err := kemchain.NewReceiver("kemchain", hybrid.X25519, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Open(nil, seal(t, s, 1)[0]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Open(wrong key) err = %v, want = ErrInvalidCiphertext", err)
+		}
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		if _, err := kemchain.NewSender("kemchain", hybrid.X25519, []byte("short")); !errors.Is(err, hybrid.ErrInvalidKey) {
+			t.Errorf("NewSender err = %v, want = ErrInvalidKey", err)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package kemchain
+
+// An Option configures a Sender.
+type Option func(*config)
+
+// WithEpochMessages sets the number of messages in each epoch, after which the sender encapsulates a fresh shared secret
+// to the receiver. Smaller epochs heal from a compromise of the sender's state sooner, at the cost of a KEM ciphertext
+// in more messages. Panics if n is zero.
+func WithEpochMessages(n uint64) Option {
+	if n == 0 {
+		panic("thyrse/kemchain: invalid epoch size")
+	}
+
+	return func(c *config) {
+		c.epochMessages = n
+	}
+}
+
+type config struct {
+	epochMessages uint64
+}
+
+func newConfig(opts []Option) config {
+	c := config{epochMessages: DefaultEpochMessages}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
@@ -0,0 +1,295 @@
+// Package merkle implements append-only Merkle trees with inclusion and consistency proofs using the Thyrse protocol.
+//
+// Trees have the shape of [RFC 9162] (Certificate Transparency 2.0) trees, and proofs use its algorithms: an inclusion
+// proof shows that a leaf is in a tree of a given size, and a consistency proof shows that a tree of one size is a
+// prefix of a tree of a larger size. This suits transparency logs, in which a log operator publishes roots and clients
+// check that entries are included and that the log has only been appended to, and content addressing of large data
+// split into chunks.
+//
+// Leaves and interior nodes are hashed with distinct transcripts, so a leaf can never be confused with a node:
+//
+//	p := thyrse.New(domain)
+//	p.Mix("leaf", data)
+//	leafHash := p.Derive("hash", nil, Size)
+//
+//	p := thyrse.New(domain)
+//	p.Mix("left", left)
+//	p.Mix("right", right)
+//	nodeHash := p.Derive("hash", nil, Size)
+//
+// The root of an empty tree is thyrse.New(domain).Derive("empty", nil, Size).
+//
+// [RFC 9162]: https://www.rfc-editor.org/rfc/rfc9162.html
+package merkle
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/codahale/thyrse"
+)
+
+// Size is the length of a leaf, node, or root hash in bytes.
+const Size = 32
+
+// ErrInvalidSize is returned when a tree size, leaf index, or pair of tree sizes does not describe a valid proof for a
+// tree.
+var ErrInvalidSize = errors.New("thyrse/merkle: invalid size")
+
+// A Tree is an append-only Merkle tree. It retains the hashes of every leaf and complete subtree, so roots and proofs
+// for the tree and any earlier version of it are computed in time logarithmic in its size.
+type Tree struct {
+	h hasher
+
+	// levels[k] holds the hashes of the complete, aligned subtrees of 2^k leaves, in order.
+	levels [][][]byte
+}
+
+// New returns an empty tree which uses the given domain separation string.
+func New(domain string) *Tree {
+	return &Tree{h: newHasher(domain)}
+}
+
+// Len returns the number of leaves in the tree.
+func (t *Tree) Len() uint64 {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return uint64(len(t.levels[0]))
+}
+
+// Append adds a leaf with the given data to the tree and returns its index.
+func (t *Tree) Append(data []byte) uint64 {
+	p := t.h.base.Clone()
+	p.Mix("leaf", data)
+	return t.AppendHash(p.Derive("hash", nil, Size))
+}
+
+// AppendReaderAt adds a leaf with the first size bytes of r as its data to the tree and returns its index. The leaf
+// hash is the same as if the data were passed to [Tree.Append], but the data is streamed with
+// [thyrse.Protocol.MixReaderAt] rather than held in memory. If r returns an error, no leaf is added.
+func (t *Tree) AppendReaderAt(r io.ReaderAt, size int64) (uint64, error) {
+	p := t.h.base.Clone()
+	if err := p.MixReaderAt("leaf", r, size); err != nil {
+		return 0, err
+	}
+	return t.AppendHash(p.Derive("hash", nil, Size)), nil
+}
+
+// AppendHash adds a leaf with the given leaf hash (see [LeafHash]) to the tree and returns its index. Panics if
+// leafHash is not Size bytes long.
+func (t *Tree) AppendHash(leafHash []byte) uint64 {
+	if len(leafHash) != Size {
+		panic("thyrse/merkle: invalid leaf hash size")
+	}
+
+	index := t.Len()
+	h := bytes.Clone(leafHash)
+	for k := 0; ; k++ {
+		if k == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		t.levels[k] = append(t.levels[k], h)
+
+		// Each completed pair of subtrees completes a subtree at the next level.
+		n := len(t.levels[k])
+		if n%2 != 0 {
+			break
+		}
+		h = t.h.node(t.levels[k][n-2], t.levels[k][n-1])
+	}
+	return index
+}
+
+// Root returns the root hash of the tree.
+func (t *Tree) Root() []byte {
+	root, _ := t.RootAt(t.Len())
+	return root
+}
+
+// RootAt returns the root hash of the tree as it was when it had size leaves. Returns ErrInvalidSize if size is greater
+// than the number of leaves in the tree.
+func (t *Tree) RootAt(size uint64) ([]byte, error) {
+	if size > t.Len() {
+		return nil, ErrInvalidSize
+	}
+	if size == 0 {
+		return t.h.empty(), nil
+	}
+	return t.hash(0, size), nil
+}
+
+// InclusionProof returns a proof that the leaf at the given index is included in the tree as it was when it had size
+// leaves, for use with [VerifyInclusion]. Returns ErrInvalidSize if index is not less than size or size is greater than
+// the number of leaves in the tree.
+func (t *Tree) InclusionProof(index, size uint64) ([][]byte, error) {
+	if index >= size || size > t.Len() {
+		return nil, ErrInvalidSize
+	}
+	return t.path(index, 0, size), nil
+}
+
+// ConsistencyProof returns a proof that the tree as it was when it had size1 leaves is a prefix of the tree as it was
+// when it had size2 leaves, for use with [VerifyConsistency]. Returns ErrInvalidSize if size1 is zero or greater than
+// size2, or if size2 is greater than the number of leaves in the tree.
+func (t *Tree) ConsistencyProof(size1, size2 uint64) ([][]byte, error) {
+	if size1 == 0 || size1 > size2 || size2 > t.Len() {
+		return nil, ErrInvalidSize
+	}
+	if size1 == size2 {
+		return nil, nil
+	}
+	return t.subproof(size1, 0, size2, true), nil
+}
+
+// hash returns the hash of the subtree of leaves [start, end), which must not be empty.
+func (t *Tree) hash(start, end uint64) []byte {
+	n := end - start
+	if n&(n-1) == 0 && start%n == 0 {
+		// The range is a complete, aligned subtree.
+		return bytes.Clone(t.levels[bits.TrailingZeros64(n)][start/n])
+	}
+
+	k := split(n)
+	return t.h.node(t.hash(start, start+k), t.hash(start+k, end))
+}
+
+// path returns the inclusion proof for leaf m of the subtree of leaves [start, end), per RFC 9162 section 2.1.3.1.
+func (t *Tree) path(m, start, end uint64) [][]byte {
+	n := end - start
+	if n == 1 {
+		return nil
+	}
+
+	k := split(n)
+	if m < k {
+		return append(t.path(m, start, start+k), t.hash(start+k, end))
+	}
+	return append(t.path(m-k, start+k, end), t.hash(start, start+k))
+}
+
+// subproof returns the consistency proof for the first m leaves of the subtree of leaves [start, end), per RFC 9162
+// section 2.1.4.1.
+func (t *Tree) subproof(m, start, end uint64, complete bool) [][]byte {
+	n := end - start
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{t.hash(start, end)}
+	}
+
+	k := split(n)
+	if m <= k {
+		return append(t.subproof(m, start, start+k, complete), t.hash(start+k, end))
+	}
+	return append(t.subproof(m-k, start+k, end, false), t.hash(start, start+k))
+}
+
+// LeafHash returns the hash of a leaf with the given data, for use with [VerifyInclusion] and [Tree.AppendHash].
+func LeafHash(domain string, data []byte) []byte {
+	p := thyrse.New(domain)
+	p.Mix("leaf", data)
+	return p.Derive("hash", nil, Size)
+}
+
+// VerifyInclusion returns true if proof shows that the leaf with the given leaf hash is at the given index in a tree
+// with the given size and root hash, per RFC 9162 section 2.1.3.2.
+func VerifyInclusion(domain string, leafHash []byte, index, size uint64, proof [][]byte, root []byte) bool {
+	if index >= size || len(leafHash) != Size {
+		return false
+	}
+
+	h := newHasher(domain)
+	fn, sn, r := index, size-1, leafHash
+	for _, p := range proof {
+		if sn == 0 || len(p) != Size {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = h.node(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn, sn = fn>>1, sn>>1
+			}
+		} else {
+			r = h.node(r, p)
+		}
+		fn, sn = fn>>1, sn>>1
+	}
+	return sn == 0 && subtle.ConstantTimeCompare(r, root) == 1
+}
+
+// VerifyConsistency returns true if proof shows that the tree with size1 leaves and root hash root1 is a prefix of the
+// tree with size2 leaves and root hash root2, per RFC 9162 section 2.1.4.2.
+func VerifyConsistency(domain string, size1, size2 uint64, proof [][]byte, root1, root2 []byte) bool {
+	switch {
+	case size1 == 0 || size1 > size2:
+		return false
+	case size1 == size2:
+		return len(proof) == 0 && subtle.ConstantTimeCompare(root1, root2) == 1
+	case len(proof) == 0:
+		return false
+	}
+
+	// If the first tree is a complete subtree of the second, its root is the first node of the path.
+	if size1&(size1-1) == 0 {
+		proof = append([][]byte{root1}, proof...)
+	}
+	for _, p := range proof {
+		if len(p) != Size {
+			return false
+		}
+	}
+
+	h := newHasher(domain)
+	fn, sn := size1-1, size2-1
+	for fn&1 == 1 {
+		fn, sn = fn>>1, sn>>1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr, sr = h.node(c, fr), h.node(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn, sn = fn>>1, sn>>1
+			}
+		} else {
+			sr = h.node(sr, c)
+		}
+		fn, sn = fn>>1, sn>>1
+	}
+	return sn == 0 && subtle.ConstantTimeCompare(fr, root1)&subtle.ConstantTimeCompare(sr, root2) == 1
+}
+
+// split returns the largest power of two less than n, which must be greater than one.
+func split(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
+
+// hasher hashes the nodes of trees with a given domain separation string.
+type hasher struct {
+	base *thyrse.Protocol
+}
+
+func newHasher(domain string) hasher {
+	return hasher{base: thyrse.New(domain)}
+}
+
+// node returns the hash of the interior node with the given children.
+func (h hasher) node(left, right []byte) []byte {
+	p := h.base.Clone()
+	p.Mix("left", left)
+	p.Mix("right", right)
+	return p.Derive("hash", nil, Size)
+}
+
+// empty returns the root hash of an empty tree.
+func (h hasher) empty() []byte {
+	return h.base.Clone().Derive("empty", nil, Size)
+}
@@ -0,0 +1,195 @@
+package merkle_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/merkle"
+)
+
+const domain = "merkle"
+
+// root computes the root of the given leaves directly from the RFC 9162 definition.
+func root(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return thyrse.New(domain).Derive("empty", nil, merkle.Size)
+	case 1:
+		return merkle.LeafHash(domain, leaves[0])
+	}
+
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	p := thyrse.New(domain)
+	p.Mix("left", root(leaves[:k]))
+	p.Mix("right", root(leaves[k:]))
+	return p.Derive("hash", nil, merkle.Size)
+}
+
+func newTree(n int) (*merkle.Tree, [][]byte) {
+	drbg := testdata.New("merkle")
+	tree := merkle.New(domain)
+	var leaves [][]byte
+	for i := range n {
+		leaves = append(leaves, drbg.Data(i%7))
+		tree.Append(leaves[i])
+	}
+	return tree, leaves
+}
+
+func TestTree_Root(t *testing.T) {
+	tree, leaves := newTree(33)
+	for size := range len(leaves) + 1 {
+		got, err := tree.RootAt(uint64(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := root(leaves[:size]); !bytes.Equal(got, want) {
+			t.Errorf("RootAt(%d) = %x, want = %x", size, got, want)
+		}
+	}
+
+	if got, want := tree.Root(), root(leaves); !bytes.Equal(got, want) {
+		t.Errorf("Root() = %x, want = %x", got, want)
+	}
+	if _, err := tree.RootAt(34); !errors.Is(err, merkle.ErrInvalidSize) {
+		t.Errorf("RootAt(34) err = %v, want = %v", err, merkle.ErrInvalidSize)
+	}
+
+	t.Run("copies", func(t *testing.T) {
+		r := tree.Root()
+		clear(r)
+		if bytes.Equal(tree.Root(), r) {
+			t.Error("Root() returned internal state")
+		}
+	})
+}
+
+func TestTree_AppendReaderAt(t *testing.T) {
+	data := []byte("streamed leaf data")
+	a, b := merkle.New(domain), merkle.New(domain)
+	a.Append(data)
+	if _, err := b.AppendReaderAt(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Root(), b.Root()) {
+		t.Error("AppendReaderAt() and Append() produced different roots")
+	}
+
+	if _, err := b.AppendReaderAt(bytes.NewReader(data), int64(len(data))+1); err == nil {
+		t.Error("AppendReaderAt() with a short reader err = nil")
+	}
+	if got, want := b.Len(), uint64(1); got != want {
+		t.Errorf("Len() = %d, want = %d", got, want)
+	}
+}
+
+func TestInclusion(t *testing.T) {
+	tree, leaves := newTree(33)
+	for size := uint64(1); size <= tree.Len(); size++ {
+		r, _ := tree.RootAt(size)
+		for index := range size {
+			proof, err := tree.InclusionProof(index, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			leafHash := merkle.LeafHash(domain, leaves[index])
+			if !merkle.VerifyInclusion(domain, leafHash, index, size, proof, r) {
+				t.Fatalf("VerifyInclusion(%d, %d) = false, want = true", index, size)
+			}
+			if merkle.VerifyInclusion(domain, leafHash, index+1, size, proof, r) && size > 1 {
+				t.Fatalf("VerifyInclusion(%d, %d) with wrong index = true, want = false", index+1, size)
+			}
+			if merkle.VerifyInclusion(domain, merkle.LeafHash(domain, []byte("other")), index, size, proof, r) {
+				t.Fatalf("VerifyInclusion(%d, %d) with wrong leaf = true, want = false", index, size)
+			}
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		size := tree.Len()
+		proof, _ := tree.InclusionProof(5, size)
+		leafHash, r := merkle.LeafHash(domain, leaves[5]), tree.Root()
+
+		for name, proof := range map[string][][]byte{
+			"truncated": proof[:len(proof)-1],
+			"extended":  append(proof[:len(proof):len(proof)], r),
+			"modified":  append([][]byte{make([]byte, merkle.Size)}, proof[1:]...),
+			"short":     append([][]byte{proof[0][:merkle.Size-1]}, proof[1:]...),
+		} {
+			if merkle.VerifyInclusion(domain, leafHash, 5, size, proof, r) {
+				t.Errorf("VerifyInclusion() with %s proof = true, want = false", name)
+			}
+		}
+
+		if merkle.VerifyInclusion("other", leafHash, 5, size, proof, r) {
+			t.Error("VerifyInclusion() with wrong domain = true, want = false")
+		}
+		for _, tc := range []struct{ index, size uint64 }{{0, 0}, {5, 5}, {0, size + 1}} {
+			if _, err := tree.InclusionProof(tc.index, tc.size); !errors.Is(err, merkle.ErrInvalidSize) {
+				t.Errorf("InclusionProof(%d, %d) err = %v, want = %v", tc.index, tc.size, err, merkle.ErrInvalidSize)
+			}
+		}
+	})
+}
+
+func TestConsistency(t *testing.T) {
+	tree, _ := newTree(33)
+	for size2 := uint64(1); size2 <= tree.Len(); size2++ {
+		root2, _ := tree.RootAt(size2)
+		for size1 := uint64(1); size1 <= size2; size1++ {
+			t.Run(fmt.Sprintf("%d-%d", size1, size2), func(t *testing.T) {
+				root1, _ := tree.RootAt(size1)
+				proof, err := tree.ConsistencyProof(size1, size2)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !merkle.VerifyConsistency(domain, size1, size2, proof, root1, root2) {
+					t.Fatal("VerifyConsistency() = false, want = true")
+				}
+				if merkle.VerifyConsistency(domain, size1, size2, proof, root2, root1) && size1 != size2 {
+					t.Fatal("VerifyConsistency() with swapped roots = true, want = false")
+				}
+				if len(proof) > 0 {
+					modified := append([][]byte{make([]byte, merkle.Size)}, proof[1:]...)
+					if merkle.VerifyConsistency(domain, size1, size2, modified, root1, root2) {
+						t.Fatal("VerifyConsistency() with modified proof = true, want = false")
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("forked log", func(t *testing.T) {
+		other, _ := newTree(20)
+		other.Append([]byte("rewritten history"))
+		for range 12 {
+			other.Append([]byte("entry"))
+		}
+
+		root1, _ := other.RootAt(21)
+		proof, _ := tree.ConsistencyProof(21, 33)
+		if merkle.VerifyConsistency(domain, 21, 33, proof, root1, tree.Root()) {
+			t.Error("VerifyConsistency() for a forked log = true, want = false")
+		}
+	})
+
+	t.Run("invalid sizes", func(t *testing.T) {
+		for _, tc := range []struct{ size1, size2 uint64 }{{0, 5}, {6, 5}, {5, 34}} {
+			if _, err := tree.ConsistencyProof(tc.size1, tc.size2); !errors.Is(err, merkle.ErrInvalidSize) {
+				t.Errorf("ConsistencyProof(%d, %d) err = %v, want = %v", tc.size1, tc.size2, err, merkle.ErrInvalidSize)
+			}
+		}
+		if merkle.VerifyConsistency(domain, 0, 5, nil, nil, tree.Root()) {
+			t.Error("VerifyConsistency() from an empty tree = true, want = false")
+		}
+	})
+}
@@ -0,0 +1,372 @@
+// Package opaque implements an [OPAQUE]-style asymmetric password-authenticated key exchange using the oprf package,
+// Ristretto255, and Thyrse.
+//
+// A client registers a password with a server without revealing it: the client blinds the password, the server
+// evaluates an OPRF over it with a per-credential key, and the client uses the OPRF output to derive a static key pair
+// and seal an envelope. The server stores the resulting record, which contains the client's public key but nothing
+// which allows an offline dictionary attack without the server's OPRF seed.
+//
+// To log in, the client repeats the OPRF evaluation, recovers its static key pair from the envelope, and performs a
+// triple Diffie-Hellman key exchange with the server. Both parties mix every message into a shared transcript and
+// exchange transcript tags, yielding a shared thyrse.Protocol on success.
+//
+// OPAQUE does not itself harden the password against offline attacks by a compromised server; callers should stretch
+// passwords (e.g. with the mhf package) before passing them to StartRegistration and StartLogin.
+//
+// [OPAQUE]: https://www.rfc-editor.org/rfc/rfc9807.html
+package opaque
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/schemes/complex/oprf"
+	"github.com/gtank/ristretto255"
+)
+
+const (
+	// RegistrationRequestSize is the size, in bytes, of a registration request.
+	RegistrationRequestSize = elementSize
+
+	// RegistrationResponseSize is the size, in bytes, of a registration response.
+	RegistrationResponseSize = 2 * elementSize
+
+	// RecordSize is the size, in bytes, of a registration record.
+	RecordSize = elementSize + keySize + envelopeSize
+
+	// KE1Size is the size, in bytes, of the client's first login message.
+	KE1Size = elementSize + nonceSize + elementSize
+
+	// KE2Size is the size, in bytes, of the server's login message.
+	KE2Size = credentialResponseSize + nonceSize + elementSize + thyrse.TranscriptTagSize
+
+	// KE3Size is the size, in bytes, of the client's final login message.
+	KE3Size = thyrse.TranscriptTagSize
+
+	// ExportKeySize is the size, in bytes, of the export key returned to the client, which can be used to encrypt
+	// additional client data stored on the server.
+	ExportKeySize = 32
+)
+
+var (
+	// ErrInvalidMessage is returned when a message or record is malformed.
+	ErrInvalidMessage = errors.New("thyrse/opaque: invalid message")
+
+	// ErrAuthenticationFailed is returned when the password is incorrect or a message fails to authenticate.
+	ErrAuthenticationFailed = errors.New("thyrse/opaque: authentication failed")
+)
+
+// Server is the server's long-term state: its static key pair and the seed from which per-credential OPRF keys are
+// derived.
+type Server struct {
+	domain   string
+	d        *ristretto255.Scalar
+	q        *ristretto255.Element
+	oprfSeed []byte
+}
+
+// NewServer returns a Server with the given domain separation string, static private key, and OPRF seed. The OPRF seed
+// must be a secret, uniformly random value (e.g. 32 bytes); if it is lost, all registered clients must re-register.
+func NewServer(domain string, d *ristretto255.Scalar, oprfSeed []byte) *Server {
+	return &Server{
+		domain:   domain,
+		d:        d,
+		q:        ristretto255.NewIdentityElement().ScalarBaseMult(d),
+		oprfSeed: oprfSeed,
+	}
+}
+
+// RegistrationResponse evaluates the client's registration request for the given credential identifier (e.g. a
+// username) and returns the response to be sent to the client.
+func (s *Server) RegistrationResponse(credentialID, request []byte) ([]byte, error) {
+	if len(request) != RegistrationRequestSize {
+		return nil, ErrInvalidMessage
+	}
+	blinded, err := ristretto255.NewIdentityElement().SetCanonicalBytes(request)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	evaluated, err := oprf.BlindEvaluate(s.oprfKey(credentialID), blinded)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	return append(evaluated.Bytes(), s.q.Bytes()...), nil
+}
+
+// StartLogin begins a login for the given credential identifier and its stored registration record, returning the
+// server's login state and the KE2 message to be sent to the client.
+func (s *Server) StartLogin(credentialID, record, ke1 []byte) (*ServerLogin, []byte, error) {
+	if len(record) != RecordSize || len(ke1) != KE1Size {
+		return nil, nil, ErrInvalidMessage
+	}
+	qC, err := ristretto255.NewIdentityElement().SetCanonicalBytes(record[:elementSize])
+	if err != nil {
+		return nil, nil, ErrInvalidMessage
+	}
+	maskingKey, envelope := record[elementSize:elementSize+keySize], record[elementSize+keySize:]
+
+	blinded, err := ristretto255.NewIdentityElement().SetCanonicalBytes(ke1[:elementSize])
+	if err != nil {
+		return nil, nil, ErrInvalidMessage
+	}
+	eC, err := decodePublicKey(ke1[elementSize+nonceSize:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Evaluate the OPRF and mask the server's public key and the client's envelope.
+	evaluated, err := oprf.BlindEvaluate(s.oprfKey(credentialID), blinded)
+	if err != nil {
+		return nil, nil, ErrInvalidMessage
+	}
+	ke2 := append(make([]byte, 0, KE2Size), evaluated.Bytes()...)
+	maskingNonce := randomBytes(nonceSize)
+	ke2 = append(ke2, maskingNonce...)
+	ke2 = maskCredentials(s.domain, maskingKey, maskingNonce).Mask("credential response", ke2, append(s.q.Bytes(), envelope...))
+
+	// Generate an ephemeral key pair.
+	dE, qE := ephemeralKeyPair()
+	ke2 = append(ke2, randomBytes(nonceSize)...)
+	ke2 = append(ke2, qE.Bytes()...)
+
+	// Calculate the triple Diffie-Hellman shared secrets and send the server's transcript tag.
+	p := newTranscript(s.domain, credentialID, qC, s.q, ke1, ke2)
+	p.Mix("dh1", ristretto255.NewIdentityElement().ScalarMult(dE, eC).Bytes())
+	p.Mix("dh2", ristretto255.NewIdentityElement().ScalarMult(s.d, eC).Bytes())
+	p.Mix("dh3", ristretto255.NewIdentityElement().ScalarMult(dE, qC).Bytes())
+	ke2 = append(ke2, p.TranscriptTag("server")...)
+
+	return &ServerLogin{p: p}, ke2, nil
+}
+
+// oprfKey derives the OPRF key for the given credential identifier from the server's OPRF seed.
+func (s *Server) oprfKey(credentialID []byte) *ristretto255.Scalar {
+	p := thyrse.New(s.domain)
+	p.Mix("oprf seed", s.oprfSeed)
+	p.Mix("credential id", credentialID)
+	k := group.DeriveRistretto255Scalar(p, "oprf key")
+	return k
+}
+
+// ServerLogin is the server's state during a login.
+type ServerLogin struct {
+	p *thyrse.Protocol
+}
+
+// Finish verifies the client's KE3 message and returns the shared protocol. Returns ErrAuthenticationFailed if the
+// client did not know the password.
+func (l *ServerLogin) Finish(ke3 []byte) (*thyrse.Protocol, error) {
+	if len(ke3) != KE3Size {
+		return nil, ErrInvalidMessage
+	}
+	if err := l.p.VerifyTranscriptTag("client", ke3); err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	return l.p, nil
+}
+
+// Registration is the client's state during registration.
+type Registration struct {
+	domain   string
+	password []byte
+	blind    *ristretto255.Scalar
+}
+
+// StartRegistration begins registering the given password, returning the client's registration state and the
+// request to be sent to the server.
+func StartRegistration(domain string, password []byte) (*Registration, []byte, error) {
+	blind, blinded, err := oprf.Blind(domain, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Registration{domain: domain, password: password, blind: blind}, blinded.Bytes(), nil
+}
+
+// Finish completes registration with the server's response, returning the record to be stored by the server and the
+// client's export key.
+func (r *Registration) Finish(response []byte) (record, exportKey []byte, err error) {
+	if len(response) != RegistrationResponseSize {
+		return nil, nil, ErrInvalidMessage
+	}
+	qS, err := decodePublicKey(response[elementSize:])
+	if err != nil {
+		return nil, nil, err
+	}
+	rwd, err := finalize(r.domain, r.password, r.blind, response[:elementSize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := randomBytes(nonceSize)
+	_, qC, tag, exportKey := openEnvelope(r.domain, rwd, nonce, qS)
+
+	record = append(make([]byte, 0, RecordSize), qC.Bytes()...)
+	record = append(record, maskingKey(r.domain, rwd)...)
+	record = append(record, nonce...)
+	record = append(record, tag...)
+	return record, exportKey, nil
+}
+
+// Login is the client's state during a login.
+type Login struct {
+	domain       string
+	credentialID []byte
+	password     []byte
+	blind        *ristretto255.Scalar
+	dE           *ristretto255.Scalar
+	ke1          []byte
+}
+
+// StartLogin begins a login with the given credential identifier and password, returning the client's login state and
+// the KE1 message to be sent to the server.
+func StartLogin(domain string, credentialID, password []byte) (*Login, []byte, error) {
+	blind, blinded, err := oprf.Blind(domain, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	dE, qE := ephemeralKeyPair()
+
+	ke1 := append(make([]byte, 0, KE1Size), blinded.Bytes()...)
+	ke1 = append(ke1, randomBytes(nonceSize)...)
+	ke1 = append(ke1, qE.Bytes()...)
+
+	return &Login{
+		domain:       domain,
+		credentialID: credentialID,
+		password:     password,
+		blind:        blind,
+		dE:           dE,
+		ke1:          ke1,
+	}, ke1, nil
+}
+
+// Finish completes the login with the server's KE2 message, returning the shared protocol, the KE3 message to be sent
+// to the server, and the client's export key. Returns ErrAuthenticationFailed if the password is incorrect or the
+// server could not be authenticated.
+func (l *Login) Finish(ke2 []byte) (p *thyrse.Protocol, ke3, exportKey []byte, err error) {
+	if len(ke2) != KE2Size {
+		return nil, nil, nil, ErrInvalidMessage
+	}
+	rwd, err := finalize(l.domain, l.password, l.blind, ke2[:elementSize])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Unmask the server's public key and the envelope, and recover the client's static key pair.
+	maskingNonce := ke2[elementSize : elementSize+nonceSize]
+	masked := ke2[elementSize+nonceSize : credentialResponseSize]
+	credentials := maskCredentials(l.domain, maskingKey(l.domain, rwd), maskingNonce).Unmask("credential response", nil, masked)
+	qS, err := ristretto255.NewIdentityElement().SetCanonicalBytes(credentials[:elementSize])
+	if err != nil {
+		return nil, nil, nil, ErrAuthenticationFailed
+	}
+	envelope := credentials[elementSize:]
+	dC, qC, tag, exportKey := openEnvelope(l.domain, rwd, envelope[:nonceSize], qS)
+	if subtle.ConstantTimeCompare(tag, envelope[nonceSize:]) != 1 {
+		return nil, nil, nil, ErrAuthenticationFailed
+	}
+
+	eS, err := decodePublicKey(ke2[credentialResponseSize+nonceSize : credentialResponseSize+nonceSize+elementSize])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Calculate the triple Diffie-Hellman shared secrets and verify the server's transcript tag.
+	n := KE2Size - thyrse.TranscriptTagSize
+	p = newTranscript(l.domain, l.credentialID, qC, qS, l.ke1, ke2[:n])
+	p.Mix("dh1", ristretto255.NewIdentityElement().ScalarMult(l.dE, eS).Bytes())
+	p.Mix("dh2", ristretto255.NewIdentityElement().ScalarMult(l.dE, qS).Bytes())
+	p.Mix("dh3", ristretto255.NewIdentityElement().ScalarMult(dC, eS).Bytes())
+	if err := p.VerifyTranscriptTag("server", ke2[n:]); err != nil {
+		return nil, nil, nil, ErrAuthenticationFailed
+	}
+
+	return p, p.TranscriptTag("client"), exportKey, nil
+}
+
+// finalize completes the OPRF evaluation of the password, returning the randomized password.
+func finalize(domain string, password []byte, blind *ristretto255.Scalar, b []byte) ([]byte, error) {
+	evaluated, err := decodePublicKey(b)
+	if err != nil {
+		return nil, err
+	}
+	rwd, err := oprf.Finalize(domain, password, blind, evaluated, 64)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	return rwd, nil
+}
+
+// openEnvelope derives the client's static key pair, the envelope's authentication tag, and the export key from the
+// randomized password, the envelope nonce, and the server's public key.
+func openEnvelope(domain string, rwd, nonce []byte, qS *ristretto255.Element) (dC *ristretto255.Scalar, qC *ristretto255.Element, tag, exportKey []byte) {
+	p := thyrse.New(domain)
+	p.Mix("randomized password", rwd)
+	p.Mix("envelope nonce", nonce)
+	dC = group.DeriveRistretto255Scalar(p, "client private key")
+	qC = ristretto255.NewIdentityElement().ScalarBaseMult(dC)
+	exportKey = p.Derive("export key", nil, ExportKeySize)
+	p.Mix("server public key", qS.Bytes())
+	p.Mix("client public key", qC.Bytes())
+	tag = p.Derive("envelope tag", nil, tagSize)
+	return dC, qC, tag, exportKey
+}
+
+// maskingKey derives the key with which the server masks the client's credentials from the randomized password.
+func maskingKey(domain string, rwd []byte) []byte {
+	p := thyrse.New(domain)
+	p.Mix("randomized password", rwd)
+	return p.Derive("masking key", nil, keySize)
+}
+
+// maskCredentials returns a protocol for masking the server's credential response.
+func maskCredentials(domain string, maskingKey, nonce []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("masking key", maskingKey)
+	p.Mix("masking nonce", nonce)
+	return p
+}
+
+// newTranscript returns the key exchange transcript, with the identities and messages exchanged so far mixed in.
+func newTranscript(domain string, credentialID []byte, qC, qS *ristretto255.Element, ke1, ke2 []byte) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("credential id", credentialID)
+	p.Mix("client public key", qC.Bytes())
+	p.Mix("server public key", qS.Bytes())
+	p.Mix("ke1", ke1)
+	p.Mix("ke2", ke2)
+	return p
+}
+
+func decodePublicKey(b []byte) (*ristretto255.Element, error) {
+	q, err := ristretto255.NewIdentityElement().SetCanonicalBytes(b)
+	if err != nil || q.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, ErrInvalidMessage
+	}
+	return q, nil
+}
+
+func ephemeralKeyPair() (*ristretto255.Scalar, *ristretto255.Element) {
+	d, _ := ristretto255.NewScalar().SetUniformBytes(randomBytes(64))
+	return d, ristretto255.NewIdentityElement().ScalarBaseMult(d)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+const (
+	elementSize            = 32
+	keySize                = 32
+	nonceSize              = 32
+	tagSize                = 32
+	envelopeSize           = nonceSize + tagSize
+	credentialResponseSize = elementSize + nonceSize + elementSize + envelopeSize
+)
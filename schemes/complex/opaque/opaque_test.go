@@ -0,0 +1,140 @@
+package opaque_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/opaque"
+)
+
+func TestLogin(t *testing.T) {
+	drbg := testdata.New("thyrse opaque")
+	dS, _ := drbg.KeyPair()
+	server := opaque.NewServer("opaque", dS, drbg.Data(32))
+	id := []byte("alice")
+
+	record, regExportKey := register(t, server, id, []byte("correct horse"))
+
+	t.Run("correct password", func(t *testing.T) {
+		client, ke1, err := opaque.StartLogin("opaque", id, []byte("correct horse"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sl, ke2, err := server.StartLogin(id, record, ke1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pC, ke3, exportKey, err := client.Finish(ke2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pS, err := sl.Finish(ke3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := pC.Derive("session key", nil, 32), pS.Derive("session key", nil, 32); !bytes.Equal(got, want) {
+			t.Errorf("client key = %x, server key = %x", got, want)
+		}
+		if got, want := exportKey, regExportKey; !bytes.Equal(got, want) {
+			t.Errorf("exportKey = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		client, ke1, err := opaque.StartLogin("opaque", id, []byte("battery staple"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ke2, err := server.StartLogin(id, record, ke1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := client.Finish(ke2); !errors.Is(err, opaque.ErrAuthenticationFailed) {
+			t.Errorf("Finish() err = %v, want = %v", err, opaque.ErrAuthenticationFailed)
+		}
+	})
+
+	t.Run("wrong credential id", func(t *testing.T) {
+		client, ke1, err := opaque.StartLogin("opaque", []byte("mallory"), []byte("correct horse"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ke2, err := server.StartLogin([]byte("mallory"), record, ke1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := client.Finish(ke2); !errors.Is(err, opaque.ErrAuthenticationFailed) {
+			t.Errorf("Finish() err = %v, want = %v", err, opaque.ErrAuthenticationFailed)
+		}
+	})
+
+	t.Run("modified ke2", func(t *testing.T) {
+		client, ke1, err := opaque.StartLogin("opaque", id, []byte("correct horse"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ke2, err := server.StartLogin(id, record, ke1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ke2[len(ke2)-opaque.KE3Size-40] ^= 1
+		if _, _, _, err := client.Finish(ke2); err == nil {
+			t.Error("Finish() err = nil, want error")
+		}
+	})
+
+	t.Run("modified ke3", func(t *testing.T) {
+		client, ke1, err := opaque.StartLogin("opaque", id, []byte("correct horse"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sl, ke2, err := server.StartLogin(id, record, ke1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ke3, _, err := client.Finish(ke2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ke3[0] ^= 1
+		if _, err := sl.Finish(ke3); !errors.Is(err, opaque.ErrAuthenticationFailed) {
+			t.Errorf("Finish() err = %v, want = %v", err, opaque.ErrAuthenticationFailed)
+		}
+	})
+
+	t.Run("malformed messages", func(t *testing.T) {
+		if _, err := server.RegistrationResponse(id, make([]byte, 31)); !errors.Is(err, opaque.ErrInvalidMessage) {
+			t.Errorf("RegistrationResponse() err = %v, want = %v", err, opaque.ErrInvalidMessage)
+		}
+		if _, _, err := server.StartLogin(id, record, make([]byte, opaque.KE1Size)); !errors.Is(err, opaque.ErrInvalidMessage) {
+			t.Errorf("StartLogin() err = %v, want = %v", err, opaque.ErrInvalidMessage)
+		}
+		if _, _, err := server.StartLogin(id, record[1:], make([]byte, opaque.KE1Size)); !errors.Is(err, opaque.ErrInvalidMessage) {
+			t.Errorf("StartLogin() err = %v, want = %v", err, opaque.ErrInvalidMessage)
+		}
+	})
+}
+
+func register(t *testing.T, server *opaque.Server, id, password []byte) (record, exportKey []byte) {
+	t.Helper()
+
+	reg, request, err := opaque.StartRegistration("opaque", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.RegistrationResponse(id, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, exportKey, err = reg.Finish(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(record), opaque.RecordSize; got != want {
+		t.Errorf("len(record) = %d, want = %d", got, want)
+	}
+	return record, exportKey
+}
@@ -0,0 +1,113 @@
+package oprf
+
+import (
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/gtank/ristretto255"
+)
+
+// PartiallyObliviousBlindEvaluate takes the server's private key, a blinded element, and public info, and returns an
+// evaluated element to be transmitted to the client, plus a proof. Unlike the blinded input, the public info is known
+// to the server and is bound into the PRF output (e.g. to bind a rate-limiting epoch or an attribute to the output).
+//
+// This is the server side of the RFC 9497-style POPRF mode: the server's key is tweaked with a scalar derived from the
+// public info, and the proof is made against the tweaked public key.
+func PartiallyObliviousBlindEvaluate(domain string, d *ristretto255.Scalar, blindedElement *ristretto255.Element, info []byte) (evaluatedElement *ristretto255.Element, c, s *ristretto255.Scalar, err error) {
+	if blindedElement.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, nil, nil, errors.New("oprf: blinded element is identity")
+	}
+
+	// Tweak the private key with the public info: t = d + m.
+	t := ristretto255.NewScalar().Add(d, tweak(domain, info))
+	if t.Equal(ristretto255.NewScalar()) == 1 {
+		return nil, nil, nil, errors.New("oprf: tweaked private key is zero")
+	}
+	tweakedKey := ristretto255.NewIdentityElement().ScalarBaseMult(t)
+
+	evaluatedElement = ristretto255.NewIdentityElement().ScalarMult(ristretto255.NewScalar().Invert(t), blindedElement)
+
+	// Prove that blindedElement = [t]evaluatedElement for the tweaked key.
+	evaluatedElements := []*ristretto255.Element{evaluatedElement}
+	blindedElements := []*ristretto255.Element{blindedElement}
+	c, s = generateProof(domain, t, ristretto255.NewGeneratorElement(), tweakedKey, evaluatedElements, blindedElements)
+	return evaluatedElement, c, s, nil
+}
+
+// PartiallyObliviousFinalize takes the client's secret input, the public info, the blind scalar and blinded element
+// generated by Blind, the server's public key, the evaluated element and proof returned by
+// PartiallyObliviousBlindEvaluate, the number of bytes to generate, and returns n bytes of PRF output, or an error if
+// the proof cannot be verified.
+func PartiallyObliviousFinalize(domain string, input, info []byte, blind *ristretto255.Scalar, q, evaluatedElement, blindedElement *ristretto255.Element, c, s *ristretto255.Scalar, n int) ([]byte, error) {
+	if blindedElement.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errors.New("oprf: blinded element is identity")
+	}
+
+	if evaluatedElement.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errors.New("oprf: evaluated element is identity")
+	}
+
+	// Calculate the tweaked public key: T = Q + [m]G.
+	tweakedKey := ristretto255.NewIdentityElement().ScalarBaseMult(tweak(domain, info))
+	tweakedKey = tweakedKey.Add(tweakedKey, q)
+	if tweakedKey.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errors.New("oprf: tweaked public key is identity")
+	}
+
+	evaluatedElements := []*ristretto255.Element{evaluatedElement}
+	blindedElements := []*ristretto255.Element{blindedElement}
+	if !verifyProof(domain, ristretto255.NewGeneratorElement(), tweakedKey, evaluatedElements, blindedElements, c, s) {
+		return nil, errors.New("oprf: invalid proof")
+	}
+
+	// Unblind the element.
+	unblindedElement := ristretto255.NewIdentityElement().ScalarMult(ristretto255.NewScalar().Invert(blind), evaluatedElement)
+	if unblindedElement.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errors.New("oprf: unblinded element is identity")
+	}
+
+	// Derive a bytestring from the input, the public info, and the unblinded element.
+	p := thyrse.New(domain)
+	p.Mix("input", input)
+	_, prf := p.Fork("output", []byte("element"), []byte("prf"))
+	prf.Mix("info", info)
+	prf.Mix("unblinded-element", unblindedElement.Bytes())
+	return prf.Derive("prf", nil, n), nil
+}
+
+// PartiallyObliviousEvaluate takes the server's private key, a secret input, public info, and the number of bytes to
+// generate, and returns n bytes of PRF output.
+//
+// Returns the same output as PartiallyObliviousFinalize, but without the blinding step performed by the client.
+func PartiallyObliviousEvaluate(domain string, d *ristretto255.Scalar, input, info []byte, n int) ([]byte, error) {
+	// Derive an element from the input.
+	p := thyrse.New(domain)
+	p.Mix("input", input)
+	element, prf := p.Fork("output", []byte("element"), []byte("prf"))
+
+	inputElement, _ := ristretto255.NewIdentityElement().SetUniformBytes(element.Derive("element", nil, 64))
+	if inputElement.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil, errors.New("oprf: input maps to identity element")
+	}
+
+	// Tweak the private key with the public info and evaluate the element ourselves.
+	t := ristretto255.NewScalar().Add(d, tweak(domain, info))
+	if t.Equal(ristretto255.NewScalar()) == 1 {
+		return nil, errors.New("oprf: tweaked private key is zero")
+	}
+	evaluatedElement := ristretto255.NewIdentityElement().ScalarMult(ristretto255.NewScalar().Invert(t), inputElement)
+
+	// Derive a bytestring from the input, the public info, and the unblinded element.
+	prf.Mix("info", info)
+	prf.Mix("unblinded-element", evaluatedElement.Bytes())
+	return prf.Derive("prf", nil, n), nil
+}
+
+// tweak derives the scalar m used to tweak the server's key from the public info.
+func tweak(domain string, info []byte) *ristretto255.Scalar {
+	p := thyrse.New(domain)
+	p.Mix("info", info)
+	m := group.DeriveRistretto255Scalar(p, "tweak")
+	return m
+}
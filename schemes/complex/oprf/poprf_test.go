@@ -0,0 +1,133 @@
+package oprf_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/oprf"
+)
+
+func Example_poprf() {
+	drbg := testdata.New("thyrse poprf")
+
+	// The server has a private key.
+	d, q := drbg.KeyPair()
+
+	// The client has a secret input and blinds it. The client and server agree on public info.
+	input := []byte("this is a sensitive input")
+	info := []byte("epoch 2026-10")
+	blind, blindedElement, err := oprf.Blind("example", input)
+	if err != nil {
+		panic(err)
+	}
+
+	// The server evaluates the blinded input with the public info and returns a proof.
+	evaluatedElement, c, s, err := oprf.PartiallyObliviousBlindEvaluate("example", d, blindedElement, info)
+	if err != nil {
+		panic(err)
+	}
+
+	// The client verifies the proof, finalizes it and derives PRF output.
+	clientPRF, err := oprf.PartiallyObliviousFinalize("example", input, info, blind, q, evaluatedElement, blindedElement, c, s, 16)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("client PRF = %x\n", clientPRF)
+
+	// If the server gets the input, it can derive the same PRF output.
+	serverPRF, err := oprf.PartiallyObliviousEvaluate("example", d, input, info, 16)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("server PRF = %x\n", serverPRF)
+
+	// Output:
+	// client PRF = 91604eeda3953fa17d4e6ca516ae7ed6
+	// server PRF = 91604eeda3953fa17d4e6ca516ae7ed6
+}
+
+func TestPartiallyObliviousFinalize(t *testing.T) {
+	drbg := testdata.New("thyrse poprf")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+	input := []byte("this is a sensitive input")
+	info := []byte("epoch 2026-10")
+
+	blind, blindedElement, err := oprf.Blind("example", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluatedElement, c, s, err := oprf.PartiallyObliviousBlindEvaluate("example", d, blindedElement, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid proof", func(t *testing.T) {
+		got, err := oprf.PartiallyObliviousFinalize("example", input, info, blind, q, evaluatedElement, blindedElement, c, s, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := oprf.PartiallyObliviousEvaluate("example", d, input, info, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("PartiallyObliviousFinalize() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("wrong info", func(t *testing.T) {
+		_, err := oprf.PartiallyObliviousFinalize("example", input, []byte("epoch 2026-11"), blind, q, evaluatedElement, blindedElement, c, s, 16)
+		if err == nil {
+			t.Error("PartiallyObliviousFinalize() err = nil, want error")
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := oprf.PartiallyObliviousFinalize("example", input, info, blind, qX, evaluatedElement, blindedElement, c, s, 16)
+		if err == nil {
+			t.Error("PartiallyObliviousFinalize() err = nil, want error")
+		}
+	})
+
+	t.Run("not a verifiable evaluation", func(t *testing.T) {
+		_, err := oprf.VerifiableFinalize("example", input, blind, q, evaluatedElement, blindedElement, c, s, 16)
+		if err == nil {
+			t.Error("VerifiableFinalize() err = nil, want error")
+		}
+	})
+}
+
+func TestPartiallyObliviousEvaluate(t *testing.T) {
+	drbg := testdata.New("thyrse poprf")
+	d, _ := drbg.KeyPair()
+	input := []byte("this is a sensitive input")
+
+	a, err := oprf.PartiallyObliviousEvaluate("example", d, input, []byte("epoch 2026-10"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("info binding", func(t *testing.T) {
+		b, err := oprf.PartiallyObliviousEvaluate("example", d, input, []byte("epoch 2026-11"), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(a, b) {
+			t.Error("PartiallyObliviousEvaluate() returned the same output for different info")
+		}
+	})
+
+	t.Run("distinct from oprf", func(t *testing.T) {
+		b, err := oprf.Evaluate("example", d, input, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(a, b) {
+			t.Error("PartiallyObliviousEvaluate() = Evaluate()")
+		}
+	})
+}
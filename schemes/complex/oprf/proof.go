@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
 	"github.com/gtank/ristretto255"
 )
 
@@ -17,7 +18,7 @@ func computeCompositesFast(domain string, k *ristretto255.Scalar, b *ristretto25
 	for i := range cM {
 		p.Mix("c", cM[i].Bytes())
 		p.Mix("d", dM[i].Bytes())
-		dI, _ := ristretto255.NewScalar().SetUniformBytes(p.Derive("scalar", nil, 64))
+		dI := group.DeriveRistretto255Scalar(p, "scalar")
 		m.Add(m, ristretto255.NewIdentityElement().ScalarMult(dI, cM[i]))
 	}
 	z = ristretto255.NewIdentityElement().ScalarMult(k, m)
@@ -41,7 +42,7 @@ func generateProof(domain string, k *ristretto255.Scalar, a, b *ristretto255.Ele
 	p.Mix("z", z.Bytes())
 	p.Mix("t2", t2.Bytes())
 	p.Mix("t3", t3.Bytes())
-	c, _ = ristretto255.NewScalar().SetUniformBytes(p.Derive("challenge", nil, 64))
+	c = group.DeriveRistretto255Scalar(p, "challenge")
 	s = ristretto255.NewScalar().Subtract(r, ristretto255.NewScalar().Multiply(c, k))
 	return c, s
 }
@@ -57,7 +58,7 @@ func computeComposites(domain string, b *ristretto255.Element, cM, dM []*ristret
 	for i := range cM {
 		p.Mix("c", cM[i].Bytes())
 		p.Mix("d", dM[i].Bytes())
-		dI, _ := ristretto255.NewScalar().SetUniformBytes(p.Derive("scalar", nil, 64))
+		dI := group.DeriveRistretto255Scalar(p, "scalar")
 		m.Add(m, ristretto255.NewIdentityElement().ScalarMult(dI, cM[i]))
 		z.Add(z, ristretto255.NewIdentityElement().ScalarMult(dI, dM[i]))
 	}
@@ -74,6 +75,6 @@ func verifyProof(domain string, a, b *ristretto255.Element, cM, dM []*ristretto2
 	p.Mix("z", z.Bytes())
 	p.Mix("t2", t2.Bytes())
 	p.Mix("t3", t3.Bytes())
-	expectedC, _ := ristretto255.NewScalar().SetUniformBytes(p.Derive("challenge", nil, 64))
+	expectedC := group.DeriveRistretto255Scalar(p, "challenge")
 	return c.Equal(expectedC) == 1
 }
@@ -0,0 +1,140 @@
+package oprf
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/codahale/thyrse"
+	"github.com/gtank/ristretto255"
+)
+
+// ThrottleKeySize is the size, in bytes, of a throttling key.
+const ThrottleKeySize = 32
+
+// ErrRateLimited is returned by Server when a client has exceeded its rate limit.
+var ErrRateLimited = errors.New("oprf: rate limited")
+
+// A Limiter decides whether a request with the given throttling key may proceed. Implementations must be safe for
+// concurrent use.
+type Limiter interface {
+	Allow(key [ThrottleKeySize]byte) bool
+}
+
+// Server wraps a server's private key with a Limiter, throttling evaluations per client.
+//
+// Blinded elements are uniformly random and unlinkable across requests, so they cannot identify an abusive client on
+// their own. Instead, each request carries a caller-supplied client identifier (e.g. an account ID or network address),
+// from which the Server derives a throttling key with a PRF keyed by its private key. The Limiter only ever sees
+// throttling keys, so a rate-limiting store does not retain client identifiers, and the server learns nothing about the
+// client's input.
+type Server struct {
+	domain  string
+	d       *ristretto255.Scalar
+	limiter Limiter
+	prf     *thyrse.Protocol
+}
+
+// NewServer returns a Server which evaluates blinded elements with the private key d, subject to the given limiter.
+func NewServer(domain string, d *ristretto255.Scalar, limiter Limiter) *Server {
+	prf := thyrse.New(domain)
+	prf.Mix("throttle-key", d.Bytes())
+	return &Server{domain: domain, d: d, limiter: limiter, prf: prf}
+}
+
+// ThrottleKey returns the throttling key for the given client identifier.
+func (s *Server) ThrottleKey(client []byte) [ThrottleKeySize]byte {
+	p := s.prf.Clone()
+	p.Mix("client", client)
+	return [ThrottleKeySize]byte(p.Derive("throttle-key", nil, ThrottleKeySize))
+}
+
+// BlindEvaluate is like the package-level BlindEvaluate, but returns ErrRateLimited if the Limiter denies the client's
+// request.
+func (s *Server) BlindEvaluate(client []byte, blindedElement *ristretto255.Element) (*ristretto255.Element, error) {
+	if !s.limiter.Allow(s.ThrottleKey(client)) {
+		return nil, ErrRateLimited
+	}
+	return BlindEvaluate(s.d, blindedElement)
+}
+
+// VerifiableBlindEvaluate is like the package-level VerifiableBlindEvaluate, but returns ErrRateLimited if the Limiter
+// denies the client's request.
+func (s *Server) VerifiableBlindEvaluate(client []byte, blindedElement *ristretto255.Element) (evaluatedElement *ristretto255.Element, c, sc *ristretto255.Scalar, err error) {
+	if !s.limiter.Allow(s.ThrottleKey(client)) {
+		return nil, nil, nil, ErrRateLimited
+	}
+	return VerifiableBlindEvaluate(s.domain, s.d, blindedElement)
+}
+
+// PartiallyObliviousBlindEvaluate is like the package-level PartiallyObliviousBlindEvaluate, but returns
+// ErrRateLimited if the Limiter denies the client's request.
+func (s *Server) PartiallyObliviousBlindEvaluate(client []byte, blindedElement *ristretto255.Element, info []byte) (evaluatedElement *ristretto255.Element, c, sc *ristretto255.Scalar, err error) {
+	if !s.limiter.Allow(s.ThrottleKey(client)) {
+		return nil, nil, nil, ErrRateLimited
+	}
+	return PartiallyObliviousBlindEvaluate(s.domain, s.d, blindedElement, info)
+}
+
+// TokenBucket is an in-memory Limiter which allows each throttling key a burst of requests, refilled at a constant
+// rate.
+type TokenBucket struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[[ThrottleKeySize]byte]*bucket
+	now     func() time.Time
+}
+
+// NewTokenBucket returns a TokenBucket which allows burst requests per key, refilled at rate requests per second.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[[ThrottleKeySize]byte]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consumes a token from the key's bucket, returning false if the bucket is empty.
+func (tb *TokenBucket) Allow(key [ThrottleKeySize]byte) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: tb.burst, last: now}
+		tb.buckets[key] = b
+	}
+	tb.refill(b, now)
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Prune discards the buckets of keys which have refilled completely, bounding the memory held for inactive clients.
+func (tb *TokenBucket) Prune() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	for key, b := range tb.buckets {
+		if tb.refill(b, now); b.tokens >= tb.burst {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+func (tb *TokenBucket) refill(b *bucket, now time.Time) {
+	b.tokens = min(tb.burst, b.tokens+now.Sub(b.last).Seconds()*tb.rate)
+	b.last = now
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
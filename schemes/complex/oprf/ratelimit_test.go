@@ -0,0 +1,123 @@
+package oprf_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/oprf"
+)
+
+func TestServer(t *testing.T) {
+	drbg := testdata.New("thyrse oprf rate limit")
+	d, q := drbg.KeyPair()
+	dX, _ := drbg.KeyPair()
+
+	input := []byte("this is a sensitive input")
+	blind, blindedElement, err := oprf.Blind("example", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		s := oprf.NewServer("example", d, oprf.NewTokenBucket(0, 1))
+		evaluatedElement, err := s.BlindEvaluate([]byte("alice"), blindedElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := oprf.Finalize("example", input, blind, evaluatedElement, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := oprf.Evaluate("example", d, input, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Finalize() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		s := oprf.NewServer("example", d, oprf.NewTokenBucket(0, 2))
+		for range 2 {
+			if _, err := s.BlindEvaluate([]byte("alice"), blindedElement); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.BlindEvaluate([]byte("alice"), blindedElement); !errors.Is(err, oprf.ErrRateLimited) {
+			t.Errorf("BlindEvaluate() err = %v, want = %v", err, oprf.ErrRateLimited)
+		}
+		if _, _, _, err := s.VerifiableBlindEvaluate([]byte("alice"), blindedElement); !errors.Is(err, oprf.ErrRateLimited) {
+			t.Errorf("VerifiableBlindEvaluate() err = %v, want = %v", err, oprf.ErrRateLimited)
+		}
+		if _, err := s.BlindEvaluate([]byte("bea"), blindedElement); err != nil {
+			t.Errorf("BlindEvaluate(bea) err = %v, want = nil", err)
+		}
+	})
+
+	t.Run("verifiable", func(t *testing.T) {
+		s := oprf.NewServer("example", d, oprf.NewTokenBucket(0, 1))
+		evaluatedElement, c, sc, err := s.VerifiableBlindEvaluate([]byte("alice"), blindedElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := oprf.VerifiableFinalize("example", input, blind, q, evaluatedElement, blindedElement, c, sc, 16); err != nil {
+			t.Errorf("VerifiableFinalize() err = %v", err)
+		}
+	})
+
+	t.Run("partially oblivious", func(t *testing.T) {
+		s := oprf.NewServer("example", d, oprf.NewTokenBucket(0, 1))
+		info := []byte("epoch 2026-10")
+		evaluatedElement, c, sc, err := s.PartiallyObliviousBlindEvaluate([]byte("alice"), blindedElement, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := oprf.PartiallyObliviousFinalize("example", input, info, blind, q, evaluatedElement, blindedElement, c, sc, 16); err != nil {
+			t.Errorf("PartiallyObliviousFinalize() err = %v", err)
+		}
+		if _, _, _, err := s.PartiallyObliviousBlindEvaluate([]byte("alice"), blindedElement, info); !errors.Is(err, oprf.ErrRateLimited) {
+			t.Errorf("PartiallyObliviousBlindEvaluate() err = %v, want = %v", err, oprf.ErrRateLimited)
+		}
+	})
+
+	t.Run("throttle key", func(t *testing.T) {
+		s := oprf.NewServer("example", d, oprf.NewTokenBucket(0, 1))
+		if s.ThrottleKey([]byte("alice")) != s.ThrottleKey([]byte("alice")) {
+			t.Error("ThrottleKey is not deterministic")
+		}
+		if s.ThrottleKey([]byte("alice")) == s.ThrottleKey([]byte("bea")) {
+			t.Error("distinct clients share a throttle key")
+		}
+		sX := oprf.NewServer("example", dX, oprf.NewTokenBucket(0, 1))
+		if s.ThrottleKey([]byte("alice")) == sX.ThrottleKey([]byte("alice")) {
+			t.Error("distinct server keys produced the same throttle key")
+		}
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	var key [oprf.ThrottleKeySize]byte
+
+	t.Run("refill", func(t *testing.T) {
+		tb := oprf.NewTokenBucket(1e12, 1)
+		for i := range 10 {
+			if !tb.Allow(key) {
+				t.Fatalf("Allow() = false on request %d, want = true", i)
+			}
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		tb := oprf.NewTokenBucket(0, 1)
+		if !tb.Allow(key) {
+			t.Fatal("Allow() = false, want = true")
+		}
+		tb.Prune()
+		if tb.Allow(key) {
+			t.Error("Prune discarded an empty bucket")
+		}
+	})
+}
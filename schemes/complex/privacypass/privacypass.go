@@ -0,0 +1,255 @@
+// Package privacypass implements a [Privacy Pass]-style anonymous token issuance and redemption protocol on top of the
+// verifiable OPRF in package oprf.
+//
+// An origin sends a client a token challenge. The client sends a TokenRequest to an Issuer, which evaluates it without
+// learning which token it is issuing, and returns a TokenResponse. The client finalizes the response into a Token,
+// which it presents to the origin. The origin verifies the token with the issuer's private key, but cannot link it to
+// the issuance which produced it.
+//
+// [Privacy Pass]: https://www.rfc-editor.org/rfc/rfc9578.html
+package privacypass
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/oprf"
+	"github.com/gtank/ristretto255"
+)
+
+const (
+	// NonceSize is the length of a token's nonce in bytes.
+	NonceSize = 32
+
+	// ChallengeDigestSize is the length of a token challenge digest in bytes.
+	ChallengeDigestSize = 32
+
+	// KeyIDSize is the length of an issuer's key ID in bytes.
+	KeyIDSize = 32
+
+	// AuthenticatorSize is the length of a token's authenticator in bytes.
+	AuthenticatorSize = 32
+
+	// TokenRequestSize is the length of an encoded TokenRequest in bytes.
+	TokenRequestSize = KeyIDSize + 32
+
+	// TokenResponseSize is the length of an encoded TokenResponse in bytes.
+	TokenResponseSize = 32 + 64
+
+	// TokenSize is the length of an encoded Token in bytes.
+	TokenSize = NonceSize + ChallengeDigestSize + KeyIDSize + AuthenticatorSize
+)
+
+var (
+	// ErrInvalidEncoding is returned when a token request, response, or token is malformed.
+	ErrInvalidEncoding = errors.New("thyrse/privacypass: invalid encoding")
+
+	// ErrUnknownKey is returned when a token request or token was not made for the issuer's key.
+	ErrUnknownKey = errors.New("thyrse/privacypass: unknown key")
+
+	// ErrInvalidToken is returned when a token's authenticator or challenge is invalid.
+	ErrInvalidToken = errors.New("thyrse/privacypass: invalid token")
+)
+
+// A TokenRequest is sent by a client to an Issuer.
+type TokenRequest struct {
+	KeyID          [KeyIDSize]byte // The ID of the issuer's key.
+	BlindedElement [32]byte        // The client's blinded token input.
+}
+
+// MarshalBinary encodes the request as its key ID followed by its blinded element.
+func (r *TokenRequest) MarshalBinary() ([]byte, error) {
+	return append(r.KeyID[:], r.BlindedElement[:]...), nil
+}
+
+// UnmarshalBinary decodes a request encoded with MarshalBinary. Returns ErrInvalidEncoding if data is malformed.
+func (r *TokenRequest) UnmarshalBinary(data []byte) error {
+	if len(data) != TokenRequestSize {
+		return ErrInvalidEncoding
+	}
+	copy(r.KeyID[:], data)
+	copy(r.BlindedElement[:], data[KeyIDSize:])
+	return nil
+}
+
+// A TokenResponse is sent by an Issuer to a client.
+type TokenResponse struct {
+	EvaluatedElement [32]byte // The issuer's evaluation of the blinded element.
+	Proof            [64]byte // A proof that the evaluation used the issuer's key.
+}
+
+// MarshalBinary encodes the response as its evaluated element followed by its proof.
+func (r *TokenResponse) MarshalBinary() ([]byte, error) {
+	return append(r.EvaluatedElement[:], r.Proof[:]...), nil
+}
+
+// UnmarshalBinary decodes a response encoded with MarshalBinary. Returns ErrInvalidEncoding if data is malformed.
+func (r *TokenResponse) UnmarshalBinary(data []byte) error {
+	if len(data) != TokenResponseSize {
+		return ErrInvalidEncoding
+	}
+	copy(r.EvaluatedElement[:], data)
+	copy(r.Proof[:], data[32:])
+	return nil
+}
+
+// A Token is presented by a client to an origin.
+type Token struct {
+	Nonce           [NonceSize]byte           // A random nonce chosen by the client.
+	ChallengeDigest [ChallengeDigestSize]byte // The digest of the origin's token challenge.
+	KeyID           [KeyIDSize]byte           // The ID of the issuer's key.
+	Authenticator   [AuthenticatorSize]byte   // The OPRF output for the token's other fields.
+}
+
+// MarshalBinary encodes the token as its nonce, challenge digest, key ID, and authenticator.
+func (t *Token) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, TokenSize)
+	b = append(b, t.input()...)
+	return append(b, t.Authenticator[:]...), nil
+}
+
+// UnmarshalBinary decodes a token encoded with MarshalBinary. Returns ErrInvalidEncoding if data is malformed.
+func (t *Token) UnmarshalBinary(data []byte) error {
+	if len(data) != TokenSize {
+		return ErrInvalidEncoding
+	}
+	data = data[copy(t.Nonce[:], data):]
+	data = data[copy(t.ChallengeDigest[:], data):]
+	data = data[copy(t.KeyID[:], data):]
+	copy(t.Authenticator[:], data)
+	return nil
+}
+
+// input returns the OPRF input for the token: its nonce, challenge digest, and key ID.
+func (t *Token) input() []byte {
+	b := make([]byte, 0, NonceSize+ChallengeDigestSize+KeyIDSize)
+	b = append(b, t.Nonce[:]...)
+	b = append(b, t.ChallengeDigest[:]...)
+	return append(b, t.KeyID[:]...)
+}
+
+// KeyID returns the key ID of the issuer's public key.
+func KeyID(domain string, q *ristretto255.Element) [KeyIDSize]byte {
+	p := thyrse.New(domain)
+	p.Mix("issuer-key", q.Bytes())
+	return thyrse.DeriveArray[[KeyIDSize]byte](p, "key-id")
+}
+
+// ChallengeDigest returns the digest of an origin's token challenge.
+func ChallengeDigest(domain string, challenge []byte) [ChallengeDigestSize]byte {
+	p := thyrse.New(domain)
+	p.Mix("token-challenge", challenge)
+	return thyrse.DeriveArray[[ChallengeDigestSize]byte](p, "challenge-digest")
+}
+
+// A PendingToken is a client's state between sending a TokenRequest and receiving its TokenResponse.
+type PendingToken struct {
+	domain         string
+	q              *ristretto255.Element
+	token          Token
+	blind          *ristretto255.Scalar
+	blindedElement *ristretto255.Element
+}
+
+// NewTokenRequest begins issuance of a token for the given token challenge from the issuer with the public key q.
+// Returns the client's pending state and a TokenRequest to be sent to the issuer.
+func NewTokenRequest(domain string, q *ristretto255.Element, challenge []byte) (*PendingToken, *TokenRequest, error) {
+	pt := &PendingToken{domain: domain, q: q}
+	if _, err := rand.Read(pt.token.Nonce[:]); err != nil {
+		panic(err)
+	}
+	pt.token.ChallengeDigest = ChallengeDigest(domain, challenge)
+	pt.token.KeyID = KeyID(domain, q)
+
+	var err error
+	pt.blind, pt.blindedElement, err = oprf.Blind(domain, pt.token.input())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &TokenRequest{KeyID: pt.token.KeyID}
+	copy(req.BlindedElement[:], pt.blindedElement.Bytes())
+	return pt, req, nil
+}
+
+// Finalize verifies the issuer's TokenResponse and returns the issued Token. Returns ErrInvalidEncoding if the response
+// is malformed, or an error if the issuer's proof is invalid.
+func (pt *PendingToken) Finalize(resp *TokenResponse) (*Token, error) {
+	evaluatedElement, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(resp.EvaluatedElement[:])
+	c, _ := ristretto255.NewScalar().SetCanonicalBytes(resp.Proof[:32])
+	s, _ := ristretto255.NewScalar().SetCanonicalBytes(resp.Proof[32:])
+	if evaluatedElement == nil || c == nil || s == nil {
+		return nil, ErrInvalidEncoding
+	}
+
+	authenticator, err := oprf.VerifiableFinalize(pt.domain, pt.token.input(), pt.blind, pt.q, evaluatedElement, pt.blindedElement, c, s, AuthenticatorSize)
+	if err != nil {
+		return nil, err
+	}
+
+	token := pt.token
+	copy(token.Authenticator[:], authenticator)
+	return &token, nil
+}
+
+// An Issuer evaluates token requests and verifies the resulting tokens.
+type Issuer struct {
+	domain string
+	d      *ristretto255.Scalar
+	keyID  [KeyIDSize]byte
+}
+
+// NewIssuer returns an Issuer with the given private key.
+func NewIssuer(domain string, d *ristretto255.Scalar) *Issuer {
+	return &Issuer{domain: domain, d: d, keyID: KeyID(domain, ristretto255.NewIdentityElement().ScalarBaseMult(d))}
+}
+
+// KeyID returns the key ID of the issuer's public key.
+func (iss *Issuer) KeyID() [KeyIDSize]byte {
+	return iss.keyID
+}
+
+// Issue evaluates a TokenRequest and returns a TokenResponse. Returns ErrUnknownKey if the request is for a different
+// key, or ErrInvalidEncoding if the request is malformed.
+func (iss *Issuer) Issue(req *TokenRequest) (*TokenResponse, error) {
+	if req.KeyID != iss.keyID {
+		return nil, ErrUnknownKey
+	}
+	blindedElement, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(req.BlindedElement[:])
+	if blindedElement == nil {
+		return nil, ErrInvalidEncoding
+	}
+
+	evaluatedElement, c, s, err := oprf.VerifiableBlindEvaluate(iss.domain, iss.d, blindedElement)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TokenResponse
+	copy(resp.EvaluatedElement[:], evaluatedElement.Bytes())
+	copy(resp.Proof[:32], c.Bytes())
+	copy(resp.Proof[32:], s.Bytes())
+	return &resp, nil
+}
+
+// Verify checks that the token was issued by the issuer for the given token challenge. Returns ErrUnknownKey if the
+// token is for a different key, or ErrInvalidToken if the token is otherwise invalid.
+//
+// Verify does not prevent double-spending: the origin MUST record the nonces of redeemed tokens and reject tokens
+// whose nonces it has already seen.
+func (iss *Issuer) Verify(challenge []byte, token *Token) error {
+	if token.KeyID != iss.keyID {
+		return ErrUnknownKey
+	}
+	if digest := ChallengeDigest(iss.domain, challenge); subtle.ConstantTimeCompare(digest[:], token.ChallengeDigest[:]) != 1 {
+		return ErrInvalidToken
+	}
+
+	authenticator, err := oprf.Evaluate(iss.domain, iss.d, token.input(), AuthenticatorSize)
+	if err != nil || subtle.ConstantTimeCompare(authenticator, token.Authenticator[:]) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
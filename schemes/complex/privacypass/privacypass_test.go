@@ -0,0 +1,99 @@
+package privacypass_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/privacypass"
+)
+
+func TestIssuance(t *testing.T) {
+	drbg := testdata.New("thyrse privacy pass")
+	d, q := drbg.KeyPair()
+	dX, _ := drbg.KeyPair()
+	issuer := privacypass.NewIssuer("privacypass", d)
+	challenge := []byte("origin.example")
+
+	pending, req, err := privacypass.NewTokenRequest("privacypass", q, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := issuer.Issue(roundTrip(t, req, new(privacypass.TokenRequest)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := pending.Finalize(roundTrip(t, resp, new(privacypass.TokenResponse)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = roundTrip(t, token, new(privacypass.Token))
+
+	t.Run("valid", func(t *testing.T) {
+		if err := issuer.Verify(challenge, token); err != nil {
+			t.Errorf("Verify() err = %v", err)
+		}
+	})
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		if err := issuer.Verify([]byte("other.example"), token); !errors.Is(err, privacypass.ErrInvalidToken) {
+			t.Errorf("Verify() err = %v, want %v", err, privacypass.ErrInvalidToken)
+		}
+	})
+
+	t.Run("modified nonce", func(t *testing.T) {
+		bad := *token
+		bad.Nonce[0] ^= 1
+		if err := issuer.Verify(challenge, &bad); !errors.Is(err, privacypass.ErrInvalidToken) {
+			t.Errorf("Verify() err = %v, want %v", err, privacypass.ErrInvalidToken)
+		}
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		other := privacypass.NewIssuer("privacypass", dX)
+		if err := other.Verify(challenge, token); !errors.Is(err, privacypass.ErrUnknownKey) {
+			t.Errorf("Verify() err = %v, want %v", err, privacypass.ErrUnknownKey)
+		}
+		if _, err := other.Issue(req); !errors.Is(err, privacypass.ErrUnknownKey) {
+			t.Errorf("Issue() err = %v, want %v", err, privacypass.ErrUnknownKey)
+		}
+	})
+
+	t.Run("invalid proof", func(t *testing.T) {
+		bad := *resp
+		bad.Proof[0] ^= 1
+		if _, err := pending.Finalize(&bad); err == nil {
+			t.Error("Finalize() err = nil, want error")
+		}
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		if err := new(privacypass.Token).UnmarshalBinary(make([]byte, privacypass.TokenSize-1)); !errors.Is(err, privacypass.ErrInvalidEncoding) {
+			t.Errorf("UnmarshalBinary() err = %v, want %v", err, privacypass.ErrInvalidEncoding)
+		}
+		bad := *req
+		bad.BlindedElement = [32]byte{0xff}
+		if _, err := issuer.Issue(&bad); !errors.Is(err, privacypass.ErrInvalidEncoding) {
+			t.Errorf("Issue() err = %v, want %v", err, privacypass.ErrInvalidEncoding)
+		}
+	})
+}
+
+type binaryCodec interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary([]byte) error
+}
+
+// roundTrip encodes v and decodes it into out, as if it had been sent over the wire.
+func roundTrip[T binaryCodec](t *testing.T, v, out T) T {
+	t.Helper()
+
+	b, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+// Package quorum implements quorum-gated encryption of secrets using Shamir secret sharing over Ristretto255 and
+// Thyrse.
+//
+// Seal encrypts a secret under a random key which is split into n shares, one for each custodian, such that any t of
+// them can reconstruct the key and unlock the secret, while any fewer learn nothing about it. The sealed secret
+// includes a commitment to each custodian's share, so Unlock identifies invalid or mismatched shares instead of failing
+// opaquely.
+//
+// Each share is a ShareSize-byte message suitable for distribution to its custodian. To unlock the secret, at least t
+// custodians return their shares, in any order, to the party holding the sealed secret.
+package quorum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/shamir"
+	"github.com/gtank/ristretto255"
+)
+
+const (
+	// ShareSize is the size, in bytes, of an encoded share.
+	ShareSize = 2 + vaultIDSize + 32
+
+	vaultIDSize = 16
+)
+
+var (
+	// ErrInvalidParameters is returned for invalid sealing parameters.
+	ErrInvalidParameters = errors.New("thyrse/quorum: invalid parameters")
+
+	// ErrInvalidSealed is returned when a sealed secret is malformed.
+	ErrInvalidSealed = errors.New("thyrse/quorum: invalid sealed secret")
+
+	// ErrInvalidShare is returned when a share is malformed, belongs to a different sealed secret, is duplicated, or
+	// does not match its commitment.
+	ErrInvalidShare = errors.New("thyrse/quorum: invalid share")
+
+	// ErrInsufficientShares is returned when fewer than the threshold number of shares are given.
+	ErrInsufficientShares = errors.New("thyrse/quorum: insufficient shares")
+)
+
+// Seal encrypts the secret such that any threshold of the returned shares can unlock it. It returns the sealed secret
+// and n shares, where shares[i] belongs to the custodian with identifier i+1.
+//
+// The threshold must be at least 2 and at most n, and n must be at most 65535. rand must contain at least 64 bytes of
+// uniform randomness.
+func Seal(domain string, n, threshold int, rand, secret []byte) (sealed []byte, shares [][]byte, err error) {
+	if threshold < 2 || n < threshold || n > shamir.MaxShares || len(rand) < 64 {
+		return nil, nil, ErrInvalidParameters
+	}
+
+	// Derive a vault ID and the polynomial coefficients from the randomness and the secret.
+	p := thyrse.New(domain)
+	dealer, _ := p.Fork("process", []byte("dealer"), []byte("unlock"))
+	dealer.Mix("rand", rand)
+	dealer.Mix("secret", secret)
+	vaultID := dealer.Derive("vault-id", nil, vaultIDSize)
+	coeffs := shamir.NewPolynomial(dealer, group.Ristretto255, threshold)
+
+	// Encode the header: the vault ID, the threshold and number of shares, and a commitment to each share.
+	header := slices.Clone(vaultID)
+	header = binary.BigEndian.AppendUint16(header, uint16(threshold))
+	header = binary.BigEndian.AppendUint16(header, uint16(n))
+	values, commitments := shamir.Deal(group.Ristretto255, coeffs, n)
+	shares = make([][]byte, n)
+	for i := range shares {
+		header = append(header, commitments[i].Bytes()...)
+		shares[i] = slices.Concat(binary.BigEndian.AppendUint16(nil, uint16(i+1)), vaultID, values[i].Bytes())
+	}
+
+	// Seal the secret with the polynomial's constant term as the key.
+	key := coeffs[0].(*group.RistrettoScalar).Ristretto255()
+	return newUnlock(domain, header, key).Seal("secret", header, secret), shares, nil
+}
+
+// Unlock reconstructs the key of a sealed secret from the given shares and decrypts it. Only the first threshold valid
+// shares are used, but every share is checked.
+//
+// Returns ErrInvalidShare if any share is invalid, ErrInsufficientShares if fewer than threshold shares are given, or
+// an error wrapping thyrse.ErrInvalidCiphertext if the sealed secret has been modified.
+func Unlock(domain string, sealed []byte, shares [][]byte) ([]byte, error) {
+	// Parse the header.
+	if len(sealed) < vaultIDSize+4 {
+		return nil, ErrInvalidSealed
+	}
+	vaultID := sealed[:vaultIDSize]
+	threshold := int(binary.BigEndian.Uint16(sealed[vaultIDSize:]))
+	n := int(binary.BigEndian.Uint16(sealed[vaultIDSize+2:]))
+	headerLen := vaultIDSize + 4 + n*32
+	if threshold < 2 || n < threshold || len(sealed) < headerLen+thyrse.TagSize {
+		return nil, ErrInvalidSealed
+	}
+	header, ciphertext := sealed[:headerLen], sealed[headerLen:]
+	commitments := header[vaultIDSize+4:]
+
+	// Check each share against its commitment.
+	ids := make([]uint16, 0, len(shares))
+	values := make([]*ristretto255.Scalar, 0, len(shares))
+	for _, share := range shares {
+		if len(share) != ShareSize || !bytes.Equal(share[2:2+vaultIDSize], vaultID) {
+			return nil, ErrInvalidShare
+		}
+		id := binary.BigEndian.Uint16(share)
+		if id == 0 || int(id) > n || slices.Contains(ids, id) {
+			return nil, ErrInvalidShare
+		}
+		value, _ := ristretto255.NewScalar().SetCanonicalBytes(share[2+vaultIDSize:])
+		if value == nil {
+			return nil, ErrInvalidShare
+		}
+		commitment := commitments[(int(id)-1)*32 : int(id)*32]
+		if !bytes.Equal(ristretto255.NewIdentityElement().ScalarBaseMult(value).Bytes(), commitment) {
+			return nil, ErrInvalidShare
+		}
+		ids = append(ids, id)
+		values = append(values, value)
+	}
+	if len(ids) < threshold {
+		return nil, ErrInsufficientShares
+	}
+
+	// Interpolate the key from the first threshold shares and open the secret.
+	ids, values = ids[:threshold], values[:threshold]
+	key := ristretto255.NewScalar()
+	for i, id := range ids {
+		lambda := shamir.Lagrange(group.Ristretto255, id, ids).(*group.RistrettoScalar).Ristretto255()
+		key.Add(key, ristretto255.NewScalar().Multiply(lambda, values[i]))
+	}
+	return newUnlock(domain, header, key).Open("secret", nil, ciphertext)
+}
+
+// newUnlock returns a protocol keyed with the sealed secret's header and key.
+func newUnlock(domain string, header []byte, key *ristretto255.Scalar) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	_, unlock := p.Fork("process", []byte("dealer"), []byte("unlock"))
+	unlock.Mix("header", header)
+	unlock.Mix("key", key.Bytes())
+	return unlock
+}
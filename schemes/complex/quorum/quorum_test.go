@@ -0,0 +1,107 @@
+package quorum_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/quorum"
+)
+
+func TestUnlock(t *testing.T) {
+	drbg := testdata.New("thyrse quorum")
+	secret := []byte("root CA private key")
+
+	sealed, shares, err := quorum.Seal("domain", 5, 3, drbg.Data(64), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(shares), 5; got != want {
+		t.Fatalf("len(shares) = %d, want %d", got, want)
+	}
+	for _, share := range shares {
+		if got, want := len(share), quorum.ShareSize; got != want {
+			t.Errorf("len(share) = %d, want %d", got, want)
+		}
+	}
+
+	t.Run("any quorum", func(t *testing.T) {
+		for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+			quorumShares := make([][]byte, len(subset))
+			for i, j := range subset {
+				quorumShares[i] = shares[j]
+			}
+			got, err := quorum.Unlock("domain", sealed, quorumShares)
+			if err != nil {
+				t.Fatalf("Unlock(%v) err = %v", subset, err)
+			}
+			if want := secret; !bytes.Equal(got, want) {
+				t.Errorf("Unlock(%v) = %q, want %q", subset, got, want)
+			}
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		shares [][]byte
+		want   error
+	}{
+		"insufficient shares": {[][]byte{shares[0], shares[1]}, quorum.ErrInsufficientShares},
+		"duplicate share":     {[][]byte{shares[0], shares[1], shares[1]}, quorum.ErrInvalidShare},
+		"modified share":      {[][]byte{shares[0], shares[1], modify(shares[2], quorum.ShareSize-1)}, quorum.ErrInvalidShare},
+		"relabeled share":     {[][]byte{shares[0], shares[1], modify(shares[2], 1)}, quorum.ErrInvalidShare},
+		"truncated share":     {[][]byte{shares[0], shares[1], shares[2][:quorum.ShareSize-1]}, quorum.ErrInvalidShare},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := quorum.Unlock("domain", sealed, tc.shares); !errors.Is(err, tc.want) {
+				t.Errorf("Unlock() err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("shares from another secret", func(t *testing.T) {
+		_, other, err := quorum.Seal("domain", 5, 3, drbg.Data(64), secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := quorum.Unlock("domain", sealed, [][]byte{shares[0], shares[1], other[2]}); !errors.Is(err, quorum.ErrInvalidShare) {
+			t.Errorf("Unlock() err = %v, want %v", err, quorum.ErrInvalidShare)
+		}
+	})
+
+	t.Run("modified sealed secret", func(t *testing.T) {
+		bad := modify(sealed, len(sealed)-1)
+		if _, err := quorum.Unlock("domain", bad, shares[:3]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Unlock() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		if _, err := quorum.Unlock("other", sealed, shares[:3]); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("Unlock() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("malformed sealed secret", func(t *testing.T) {
+		if _, err := quorum.Unlock("domain", sealed[:20], shares[:3]); !errors.Is(err, quorum.ErrInvalidSealed) {
+			t.Errorf("Unlock() err = %v, want %v", err, quorum.ErrInvalidSealed)
+		}
+	})
+}
+
+func TestSeal(t *testing.T) {
+	drbg := testdata.New("thyrse quorum")
+	for _, tc := range []struct{ n, t, randLen int }{{3, 1, 64}, {2, 3, 64}, {3, 2, 32}, {1 << 16, 2, 64}} {
+		if _, _, err := quorum.Seal("domain", tc.n, tc.t, drbg.Data(tc.randLen), nil); !errors.Is(err, quorum.ErrInvalidParameters) {
+			t.Errorf("Seal(n=%d, t=%d) err = %v, want %v", tc.n, tc.t, err, quorum.ErrInvalidParameters)
+		}
+	}
+}
+
+func modify(b []byte, i int) []byte {
+	b = slices.Clone(b)
+	b[i] ^= 1
+	return b
+}
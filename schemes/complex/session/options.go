@@ -0,0 +1,38 @@
+package session
+
+// An Option configures a Conn. Both ends of a connection MUST use the same options.
+type Option func(*config)
+
+// WithMaxRecordSize sets the maximum size of a record's payload, in bytes. Writes larger than this are broken up into
+// records of this size, and received records with larger payloads are rejected with thyrse.ErrTooLarge. Panics if size
+// is not between 1 and MaxRecordSize.
+func WithMaxRecordSize(size int) Option {
+	if size < 1 || size > MaxRecordSize {
+		panic("thyrse/session: invalid max record size")
+	}
+
+	return func(c *config) {
+		c.maxRecordSize = size
+	}
+}
+
+// WithRekeyBytes sets the number of payload bytes after which each direction of the connection is ratcheted (see
+// [thyrse.Protocol.SetRekeyPolicy]). Zero disables byte-based rekeying, which is the default.
+func WithRekeyBytes(n uint64) Option {
+	return func(c *config) {
+		c.rekeyBytes = n
+	}
+}
+
+type config struct {
+	maxRecordSize int
+	rekeyBytes    uint64
+}
+
+func newConfig(opts []Option) config {
+	c := config{maxRecordSize: DefaultMaxRecordSize}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
@@ -0,0 +1,165 @@
+// Package session implements a secure channel over a net.Conn using the transport protocols of a completed handshake.
+//
+// Data written to a Conn is broken up into records. Each record is written as a 2-byte big endian length followed by
+// the payload sealed with [thyrse.Protocol.Seal] under the send protocol, and read back by opening it with the peer's
+// receive protocol. Seal chains the protocol to a state derived one-way from its transcript, so every record is sealed
+// under a fresh key, and compromising a Conn's state does not reveal earlier records. Each direction can also be
+// ratcheted after a number of bytes with WithRekeyBytes.
+//
+// Closing a Conn writes a record with an empty payload, which the peer's Read reports as io.EOF. If the connection ends
+// without one, Read returns thyrse.ErrTruncated, so a truncation attack cannot pass for the end of the stream.
+package session
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/codahale/thyrse"
+)
+
+const (
+	// MaxRecordSize is the largest record payload, in bytes.
+	MaxRecordSize = 1<<16 - 1 - thyrse.TagSize
+
+	// DefaultMaxRecordSize is the maximum record payload size used unless WithMaxRecordSize is given, in bytes.
+	DefaultMaxRecordSize = 16 << 10
+)
+
+// A Conn is a secure channel over a net.Conn. Read and Write may be called concurrently with each other, but not with
+// themselves.
+type Conn struct {
+	conn net.Conn
+	cfg  config
+
+	wmu  sync.Mutex
+	send *thyrse.Protocol
+	wbuf []byte
+	werr error
+
+	rmu     sync.Mutex
+	recv    *thyrse.Protocol
+	rbuf    []byte
+	pending []byte
+	rerr    error
+}
+
+var _ io.ReadWriteCloser = (*Conn)(nil)
+
+// New returns a Conn which sends records over conn sealed with send, and receives records opened with recv, e.g. the
+// protocols returned by the handshake package's Transport method. The Conn takes ownership of the protocols, which MUST
+// NOT be used elsewhere.
+func New(conn net.Conn, send, recv *thyrse.Protocol, opts ...Option) *Conn {
+	cfg := newConfig(opts)
+	send.SetRekeyPolicy(thyrse.RekeyPolicy{Bytes: cfg.rekeyBytes})
+	recv.SetRekeyPolicy(thyrse.RekeyPolicy{Bytes: cfg.rekeyBytes})
+	return &Conn{conn: conn, cfg: cfg, send: send, recv: recv}
+}
+
+// Write seals p into one or more records and writes them to the underlying connection. After an error, all writes
+// return it.
+func (c *Conn) Write(p []byte) (n int, err error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	for len(p) > 0 {
+		record := p[:min(len(p), c.cfg.maxRecordSize)]
+		if err := c.writeRecord(record); err != nil {
+			return n, err
+		}
+		n += len(record)
+		p = p[len(record):]
+	}
+	return n, nil
+}
+
+// Close writes the closing record and closes the underlying connection.
+func (c *Conn) Close() error {
+	c.wmu.Lock()
+	err := c.writeRecord(nil)
+	c.werr = net.ErrClosed
+	c.wmu.Unlock()
+
+	return errors.Join(err, c.conn.Close())
+}
+
+func (c *Conn) writeRecord(payload []byte) error {
+	if c.werr != nil {
+		return c.werr
+	}
+
+	c.wbuf = binary.BigEndian.AppendUint16(c.wbuf[:0], uint16(len(payload)+thyrse.TagSize))
+	c.wbuf = c.send.Seal("record", c.wbuf, payload)
+	if _, err := c.conn.Write(c.wbuf); err != nil {
+		c.werr = err
+		return err
+	}
+	return nil
+}
+
+// Read reads and opens records from the underlying connection, and copies their payloads into p.
+//
+// Returns io.EOF once the peer's closing record has been read, thyrse.ErrTruncated if the connection ends before it,
+// thyrse.ErrTooLarge if a record is larger than the maximum record size, or the error from [thyrse.Protocol.Open] if a
+// record fails to open. After any such error, the Conn's receive transcript has diverged from the peer's, and
+// subsequent reads return thyrse.ErrDesynchronized.
+func (c *Conn) Read(p []byte) (n int, err error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	for len(c.pending) == 0 {
+		if c.rerr != nil {
+			return 0, c.rerr
+		}
+
+		c.pending, err = c.readRecord()
+		if err != nil {
+			c.rerr = thyrse.ErrDesynchronized
+			return 0, err
+		}
+		if len(c.pending) == 0 {
+			c.rerr = io.EOF
+		}
+	}
+
+	n = copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// readRecord reads and opens a record, returning its payload.
+func (c *Conn) readRecord() ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		return nil, readErr(err)
+	}
+
+	size := int(binary.BigEndian.Uint16(header[:]))
+	switch {
+	case size < thyrse.TagSize:
+		return nil, thyrse.ErrTruncated
+	case size-thyrse.TagSize > c.cfg.maxRecordSize:
+		return nil, thyrse.ErrTooLarge
+	}
+
+	if cap(c.rbuf) < size {
+		c.rbuf = make([]byte, size)
+	}
+	sealed := c.rbuf[:size]
+	if _, err := io.ReadFull(c.conn, sealed); err != nil {
+		return nil, readErr(err)
+	}
+
+	// Open the record in place; the payload is consumed before the buffer is reused.
+	return c.recv.Open("record", sealed[:0], sealed)
+}
+
+// readErr maps a premature end of the underlying connection to thyrse.ErrTruncated.
+func readErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return thyrse.ErrTruncated
+	}
+	return err
+}
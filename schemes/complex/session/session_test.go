@@ -0,0 +1,178 @@
+package session_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/handshake"
+	"github.com/codahale/thyrse/schemes/complex/hpke"
+	"github.com/codahale/thyrse/schemes/complex/session"
+)
+
+// newTransport returns the transport protocols of a completed NN handshake.
+func newTransport(t *testing.T) (iSend, iRecv, rSend, rRecv *thyrse.Protocol) {
+	t.Helper()
+
+	drbg := testdata.New("thyrse session")
+	initiator, err := handshake.New("session", handshake.Config{KEM: hpke.X25519, Pattern: handshake.NN, Initiator: true},
+		drbg.Data(hpke.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := handshake.New("session", handshake.Config{KEM: hpke.X25519, Pattern: handshake.NN},
+		drbg.Data(hpke.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NN is two messages: initiator to responder, then responder to initiator.
+	for _, pair := range [][2]*handshake.Handshake{{initiator, responder}, {responder, initiator}} {
+		msg, err := pair[0].WriteMessage(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pair[1].ReadMessage(nil, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	iSend, iRecv = initiator.Transport()
+	rSend, rRecv = responder.Transport()
+	return iSend, iRecv, rSend, rRecv
+}
+
+// newPair returns a pair of Conns connected by an in-memory pipe.
+func newPair(t *testing.T, opts ...session.Option) (*session.Conn, *session.Conn) {
+	t.Helper()
+
+	iSend, iRecv, rSend, rRecv := newTransport(t)
+	a, b := net.Pipe()
+	return session.New(a, iSend, iRecv, opts...), session.New(b, rSend, rRecv, opts...)
+}
+
+func TestConn(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		initiator, responder := newPair(t, session.WithMaxRecordSize(100), session.WithRekeyBytes(250))
+		message := testdata.New("thyrse session message").Data(1000)
+
+		go func() {
+			_, _ = initiator.Write(message)
+			_ = initiator.Close()
+		}()
+
+		got, err := io.ReadAll(responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("Read() = %x, want = %x", got, message)
+		}
+	})
+
+	t.Run("both directions", func(t *testing.T) {
+		initiator, responder := newPair(t)
+
+		go func() {
+			buf := make([]byte, 5)
+			_, _ = io.ReadFull(responder, buf)
+			_, _ = responder.Write(bytes.ToUpper(buf))
+		}()
+
+		if _, err := initiator.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(initiator, buf); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(buf), "HELLO"; got != want {
+			t.Errorf("Read() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		iSend, _, _, rRecv := newTransport(t)
+		a, b := net.Pipe()
+		initiator, responder := session.New(a, iSend, thyrse.New("unused")), session.New(b, thyrse.New("unused"), rRecv)
+
+		go func() {
+			_, _ = initiator.Write([]byte("hello"))
+			_ = a.Close()
+		}()
+
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(responder, buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := responder.Read(buf); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrTruncated)
+		}
+	})
+
+	t.Run("modified record", func(t *testing.T) {
+		iSend, _, _, rRecv := newTransport(t)
+		var buf bytes.Buffer
+		_, _ = session.New(&bufConn{Buffer: &buf}, iSend, thyrse.New("unused")).Write([]byte("hello"))
+		buf.Bytes()[3] ^= 1
+
+		responder := session.New(&bufConn{Buffer: &buf}, thyrse.New("unused"), rRecv)
+		if _, err := responder.Read(make([]byte, 5)); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrTagMismatch)
+		}
+		if _, err := responder.Read(make([]byte, 5)); !errors.Is(err, thyrse.ErrDesynchronized) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrDesynchronized)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		iSend, _, _, rRecv := newTransport(t)
+		var buf bytes.Buffer
+		_, _ = session.New(&bufConn{Buffer: &buf}, iSend, thyrse.New("unused")).Write(make([]byte, 200))
+
+		responder := session.New(&bufConn{Buffer: &buf}, thyrse.New("unused"), rRecv, session.WithMaxRecordSize(100))
+		if _, err := responder.Read(make([]byte, 200)); !errors.Is(err, thyrse.ErrTooLarge) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrTooLarge)
+		}
+	})
+
+	t.Run("mismatched rekeying", func(t *testing.T) {
+		iSend, _, _, rRecv := newTransport(t)
+		var buf bytes.Buffer
+		initiator := session.New(&bufConn{Buffer: &buf}, iSend, thyrse.New("unused"), session.WithRekeyBytes(5))
+		_, _ = initiator.Write([]byte("hello"))
+		_, _ = initiator.Write([]byte("world"))
+
+		responder := session.New(&bufConn{Buffer: &buf}, thyrse.New("unused"), rRecv)
+		_, _ = responder.Read(make([]byte, 5))
+		if _, err := responder.Read(make([]byte, 5)); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("Read() err = %v, want = %v", err, thyrse.ErrTagMismatch)
+		}
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		initiator, responder := newPair(t)
+		go func() { _, _ = io.ReadAll(responder) }()
+
+		if err := initiator.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := initiator.Write([]byte("hello")); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Write() err = %v, want = %v", err, net.ErrClosed)
+		}
+	})
+}
+
+// bufConn is a net.Conn which reads from and writes to a buffer.
+type bufConn struct {
+	net.Conn
+	*bytes.Buffer
+}
+
+func (c *bufConn) Read(p []byte) (int, error)  { return c.Buffer.Read(p) }
+func (c *bufConn) Write(p []byte) (int, error) { return c.Buffer.Write(p) }
+func (c *bufConn) Close() error                { return nil }
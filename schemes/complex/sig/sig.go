@@ -1,4 +1,6 @@
 // Package sig implements an EdDSA-style Schnorr digital signature scheme using Ristretto255 and Thyrse.
+//
+// The package-level functions use Ristretto255. ForGroup returns the same scheme over any prime-order group.
 package sig
 
 import (
@@ -6,49 +8,78 @@ import (
 	"io"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
 	"github.com/gtank/ristretto255"
 )
 
-// Size is the length of a signature in bytes.
-const Size = 64
+const (
+	// Size is the length of a Ristretto255 signature in bytes.
+	Size = 64
+
+	// DigestSize is the length of a message digest produced by Digest, in bytes.
+	DigestSize = 64
+)
 
 // Sign uses the given Ristretto255 private key and an optional slice of random data to generate a strongly unforgeable
 // digital signature of the reader's contents.
 //
 // Returns any error from the underlying reader.
 func Sign(domain string, d *ristretto255.Scalar, rand []byte, message io.Reader) ([]byte, error) {
-	// Initialize the protocol and mix in the signer's public key and the message.
+	return ristretto.Sign(domain, group.Ristretto255Scalar(d), rand, message)
+}
+
+// Digest returns the DigestSize-byte digest of the reader's contents for use with SignDigest and VerifyDigest.
+//
+// The digest is computed as follows:
+//
+//	p := thyrse.New(domain)
+//	p.Mix("message", message)
+//	digest := p.Derive("digest", nil, DigestSize)
+//
+// Returns any error from the underlying reader.
+func Digest(domain string, message io.Reader) ([]byte, error) {
 	p := thyrse.New(domain)
-	p.Mix("signer", ristretto255.NewIdentityElement().ScalarBaseMult(d).Bytes())
 	msg, err := io.ReadAll(message)
 	if err != nil {
 		return nil, err
 	}
 	p.Mix("message", msg)
+	return p.Derive("digest", nil, DigestSize), nil
+}
 
-	// Fork the protocol into prover/verifier roles and mix both the signer's private key and the provided random data
-	// (if any) into the prover.
-	prover, verifier := p.Fork("role", []byte("prover"), []byte("verifier"))
-	prover.Mix("signer-private", d.Bytes())
-	prover.Mix("hedged-rand", rand)
-
-	// Use the prover to derive a commitment scalar and commitment point which is guaranteed to be unique for the
-	// combination of signer and message. This eliminates the risk of private key recovery via nonce reuse, and the
-	// user-provided random data hedges the deterministic scheme against fault attacks.
-	k, _ := ristretto255.NewScalar().SetUniformBytes(prover.Derive("commitment", nil, 64))
-	r := ristretto255.NewIdentityElement().ScalarBaseMult(k)
-	rOut := r.Bytes()
+// SignDigest uses the given Ristretto255 private key and an optional slice of random data to generate a strongly
+// unforgeable digital signature of a message digest produced by Digest. This allows the digest to be computed by a
+// different party than the signer (e.g. a host computing the digest of a large message for a smart card).
+//
+// Digest signatures are domain-separated from message signatures: a signature produced by SignDigest will not verify
+// with Verify, and vice versa. Panics if the digest is not DigestSize bytes long.
+func SignDigest(domain string, d *ristretto255.Scalar, rand, digest []byte) []byte {
+	return ristretto.SignDigest(domain, group.Ristretto255Scalar(d), rand, digest)
+}
 
-	// Mix the commitment point into the verifier.
-	verifier.Mix("commitment", rOut)
+// VerifyDigest uses the given Ristretto255 public key and signature to verify a message digest produced by Digest.
+// Returns true if and only if the signature was made of the digest by the holder of the signer's private key with
+// SignDigest.
+func VerifyDigest(domain string, q *ristretto255.Element, sig, digest []byte) bool {
+	return ristretto.VerifyDigest(domain, group.Ristretto255Element(q), sig, digest)
+}
 
-	// Derive a challenge scalar from the verifier.
-	c, _ := ristretto255.NewScalar().SetUniformBytes(verifier.Derive("challenge", nil, 64))
+// SignPrehashed uses the given Ristretto255 private key and an optional slice of random data to generate a strongly
+// unforgeable digital signature of a digest computed by the caller with an arbitrary hash function (e.g. a Merkle root),
+// bound to the given context string. This allows signing messages which were hashed upstream and cannot be read again.
+//
+// The context string identifies the hash function and the purpose of the signature (e.g. "sha256 merkle root"), so a
+// signature of one kind of digest cannot be passed off as a signature of another. Prehashed signatures are
+// domain-separated from message and digest signatures.
+func SignPrehashed(domain string, d *ristretto255.Scalar, rand []byte, context string, digest []byte) []byte {
+	return ristretto.SignPrehashed(domain, group.Ristretto255Scalar(d), rand, context, digest)
+}
 
-	// Calculate the proof scalar s = k + d*c.
-	s := ristretto255.NewScalar().Multiply(d, c)
-	s = s.Add(s, k)
-	return append(rOut, s.Bytes()...), nil
+// VerifyPrehashed uses the given Ristretto255 public key and signature to verify a digest with the given context
+// string. Returns true if and only if the signature was made of the digest and context by the holder of the signer's
+// private key with SignPrehashed.
+func VerifyPrehashed(domain string, q *ristretto255.Element, sig []byte, context string, digest []byte) bool {
+	return ristretto.VerifyPrehashed(domain, group.Ristretto255Element(q), sig, context, digest)
 }
 
 // Verify uses the given Ristretto255 public key and signature to verify the contents of the given reader. Returns true
@@ -56,8 +87,52 @@ func Sign(domain string, d *ristretto255.Scalar, rand []byte, message io.Reader)
 //
 // Returns any error from the underlying reader.
 func Verify(domain string, q *ristretto255.Element, sig []byte, message io.Reader) (bool, error) {
-	// Valid signatures consist of a 32-byte point and a 32-byte scalar.
-	if len(sig) != Size {
+	return ristretto.Verify(domain, group.Ristretto255Element(q), sig, message)
+}
+
+// ristretto is the scheme over Ristretto255 used by the package-level functions.
+var ristretto = ForGroup(group.Ristretto255)
+
+// A Scheme is the signature scheme over a prime-order group. Its methods behave like the package-level functions of
+// the same names, with the group's scalars and elements as keys. Signatures are the encoded commitment element followed
+// by the encoded proof scalar. Over Ristretto255, signatures are identical to those of the package-level functions.
+type Scheme struct {
+	g group.Group
+}
+
+// ForGroup returns the signature scheme over the given group.
+func ForGroup(g group.Group) Scheme {
+	return Scheme{g: g}
+}
+
+// Size returns the length of a signature in bytes.
+func (sc Scheme) Size() int {
+	return sc.g.ElementSize() + sc.g.ScalarSize()
+}
+
+// Sign uses the given private key and an optional slice of random data to generate a strongly unforgeable digital
+// signature of the reader's contents.
+//
+// Returns any error from the underlying reader.
+func (sc Scheme) Sign(domain string, d group.Scalar, rand []byte, message io.Reader) ([]byte, error) {
+	// Initialize the protocol and mix in the signer's public key and the message.
+	p := thyrse.New(domain)
+	p.Mix("signer", sc.g.NewElement().ScalarBaseMult(d).Bytes())
+	msg, err := io.ReadAll(message)
+	if err != nil {
+		return nil, err
+	}
+	p.Mix("message", msg)
+
+	return sc.sign(p, d, rand), nil
+}
+
+// Verify uses the given public key and signature to verify the contents of the given reader. Returns true if and only
+// if the signature was made of the message by the holder of the signer's private key.
+//
+// Returns any error from the underlying reader.
+func (sc Scheme) Verify(domain string, q group.Element, sig []byte, message io.Reader) (bool, error) {
+	if len(sig) != sc.Size() {
 		return false, nil
 	}
 
@@ -70,25 +145,107 @@ func Verify(domain string, q *ristretto255.Element, sig []byte, message io.Reade
 	}
 	p.Mix("message", msg)
 
+	return sc.verify(p, q, sig), nil
+}
+
+// SignDigest uses the given private key and an optional slice of random data to generate a strongly unforgeable
+// digital signature of a message digest produced by Digest. Panics if the digest is not DigestSize bytes long.
+func (sc Scheme) SignDigest(domain string, d group.Scalar, rand, digest []byte) []byte {
+	if len(digest) != DigestSize {
+		panic("thyrse/sig: invalid digest size")
+	}
+
+	p := thyrse.New(domain)
+	p.Mix("signer", sc.g.NewElement().ScalarBaseMult(d).Bytes())
+	p.Mix("digest", digest)
+	return sc.sign(p, d, rand)
+}
+
+// VerifyDigest uses the given public key and signature to verify a message digest produced by Digest.
+func (sc Scheme) VerifyDigest(domain string, q group.Element, sig, digest []byte) bool {
+	if len(sig) != sc.Size() || len(digest) != DigestSize {
+		return false
+	}
+
+	p := thyrse.New(domain)
+	p.Mix("signer", q.Bytes())
+	p.Mix("digest", digest)
+	return sc.verify(p, q, sig)
+}
+
+// SignPrehashed uses the given private key and an optional slice of random data to generate a strongly unforgeable
+// digital signature of a digest computed by the caller, bound to the given context string.
+func (sc Scheme) SignPrehashed(domain string, d group.Scalar, rand []byte, context string, digest []byte) []byte {
+	p := thyrse.New(domain)
+	p.Mix("signer", sc.g.NewElement().ScalarBaseMult(d).Bytes())
+	p.Mix("context", []byte(context))
+	p.Mix("prehashed", digest)
+	return sc.sign(p, d, rand)
+}
+
+// VerifyPrehashed uses the given public key and signature to verify a digest with the given context string.
+func (sc Scheme) VerifyPrehashed(domain string, q group.Element, sig []byte, context string, digest []byte) bool {
+	if len(sig) != sc.Size() {
+		return false
+	}
+
+	p := thyrse.New(domain)
+	p.Mix("signer", q.Bytes())
+	p.Mix("context", []byte(context))
+	p.Mix("prehashed", digest)
+	return sc.verify(p, q, sig)
+}
+
+// sign generates a signature over the transcript with the given private key and optional random data.
+func (sc Scheme) sign(p *thyrse.Protocol, d group.Scalar, rand []byte) []byte {
+	// Fork the protocol into prover/verifier roles and mix both the signer's private key and the provided random data
+	// (if any) into the prover.
+	prover, verifier := p.Fork("role", []byte("prover"), []byte("verifier"))
+	prover.Mix("signer-private", d.Bytes())
+	prover.Mix("hedged-rand", rand)
+
+	// Use the prover to derive a commitment scalar and commitment point which is guaranteed to be unique for the
+	// combination of signer and message. This eliminates the risk of private key recovery via nonce reuse, and the
+	// user-provided random data hedges the deterministic scheme against fault attacks.
+	k := group.DeriveScalar(prover, sc.g, "commitment")
+	r := sc.g.NewElement().ScalarBaseMult(k)
+	rOut := r.Bytes()
+
+	// Mix the commitment point into the verifier.
+	verifier.Mix("commitment", rOut)
+
+	// Derive a challenge scalar from the verifier.
+	c := group.DeriveScalar(verifier, sc.g, "challenge")
+
+	// Calculate the proof scalar s = k + d*c.
+	s := sc.g.NewScalar().Multiply(d, c)
+	s = s.Add(s, k)
+	return append(rOut, s.Bytes()...)
+}
+
+// verify checks a signature over the transcript with the given public key. The signature must be Size bytes long.
+func (sc Scheme) verify(p *thyrse.Protocol, q group.Element, sig []byte) bool {
+	n := sc.g.ElementSize()
+
 	// Fork the protocol, keeping only the verifier.
 	_, verifier := p.Fork("role", []byte("prover"), []byte("verifier"))
 
 	// Mix the received commitment point into the verifier. As we do not use it for calculations, leave it encoded.
-	verifier.Mix("commitment", sig[:32])
+	verifier.Mix("commitment", sig[:n])
 
 	// Derive an expected challenge scalar from the signer's public key, the message, and the commitment point.
-	c, _ := ristretto255.NewScalar().SetUniformBytes(verifier.Derive("challenge", nil, 64))
+	c := group.DeriveScalar(verifier, sc.g, "challenge")
 
 	// Decode the proof scalar. If not canonically encoded, the signature is invalid.
-	s, _ := ristretto255.NewScalar().SetCanonicalBytes(sig[32:])
-	if s == nil {
-		return false, nil
+	s, err := sc.g.NewScalar().SetCanonicalBytes(sig[n:])
+	if err != nil {
+		return false
 	}
 
 	// Calculate the expected commitment point: R' = [s]G + [-c']Q
-	expectedR := ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(ristretto255.NewScalar().Negate(c), q, s)
+	expectedR := sc.g.NewElement().VarTimeDoubleScalarBaseMult(sc.g.NewScalar().Negate(c), q, s)
 
 	// If the received and expected commitment points are equal (as compared in their encoded forms), the signature is
 	// valid.
-	return bytes.Equal(sig[:32], expectedR.Bytes()), nil
+	return bytes.Equal(sig[:n], expectedR.Bytes())
 }
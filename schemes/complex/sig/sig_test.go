@@ -2,11 +2,13 @@ package sig_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"slices"
 	"strings"
 	"testing"
 
+	"github.com/codahale/thyrse/group"
 	"github.com/codahale/thyrse/internal/testdata"
 	"github.com/codahale/thyrse/schemes/complex/sig"
 )
@@ -178,3 +180,178 @@ func FuzzVerify(f *testing.F) {
 		}
 	})
 }
+
+func TestDigest(t *testing.T) {
+	t.Run("known answer", func(t *testing.T) {
+		digest, err := sig.Digest("sig", strings.NewReader("this is a message"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := hex.EncodeToString(digest), "1a664db65ab836fc210038dde69db376ba3b33d908b35b03d945e06dc1ccb96b94a594bca866954287b94801af8424191950dbf52914b2cf588d2c8140a58d33"; got != want {
+			t.Errorf("Digest() = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("reader failure", func(t *testing.T) {
+		_, err := sig.Digest("sig", &testdata.ErrReader{Err: errors.New("broken")})
+		if err == nil {
+			t.Error("Digest() err = nil, want error")
+		}
+	})
+}
+
+func TestSignDigest(t *testing.T) {
+	drbg := testdata.New("thyrse digital signature")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+
+	digest, err := sig.Digest("sig", strings.NewReader("this is a message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := sig.SignDigest("sig", d, nil, digest)
+
+	t.Run("known answer", func(t *testing.T) {
+		if got, want := hex.EncodeToString(signature), "f28141be548b45c1dfd99c457752da3f9a3baf352d464481100af4024a30cb2234bf4810f367762e6f95ff417c608ca6922c6193b2ee1d62bd3ce269df9eed02"; got != want {
+			t.Errorf("SignDigest() = %s, want = %s", got, want)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		if !sig.VerifyDigest("sig", q, signature, digest) {
+			t.Error("VerifyDigest() = false, want true")
+		}
+	})
+
+	t.Run("wrong signer", func(t *testing.T) {
+		if sig.VerifyDigest("sig", qX, signature, digest) {
+			t.Error("VerifyDigest() = true, want false")
+		}
+	})
+
+	t.Run("wrong digest", func(t *testing.T) {
+		bad := slices.Clone(digest)
+		bad[0] ^= 1
+		if sig.VerifyDigest("sig", q, signature, bad) {
+			t.Error("VerifyDigest() = true, want false")
+		}
+	})
+
+	t.Run("short digest", func(t *testing.T) {
+		if sig.VerifyDigest("sig", q, signature, digest[:sig.DigestSize-1]) {
+			t.Error("VerifyDigest() = true, want false")
+		}
+	})
+
+	t.Run("not a message signature", func(t *testing.T) {
+		valid, err := sig.Verify("sig", q, signature, strings.NewReader("this is a message"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if valid {
+			t.Error("Verify() = true for a digest signature, want false")
+		}
+	})
+}
+
+func TestSignPrehashed(t *testing.T) {
+	drbg := testdata.New("thyrse digital signature")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+
+	digest := drbg.Data(32)
+	signature := sig.SignPrehashed("sig", d, nil, "merkle root", digest)
+
+	t.Run("valid", func(t *testing.T) {
+		if !sig.VerifyPrehashed("sig", q, signature, "merkle root", digest) {
+			t.Error("VerifyPrehashed() = false, want true")
+		}
+	})
+
+	t.Run("wrong signer", func(t *testing.T) {
+		if sig.VerifyPrehashed("sig", qX, signature, "merkle root", digest) {
+			t.Error("VerifyPrehashed() = true, want false")
+		}
+	})
+
+	t.Run("wrong context", func(t *testing.T) {
+		if sig.VerifyPrehashed("sig", q, signature, "file hash", digest) {
+			t.Error("VerifyPrehashed() = true, want false")
+		}
+	})
+
+	t.Run("wrong digest", func(t *testing.T) {
+		bad := slices.Clone(digest)
+		bad[0] ^= 1
+		if sig.VerifyPrehashed("sig", q, signature, "merkle root", bad) {
+			t.Error("VerifyPrehashed() = true, want false")
+		}
+	})
+
+	t.Run("short signature", func(t *testing.T) {
+		if sig.VerifyPrehashed("sig", q, signature[:sig.Size-1], "merkle root", digest) {
+			t.Error("VerifyPrehashed() = true, want false")
+		}
+	})
+
+	t.Run("not a digest signature", func(t *testing.T) {
+		digest := drbg.Data(sig.DigestSize)
+		signature := sig.SignPrehashed("sig", d, nil, "", digest)
+		if sig.VerifyDigest("sig", q, signature, digest) {
+			t.Error("VerifyDigest() = true for a prehashed signature, want false")
+		}
+	})
+}
+
+func TestForGroup(t *testing.T) {
+	drbg := testdata.New("thyrse digital signature group")
+	d, q := drbg.KeyPair()
+	sc := sig.ForGroup(group.Ristretto255)
+	gd, gq := group.Ristretto255Scalar(d), group.Ristretto255Element(q)
+	rand := drbg.Data(64)
+
+	if got, want := sc.Size(), sig.Size; got != want {
+		t.Errorf("Size() = %d, want %d", got, want)
+	}
+
+	t.Run("message", func(t *testing.T) {
+		got, err := sc.Sign("sig", gd, rand, strings.NewReader("message"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := sig.Sign("sig", d, rand, strings.NewReader("message"))
+		if !bytes.Equal(got, want) {
+			t.Errorf("Sign() = %x, want %x", got, want)
+		}
+
+		if ok, err := sc.Verify("sig", gq, got, strings.NewReader("message")); err != nil || !ok {
+			t.Errorf("Verify() = %v, %v, want true", ok, err)
+		}
+		if ok, _ := sc.Verify("sig", gq, got, strings.NewReader("other")); ok {
+			t.Error("Verify(wrong message) = true, want false")
+		}
+	})
+
+	t.Run("digest", func(t *testing.T) {
+		digest := drbg.Data(sig.DigestSize)
+		got := sc.SignDigest("sig", gd, rand, digest)
+		if want := sig.SignDigest("sig", d, rand, digest); !bytes.Equal(got, want) {
+			t.Errorf("SignDigest() = %x, want %x", got, want)
+		}
+		if !sc.VerifyDigest("sig", gq, got, digest) {
+			t.Error("VerifyDigest() = false, want true")
+		}
+	})
+
+	t.Run("prehashed", func(t *testing.T) {
+		digest := drbg.Data(32)
+		got := sc.SignPrehashed("sig", gd, rand, "sha256", digest)
+		if want := sig.SignPrehashed("sig", d, rand, "sha256", digest); !bytes.Equal(got, want) {
+			t.Errorf("SignPrehashed() = %x, want %x", got, want)
+		}
+		if !sc.VerifyPrehashed("sig", gq, got, "sha256", digest) {
+			t.Error("VerifyPrehashed() = false, want true")
+		}
+	})
+}
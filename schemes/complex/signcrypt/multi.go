@@ -0,0 +1,194 @@
+package signcrypt
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/hazmat/secmem"
+	"github.com/gtank/ristretto255"
+)
+
+// SlotSize is the length, in bytes, of each recipient key slot in a multi-recipient ciphertext.
+const SlotSize = 32 + thyrse.TagSize
+
+// MultiOverhead returns the length, in bytes, of the additional data added to a plaintext to produce a multi-recipient
+// signcrypted ciphertext with the given number of key slots.
+func MultiOverhead(slots int) int {
+	return ristretto.MultiOverhead(slots)
+}
+
+// SealMulti encrypts and signs the message for multiple recipients while hiding which recipients, and how many, a
+// message has. Only the owners of the recipients' private keys can decrypt it, and only the owner of the sender's
+// private key could have sent it.
+//
+// The ciphertext contains exactly slots key slots, one for each recipient and the rest filled with pseudorandom
+// padding. Slots are indistinguishable from one another and are stored in a random order, so neither outsiders nor
+// recipients can tell which public keys a slot belongs to or whether it is padding. Panics if slots is less than the
+// number of recipients or greater than 65535.
+func SealMulti(domain string, dS *ristretto255.Scalar, recipients []*ristretto255.Element, slots int, rand, message []byte) []byte {
+	qRs := make([]group.Element, len(recipients))
+	for i, qR := range recipients {
+		qRs[i] = group.Ristretto255Element(qR)
+	}
+	return ristretto.SealMulti(domain, group.Ristretto255Scalar(dS), qRs, slots, rand, message)
+}
+
+// OpenMulti decrypts and verifies a ciphertext produced by SealMulti. Returns either the confidential, authentic
+// plaintext or an error wrapping thyrse.ErrInvalidCiphertext. If the receiver has no slot in the ciphertext, returns
+// thyrse.ErrTagMismatch.
+func OpenMulti(domain string, dR *ristretto255.Scalar, qS *ristretto255.Element, ciphertext []byte) ([]byte, error) {
+	return ristretto.OpenMulti(domain, group.Ristretto255Scalar(dR), group.Ristretto255Element(qS), ciphertext)
+}
+
+// MultiOverhead returns the length, in bytes, of the additional data added to a plaintext to produce a multi-recipient
+// signcrypted ciphertext with the given number of key slots.
+func (sc Scheme) MultiOverhead(slots int) int {
+	return sc.g.ElementSize() + 2 + slots*SlotSize + sc.g.ElementSize() + sc.g.ScalarSize()
+}
+
+// SealMulti encrypts and signs the message for multiple recipients while hiding which recipients, and how many, a
+// message has. Only the owners of the recipients' private keys can decrypt it, and only the owner of the sender's
+// private key could have sent it.
+//
+// The ciphertext contains exactly slots key slots, one for each recipient and the rest filled with pseudorandom
+// padding. Slots are indistinguishable from one another and are stored in a random order, so neither outsiders nor
+// recipients can tell which public keys a slot belongs to or whether it is padding. Panics if slots is less than the
+// number of recipients or greater than 65535.
+func (sc Scheme) SealMulti(domain string, dS group.Scalar, recipients []group.Element, slots int, rand, message []byte) []byte {
+	if slots < len(recipients) || slots > 0xffff {
+		panic("thyrse/signcrypt: invalid number of slots")
+	}
+	g := sc.g
+
+	// Initialize the protocol and mix in the sender's public key.
+	qS := g.NewElement().ScalarBaseMult(dS)
+	p := thyrse.New(domain)
+	p.Mix("sender", qS.Bytes())
+
+	// Fork the protocol into sender and receiver roles.
+	sender, receiver := p.Fork("role", []byte("sender"), []byte("receivers"))
+
+	// Mix the sender's private key, the user-supplied randomness, the recipients, and the message into the sender. Use
+	// the sender to derive an ephemeral private key, a content key, and a commitment scalar which are unique to the
+	// inputs.
+	sender.Mix("sender-private", dS.Bytes())
+	sender.Mix("rand", rand)
+	for _, qR := range recipients {
+		sender.Mix("receiver", qR.Bytes())
+	}
+	sender.Mix("message", message)
+	dE := group.DeriveScalar(sender, g, "ephemeral-private")
+	qE := g.NewElement().ScalarBaseMult(dE)
+	contentKey := sender.Derive("content-key", nil, 32)
+	defer secmem.Wipe(contentKey)
+	k := group.DeriveScalar(sender, g, "commitment")
+	r := g.NewElement().ScalarBaseMult(k)
+
+	// Seal the content key in a slot for each recipient, fill the remaining slots with padding, and sort them. As the
+	// slots are pseudorandom, sorting them shuffles them.
+	keySlots := make([][]byte, 0, slots)
+	for _, qR := range recipients {
+		ecdh := g.NewElement().ScalarMult(dE, qR)
+		keySlots = append(keySlots, newSlot(domain, qE, qR, ecdh).Seal("content-key", nil, contentKey))
+	}
+	for len(keySlots) < slots {
+		keySlots = append(keySlots, sender.Derive("padding", nil, SlotSize))
+	}
+	slices.SortFunc(keySlots, bytes.Compare)
+
+	// Encode the ephemeral public key and slots.
+	out := slices.Concat(qE.Bytes(), binary.BigEndian.AppendUint16(nil, uint16(slots)))
+	out = append(out, slices.Concat(keySlots...)...)
+
+	// Mix the header and content key into the receiver and mask the message.
+	receiver.Mix("header", out)
+	receiver.Mix("content-key", contentKey)
+	out = receiver.Mask("message", out, message)
+
+	// Mask the commitment point, derive a challenge scalar, and mask the proof scalar s = k + d*c, as in Seal.
+	out = receiver.Mask("commitment", out, r.Bytes())
+	c := group.DeriveScalar(receiver, g, "challenge")
+	s := g.NewScalar().Multiply(dS, c)
+	s = s.Add(s, k)
+	return receiver.Mask("proof", out, s.Bytes())
+}
+
+// OpenMulti decrypts and verifies a ciphertext produced by SealMulti. Returns either the confidential, authentic
+// plaintext or an error wrapping thyrse.ErrInvalidCiphertext. If the receiver has no slot in the ciphertext, returns
+// thyrse.ErrTagMismatch.
+func (sc Scheme) OpenMulti(domain string, dR group.Scalar, qS group.Element, ciphertext []byte) ([]byte, error) {
+	g := sc.g
+	en, sn := g.ElementSize(), g.ScalarSize()
+	if len(ciphertext) < sc.MultiOverhead(0) {
+		return nil, thyrse.ErrTruncated
+	}
+	slots := int(binary.BigEndian.Uint16(ciphertext[en:]))
+	if len(ciphertext) < sc.MultiOverhead(slots) {
+		return nil, thyrse.ErrTruncated
+	}
+	headerLen := en + 2 + slots*SlotSize
+	header, body := ciphertext[:headerLen], ciphertext[headerLen:]
+
+	// Decode the ephemeral public key and calculate the ECDH shared secret.
+	qE, err := g.NewElement().SetCanonicalBytes(header[:en])
+	if err != nil {
+		return nil, thyrse.ErrInvalidCiphertext
+	}
+	qR := g.NewElement().ScalarBaseMult(dR)
+	ecdh := g.NewElement().ScalarMult(dR, qE)
+
+	// Try to open every slot, keeping the content key from the one which opens.
+	slot := newSlot(domain, qE, qR, ecdh)
+	var contentKey []byte
+	for keySlot := range slices.Chunk(header[en+2:], SlotSize) {
+		if key, err := slot.Clone().Open("content-key", nil, keySlot); err == nil {
+			contentKey = key
+		}
+	}
+	if contentKey == nil {
+		return nil, thyrse.ErrTagMismatch
+	}
+
+	// Initialize the protocol and mix in the sender's public key.
+	p := thyrse.New(domain)
+	p.Mix("sender", qS.Bytes())
+
+	// Fork the protocol into sender and receiver roles.
+	_, receiver := p.Fork("role", []byte("sender"), []byte("receivers"))
+
+	// Mix the header and content key into the receiver and unmask the message.
+	receiver.Mix("header", header)
+	receiver.Mix("content-key", contentKey)
+	secmem.Wipe(contentKey)
+	plaintext := receiver.Unmask("message", nil, body[:len(body)-en-sn])
+
+	// Unmask the commitment point, derive the expected challenge scalar, and unmask the proof scalar.
+	receivedR := receiver.Unmask("commitment", nil, body[len(body)-en-sn:len(body)-sn])
+	expectedC := group.DeriveScalar(receiver, g, "challenge")
+	s, err := g.NewScalar().SetCanonicalBytes(receiver.Unmask("proof", nil, body[len(body)-sn:]))
+	if err != nil {
+		return nil, thyrse.ErrInvalidCiphertext
+	}
+
+	// Calculate the expected commitment point: R' = [s]G + [-c']Q
+	expectedR := g.NewElement().ScalarBaseMult(s)
+	expectedR.Add(expectedR, g.NewElement().ScalarMult(g.NewScalar().Negate(expectedC), qS))
+	if subtle.ConstantTimeCompare(receivedR, expectedR.Bytes()) == 0 {
+		return nil, thyrse.ErrTagMismatch
+	}
+
+	return plaintext, nil
+}
+
+// newSlot returns a protocol for sealing or opening a recipient's key slot.
+func newSlot(domain string, qE, qR, ecdh group.Element) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("slot-ephemeral", qE.Bytes())
+	p.Mix("slot-receiver", qR.Bytes())
+	p.Mix("slot-ecdh", ecdh.Bytes())
+	return p
+}
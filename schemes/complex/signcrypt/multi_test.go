@@ -0,0 +1,83 @@
+package signcrypt_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/signcrypt"
+	"github.com/gtank/ristretto255"
+)
+
+func TestOpenMulti(t *testing.T) {
+	drbg := testdata.New("thyrse signcrypt multi")
+	dS, qS := drbg.KeyPair()
+	dA, qA := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	dX, qX := drbg.KeyPair()
+	message := []byte("this is a message")
+
+	ciphertext := signcrypt.SealMulti("signcrypt", dS, []*ristretto255.Element{qA, qB}, 8, drbg.Data(64), message)
+
+	t.Run("valid", func(t *testing.T) {
+		for _, dR := range []*ristretto255.Scalar{dA, dB} {
+			plaintext, err := signcrypt.OpenMulti("signcrypt", dR, qS, ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := plaintext, message; !bytes.Equal(got, want) {
+				t.Errorf("OpenMulti() = %x, want %x", got, want)
+			}
+		}
+	})
+
+	t.Run("length hides recipient count", func(t *testing.T) {
+		other := signcrypt.SealMulti("signcrypt", dS, []*ristretto255.Element{qX}, 8, drbg.Data(64), message)
+		if got, want := len(other), len(ciphertext); got != want {
+			t.Errorf("len(SealMulti()) = %d, want %d", got, want)
+		}
+		if got, want := len(ciphertext), signcrypt.MultiOverhead(8)+len(message); got != want {
+			t.Errorf("len(SealMulti()) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("non-recipient", func(t *testing.T) {
+		if _, err := signcrypt.OpenMulti("signcrypt", dX, qS, ciphertext); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("OpenMulti() err = %v, want %v", err, thyrse.ErrTagMismatch)
+		}
+	})
+
+	t.Run("wrong sender", func(t *testing.T) {
+		if _, err := signcrypt.OpenMulti("signcrypt", dA, qX, ciphertext); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+			t.Errorf("OpenMulti() err = %v, want %v", err, thyrse.ErrInvalidCiphertext)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := signcrypt.OpenMulti("signcrypt", dA, qS, ciphertext[:signcrypt.MultiOverhead(8)-1]); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("OpenMulti() err = %v, want %v", err, thyrse.ErrTruncated)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		for i := range ciphertext {
+			bad := slices.Clone(ciphertext)
+			bad[i] ^= 1
+			if _, err := signcrypt.OpenMulti("signcrypt", dA, qS, bad); !errors.Is(err, thyrse.ErrInvalidCiphertext) {
+				t.Fatalf("OpenMulti() with byte %d modified err = %v, want %v", i, err, thyrse.ErrInvalidCiphertext)
+			}
+		}
+	})
+
+	t.Run("too few slots", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("SealMulti() did not panic")
+			}
+		}()
+		signcrypt.SealMulti("signcrypt", dS, []*ristretto255.Element{qA, qB}, 1, nil, message)
+	})
+}
@@ -1,10 +1,13 @@
 // Package signcrypt implements an integrated signcryption scheme using Ristretto255 and Thyrse.
+//
+// The package-level functions use Ristretto255. ForGroup returns the same scheme over any prime-order group.
 package signcrypt
 
 import (
 	"crypto/subtle"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
 	"github.com/gtank/ristretto255"
 )
 
@@ -14,10 +17,45 @@ const Overhead = 32 + 32 + 32
 // Seal encrypts and signs the message to protect its confidentiality and authenticity. Only the owner of the
 // receiver's private key can decrypt it, and only the owner of the sender's private key could have sent it.
 func Seal(domain string, dS *ristretto255.Scalar, qR *ristretto255.Element, rand, message []byte) []byte {
+	return ristretto.Seal(domain, group.Ristretto255Scalar(dS), group.Ristretto255Element(qR), rand, message)
+}
+
+// Open decrypts and verifies a ciphertext produced by Seal. Returns either the confidential, authentic plaintext or
+// thyrse.ErrInvalidCiphertext.
+func Open(domain string, dR *ristretto255.Scalar, qS *ristretto255.Element, ciphertext []byte) ([]byte, error) {
+	return ristretto.Open(domain, group.Ristretto255Scalar(dR), group.Ristretto255Element(qS), ciphertext)
+}
+
+// ristretto is the scheme over Ristretto255 used by the package-level functions.
+var ristretto = ForGroup(group.Ristretto255)
+
+// A Scheme is the signcryption scheme over a prime-order group. Its methods behave like the package-level functions of
+// the same names, with the group's scalars and elements as keys. Over Ristretto255, ciphertexts are identical to those
+// of the package-level functions.
+type Scheme struct {
+	g group.Group
+}
+
+// ForGroup returns the signcryption scheme over the given group.
+func ForGroup(g group.Group) Scheme {
+	return Scheme{g: g}
+}
+
+// Overhead returns the length, in bytes, of the additional data added to a plaintext to produce a signcrypted
+// ciphertext: the ephemeral public key, the commitment point, and the proof scalar.
+func (sc Scheme) Overhead() int {
+	return 2*sc.g.ElementSize() + sc.g.ScalarSize()
+}
+
+// Seal encrypts and signs the message to protect its confidentiality and authenticity. Only the owner of the
+// receiver's private key can decrypt it, and only the owner of the sender's private key could have sent it.
+func (sc Scheme) Seal(domain string, dS group.Scalar, qR group.Element, rand, message []byte) []byte {
+	g := sc.g
+
 	// Initialize the protocol and mix in the sender and receiver's public keys.
 	p := thyrse.New(domain)
 	p.Mix("receiver", qR.Bytes())
-	p.Mix("sender", ristretto255.NewIdentityElement().ScalarBaseMult(dS).Bytes())
+	p.Mix("sender", g.NewElement().ScalarBaseMult(dS).Bytes())
 
 	// Fork the protocol into sender and receiver roles.
 	sender, receiver := p.Fork("role", []byte("sender"), []byte("receiver"))
@@ -27,14 +65,14 @@ func Seal(domain string, dS *ristretto255.Scalar, qR *ristretto255.Element, rand
 	sender.Mix("sender-private", dS.Bytes())
 	sender.Mix("rand", rand)
 	sender.Mix("message", message)
-	dE, _ := ristretto255.NewScalar().SetUniformBytes(sender.Derive("ephemeral-private", nil, 64))
-	qE := ristretto255.NewIdentityElement().ScalarBaseMult(dE)
-	k, _ := ristretto255.NewScalar().SetUniformBytes(sender.Derive("commitment", nil, 64))
-	r := ristretto255.NewIdentityElement().ScalarBaseMult(k)
+	dE := group.DeriveScalar(sender, g, "ephemeral-private")
+	qE := g.NewElement().ScalarBaseMult(dE)
+	k := group.DeriveScalar(sender, g, "commitment")
+	r := g.NewElement().ScalarBaseMult(k)
 
 	// Mix the ephemeral public key and ECDH shared secret into the receiver.
 	receiver.Mix("ephemeral", qE.Bytes())
-	receiver.Mix("ecdh", ristretto255.NewIdentityElement().ScalarMult(dE, qR).Bytes())
+	receiver.Mix("ecdh", g.NewElement().ScalarMult(dE, qR).Bytes())
 
 	// Mask the message.
 	ciphertext := receiver.Mask("message", qE.Bytes(), message)
@@ -44,62 +82,64 @@ func Seal(domain string, dS *ristretto255.Scalar, qR *ristretto255.Element, rand
 	sig := receiver.Mask("commitment", ciphertext, r.Bytes())
 
 	// Derive a challenge scalar from the signer's public key, the message, and the commitment point.
-	c, _ := ristretto255.NewScalar().SetUniformBytes(receiver.Derive("challenge", nil, 64))
+	c := group.DeriveScalar(receiver, g, "challenge")
 
 	// Calculate the proof scalar s = k + d*c and mask it.
-	s := ristretto255.NewScalar().Multiply(dS, c)
+	s := g.NewScalar().Multiply(dS, c)
 	s = s.Add(s, k)
 	return receiver.Mask("proof", sig, s.Bytes())
 }
 
 // Open decrypts and verifies a ciphertext produced by Seal. Returns either the confidential, authentic plaintext or
 // thyrse.ErrInvalidCiphertext.
-func Open(domain string, dR *ristretto255.Scalar, qS *ristretto255.Element, ciphertext []byte) ([]byte, error) {
-	if len(ciphertext) < Overhead {
-		return nil, thyrse.ErrInvalidCiphertext
+func (sc Scheme) Open(domain string, dR group.Scalar, qS group.Element, ciphertext []byte) ([]byte, error) {
+	g := sc.g
+	if len(ciphertext) < sc.Overhead() {
+		return nil, thyrse.ErrTruncated
 	}
+	en, sn := g.ElementSize(), g.ScalarSize()
 
 	// Initialize the protocol and mix in the sender and receiver's public keys.
 	p := thyrse.New(domain)
-	p.Mix("receiver", ristretto255.NewIdentityElement().ScalarBaseMult(dR).Bytes())
+	p.Mix("receiver", g.NewElement().ScalarBaseMult(dR).Bytes())
 	p.Mix("sender", qS.Bytes())
 
 	// Fork the protocol into sender and receiver roles.
 	_, receiver := p.Fork("role", []byte("sender"), []byte("receiver"))
 
 	// Mix in the ephemeral public key and decode it.
-	receiver.Mix("ephemeral", ciphertext[:32])
-	qE, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(ciphertext[:32])
-	if qE == nil {
+	receiver.Mix("ephemeral", ciphertext[:en])
+	qE, err := g.NewElement().SetCanonicalBytes(ciphertext[:en])
+	if err != nil {
 		return nil, thyrse.ErrInvalidCiphertext
 	}
 
 	// Mix in the ECDH shared secret.
-	receiver.Mix("ecdh", ristretto255.NewIdentityElement().ScalarMult(dR, qE).Bytes())
+	receiver.Mix("ecdh", g.NewElement().ScalarMult(dR, qE).Bytes())
 
 	// Unmask the message.
-	plaintext := receiver.Unmask("message", nil, ciphertext[32:len(ciphertext)-64])
+	plaintext := receiver.Unmask("message", nil, ciphertext[en:len(ciphertext)-en-sn])
 
 	// Unmask the received commitment point. As we do not use it for calculations, leave it encoded.
-	receivedR := receiver.Unmask("commitment", nil, ciphertext[len(ciphertext)-64:len(ciphertext)-32])
+	receivedR := receiver.Unmask("commitment", nil, ciphertext[len(ciphertext)-en-sn:len(ciphertext)-sn])
 
 	// Derive an expected challenge scalar from the signer's public key, the message, and the commitment point.
-	expectedC, _ := ristretto255.NewScalar().SetUniformBytes(receiver.Derive("challenge", nil, 64))
+	expectedC := group.DeriveScalar(receiver, g, "challenge")
 
 	// Unmask the proof scalar. If not canonically encoded, the signature is invalid.
-	s, _ := ristretto255.NewScalar().SetCanonicalBytes(receiver.Unmask("proof", nil, ciphertext[len(ciphertext)-32:]))
-	if s == nil {
+	s, err := g.NewScalar().SetCanonicalBytes(receiver.Unmask("proof", nil, ciphertext[len(ciphertext)-sn:]))
+	if err != nil {
 		return nil, thyrse.ErrInvalidCiphertext
 	}
 
 	// Calculate the expected commitment point: R' = [s]G + [-c']Q
-	expectedR := ristretto255.NewIdentityElement().ScalarBaseMult(s)
-	expectedR.Add(expectedR, ristretto255.NewIdentityElement().ScalarMult(ristretto255.NewScalar().Negate(expectedC), qS))
+	expectedR := g.NewElement().ScalarBaseMult(s)
+	expectedR.Add(expectedR, g.NewElement().ScalarMult(g.NewScalar().Negate(expectedC), qS))
 
 	// If the received and expected commitment points are equal (as compared in their encoded forms), the signature is
 	// valid.
 	if subtle.ConstantTimeCompare(receivedR, expectedR.Bytes()) == 0 {
-		return nil, thyrse.ErrInvalidCiphertext
+		return nil, thyrse.ErrTagMismatch
 	}
 
 	return plaintext, nil
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
 	"github.com/codahale/thyrse/internal/testdata"
 	"github.com/codahale/thyrse/schemes/complex/signcrypt"
 	"github.com/gtank/ristretto255"
@@ -134,6 +135,67 @@ func FuzzOpen(f *testing.F) {
 	})
 }
 
+func TestForGroup(t *testing.T) {
+	t.Run("ristretto255", func(t *testing.T) {
+		r, dS, qS, dR, qR, _, _ := setup()
+		sc := signcrypt.ForGroup(group.Ristretto255)
+		if got, want := sc.Overhead(), signcrypt.Overhead; got != want {
+			t.Errorf("Overhead() = %d, want %d", got, want)
+		}
+		if got, want := sc.MultiOverhead(3), signcrypt.MultiOverhead(3); got != want {
+			t.Errorf("MultiOverhead(3) = %d, want %d", got, want)
+		}
+
+		got := sc.Seal("signcrypt", group.Ristretto255Scalar(dS), group.Ristretto255Element(qR), r, []byte("message"))
+		if want := signcrypt.Seal("signcrypt", dS, qR, r, []byte("message")); !bytes.Equal(got, want) {
+			t.Errorf("Seal() = %x, want %x", got, want)
+		}
+
+		got = sc.SealMulti("signcrypt", group.Ristretto255Scalar(dS), []group.Element{group.Ristretto255Element(qR)}, 3, r, []byte("message"))
+		if want := signcrypt.SealMulti("signcrypt", dS, []*ristretto255.Element{qR}, 3, r, []byte("message")); !bytes.Equal(got, want) {
+			t.Errorf("SealMulti() = %x, want %x", got, want)
+		}
+		if plaintext, err := signcrypt.OpenMulti("signcrypt", dR, qS, got); err != nil || !bytes.Equal(plaintext, []byte("message")) {
+			t.Errorf("OpenMulti() = %x, %v", plaintext, err)
+		}
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		g := group.Secp256k1
+		sc := signcrypt.ForGroup(g)
+		drbg := testdata.New("thyrse signcrypt secp256k1")
+		keyPair := func() (group.Scalar, group.Element) {
+			d, _ := g.NewScalar().SetUniformBytes(drbg.Data(group.UniformSize))
+			return d, g.NewElement().ScalarBaseMult(d)
+		}
+		dS, qS := keyPair()
+		dR, qR := keyPair()
+		dX, _ := keyPair()
+
+		ciphertext := sc.Seal("signcrypt", dS, qR, drbg.Data(64), []byte("message"))
+		if got, want := len(ciphertext), sc.Overhead()+len("message"); got != want {
+			t.Errorf("len(ciphertext) = %d, want %d", got, want)
+		}
+		if plaintext, err := sc.Open("signcrypt", dR, qS, ciphertext); err != nil || !bytes.Equal(plaintext, []byte("message")) {
+			t.Errorf("Open() = %x, %v", plaintext, err)
+		}
+		if plaintext, err := sc.Open("signcrypt", dX, qS, ciphertext); err == nil {
+			t.Errorf("Open(wrong receiver) = %x, want error", plaintext)
+		}
+
+		ciphertext = sc.SealMulti("signcrypt", dS, []group.Element{qR}, 3, drbg.Data(64), []byte("message"))
+		if got, want := len(ciphertext), sc.MultiOverhead(3)+len("message"); got != want {
+			t.Errorf("len(ciphertext) = %d, want %d", got, want)
+		}
+		if plaintext, err := sc.OpenMulti("signcrypt", dR, qS, ciphertext); err != nil || !bytes.Equal(plaintext, []byte("message")) {
+			t.Errorf("OpenMulti() = %x, %v", plaintext, err)
+		}
+		if plaintext, err := sc.OpenMulti("signcrypt", dX, qS, ciphertext); err == nil {
+			t.Errorf("OpenMulti(wrong receiver) = %x, want error", plaintext)
+		}
+	})
+}
+
 func setup() ([]byte, *ristretto255.Scalar, *ristretto255.Element, *ristretto255.Scalar, *ristretto255.Element, *ristretto255.Scalar, *ristretto255.Element) {
 	drbg := testdata.New("thyrse hpke")
 	dR, qR := drbg.KeyPair()
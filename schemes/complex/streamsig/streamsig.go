@@ -0,0 +1,244 @@
+// Package streamsig implements signed streams, which readers can verify progressively.
+//
+// The writer breaks a stream of data into segments and signs each one as it is written. Each segment is mixed into a
+// running Thyrse transcript along with a flag marking whether it is the final segment, a 32-byte checkpoint is derived
+// from the transcript, and the checkpoint is signed with the sig scheme. Because each checkpoint is derived from a
+// transcript which includes every previous segment, a checkpoint's signature covers the entire stream up to and
+// including its segment.
+//
+// Each segment is encoded as a 1-byte final flag, a 4-byte big endian length, the segment data, and the checkpoint's
+// signature.
+//
+// The reader releases a segment's data only after verifying its signature, so consumers can start processing a large
+// signed stream before its end arrives, with at most one segment of unverified data buffered at a time. If the stream
+// ends before the final segment, or any signature is invalid, ErrInvalidStream is returned.
+package streamsig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+	"github.com/gtank/ristretto255"
+)
+
+// DefaultSegmentSize is the default maximum size of a segment, in bytes.
+const DefaultSegmentSize = 64 * 1024
+
+// ErrInvalidStream is returned by Reader when a stream has been modified, truncated, or not signed by the expected
+// signer.
+var ErrInvalidStream = errors.New("thyrse/streamsig: invalid stream")
+
+// Writer signs written data in segments.
+type Writer struct {
+	domain      string
+	d           *ristretto255.Scalar
+	w           io.Writer
+	p           *thyrse.Protocol
+	buf         []byte
+	segmentSize int
+	closed      bool
+	err         error
+}
+
+// NewWriter returns a Writer which signs data with the private key d and writes the signed stream to w. Data is
+// buffered and written in segments of segmentSize bytes; readers must allow segments of at least that size.
+//
+// The returned Writer MUST be closed for the signed stream to be valid.
+func NewWriter(domain string, d *ristretto255.Scalar, w io.Writer, segmentSize int) *Writer {
+	if segmentSize <= 0 {
+		panic("thyrse/streamsig: segment size must be positive")
+	}
+
+	return &Writer{
+		domain:      domain,
+		d:           d,
+		w:           w,
+		p:           newProtocol(domain, ristretto255.NewIdentityElement().ScalarBaseMult(d)),
+		buf:         make([]byte, 0, segmentSize),
+		segmentSize: segmentSize,
+	}
+}
+
+func (s *Writer) Write(p []byte) (n int, err error) {
+	if s.closed {
+		return 0, thyrse.ErrClosed
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	total := len(p)
+	for len(p) > 0 {
+		n := min(len(p), s.segmentSize-len(s.buf))
+		s.buf = append(s.buf, p[:n]...)
+		p = p[n:]
+
+		if len(s.buf) == s.segmentSize {
+			if err := s.signAndWrite(false); err != nil {
+				return total - len(p), err
+			}
+		}
+	}
+	return total, nil
+}
+
+// Close signs and writes any buffered data as the final segment.
+func (s *Writer) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.err != nil {
+		return s.err
+	}
+	return s.signAndWrite(true)
+}
+
+func (s *Writer) signAndWrite(final bool) error {
+	var hedge [64]byte
+	if _, err := rand.Read(hedge[:]); err != nil {
+		panic(err)
+	}
+
+	checkpoint := deriveCheckpoint(s.p, s.buf, final)
+	signature, err := sig.Sign(s.domain, s.d, hedge[:], bytes.NewReader(checkpoint))
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, headerSize, headerSize+len(s.buf)+sig.Size)
+	if final {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(s.buf)))
+	segment := append(append(header, s.buf...), signature...)
+	if _, err := s.w.Write(segment); err != nil {
+		s.err = err
+		return err
+	}
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// Reader verifies a signed stream progressively.
+type Reader struct {
+	domain         string
+	q              *ristretto255.Element
+	r              io.Reader
+	p              *thyrse.Protocol
+	buf, segment   []byte
+	maxSegmentSize int
+	eos            bool
+}
+
+// NewReader returns a Reader which verifies the signed stream read from r against the public key q. Segments longer
+// than maxSegmentSize bytes are rejected with ErrInvalidStream, bounding the amount of unverified data buffered.
+func NewReader(domain string, q *ristretto255.Element, r io.Reader, maxSegmentSize int) *Reader {
+	return &Reader{
+		domain:         domain,
+		q:              q,
+		r:              r,
+		p:              newProtocol(domain, q),
+		maxSegmentSize: maxSegmentSize,
+	}
+}
+
+func (o *Reader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		// If a verified segment is buffered, satisfy the read with that.
+		if len(o.segment) > 0 {
+			n = copy(p, o.segment)
+			o.segment = o.segment[n:]
+			return n, nil
+		}
+
+		// If the final segment has been read, return EOF.
+		if o.eos {
+			return 0, io.EOF
+		}
+
+		// Read the header and decode the final flag and segment length.
+		header, err := o.read(headerSize)
+		if err != nil {
+			return 0, err
+		}
+		if header[0] > 1 {
+			return 0, ErrInvalidStream
+		}
+		final := header[0] == 1
+		segmentLen := binary.BigEndian.Uint32(header[1:])
+		if int64(segmentLen) > int64(o.maxSegmentSize) || int64(segmentLen) > math.MaxInt-sig.Size {
+			return 0, ErrInvalidStream
+		}
+
+		// Read the segment and its signature, and verify the signature of the checkpoint.
+		data, err := o.read(int(segmentLen) + sig.Size)
+		if err != nil {
+			return 0, err
+		}
+		segment, signature := data[:segmentLen], data[segmentLen:]
+		checkpoint := deriveCheckpoint(o.p, segment, final)
+		valid, err := sig.Verify(o.domain, o.q, signature, bytes.NewReader(checkpoint))
+		if err != nil {
+			return 0, err
+		}
+		if !valid {
+			return 0, ErrInvalidStream
+		}
+
+		o.eos = final
+		o.segment = segment
+	}
+}
+
+func (o *Reader) read(n int) ([]byte, error) {
+	o.buf = slices.Grow(o.buf[:0], n)
+	data := o.buf[:n]
+	_, err := io.ReadFull(o.r, data)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrInvalidStream
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func newProtocol(domain string, q *ristretto255.Element) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("signer", q.Bytes())
+	return p
+}
+
+// deriveCheckpoint mixes the segment and its final flag into the stream's transcript and derives a checkpoint from it.
+func deriveCheckpoint(p *thyrse.Protocol, segment []byte, final bool) []byte {
+	flag := []byte{0}
+	if final {
+		flag[0] = 1
+	}
+	p.Mix("segment", segment)
+	p.Mix("final", flag)
+	return p.Derive("checkpoint", nil, checkpointSize)
+}
+
+const (
+	headerSize     = 1 + 4
+	checkpointSize = 32
+)
+
+var (
+	_ io.WriteCloser = (*Writer)(nil)
+	_ io.Reader      = (*Reader)(nil)
+)
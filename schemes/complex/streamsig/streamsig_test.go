@@ -0,0 +1,157 @@
+package streamsig_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/streamsig"
+	"github.com/gtank/ristretto255"
+)
+
+func Example() {
+	drbg := testdata.New("thyrse streamsig")
+
+	// The signer has a private and public key.
+	d, q := drbg.KeyPair()
+
+	// The signer writes a signed stream.
+	signed := bytes.NewBuffer(nil)
+	w := streamsig.NewWriter("example", d, signed, streamsig.DefaultSegmentSize)
+	if _, err := w.Write([]byte("this is a large artifact")); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+
+	// The verifier reads the stream, receiving data as each segment is verified.
+	r := streamsig.NewReader("example", q, signed, streamsig.DefaultSegmentSize)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%q\n", data)
+
+	// Output:
+	// "this is a large artifact"
+}
+
+func TestRoundTrip(t *testing.T) {
+	drbg := testdata.New("thyrse streamsig round trip")
+	d, q := drbg.KeyPair()
+
+	for _, n := range []int{0, 1, 1023, 1024, 1025, 5000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			message := drbg.Data(n)
+			signed := sign(t, d, message, 1024)
+
+			got, err := io.ReadAll(streamsig.NewReader("test", q, bytes.NewReader(signed), 1024))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, message) {
+				t.Errorf("ReadAll() = %x, want = %x", got, message)
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	drbg := testdata.New("thyrse streamsig reader")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+	message := drbg.Data(3000)
+	signed := sign(t, d, message, 1024)
+
+	read := func(q *ristretto255.Element, signed []byte, maxSegmentSize int) error {
+		_, err := io.ReadAll(streamsig.NewReader("test", q, bytes.NewReader(signed), maxSegmentSize))
+		return err
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		if err := read(qX, signed, 1024); !errors.Is(err, streamsig.ErrInvalidStream) {
+			t.Errorf("err = %v, want = %v", err, streamsig.ErrInvalidStream)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		for _, i := range []int{0, 3, 100, len(signed) - 1} {
+			bad := bytes.Clone(signed)
+			bad[i] ^= 1
+			if err := read(q, bad, 1024); !errors.Is(err, streamsig.ErrInvalidStream) {
+				t.Errorf("byte %d: err = %v, want = %v", i, err, streamsig.ErrInvalidStream)
+			}
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		// Drop the final segment (5-byte header, 952 bytes of data, 64-byte signature).
+		if err := read(q, signed[:len(signed)-(5+952+64)], 1024); !errors.Is(err, streamsig.ErrInvalidStream) {
+			t.Errorf("err = %v, want = %v", err, streamsig.ErrInvalidStream)
+		}
+	})
+
+	t.Run("segment too large", func(t *testing.T) {
+		if err := read(q, signed, 512); !errors.Is(err, streamsig.ErrInvalidStream) {
+			t.Errorf("err = %v, want = %v", err, streamsig.ErrInvalidStream)
+		}
+	})
+
+	t.Run("progressive", func(t *testing.T) {
+		// The first segment is released before the rest of the stream is available.
+		r := streamsig.NewReader("test", q, bytes.NewReader(signed[:5+1024+64]), 1024)
+		buf := make([]byte, 1024)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, message[:1024]) {
+			t.Error("first segment does not match")
+		}
+		if _, err := r.Read(buf); !errors.Is(err, streamsig.ErrInvalidStream) {
+			t.Errorf("err = %v, want = %v", err, streamsig.ErrInvalidStream)
+		}
+	})
+}
+
+func TestWriter(t *testing.T) {
+	drbg := testdata.New("thyrse streamsig writer")
+	d, _ := drbg.KeyPair()
+
+	t.Run("write error", func(t *testing.T) {
+		w := streamsig.NewWriter("test", d, &testdata.ErrWriter{Err: errors.New("broken")}, 16)
+		if _, err := w.Write(make([]byte, 32)); err == nil {
+			t.Error("Write() err = nil, want error")
+		}
+		if err := w.Close(); err == nil {
+			t.Error("Close() err = nil, want sticky error")
+		}
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		w := streamsig.NewWriter("test", d, io.Discard, 16)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("more")); err == nil {
+			t.Error("Write() err = nil, want error")
+		}
+	})
+}
+
+func sign(t *testing.T, d *ristretto255.Scalar, message []byte, segmentSize int) []byte {
+	t.Helper()
+
+	signed := bytes.NewBuffer(nil)
+	w := streamsig.NewWriter("test", d, signed, segmentSize)
+	if _, err := w.Write(message); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return signed.Bytes()
+}
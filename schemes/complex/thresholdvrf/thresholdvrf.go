@@ -0,0 +1,296 @@
+// Package thresholdvrf implements a threshold verifiable random function using Ristretto255 and Thyrse.
+//
+// A group key is split among n participants such that any t of them can jointly evaluate the VRF for an input. Each
+// participant produces a partial evaluation with a DLEQ proof that it was computed with the participant's key share.
+// Any party holding the verifying shares can check the partial evaluations and aggregate t of them into the VRF output
+// and a proof which anyone holding the verifying shares can check. No single participant (or coalition of fewer than
+// t participants) can predict or bias the output.
+//
+// The output is the same as that of the vrf package's Prove function using the group's (never reconstructed) private
+// key.
+package thresholdvrf
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/codahale/thyrse/internal/shamir"
+	"github.com/gtank/ristretto255"
+)
+
+// PartialSize is the size, in bytes, of an encoded partial evaluation.
+const PartialSize = 2 + 32 + 32 + 32
+
+var (
+	// ErrInvalidParameters is returned for invalid key generation or aggregation parameters.
+	ErrInvalidParameters = errors.New("thyrse/thresholdvrf: invalid parameters")
+
+	// ErrInvalidPartial is returned when a partial evaluation cannot be decoded or its proof is invalid.
+	ErrInvalidPartial = errors.New("thyrse/thresholdvrf: invalid partial evaluation")
+
+	// ErrDuplicateIdentifier is returned when more than one partial evaluation has the same identifier.
+	ErrDuplicateIdentifier = errors.New("thyrse/thresholdvrf: duplicate identifier in partial evaluations")
+)
+
+// A Participant holds the secret key share for a single participant.
+type Participant struct {
+	domain         string
+	identifier     uint16
+	share          *ristretto255.Scalar
+	verifyingShare *ristretto255.Element
+	groupKey       *ristretto255.Element
+}
+
+// Identifier returns the participant's 1-based identifier.
+func (p *Participant) Identifier() uint16 {
+	return p.identifier
+}
+
+// VerifyingShare returns the participant's verifying share (public key corresponding to their key share).
+func (p *Participant) VerifyingShare() *ristretto255.Element {
+	return p.verifyingShare
+}
+
+// GroupKey returns the group's public key.
+func (p *Participant) GroupKey() *ristretto255.Element {
+	return p.groupKey
+}
+
+// KeyGen performs trusted-dealer key generation for a threshold-of-maxParticipants VRF. It returns the group public
+// key, the participants (each containing their secret share), and the verifying shares (public keys corresponding to
+// each participant's share).
+//
+// Identifiers are 1-based: participants[i] has identifier i+1 and verifying share verifyingShares[i]. The threshold must
+// be at least 2 and at most maxParticipants. rand must contain at least 64 bytes of uniform randomness.
+func KeyGen(domain string, maxParticipants, threshold int, rand []byte) (*ristretto255.Element, []Participant, []*ristretto255.Element, error) {
+	if threshold < 2 || maxParticipants < threshold || maxParticipants > shamir.MaxShares || len(rand) < 64 {
+		return nil, nil, nil, ErrInvalidParameters
+	}
+
+	// Derive polynomial coefficients deterministically from the seed.
+	p := thyrse.New(domain)
+	keygen, _ := p.Fork("process", []byte("keygen"), []byte("evaluation"))
+	keygen.Mix("seed", rand)
+	coeffs := shamir.NewPolynomial(keygen, group.Ristretto255, threshold)
+
+	// The group public key is [a_0]G where a_0 is the secret.
+	groupKey := ristretto255.NewIdentityElement().ScalarBaseMult(coeffs[0].(*group.RistrettoScalar).Ristretto255())
+
+	// Evaluate the polynomial at each participant's identifier to produce shares.
+	shares, vss := shamir.Deal(group.Ristretto255, coeffs, maxParticipants)
+	participants := make([]Participant, maxParticipants)
+	verifyingShares := make([]*ristretto255.Element, maxParticipants)
+	for i := range maxParticipants {
+		verifyingShares[i] = vss[i].(*group.RistrettoElement).Ristretto255()
+		participants[i] = Participant{
+			domain:         domain,
+			identifier:     uint16(i + 1),
+			share:          shares[i].(*group.RistrettoScalar).Ristretto255(),
+			verifyingShare: verifyingShares[i],
+			groupKey:       groupKey,
+		}
+	}
+
+	return groupKey, participants, verifyingShares, nil
+}
+
+// Evaluate returns the participant's encoded partial evaluation of the VRF for the given input, along with a proof
+// that it was computed with the participant's key share. The rand parameter should contain at least 64 bytes of random
+// data; the proof's nonce is derived from the participant's share and the random data, protecting against both nonce
+// reuse and weak randomness.
+func (p *Participant) Evaluate(input, rand []byte) []byte {
+	h := hashToPoint(p.domain, p.groupKey, input)
+	gamma := ristretto255.NewIdentityElement().ScalarMult(p.share, h)
+
+	// Fork the protocol into prover and verifier roles.
+	prover, verifier := partialTranscript(p.domain, h, p.identifier, p.verifyingShare, gamma).
+		Fork("role", []byte("prover"), []byte("verifier"))
+
+	// Calculate a hedged nonce k.
+	prover.Mix("prover-private", p.share.Bytes())
+	prover.Mix("rand", rand)
+	k := group.DeriveRistretto255Scalar(prover, "commitment")
+
+	// Calculate the commitment points.
+	verifier.Mix("commitment-u", ristretto255.NewIdentityElement().ScalarBaseMult(k).Bytes())
+	verifier.Mix("commitment-v", ristretto255.NewIdentityElement().ScalarMult(k, h).Bytes())
+
+	// Calculate a challenge and a response.
+	c := group.DeriveRistretto255Scalar(verifier, "challenge")
+	s := ristretto255.NewScalar().Multiply(c, p.share)
+	s = s.Add(s, k)
+
+	return slices.Concat(binary.BigEndian.AppendUint16(nil, p.identifier), gamma.Bytes(), c.Bytes(), s.Bytes())
+}
+
+// Identifier returns the identifier of the participant which produced the given encoded partial evaluation. If the
+// partial evaluation is malformed, returns false.
+func Identifier(partial []byte) (uint16, bool) {
+	if len(partial) != PartialSize {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(partial), true
+}
+
+// VerifyPartial checks a participant's partial evaluation of the VRF for the given input against the participant's
+// verifying share. This can be used to identify which participant produced an invalid partial evaluation before
+// aggregation.
+func VerifyPartial(domain string, groupKey, verifyingShare *ristretto255.Element, input, partial []byte) bool {
+	_, err := verifyPartial(domain, hashToPoint(domain, groupKey, input), verifyingShare, partial)
+	return err == nil
+}
+
+// Aggregate checks each of the partial evaluations against the corresponding verifying share (where
+// verifyingShares[i] belongs to the participant with identifier i+1) and combines them into n bytes of VRF output and
+// a proof which can be checked with [Verify].
+//
+// At least threshold partial evaluations from distinct participants are required; if fewer are given, returns
+// ErrInvalidParameters. If any partial evaluation is invalid, returns ErrInvalidPartial.
+func Aggregate(domain string, groupKey *ristretto255.Element, verifyingShares []*ristretto255.Element, input []byte, partials [][]byte, n int) (prf, proof []byte, err error) {
+	for _, partial := range partials {
+		if len(partial) != PartialSize {
+			return nil, nil, ErrInvalidPartial
+		}
+	}
+
+	sorted := slices.Clone(partials)
+	slices.SortFunc(sorted, func(a, b []byte) int {
+		return cmp.Compare(binary.BigEndian.Uint16(a), binary.BigEndian.Uint16(b))
+	})
+
+	prf, err = combine(domain, groupKey, verifyingShares, input, sorted, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prf, slices.Concat(sorted...), nil
+}
+
+// Verify checks the given proof, produced by [Aggregate], against the group's verifying shares and the input. If the
+// proof is valid, returns true and n bytes of VRF output; otherwise, returns false and nil.
+func Verify(domain string, groupKey *ristretto255.Element, verifyingShares []*ristretto255.Element, input, proof []byte, n int) (valid bool, prf []byte) {
+	if len(proof) == 0 || len(proof)%PartialSize != 0 {
+		return false, nil
+	}
+
+	partials := make([][]byte, 0, len(proof)/PartialSize)
+	for partial := range slices.Chunk(proof, PartialSize) {
+		partials = append(partials, partial)
+	}
+
+	prf, err := combine(domain, groupKey, verifyingShares, input, partials, n)
+	if err != nil {
+		return false, nil
+	}
+	return true, prf
+}
+
+// combine verifies the given partial evaluations, which must be sorted by identifier, and interpolates them into the
+// VRF output.
+func combine(domain string, groupKey *ristretto255.Element, verifyingShares []*ristretto255.Element, input []byte, partials [][]byte, n int) ([]byte, error) {
+	for _, partial := range partials {
+		if len(partial) != PartialSize {
+			return nil, ErrInvalidPartial
+		}
+	}
+
+	identifiers := make([]uint16, len(partials))
+	for i, partial := range partials {
+		identifiers[i] = binary.BigEndian.Uint16(partial)
+		if identifiers[i] == 0 || int(identifiers[i]) > len(verifyingShares) {
+			return nil, ErrInvalidPartial
+		}
+		if i > 0 && identifiers[i-1] >= identifiers[i] {
+			return nil, ErrDuplicateIdentifier
+		}
+	}
+
+	// Check that the participants' verifying shares interpolate to the group key. If fewer than threshold partial
+	// evaluations are given, they will not.
+	lambdas := make([]*ristretto255.Scalar, len(identifiers))
+	shares := make([]*ristretto255.Element, len(identifiers))
+	for i, id := range identifiers {
+		lambdas[i] = shamir.Lagrange(group.Ristretto255, id, identifiers).(*group.RistrettoScalar).Ristretto255()
+		shares[i] = verifyingShares[id-1]
+	}
+	if ristretto255.NewIdentityElement().VarTimeMultiScalarMult(lambdas, shares).Equal(groupKey) == 0 {
+		return nil, ErrInvalidParameters
+	}
+
+	h := hashToPoint(domain, groupKey, input)
+	gammas := make([]*ristretto255.Element, len(partials))
+	for i, partial := range partials {
+		gamma, err := verifyPartial(domain, h, shares[i], partial)
+		if err != nil {
+			return nil, err
+		}
+		gammas[i] = gamma
+	}
+
+	// Interpolate gamma = [d]H and calculate the VRF output as in vrf.Prove.
+	gamma := ristretto255.NewIdentityElement().VarTimeMultiScalarMult(lambdas, gammas)
+	p := thyrse.New(domain)
+	p.Mix("generator", ristretto255.NewGeneratorElement().Bytes())
+	p.Mix("prover", groupKey.Bytes())
+	p.Mix("input", input)
+	p.Derive("point", nil, 64)
+	p.Mix("gamma", gamma.Bytes())
+	return p.Derive("prf", nil, n), nil
+}
+
+// verifyPartial checks the DLEQ proof of the given partial evaluation and returns its gamma point.
+func verifyPartial(domain string, h, verifyingShare *ristretto255.Element, partial []byte) (*ristretto255.Element, error) {
+	if len(partial) != PartialSize {
+		return nil, ErrInvalidPartial
+	}
+
+	// Parse the partial evaluation.
+	id := binary.BigEndian.Uint16(partial)
+	gamma, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(partial[2:34])
+	c, _ := ristretto255.NewScalar().SetCanonicalBytes(partial[34:66])
+	s, _ := ristretto255.NewScalar().SetCanonicalBytes(partial[66:])
+	if gamma == nil || c == nil || s == nil {
+		return nil, ErrInvalidPartial
+	}
+
+	// Calculate the commitment points.
+	negC := ristretto255.NewScalar().Negate(c)
+	u := ristretto255.NewIdentityElement().VarTimeDoubleScalarBaseMult(negC, verifyingShare, s)
+	v := ristretto255.NewIdentityElement().VarTimeMultiScalarMult([]*ristretto255.Scalar{s, negC}, []*ristretto255.Element{h, gamma})
+
+	// Fork the protocol into prover and verifier roles.
+	_, verifier := partialTranscript(domain, h, id, verifyingShare, gamma).
+		Fork("role", []byte("prover"), []byte("verifier"))
+	verifier.Mix("commitment-u", u.Bytes())
+	verifier.Mix("commitment-v", v.Bytes())
+
+	// Recalculate the challenge.
+	expectedC := group.DeriveRistretto255Scalar(verifier, "challenge")
+	if expectedC.Equal(c) == 0 {
+		return nil, ErrInvalidPartial
+	}
+	return gamma, nil
+}
+
+// hashToPoint hashes the input to a point on the curve using the same transcript as vrf.Prove.
+func hashToPoint(domain string, groupKey *ristretto255.Element, input []byte) *ristretto255.Element {
+	p := thyrse.New(domain)
+	p.Mix("generator", ristretto255.NewGeneratorElement().Bytes())
+	p.Mix("prover", groupKey.Bytes())
+	p.Mix("input", input)
+	h, _ := ristretto255.NewIdentityElement().SetUniformBytes(p.Derive("point", nil, 64))
+	return h
+}
+
+// partialTranscript returns a protocol bound to a participant's partial evaluation.
+func partialTranscript(domain string, h *ristretto255.Element, id uint16, verifyingShare, gamma *ristretto255.Element) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("point", h.Bytes())
+	p.Mix("identifier", binary.BigEndian.AppendUint16(nil, id))
+	p.Mix("verifying-share", verifyingShare.Bytes())
+	p.Mix("partial-gamma", gamma.Bytes())
+	return p
+}
@@ -0,0 +1,143 @@
+package thresholdvrf_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/thresholdvrf"
+)
+
+func TestAggregate(t *testing.T) {
+	drbg := testdata.New("thyrse threshold vrf")
+	groupKey, participants, verifyingShares, err := thresholdvrf.KeyGen("domain", 5, 3, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []byte("epoch 42")
+
+	evaluate := func(ids ...int) [][]byte {
+		partials := make([][]byte, len(ids))
+		for i, id := range ids {
+			partials[i] = participants[id-1].Evaluate(input, drbg.Data(64))
+		}
+		return partials
+	}
+
+	prf, proof, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, evaluate(1, 3, 5), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		valid, got := thresholdvrf.Verify("domain", groupKey, verifyingShares, input, proof, 32)
+		if !valid {
+			t.Fatal("Verify() = false, want true")
+		}
+		if want := prf; !bytes.Equal(got, want) {
+			t.Errorf("Verify() output = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("any quorum", func(t *testing.T) {
+		got, _, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, evaluate(4, 2, 1), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := prf; !bytes.Equal(got, want) {
+			t.Errorf("Aggregate() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("more than threshold", func(t *testing.T) {
+		got, _, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, evaluate(1, 2, 3, 4, 5), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := prf; !bytes.Equal(got, want) {
+			t.Errorf("Aggregate() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("fewer than threshold", func(t *testing.T) {
+		_, _, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, evaluate(1, 2), 32)
+		if !errors.Is(err, thresholdvrf.ErrInvalidParameters) {
+			t.Errorf("Aggregate() err = %v, want = %v", err, thresholdvrf.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("duplicate participant", func(t *testing.T) {
+		_, _, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, evaluate(1, 2, 2), 32)
+		if !errors.Is(err, thresholdvrf.ErrDuplicateIdentifier) {
+			t.Errorf("Aggregate() err = %v, want = %v", err, thresholdvrf.ErrDuplicateIdentifier)
+		}
+	})
+
+	t.Run("invalid partial", func(t *testing.T) {
+		partials := evaluate(1, 2, 3)
+		partials[1][10] ^= 1
+		_, _, err := thresholdvrf.Aggregate("domain", groupKey, verifyingShares, input, partials, 32)
+		if !errors.Is(err, thresholdvrf.ErrInvalidPartial) {
+			t.Errorf("Aggregate() err = %v, want = %v", err, thresholdvrf.ErrInvalidPartial)
+		}
+	})
+
+	t.Run("wrong input", func(t *testing.T) {
+		if valid, got := thresholdvrf.Verify("domain", groupKey, verifyingShares, []byte("epoch 43"), proof, 32); valid || got != nil {
+			t.Errorf("Verify() = %v, %x, want false, nil", valid, got)
+		}
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		if valid, got := thresholdvrf.Verify("other", groupKey, verifyingShares, input, proof, 32); valid || got != nil {
+			t.Errorf("Verify() = %v, %x, want false, nil", valid, got)
+		}
+	})
+
+	t.Run("modified proof", func(t *testing.T) {
+		for i := range proof {
+			bad := bytes.Clone(proof)
+			bad[i] ^= 1
+			if valid, _ := thresholdvrf.Verify("domain", groupKey, verifyingShares, input, bad, 32); valid {
+				t.Fatalf("Verify() = true with byte %d modified, want false", i)
+			}
+		}
+	})
+
+	t.Run("truncated proof", func(t *testing.T) {
+		if valid, _ := thresholdvrf.Verify("domain", groupKey, verifyingShares, input, proof[:len(proof)-1], 32); valid {
+			t.Error("Verify() = true, want false")
+		}
+	})
+}
+
+func TestVerifyPartial(t *testing.T) {
+	drbg := testdata.New("thyrse threshold vrf")
+	groupKey, participants, verifyingShares, err := thresholdvrf.KeyGen("domain", 3, 2, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial := participants[0].Evaluate([]byte("input"), drbg.Data(64))
+	if id, ok := thresholdvrf.Identifier(partial); !ok || id != 1 {
+		t.Errorf("Identifier() = %d, %v, want 1, true", id, ok)
+	}
+
+	if !thresholdvrf.VerifyPartial("domain", groupKey, verifyingShares[0], []byte("input"), partial) {
+		t.Error("VerifyPartial() = false, want true")
+	}
+
+	if thresholdvrf.VerifyPartial("domain", groupKey, verifyingShares[1], []byte("input"), partial) {
+		t.Error("VerifyPartial() with wrong verifying share = true, want false")
+	}
+}
+
+func TestKeyGen(t *testing.T) {
+	drbg := testdata.New("thyrse threshold vrf")
+	for _, tc := range []struct{ n, t, randLen int }{{3, 1, 64}, {2, 3, 64}, {3, 2, 63}} {
+		if _, _, _, err := thresholdvrf.KeyGen("domain", tc.n, tc.t, drbg.Data(tc.randLen)); !errors.Is(err, thresholdvrf.ErrInvalidParameters) {
+			t.Errorf("KeyGen(%d, %d) err = %v, want = %v", tc.n, tc.t, err, thresholdvrf.ErrInvalidParameters)
+		}
+	}
+}
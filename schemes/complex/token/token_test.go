@@ -0,0 +1,94 @@
+package token_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/token"
+)
+
+func TestOpen(t *testing.T) {
+	drbg := testdata.New("thyrse token")
+	d, q := drbg.KeyPair()
+	_, qX := drbg.KeyPair()
+	key := drbg.Data(32)
+	payload := []byte(`{"sub":"alice","exp":1700000000}`)
+
+	tok := token.Seal("domain", key, d, drbg.Data(64), payload)
+
+	t.Run("valid", func(t *testing.T) {
+		got, err := token.Open("domain", key, q, tok)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := payload; string(got) != string(want) {
+			t.Errorf("Open() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("payload is encrypted", func(t *testing.T) {
+		if strings.Contains(tok, base64.RawURLEncoding.EncodeToString(payload)[:8]) {
+			t.Error("token contains the plaintext payload")
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		domain string
+		key    []byte
+		token  string
+		want   error
+	}{
+		"wrong domain":        {"other", key, tok, token.ErrInvalidToken},
+		"wrong key":           {"domain", drbg.Data(32), tok, token.ErrInvalidToken},
+		"missing segments":    {"domain", key, tok[:strings.LastIndex(tok, ".")], token.ErrInvalidToken},
+		"extra segment":       {"domain", key, tok + ".AAAA", token.ErrInvalidToken},
+		"no segments":         {"domain", key, "garbage", token.ErrInvalidToken},
+		"padded base64":       {"domain", key, tok + "=", token.ErrInvalidToken},
+		"modified payload":    {"domain", key, modify(tok, 1), token.ErrInvalidToken},
+		"modified signature":  {"domain", key, modify(tok, 2), token.ErrInvalidToken},
+		"algorithm confusion": {"domain", key, withHeader(tok, `{"alg":"none","typ":"JWT"}`), token.ErrUnsupportedAlgorithm},
+		"reordered header":    {"domain", key, withHeader(tok, `{"typ":"thyrse+token","alg":"thyrse-r255"}`), token.ErrUnsupportedAlgorithm},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := token.Open(tc.domain, tc.key, q, tc.token); !errors.Is(err, tc.want) {
+				t.Errorf("Open() err = %v, want = %v", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		if _, err := token.Open("domain", key, qX, tok); !errors.Is(err, token.ErrInvalidToken) {
+			t.Errorf("Open() err = %v, want = %v", err, token.ErrInvalidToken)
+		}
+	})
+
+	t.Run("re-signed by another issuer", func(t *testing.T) {
+		dX, qX := drbg.KeyPair()
+		other := token.Seal("domain", key, dX, nil, payload)
+		forged := strings.Join([]string{segment(tok, 0), segment(tok, 1), segment(other, 2)}, ".")
+		if _, err := token.Open("domain", key, qX, forged); !errors.Is(err, token.ErrInvalidToken) {
+			t.Errorf("Open() err = %v, want = %v", err, token.ErrInvalidToken)
+		}
+	})
+}
+
+func segment(tok string, i int) string {
+	return strings.Split(tok, ".")[i]
+}
+
+func modify(tok string, i int) string {
+	parts := strings.Split(tok, ".")
+	b, _ := base64.RawURLEncoding.DecodeString(parts[i])
+	b[0] ^= 1
+	parts[i] = base64.RawURLEncoding.EncodeToString(b)
+	return strings.Join(parts, ".")
+}
+
+func withHeader(tok, header string) string {
+	parts := strings.Split(tok, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(header))
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,124 @@
+package treekem
+
+import (
+	"maps"
+	"math/bits"
+	"slices"
+
+	"github.com/gtank/ristretto255"
+)
+
+// A tree is a left-balanced binary tree of public keys, stored in an array with leaf i at node 2i and a blank node
+// represented by nil. The number of leaves is always a power of two.
+type tree struct {
+	nodes   []*ristretto255.Element
+	private map[uint32]*ristretto255.Scalar
+}
+
+// newTree returns a tree with the given number of leaves, which must be a power of two.
+func newTree(leaves uint32) *tree {
+	return &tree{nodes: make([]*ristretto255.Element, 2*leaves-1), private: make(map[uint32]*ristretto255.Scalar)}
+}
+
+// clone returns a copy of the tree which can be modified independently.
+func (t *tree) clone() *tree {
+	return &tree{nodes: slices.Clone(t.nodes), private: maps.Clone(t.private)}
+}
+
+// leaves returns the number of leaves in the tree.
+func (t *tree) leaves() uint32 {
+	return uint32(len(t.nodes)+1) / 2
+}
+
+// root returns the index of the root node.
+func (t *tree) root() uint32 {
+	return t.leaves() - 1
+}
+
+// occupied returns true if the given leaf is in the tree and not blank.
+func (t *tree) occupied(leaf uint32) bool {
+	return leaf < t.leaves() && t.nodes[2*leaf] != nil
+}
+
+// grow doubles the number of leaves in the tree, making the old tree the left subtree of a new, blank root.
+func (t *tree) grow() {
+	t.nodes = append(t.nodes, make([]*ristretto255.Element, len(t.nodes)+1)...)
+}
+
+// setNode sets the public and (optional) private key of a node.
+func (t *tree) setNode(x uint32, pub *ristretto255.Element, priv *ristretto255.Scalar) {
+	t.nodes[x] = pub
+	if priv != nil {
+		t.private[x] = priv
+	} else {
+		delete(t.private, x)
+	}
+}
+
+// blank removes the keys of a leaf and every node on its direct path.
+func (t *tree) blank(leaf uint32) {
+	t.setNode(2*leaf, nil, nil)
+	for _, x := range t.directPath(leaf) {
+		t.setNode(x, nil, nil)
+	}
+}
+
+// directPath returns the nodes between the given leaf and the root, inclusive of the root.
+func (t *tree) directPath(leaf uint32) []uint32 {
+	var path []uint32
+	for x := 2 * leaf; x != t.root(); {
+		x = parent(x)
+		path = append(path, x)
+	}
+	return path
+}
+
+// resolution returns the non-blank nodes which cover the subtree rooted at x: x itself if it is not blank, otherwise the
+// resolutions of its children.
+func (t *tree) resolution(x uint32) []uint32 {
+	switch {
+	case t.nodes[x] != nil:
+		return []uint32{x}
+	case level(x) == 0:
+		return nil
+	default:
+		return append(t.resolution(left(x)), t.resolution(right(x))...)
+	}
+}
+
+// level returns the height of node x above the leaves.
+func level(x uint32) int {
+	return bits.TrailingZeros32(^x)
+}
+
+// left returns the left child of the intermediate node x.
+func left(x uint32) uint32 {
+	return x ^ (1 << (level(x) - 1))
+}
+
+// right returns the right child of the intermediate node x.
+func right(x uint32) uint32 {
+	return x ^ (3 << (level(x) - 1))
+}
+
+// parent returns the parent of the non-root node x.
+func parent(x uint32) uint32 {
+	k := level(x)
+	b := (x >> (k + 1)) & 1
+	return (x | (1 << k)) ^ (b << (k + 1))
+}
+
+// sibling returns the other child of the parent of the non-root node x.
+func sibling(x uint32) uint32 {
+	if p := parent(x); x < p {
+		return right(p)
+	} else {
+		return left(p)
+	}
+}
+
+// covers returns true if the subtree rooted at x contains node y.
+func covers(x, y uint32) bool {
+	span := uint32(1)<<level(x) - 1
+	return y >= x-span && y <= x+span
+}
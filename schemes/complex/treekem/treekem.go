@@ -0,0 +1,491 @@
+// Package treekem implements a [TreeKEM]-style group key agreement using Thyrse and Ristretto255.
+//
+// Members of a group occupy the leaves of a binary tree of key pairs, where each member knows the private keys of the
+// nodes between its leaf and the root. A member changes the group by creating a Commit, which adds and removes members
+// and replaces the keys on the committer's path to the root. The new path secrets are encrypted to the minimal set of
+// nodes covering the rest of the tree, so a commit costs O(log n) encryptions in a well-populated tree. Every commit
+// starts a new epoch, whose Protocol is shared by the current members and can be used to protect application messages.
+//
+// Added members receive a Welcome, which they pass to Join. Removed members learn nothing about later epochs, and
+// compromising a member's state reveals nothing about epochs before its last commit (forward secrecy) or, once it
+// commits again, after it (post-compromise security).
+//
+// Commits are not authenticated beyond proving knowledge of the current epoch: callers which need to attribute commits
+// to individual members should sign them, e.g. with package sig, and deliver them to all members in the same order.
+//
+// [TreeKEM]: https://www.rfc-editor.org/rfc/rfc9420.html
+package treekem
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
+	"github.com/gtank/ristretto255"
+)
+
+var (
+	// ErrInvalidProposal is returned by Group.Commit when adding or removing members is not possible.
+	ErrInvalidProposal = errors.New("thyrse/treekem: invalid proposal")
+
+	// ErrInvalidCommit is returned by Group.Apply when a commit is malformed, for a different epoch, or inconsistent with
+	// the group's tree.
+	ErrInvalidCommit = errors.New("thyrse/treekem: invalid commit")
+
+	// ErrRemoved is returned by Group.Apply when the commit removes the member from the group.
+	ErrRemoved = errors.New("thyrse/treekem: removed from group")
+
+	// ErrInvalidWelcome is returned by Join when a welcome is malformed or cannot be decrypted.
+	ErrInvalidWelcome = errors.New("thyrse/treekem: invalid welcome")
+)
+
+// A Commit moves a group to a new epoch, adding and removing members and replacing the keys on the committer's path.
+type Commit struct {
+	Epoch     uint64     // The epoch in which the commit was created.
+	Committer uint32     // The committer's leaf index.
+	Adds      [][]byte   // The encoded public keys of added members, in order of their leaf assignment.
+	Removes   []uint32   // The leaf indexes of removed members.
+	LeafKey   []byte     // The committer's new encoded leaf public key.
+	Path      []PathNode // The committer's new path, from its leaf's parent to the root.
+}
+
+// A PathNode is a node on a committer's path.
+type PathNode struct {
+	PublicKey   []byte   // The node's new encoded public key.
+	Ephemeral   []byte   // The encoded ephemeral public key used to encrypt the node's path secret.
+	Ciphertexts [][]byte // The node's path secret, encrypted to each node in the resolution of its other child.
+}
+
+// A Welcome allows a member added by a commit to join the group in the commit's epoch.
+type Welcome struct {
+	Leaf       uint32 // The new member's leaf index.
+	Ephemeral  []byte // The encoded ephemeral public key used to encrypt the welcome.
+	Ciphertext []byte // The encrypted group state.
+}
+
+// A Group is a member's view of a group in an epoch.
+type Group struct {
+	domain string
+	epoch  uint64
+	self   uint32
+	tree   *tree
+	state  *thyrse.Protocol
+}
+
+// NewGroup creates a new group with the given ID whose only member has the given leaf private key, using a random
+// value (which must be exactly 64 bytes) to seed the first epoch.
+//
+// Panics if rand is not exactly 64 bytes.
+func NewGroup(domain string, groupID []byte, leafKey *ristretto255.Scalar, rand []byte) *Group {
+	if len(rand) != 64 {
+		panic("thyrse/treekem: rand must be 64 bytes")
+	}
+
+	t := newTree(1)
+	t.setNode(0, ristretto255.NewIdentityElement().ScalarBaseMult(leafKey), leafKey)
+
+	state := thyrse.New(domain)
+	state.SetSerializable(true)
+	state.Mix("group-id", groupID)
+	state.Mix("rand", rand)
+	state.Ratchet("epoch")
+	return &Group{domain: domain, tree: t, state: state}
+}
+
+// Epoch returns the group's current epoch.
+func (g *Group) Epoch() uint64 {
+	return g.epoch
+}
+
+// Leaf returns the member's leaf index.
+func (g *Group) Leaf() uint32 {
+	return g.self
+}
+
+// Members returns the leaf indexes of the group's members, in order.
+func (g *Group) Members() []uint32 {
+	var members []uint32
+	for i := range g.tree.leaves() {
+		if g.tree.occupied(i) {
+			members = append(members, i)
+		}
+	}
+	return members
+}
+
+// Protocol returns a protocol shared by all members of the group in the current epoch, for protecting application
+// messages.
+func (g *Group) Protocol() *thyrse.Protocol {
+	p := g.state.Clone()
+	p.Mix("purpose", []byte("application"))
+	return p
+}
+
+// Commit creates a commit which adds members with the given public keys, removes the members at the given leaf
+// indexes, and updates the member's own keys, using a random value (which must be exactly 64 bytes). The group moves to
+// the new epoch immediately. Returns the commit, to be sent to the other current members, and a welcome for each added
+// member, in the same order as adds.
+//
+// Returns ErrInvalidProposal if any of the public keys are the identity element, or if any of the leaves are blank,
+// duplicated, or the member's own.
+//
+// Panics if rand is not exactly 64 bytes.
+func (g *Group) Commit(adds []*ristretto255.Element, removes []uint32, rand []byte) (*Commit, []*Welcome, error) {
+	if len(rand) != 64 {
+		panic("thyrse/treekem: rand must be 64 bytes")
+	}
+
+	c := &Commit{Epoch: g.epoch, Committer: g.self, Removes: removes}
+	for _, q := range adds {
+		if q.Equal(ristretto255.NewIdentityElement()) == 1 {
+			return nil, nil, ErrInvalidProposal
+		}
+		c.Adds = append(c.Adds, q.Bytes())
+	}
+
+	t := g.tree.clone()
+	added, err := applyProposals(t, c, adds)
+	if err != nil {
+		return nil, nil, ErrInvalidProposal
+	}
+
+	// Derive a leaf secret and ephemeral keys from the random value, hedged with the member's leaf key.
+	r := thyrse.New(g.domain)
+	r.Mix("leaf-key", t.private[2*g.self].Bytes())
+	r.Mix("rand", rand)
+	pathSecret := r.Derive("leaf-secret", nil, 32)
+
+	// Replace the member's leaf key and every key on its path, encrypting each node's path secret to the resolution of
+	// its other child.
+	var priv *ristretto255.Scalar
+	priv, pathSecret = deriveNode(g.domain, pathSecret)
+	t.setNode(2*g.self, ristretto255.NewIdentityElement().ScalarBaseMult(priv), priv)
+	c.LeafKey = t.nodes[2*g.self].Bytes()
+
+	child := 2 * g.self
+	pathSecrets := make(map[uint32][]byte)
+	for _, x := range t.directPath(g.self) {
+		pathSecrets[x] = pathSecret
+		priv, next := deriveNode(g.domain, pathSecret)
+		t.setNode(x, ristretto255.NewIdentityElement().ScalarBaseMult(priv), priv)
+
+		e := group.DeriveRistretto255Scalar(r, "ephemeral")
+		node := PathNode{PublicKey: t.nodes[x].Bytes(), Ephemeral: ristretto255.NewIdentityElement().ScalarBaseMult(e).Bytes()}
+		for _, y := range t.resolution(sibling(child)) {
+			dh := ristretto255.NewIdentityElement().ScalarMult(e, t.nodes[y])
+			p := pathProtocol(g.state, c, x, node.Ephemeral, t.nodes[y], dh)
+			node.Ciphertexts = append(node.Ciphertexts, p.Seal("path-secret", nil, pathSecret))
+		}
+		c.Path = append(c.Path, node)
+
+		child, pathSecret = x, next
+	}
+
+	// Advance to the next epoch with the commit secret.
+	state := nextEpoch(g.state, c, pathSecret)
+
+	// Welcome each added member with the group state and the path secret of the lowest node shared with the committer.
+	welcomes := make([]*Welcome, len(added))
+	for i, leaf := range added {
+		lca := 2 * leaf
+		for !covers(lca, 2*g.self) {
+			lca = parent(lca)
+		}
+		welcomes[i], err = welcome(g.domain, r, t, leaf, lca, pathSecrets[lca], g.epoch+1, state)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	g.epoch, g.tree, g.state = g.epoch+1, t, state
+	return c, welcomes, nil
+}
+
+// Apply processes a commit created by another member, moving the group to the new epoch. Returns ErrRemoved if the
+// commit removes the member, or ErrInvalidCommit if the commit is malformed, for a different epoch, or inconsistent
+// with the group's tree. If Apply returns an error, the group is unchanged.
+func (g *Group) Apply(c *Commit) error {
+	if c.Epoch != g.epoch || c.Committer == g.self || !g.tree.occupied(c.Committer) {
+		return ErrInvalidCommit
+	}
+
+	adds := make([]*ristretto255.Element, len(c.Adds))
+	for i, b := range c.Adds {
+		if adds[i] = decodeElement(b); adds[i] == nil {
+			return ErrInvalidCommit
+		}
+	}
+
+	t := g.tree.clone()
+	if _, err := applyProposals(t, c, adds); err != nil {
+		return ErrInvalidCommit
+	}
+	if !t.occupied(g.self) {
+		return ErrRemoved
+	}
+
+	// Set the committer's new keys, and find the lowest node on the committer's path which is shared with the member.
+	path := t.directPath(c.Committer)
+	leafKey := decodeElement(c.LeafKey)
+	if leafKey == nil || len(c.Path) != len(path) {
+		return ErrInvalidCommit
+	}
+	t.setNode(2*c.Committer, leafKey, nil)
+
+	var (
+		pathSecret []byte
+		shared     = -1
+		child      = 2 * c.Committer
+	)
+	for i, x := range path {
+		node := c.Path[i]
+		copath := sibling(child)
+		if covers(copath, 2*g.self) {
+			// Decrypt the path secret with the private key of the node in the copath's resolution which the member
+			// knows. The resolution is computed before any of the committer's new keys are set, as none are in the
+			// copath.
+			resolution := t.resolution(copath)
+			ephemeral := decodeElement(node.Ephemeral)
+			if ephemeral == nil || len(node.Ciphertexts) != len(resolution) {
+				return ErrInvalidCommit
+			}
+			for j, y := range resolution {
+				if priv, ok := t.private[y]; ok {
+					dh := ristretto255.NewIdentityElement().ScalarMult(priv, ephemeral)
+					p := pathProtocol(g.state, c, x, node.Ephemeral, t.nodes[y], dh)
+					secret, err := p.Open("path-secret", nil, node.Ciphertexts[j])
+					if err != nil {
+						return ErrInvalidCommit
+					}
+					pathSecret, shared = secret, i
+					break
+				}
+			}
+			if shared < 0 {
+				return ErrInvalidCommit
+			}
+		}
+
+		pub := decodeElement(node.PublicKey)
+		if pub == nil {
+			return ErrInvalidCommit
+		}
+		t.setNode(x, pub, nil)
+		child = x
+	}
+
+	// Derive the private keys of the shared nodes from the path secret, checking them against the public keys.
+	for _, x := range path[shared:] {
+		var priv *ristretto255.Scalar
+		priv, pathSecret = deriveNode(g.domain, pathSecret)
+		if ristretto255.NewIdentityElement().ScalarBaseMult(priv).Equal(t.nodes[x]) != 1 {
+			return ErrInvalidCommit
+		}
+		t.private[x] = priv
+	}
+
+	g.epoch, g.tree, g.state = g.epoch+1, t, nextEpoch(g.state, c, pathSecret)
+	return nil
+}
+
+// Join creates a member's view of a group from a welcome, using the private key whose public key was added to the
+// group. Returns ErrInvalidWelcome if the welcome is malformed or cannot be decrypted.
+func Join(domain string, leafKey *ristretto255.Scalar, w *Welcome) (*Group, error) {
+	ephemeral := decodeElement(w.Ephemeral)
+	if ephemeral == nil {
+		return nil, ErrInvalidWelcome
+	}
+	q := ristretto255.NewIdentityElement().ScalarBaseMult(leafKey)
+	dh := ristretto255.NewIdentityElement().ScalarMult(leafKey, ephemeral)
+	b, err := welcomeProtocol(domain, w.Leaf, w.Ephemeral, q, dh).Open("welcome", nil, w.Ciphertext)
+	if err != nil {
+		return nil, ErrInvalidWelcome
+	}
+
+	// Decode the epoch, tree, and the lowest shared node's path secret.
+	if len(b) < 8+4 {
+		return nil, ErrInvalidWelcome
+	}
+	epoch, leaves := binary.BigEndian.Uint64(b), binary.BigEndian.Uint32(b[8:])
+	b = b[12:]
+	if leaves == 0 || leaves&(leaves-1) != 0 || w.Leaf >= leaves || uint64(len(b)) < 2*uint64(leaves) {
+		return nil, ErrInvalidWelcome
+	}
+	t := newTree(leaves)
+	for x := range t.nodes {
+		if len(b) < 1 {
+			return nil, ErrInvalidWelcome
+		}
+		present := b[0]
+		b = b[1:]
+		if present == 1 {
+			if len(b) < 32 {
+				return nil, ErrInvalidWelcome
+			}
+			if t.nodes[x] = decodeElement(b[:32]); t.nodes[x] == nil {
+				return nil, ErrInvalidWelcome
+			}
+			b = b[32:]
+		} else if present != 0 {
+			return nil, ErrInvalidWelcome
+		}
+	}
+	if len(b) < 4+32 || t.nodes[2*w.Leaf] == nil || t.nodes[2*w.Leaf].Equal(q) != 1 {
+		return nil, ErrInvalidWelcome
+	}
+	t.private[2*w.Leaf] = ristretto255.NewScalar().Set(leafKey)
+	lca, pathSecret := binary.BigEndian.Uint32(b), b[4:36]
+	b = b[36:]
+
+	// Derive the private keys of the nodes from the lowest shared node to the root, checking them against the public
+	// keys.
+	path := t.directPath(w.Leaf)
+	start := -1
+	for i, x := range path {
+		if x == lca {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, ErrInvalidWelcome
+	}
+	for _, x := range path[start:] {
+		var priv *ristretto255.Scalar
+		priv, pathSecret = deriveNode(domain, pathSecret)
+		if t.nodes[x] == nil || ristretto255.NewIdentityElement().ScalarBaseMult(priv).Equal(t.nodes[x]) != 1 {
+			return nil, ErrInvalidWelcome
+		}
+		t.private[x] = priv
+	}
+
+	state := new(thyrse.Protocol)
+	if err := state.UnmarshalBinary(b); err != nil {
+		return nil, ErrInvalidWelcome
+	}
+	return &Group{domain: domain, epoch: epoch, self: w.Leaf, tree: t, state: state}, nil
+}
+
+// applyProposals removes and adds members to the tree, returning the leaves assigned to added members. Added members
+// are assigned the leftmost blank leaves, growing the tree if necessary, and their paths are blanked.
+func applyProposals(t *tree, c *Commit, adds []*ristretto255.Element) ([]uint32, error) {
+	for i, leaf := range c.Removes {
+		if leaf == c.Committer || !t.occupied(leaf) {
+			return nil, ErrInvalidProposal
+		}
+		for _, other := range c.Removes[:i] {
+			if other == leaf {
+				return nil, ErrInvalidProposal
+			}
+		}
+		t.blank(leaf)
+	}
+
+	added := make([]uint32, len(adds))
+	for i, q := range adds {
+		leaf := uint32(0)
+		for t.occupied(leaf) {
+			leaf++
+		}
+		if leaf == t.leaves() {
+			t.grow()
+		}
+		t.blank(leaf)
+		t.setNode(2*leaf, q, nil)
+		added[i] = leaf
+	}
+	return added, nil
+}
+
+// welcome encrypts the group state for a new member.
+func welcome(domain string, r *thyrse.Protocol, t *tree, leaf, lca uint32, pathSecret []byte, epoch uint64, state *thyrse.Protocol) (*Welcome, error) {
+	b := binary.BigEndian.AppendUint64(nil, epoch)
+	b = binary.BigEndian.AppendUint32(b, t.leaves())
+	for _, q := range t.nodes {
+		if q == nil {
+			b = append(b, 0)
+		} else {
+			b = append(append(b, 1), q.Bytes()...)
+		}
+	}
+	b = binary.BigEndian.AppendUint32(b, lca)
+	b = append(b, pathSecret...)
+	s, err := state.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, s...)
+
+	e := group.DeriveRistretto255Scalar(r, "ephemeral")
+	w := &Welcome{Leaf: leaf, Ephemeral: ristretto255.NewIdentityElement().ScalarBaseMult(e).Bytes()}
+	dh := ristretto255.NewIdentityElement().ScalarMult(e, t.nodes[2*leaf])
+	w.Ciphertext = welcomeProtocol(domain, leaf, w.Ephemeral, t.nodes[2*leaf], dh).Seal("welcome", nil, b)
+	return w, nil
+}
+
+// deriveNode derives a node's private key and its parent's path secret from the node's path secret.
+func deriveNode(domain string, pathSecret []byte) (*ristretto255.Scalar, []byte) {
+	p := thyrse.New(domain)
+	p.Mix("path-secret", pathSecret)
+	priv := group.DeriveRistretto255Scalar(p, "node-key")
+	return priv, p.Derive("path-secret", nil, 32)
+}
+
+// mixCommit returns a clone of the epoch's state with the commit's proposals and committer's keys mixed in.
+func mixCommit(state *thyrse.Protocol, c *Commit) *thyrse.Protocol {
+	p := state.Clone()
+	p.MixUint64("committer", uint64(c.Committer))
+	for _, q := range c.Adds {
+		p.Mix("add", q)
+	}
+	for _, leaf := range c.Removes {
+		p.MixUint64("remove", uint64(leaf))
+	}
+	p.Mix("leaf-key", c.LeafKey)
+	return p
+}
+
+// pathProtocol returns a protocol for encrypting a path secret of the given node to a recipient node's public key.
+func pathProtocol(state *thyrse.Protocol, c *Commit, x uint32, ephemeral []byte, recipient, dh *ristretto255.Element) *thyrse.Protocol {
+	p := mixCommit(state, c)
+	p.Mix("purpose", []byte("path-secret"))
+	p.MixUint64("node", uint64(x))
+	p.Mix("ephemeral", ephemeral)
+	p.Mix("recipient", recipient.Bytes())
+	p.Mix("dh", dh.Bytes())
+	return p
+}
+
+// welcomeProtocol returns a protocol for encrypting a welcome to a new member's leaf key.
+func welcomeProtocol(domain string, leaf uint32, ephemeral []byte, recipient, dh *ristretto255.Element) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.MixUint64("welcome", uint64(leaf))
+	p.Mix("ephemeral", ephemeral)
+	p.Mix("recipient", recipient.Bytes())
+	p.Mix("dh", dh.Bytes())
+	return p
+}
+
+// nextEpoch returns the state of the epoch following the commit.
+func nextEpoch(state *thyrse.Protocol, c *Commit, commitSecret []byte) *thyrse.Protocol {
+	p := mixCommit(state, c)
+	for _, node := range c.Path {
+		p.Mix("path-key", node.PublicKey)
+		p.Mix("path-ephemeral", node.Ephemeral)
+		for _, ct := range node.Ciphertexts {
+			p.Mix("path-ciphertext", ct)
+		}
+	}
+	p.Mix("commit-secret", commitSecret)
+	p.Ratchet("epoch")
+	return p
+}
+
+// decodeElement decodes a non-identity element, returning nil if it is invalid.
+func decodeElement(b []byte) *ristretto255.Element {
+	q, _ := ristretto255.NewIdentityElement().SetCanonicalBytes(b)
+	if q == nil || q.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return nil
+	}
+	return q
+}
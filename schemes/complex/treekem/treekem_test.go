@@ -0,0 +1,253 @@
+package treekem_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/treekem"
+	"github.com/gtank/ristretto255"
+)
+
+// groupKey returns a value derived from the group's current epoch protocol.
+func groupKey(g *treekem.Group) []byte {
+	return g.Protocol().Derive("key", nil, 16)
+}
+
+// checkAgreement fails the test unless all the groups are in the same epoch with the same protocol.
+func checkAgreement(t *testing.T, groups ...*treekem.Group) {
+	t.Helper()
+
+	for _, g := range groups[1:] {
+		if got, want := g.Epoch(), groups[0].Epoch(); got != want {
+			t.Fatalf("member %d Epoch() = %d, want = %d", g.Leaf(), got, want)
+		}
+		if got, want := groupKey(g), groupKey(groups[0]); !bytes.Equal(got, want) {
+			t.Fatalf("member %d key = %x, want = %x", g.Leaf(), got, want)
+		}
+	}
+}
+
+// apply applies the commit to every group, failing the test on error.
+func apply(t *testing.T, c *treekem.Commit, groups ...*treekem.Group) {
+	t.Helper()
+
+	for _, g := range groups {
+		if err := g.Apply(c); err != nil {
+			t.Fatalf("member %d Apply() err = %v", g.Leaf(), err)
+		}
+	}
+}
+
+// join joins the group with each welcome, failing the test on error.
+func join(t *testing.T, keys []*ristretto255.Scalar, welcomes []*treekem.Welcome) []*treekem.Group {
+	t.Helper()
+
+	groups := make([]*treekem.Group, len(welcomes))
+	for i, w := range welcomes {
+		var err error
+		if groups[i], err = treekem.Join("treekem", keys[i], w); err != nil {
+			t.Fatalf("Join() err = %v", err)
+		}
+	}
+	return groups
+}
+
+func TestGroup(t *testing.T) {
+	drbg := testdata.New("thyrse treekem")
+	dA, _ := drbg.KeyPair()
+	var (
+		keys []*ristretto255.Scalar
+		pubs []*ristretto255.Element
+	)
+	for range 5 {
+		d, q := drbg.KeyPair()
+		keys, pubs = append(keys, d), append(pubs, q)
+	}
+
+	a := treekem.NewGroup("treekem", []byte("group"), dA, drbg.Data(64))
+
+	// Alice adds Bea and Cho.
+	c, welcomes, err := a.Commit(pubs[:2], nil, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Epoch != 0 {
+		t.Errorf("Commit.Epoch = %d, want = 0", c.Epoch)
+	}
+	joined := join(t, keys[:2], welcomes)
+	b, ch := joined[0], joined[1]
+	checkAgreement(t, a, b, ch)
+
+	t.Run("update", func(t *testing.T) {
+		before := groupKey(a)
+		c, welcomes, err := b.Commit(nil, nil, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(welcomes) != 0 {
+			t.Errorf("len(welcomes) = %d, want = 0", len(welcomes))
+		}
+		apply(t, c, a, ch)
+		checkAgreement(t, a, b, ch)
+		if bytes.Equal(groupKey(a), before) {
+			t.Error("update did not change the group key")
+		}
+	})
+
+	// Cho adds three more members, growing the tree.
+	c, welcomes, err = ch.Commit(pubs[2:], nil, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(t, c, a, b)
+	joined = join(t, keys[2:], welcomes)
+	all := append([]*treekem.Group{a, b, ch}, joined...)
+	checkAgreement(t, all...)
+
+	t.Run("members", func(t *testing.T) {
+		if got, want := a.Members(), []uint32{0, 1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+			t.Errorf("Members() = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("every member commits", func(t *testing.T) {
+		for i, g := range all {
+			c, _, err := g.Commit(nil, nil, drbg.Data(64))
+			if err != nil {
+				t.Fatal(err)
+			}
+			apply(t, c, slices.Delete(slices.Clone(all), i, i+1)...)
+			checkAgreement(t, all...)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		before := groupKey(ch)
+		c, _, err := a.Commit(nil, []uint32{ch.Leaf()}, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ch.Apply(c); !errors.Is(err, treekem.ErrRemoved) {
+			t.Errorf("Apply() err = %v, want = %v", err, treekem.ErrRemoved)
+		}
+		remaining := slices.DeleteFunc(slices.Clone(all), func(g *treekem.Group) bool { return g == ch })
+		apply(t, c, remaining[1:]...)
+		checkAgreement(t, remaining...)
+		if bytes.Equal(groupKey(a), before) {
+			t.Error("removal did not change the group key")
+		}
+
+		// The removed member's leaf is reused by the next added member.
+		dD, qD := drbg.KeyPair()
+		c, welcomes, err := b.Commit([]*ristretto255.Element{qD}, nil, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := welcomes[0].Leaf, ch.Leaf(); got != want {
+			t.Errorf("Welcome.Leaf = %d, want = %d", got, want)
+		}
+		apply(t, c, slices.DeleteFunc(slices.Clone(remaining), func(g *treekem.Group) bool { return g == b })...)
+		d, err := treekem.Join("treekem", dD, welcomes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkAgreement(t, append(remaining, d)...)
+	})
+}
+
+func TestGroup_Commit(t *testing.T) {
+	drbg := testdata.New("thyrse treekem commit")
+	dA, _ := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	a := treekem.NewGroup("treekem", []byte("group"), dA, drbg.Data(64))
+	c, welcomes, err := a.Commit([]*ristretto255.Element{qB}, nil, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := treekem.Join("treekem", dB, welcomes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, removes := range map[string][]uint32{
+		"self":      {a.Leaf()},
+		"blank":     {7},
+		"duplicate": {b.Leaf(), b.Leaf()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := a.Commit(nil, removes, drbg.Data(64)); !errors.Is(err, treekem.ErrInvalidProposal) {
+				t.Errorf("Commit() err = %v, want = %v", err, treekem.ErrInvalidProposal)
+			}
+		})
+	}
+
+	t.Run("identity key", func(t *testing.T) {
+		if _, _, err := a.Commit([]*ristretto255.Element{ristretto255.NewIdentityElement()}, nil, drbg.Data(64)); !errors.Is(err, treekem.ErrInvalidProposal) {
+			t.Errorf("Commit() err = %v, want = %v", err, treekem.ErrInvalidProposal)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		dX, _ := drbg.KeyPair()
+		if _, err := treekem.Join("treekem", dX, welcomes[0]); !errors.Is(err, treekem.ErrInvalidWelcome) {
+			t.Errorf("Join() err = %v, want = %v", err, treekem.ErrInvalidWelcome)
+		}
+	})
+
+	t.Run("old epoch", func(t *testing.T) {
+		if err := b.Apply(c); !errors.Is(err, treekem.ErrInvalidCommit) {
+			t.Errorf("Apply() err = %v, want = %v", err, treekem.ErrInvalidCommit)
+		}
+	})
+}
+
+func TestGroup_Apply(t *testing.T) {
+	drbg := testdata.New("thyrse treekem apply")
+	dA, _ := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	a := treekem.NewGroup("treekem", []byte("group"), dA, drbg.Data(64))
+	_, welcomes, err := a.Commit([]*ristretto255.Element{qB}, nil, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := treekem.Join("treekem", dB, welcomes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _, err := a.Commit(nil, nil, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("modified ciphertext", func(t *testing.T) {
+		bad := *c
+		bad.Path = slices.Clone(c.Path)
+		bad.Path[0].Ciphertexts = [][]byte{slices.Clone(c.Path[0].Ciphertexts[0])}
+		bad.Path[0].Ciphertexts[0][0] ^= 1
+		before := groupKey(b)
+		if err := b.Apply(&bad); !errors.Is(err, treekem.ErrInvalidCommit) {
+			t.Errorf("Apply() err = %v, want = %v", err, treekem.ErrInvalidCommit)
+		}
+		if got, want := groupKey(b), before; !bytes.Equal(got, want) {
+			t.Error("failed Apply() modified the group")
+		}
+	})
+
+	t.Run("modified public key", func(t *testing.T) {
+		bad := *c
+		_, q := drbg.KeyPair()
+		bad.Path = []treekem.PathNode{{PublicKey: q.Bytes(), Ephemeral: c.Path[0].Ephemeral, Ciphertexts: c.Path[0].Ciphertexts}}
+		if err := b.Apply(&bad); !errors.Is(err, treekem.ErrInvalidCommit) {
+			t.Errorf("Apply() err = %v, want = %v", err, treekem.ErrInvalidCommit)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		apply(t, c, b)
+		checkAgreement(t, a, b)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"slices"
 
 	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/group"
 	"github.com/gtank/ristretto255"
 )
 
@@ -32,7 +33,7 @@ func Prove(domain string, d *ristretto255.Scalar, rand, m []byte, n int) (prf, p
 	// Calculate a hedged nonce k.
 	prover.Mix("prover-private", d.Bytes())
 	prover.Mix("rand", rand)
-	k, _ := ristretto255.NewScalar().SetUniformBytes(prover.Derive("commitment", nil, 64))
+	k := group.DeriveRistretto255Scalar(prover, "commitment")
 
 	// Calculate the commitment points.
 	u := ristretto255.NewIdentityElement().ScalarBaseMult(k)
@@ -41,7 +42,7 @@ func Prove(domain string, d *ristretto255.Scalar, rand, m []byte, n int) (prf, p
 	verifier.Mix("commitment-v", v.Bytes())
 
 	// Calculate a challenge and a response.
-	c, _ := ristretto255.NewScalar().SetUniformBytes(verifier.Derive("challenge", nil, 64))
+	c := group.DeriveRistretto255Scalar(verifier, "challenge")
 	s := ristretto255.NewScalar().Multiply(c, d)
 	s = s.Add(s, k)
 
@@ -88,7 +89,7 @@ func Verify(domain string, q *ristretto255.Element, m, proof []byte, n int) (val
 	verifier.Mix("commitment-v", v.Bytes())
 
 	// Calculate a challenge and a response.
-	expectedC, _ := ristretto255.NewScalar().SetUniformBytes(verifier.Derive("challenge", nil, 64))
+	expectedC := group.DeriveRistretto255Scalar(verifier, "challenge")
 	if expectedC.Equal(c) == 0 {
 		return false, nil
 	}
@@ -0,0 +1,149 @@
+// Package x3dh implements an [X3DH]-style asynchronous key agreement using Thyrse and Ristretto255.
+//
+// A responder publishes a Bundle of its identity key, a signed prekey, and optionally a one-time prekey. An initiator
+// who has the bundle calls Initiate to derive a shared protocol and an InitialMessage, which it sends to the responder
+// along with its first messages. The responder calls Respond with the message to derive the same protocol.
+//
+// The shared protocol is suitable for bootstrapping an asynchronous double ratchet with package adratchet. The
+// initiator uses the responder's signed prekey as the remote key:
+//
+//	a := adratchet.NewInitiator(p, identity, bundle.SignedPrekey)
+//
+// and the responder uses the private key of its signed prekey as the local key:
+//
+//	b := adratchet.NewResponder(p, signedPrekey, msg.EphemeralKey)
+//
+// [X3DH]: https://signal.org/docs/specifications/x3dh/
+package x3dh
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/schemes/complex/sig"
+	"github.com/gtank/ristretto255"
+)
+
+var (
+	// ErrInvalidBundle is returned when a prekey bundle's signature is invalid or one of its keys is the identity
+	// element.
+	ErrInvalidBundle = errors.New("thyrse/x3dh: invalid prekey bundle")
+
+	// ErrInvalidMessage is returned when an initial message is invalid or does not match the responder's prekeys.
+	ErrInvalidMessage = errors.New("thyrse/x3dh: invalid initial message")
+)
+
+// A Bundle is a responder's published set of public keys.
+type Bundle struct {
+	IdentityKey   *ristretto255.Element // The responder's long-term identity key.
+	SignedPrekey  *ristretto255.Element // The responder's medium-term prekey.
+	Signature     []byte                // The identity key's signature of the signed prekey (see SignPrekey).
+	OneTimePrekey *ristretto255.Element // A single-use prekey, or nil if the responder has none left.
+}
+
+// An InitialMessage is sent by the initiator to the responder to complete the key agreement.
+type InitialMessage struct {
+	IdentityKey   *ristretto255.Element // The initiator's long-term identity key.
+	EphemeralKey  *ristretto255.Element // The initiator's ephemeral key.
+	OneTimePrekey *ristretto255.Element // The responder's one-time prekey which was used, or nil if none was used.
+}
+
+// SignPrekey uses the responder's identity private key and an optional slice of random data to sign a prekey for
+// inclusion in a Bundle.
+func SignPrekey(domain string, identity *ristretto255.Scalar, prekey *ristretto255.Element, rand []byte) []byte {
+	signature, _ := sig.Sign(domain, identity, rand, bytes.NewReader(prekey.Bytes()))
+	return signature
+}
+
+// Initiate performs a key agreement as the initiator, using the given domain separation string, the initiator's
+// identity private key, the responder's bundle, and a random value (which must be exactly 64 bytes). Returns a shared
+// protocol and an InitialMessage to be sent to the responder.
+//
+// Returns ErrInvalidBundle if the bundle's signature is invalid or any of its keys are the identity element.
+//
+// Panics if rand is not exactly 64 bytes.
+func Initiate(domain string, identity *ristretto255.Scalar, bundle *Bundle, rand []byte) (*thyrse.Protocol, *InitialMessage, error) {
+	if len(rand) != 64 {
+		panic("thyrse/x3dh: rand must be 64 bytes")
+	}
+
+	// Check the bundle's keys and the signature of the signed prekey.
+	if isIdentity(bundle.IdentityKey) || isIdentity(bundle.SignedPrekey) ||
+		(bundle.OneTimePrekey != nil && isIdentity(bundle.OneTimePrekey)) {
+		return nil, nil, ErrInvalidBundle
+	}
+	if valid, _ := sig.Verify(domain, bundle.IdentityKey, bundle.Signature, bytes.NewReader(bundle.SignedPrekey.Bytes())); !valid {
+		return nil, nil, ErrInvalidBundle
+	}
+
+	// Generate an ephemeral key pair.
+	ephemeral, _ := ristretto255.NewScalar().SetUniformBytes(rand)
+	msg := &InitialMessage{
+		IdentityKey:   ristretto255.NewIdentityElement().ScalarBaseMult(identity),
+		EphemeralKey:  ristretto255.NewIdentityElement().ScalarBaseMult(ephemeral),
+		OneTimePrekey: bundle.OneTimePrekey,
+	}
+
+	// Calculate the shared secrets and mix them into the protocol.
+	p := newProtocol(domain, msg, bundle.IdentityKey, bundle.SignedPrekey)
+	p.Mix("dh1", ristretto255.NewIdentityElement().ScalarMult(identity, bundle.SignedPrekey).Bytes())
+	p.Mix("dh2", ristretto255.NewIdentityElement().ScalarMult(ephemeral, bundle.IdentityKey).Bytes())
+	p.Mix("dh3", ristretto255.NewIdentityElement().ScalarMult(ephemeral, bundle.SignedPrekey).Bytes())
+	if bundle.OneTimePrekey != nil {
+		p.Mix("dh4", ristretto255.NewIdentityElement().ScalarMult(ephemeral, bundle.OneTimePrekey).Bytes())
+	}
+	p.Ratchet("x3dh")
+	return p, msg, nil
+}
+
+// Respond completes a key agreement as the responder, using the given domain separation string, the responder's
+// identity private key, signed prekey private key, one-time prekey private key (or nil if the initiator did not use
+// one), and the initiator's message. Returns the shared protocol.
+//
+// Returns ErrInvalidMessage if the message's keys are the identity element, or if the message's one-time prekey does not
+// match oneTimePrekey. Once Respond succeeds, the responder MUST delete the one-time prekey.
+func Respond(domain string, identity, signedPrekey, oneTimePrekey *ristretto255.Scalar, msg *InitialMessage) (*thyrse.Protocol, error) {
+	if isIdentity(msg.IdentityKey) || isIdentity(msg.EphemeralKey) {
+		return nil, ErrInvalidMessage
+	}
+
+	// Check that the initiator used the given one-time prekey, if any.
+	switch {
+	case oneTimePrekey == nil && msg.OneTimePrekey != nil, oneTimePrekey != nil && msg.OneTimePrekey == nil:
+		return nil, ErrInvalidMessage
+	case oneTimePrekey != nil && ristretto255.NewIdentityElement().ScalarBaseMult(oneTimePrekey).Equal(msg.OneTimePrekey) != 1:
+		return nil, ErrInvalidMessage
+	}
+
+	// Calculate the shared secrets and mix them into the protocol.
+	p := newProtocol(domain, msg,
+		ristretto255.NewIdentityElement().ScalarBaseMult(identity),
+		ristretto255.NewIdentityElement().ScalarBaseMult(signedPrekey))
+	p.Mix("dh1", ristretto255.NewIdentityElement().ScalarMult(signedPrekey, msg.IdentityKey).Bytes())
+	p.Mix("dh2", ristretto255.NewIdentityElement().ScalarMult(identity, msg.EphemeralKey).Bytes())
+	p.Mix("dh3", ristretto255.NewIdentityElement().ScalarMult(signedPrekey, msg.EphemeralKey).Bytes())
+	if oneTimePrekey != nil {
+		p.Mix("dh4", ristretto255.NewIdentityElement().ScalarMult(oneTimePrekey, msg.EphemeralKey).Bytes())
+	}
+	p.Ratchet("x3dh")
+	return p, nil
+}
+
+// newProtocol returns a protocol with both parties' public keys mixed in.
+func newProtocol(domain string, msg *InitialMessage, identityKey, signedPrekey *ristretto255.Element) *thyrse.Protocol {
+	p := thyrse.New(domain)
+	p.Mix("initiator-identity", msg.IdentityKey.Bytes())
+	p.Mix("responder-identity", identityKey.Bytes())
+	p.Mix("signed-prekey", signedPrekey.Bytes())
+	if msg.OneTimePrekey != nil {
+		p.Mix("one-time-prekey", msg.OneTimePrekey.Bytes())
+	}
+	p.Mix("ephemeral", msg.EphemeralKey.Bytes())
+	return p
+}
+
+// isIdentity returns true if q is the identity element.
+func isIdentity(q *ristretto255.Element) bool {
+	return q.Equal(ristretto255.NewIdentityElement()) == 1
+}
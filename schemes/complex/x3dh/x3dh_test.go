@@ -0,0 +1,138 @@
+package x3dh_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/schemes/complex/adratchet"
+	"github.com/codahale/thyrse/schemes/complex/x3dh"
+)
+
+func Example() {
+	drbg := testdata.New("thyrse x3dh")
+
+	// Bea has an identity key, a signed prekey, and a one-time prekey, and publishes a bundle of them.
+	dB, qB := drbg.KeyPair()
+	dSPK, qSPK := drbg.KeyPair()
+	dOPK, qOPK := drbg.KeyPair()
+	bundle := &x3dh.Bundle{
+		IdentityKey:   qB,
+		SignedPrekey:  qSPK,
+		Signature:     x3dh.SignPrekey("example", dB, qSPK, drbg.Data(64)),
+		OneTimePrekey: qOPK,
+	}
+
+	// Alice has an identity key and uses Bea's bundle to start a double ratchet and send her a message.
+	dA, _ := drbg.KeyPair()
+	pA, msg, err := x3dh.Initiate("example", dA, bundle, drbg.Data(64))
+	if err != nil {
+		panic(err)
+	}
+	a := adratchet.NewInitiator(pA, dA, bundle.SignedPrekey)
+	ciphertext := a.SendMessage([]byte("hello, Bea"))
+
+	// Bea later receives Alice's initial message and ciphertext, and completes the key agreement.
+	pB, err := x3dh.Respond("example", dB, dSPK, dOPK, msg)
+	if err != nil {
+		panic(err)
+	}
+	b := adratchet.NewResponder(pB, dSPK, msg.EphemeralKey)
+	v, err := b.ReceiveMessage(ciphertext)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("message from A: %q\n", v)
+
+	// Output:
+	// message from A: "hello, Bea"
+}
+
+func TestInitiate(t *testing.T) {
+	drbg := testdata.New("thyrse x3dh test")
+	dA, _ := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	dSPK, qSPK := drbg.KeyPair()
+	dOPK, qOPK := drbg.KeyPair()
+	signature := x3dh.SignPrekey("x3dh", dB, qSPK, nil)
+
+	t.Run("one-time prekey", func(t *testing.T) {
+		bundle := &x3dh.Bundle{IdentityKey: qB, SignedPrekey: qSPK, Signature: signature, OneTimePrekey: qOPK}
+		pA, msg, err := x3dh.Initiate("x3dh", dA, bundle, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pB, err := x3dh.Respond("x3dh", dB, dSPK, dOPK, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := pB.Derive("key", nil, 16), pA.Derive("key", nil, 16); !bytes.Equal(got, want) {
+			t.Errorf("Derive() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("no one-time prekey", func(t *testing.T) {
+		bundle := &x3dh.Bundle{IdentityKey: qB, SignedPrekey: qSPK, Signature: signature}
+		pA, msg, err := x3dh.Initiate("x3dh", dA, bundle, drbg.Data(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pB, err := x3dh.Respond("x3dh", dB, dSPK, nil, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := pB.Derive("key", nil, 16), pA.Derive("key", nil, 16); !bytes.Equal(got, want) {
+			t.Errorf("Derive() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		bundle := &x3dh.Bundle{IdentityKey: qB, SignedPrekey: qOPK, Signature: signature}
+		if _, _, err := x3dh.Initiate("x3dh", dA, bundle, drbg.Data(64)); !errors.Is(err, x3dh.ErrInvalidBundle) {
+			t.Errorf("Initiate() err = %v, want = %v", err, x3dh.ErrInvalidBundle)
+		}
+	})
+}
+
+func TestRespond(t *testing.T) {
+	drbg := testdata.New("thyrse x3dh test")
+	dA, _ := drbg.KeyPair()
+	dB, qB := drbg.KeyPair()
+	dSPK, qSPK := drbg.KeyPair()
+	dOPK, qOPK := drbg.KeyPair()
+	dX, _ := drbg.KeyPair()
+	bundle := &x3dh.Bundle{
+		IdentityKey:   qB,
+		SignedPrekey:  qSPK,
+		Signature:     x3dh.SignPrekey("x3dh", dB, qSPK, nil),
+		OneTimePrekey: qOPK,
+	}
+	pA, msg, err := x3dh.Initiate("x3dh", dA, bundle, drbg.Data(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("wrong one-time prekey", func(t *testing.T) {
+		if _, err := x3dh.Respond("x3dh", dB, dSPK, dX, msg); !errors.Is(err, x3dh.ErrInvalidMessage) {
+			t.Errorf("Respond() err = %v, want = %v", err, x3dh.ErrInvalidMessage)
+		}
+	})
+
+	t.Run("missing one-time prekey", func(t *testing.T) {
+		if _, err := x3dh.Respond("x3dh", dB, dSPK, nil, msg); !errors.Is(err, x3dh.ErrInvalidMessage) {
+			t.Errorf("Respond() err = %v, want = %v", err, x3dh.ErrInvalidMessage)
+		}
+	})
+
+	t.Run("wrong signed prekey", func(t *testing.T) {
+		pB, err := x3dh.Respond("x3dh", dB, dX, dOPK, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(pB.Derive("key", nil, 16), pA.Clone().Derive("key", nil, 16)) {
+			t.Error("Respond() derived the same key with the wrong signed prekey")
+		}
+	})
+}
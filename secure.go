@@ -0,0 +1,118 @@
+package thyrse
+
+import (
+	"bytes"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/codahale/kt128"
+	"github.com/codahale/thyrse/hazmat/secmem"
+)
+
+// secureMemory is the package-level policy set by SetSecureMemory.
+var secureMemory atomic.Bool
+
+// SetSecureMemory sets whether protocols created by [New], [NewWithVersion], and [Protocol.UnmarshalBinary] use secure
+// memory, as if created by [NewSecure]. It is disabled by default, and does not affect existing protocols.
+func SetSecureMemory(enabled bool) {
+	secureMemory.Store(enabled)
+}
+
+// NewSecure creates a new protocol instance like [New], which keeps its state in secure memory for applications which
+// must limit where key material resides.
+//
+// A serializable secure protocol (see [Protocol.SetSerializable]) records its transcript in a buffer locked into RAM,
+// taken from a shared pool of locked pages (see [secmem.Pool]), so it is never written to swap. Where memory cannot be
+// locked, e.g. because of a resource limit, the buffer is an ordinary one, and [Protocol.Locked] reports false. Either
+// way, the protocol's state is wiped by [Protocol.Clear] or, if it is never cleared, when it is garbage collected.
+// Clones and forks of a secure protocol are also secure.
+//
+// The KT128 hasher's state is owned by the kt128 package and cannot be locked, but is reset along with the transcript.
+// Intermediate chain values and keys are wiped after use by all protocols, secure or not.
+func NewSecure(label string) *Protocol {
+	p := &Protocol{}
+	p.init(true)
+	p.writeInit(label)
+	return p
+}
+
+// Secure reports whether the protocol keeps its state in secure memory.
+func (p *Protocol) Secure() bool {
+	return p.secure != nil
+}
+
+// init initializes the protocol with a new hasher, in secure memory if secure is true.
+func (p *Protocol) init(secure bool) {
+	p.h = kt128.New(nil)
+	if secure {
+		p.makeSecure()
+	}
+}
+
+// makeSecure arranges for the protocol's state to be wiped if it is garbage collected without being cleared, and moves
+// its transcript into secure memory if it is serializable. Otherwise, the protocol has recorded nothing more than its
+// public Init frame, which is copied as is.
+func (p *Protocol) makeSecure() {
+	s := &secureState{h: p.h}
+	p.secure = s
+	p.cleanup = runtime.AddCleanup(p, (*secureState).clear, s)
+	if p.serializable {
+		p.lockTranscript()
+	} else {
+		p.transcript = bytes.Clone(p.transcript)
+	}
+}
+
+// lockTranscript moves the secure protocol's transcript into a buffer from the locked pool, or an ordinary buffer if the
+// pool cannot lock any more memory. The buffer holds the most the protocol records, so it never grows.
+func (p *Protocol) lockTranscript() {
+	s := p.secure
+	if s.transcript != nil {
+		return
+	}
+	buf, err := securePool.Get()
+	if err == nil {
+		s.locked = true
+	} else {
+		buf = make([]byte, maxTranscriptSize)
+	}
+	s.transcript = buf
+	p.transcript = append(buf[:0], p.transcript...)
+}
+
+// securePool is the pool of locked transcript buffers shared by all secure protocols.
+var securePool = secmem.NewPool(maxTranscriptSize)
+
+// Locked reports whether the protocol is secure and keeps its recorded transcript, if it has one, in memory locked into
+// RAM. It returns false if the protocol is not secure, or if it is serializable and its transcript buffer could not be
+// locked (e.g. because of a limit on locked memory).
+func (p *Protocol) Locked() bool {
+	return p.secure != nil && (p.secure.transcript == nil || p.secure.locked)
+}
+
+// secureState is the state of a secure protocol which is wiped when it is cleared or garbage collected. It does not
+// refer to the protocol, so it can be used by the protocol's cleanup.
+type secureState struct {
+	h          *kt128.Hasher
+	transcript []byte
+	locked     bool
+}
+
+// clear resets the hasher and releases the transcript buffer.
+func (s *secureState) clear() {
+	s.h.Reset()
+	s.release()
+}
+
+// release wipes the transcript buffer, if any, and returns it to the pool if it is locked.
+func (s *secureState) release() {
+	if s.transcript == nil {
+		return
+	}
+	if s.locked {
+		securePool.Put(s.transcript)
+	} else {
+		secmem.Wipe(s.transcript[:cap(s.transcript)])
+	}
+	s.transcript, s.locked = nil, false
+}
@@ -0,0 +1,158 @@
+package thyrse
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewSecure(t *testing.T) {
+	t.Run("same transcript as New", func(t *testing.T) {
+		a, b := New("test"), NewSecure("test")
+		a.Mix("key", []byte("key"))
+		b.Mix("key", []byte("key"))
+		if a.Equal(b) != 1 {
+			t.Error("secure protocol has a different transcript")
+		}
+		if !bytes.Equal(a.Derive("out", nil, 32), b.Derive("out", nil, 32)) {
+			t.Error("secure protocol derived different output")
+		}
+		if a.Secure() || !b.Secure() {
+			t.Errorf("Secure() = %v, %v, want = false, true", a.Secure(), b.Secure())
+		}
+	})
+
+	t.Run("clones and forks", func(t *testing.T) {
+		p := NewSecure("test")
+		p.SetSerializable(true)
+		p.Mix("key", []byte("key"))
+		c := p.Clone()
+		l, r := p.Fork("role", []byte("l"), []byte("r"))
+		for name, q := range map[string]*Protocol{"clone": c, "left": l, "right": r} {
+			if !q.Secure() {
+				t.Errorf("%s is not secure", name)
+			}
+		}
+
+		if &c.transcript[0] == &p.transcript[0] {
+			t.Error("clone shares its transcript buffer")
+		}
+	})
+
+	t.Run("long transcript", func(t *testing.T) {
+		a, b := New("test"), NewSecure("test")
+		b.SetSerializable(true)
+		data := bytes.Repeat([]byte{0xaa}, 2*maxTranscriptSize)
+		a.Mix("data", data)
+		b.Mix("data", data)
+		if _, err := b.MarshalBinary(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("MarshalBinary() err = %v, want = %v", err, ErrNotSerializable)
+		}
+		if got, want := cap(b.transcript), maxTranscriptSize; got != want {
+			t.Errorf("cap(transcript) = %d, want = %d", got, want)
+		}
+		if !bytes.Equal(a.Derive("out", nil, 32), b.Derive("out", nil, 32)) {
+			t.Error("secure protocol derived different output")
+		}
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		if New("test").Locked() {
+			t.Error("Locked() = true for an insecure protocol")
+		}
+
+		p := NewSecure("test")
+		if p.secure.transcript != nil {
+			t.Error("unserializable protocol has a transcript buffer")
+		}
+		if !p.Locked() {
+			t.Error("Locked() = false for an unserializable protocol")
+		}
+
+		p.SetSerializable(true)
+		if got, want := p.Locked(), p.secure.locked; got != want {
+			t.Errorf("Locked() = %v, want = %v", got, want)
+		}
+		if got, want := cap(p.transcript), maxTranscriptSize; got != want {
+			t.Errorf("cap(transcript) = %d, want = %d", got, want)
+		}
+
+		p.SetSerializable(false)
+		if p.secure.transcript != nil || p.transcript != nil {
+			t.Error("SetSerializable(false) did not release the transcript buffer")
+		}
+	})
+
+	t.Run("pooled buffers", func(t *testing.T) {
+		p := NewSecure("test")
+		p.SetSerializable(true)
+		if !p.Locked() {
+			t.Skip("memory cannot be locked")
+		}
+		buf := &p.secure.transcript[:1][0]
+		p.Clear()
+
+		q := NewSecure("test")
+		q.SetSerializable(true)
+		if &q.secure.transcript[:1][0] != buf {
+			t.Error("cleared protocol's buffer was not reused")
+		}
+		q.Clear()
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		p := NewSecure("test")
+		p.Mix("key", []byte("key"))
+		buf := p.secure
+		p.Clear()
+		if p.Secure() || buf.transcript != nil {
+			t.Error("Clear() did not release the secure state")
+		}
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		p := NewSecure("test")
+		p.SetSerializable(true)
+		p.Mix("key", []byte("key"))
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restored := NewSecure("other")
+		if err := restored.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if !restored.Secure() || restored.Equal(p) != 1 {
+			t.Error("restored protocol is not a secure copy")
+		}
+	})
+}
+
+func TestSetSecureMemory(t *testing.T) {
+	SetSecureMemory(true)
+	defer SetSecureMemory(false)
+
+	if !New("test").Secure() {
+		t.Error("New() is not secure")
+	}
+
+	p := New("test")
+	p.SetSerializable(true)
+	var restored Protocol
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Secure() {
+		t.Error("UnmarshalBinary() is not secure")
+	}
+
+	SetSecureMemory(false)
+	if New("test").Secure() {
+		t.Error("New() is secure after the policy is disabled")
+	}
+}
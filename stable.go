@@ -0,0 +1,7 @@
+//go:build !thyrse_experimental
+
+package thyrse
+
+// Experimental reports whether the package was built with the thyrse_experimental build tag, which enables operations
+// whose transcript encodings are not yet part of the stable specification.
+const Experimental = false
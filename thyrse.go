@@ -2,13 +2,20 @@
 package thyrse
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/subtle"
+	"encoding"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"sync"
 
 	"github.com/codahale/kt128"
+	"github.com/codahale/thyrse/hazmat/secmem"
 	"github.com/codahale/thyrse/internal/enc"
 	"github.com/codahale/thyrse/internal/mem"
 )
@@ -17,10 +24,57 @@ import (
 // transcript after the ciphertext is absorbed.
 const TagSize = 32
 
+// TranscriptTagSize is the size in bytes of a tag produced by [Protocol.TranscriptTag].
+const TranscriptTagSize = 32
+
+// TranscriptHashSize is the size in bytes of a digest produced by [Protocol.TranscriptHash].
+const TranscriptHashSize = 32
+
 // ErrInvalidCiphertext is returned by [Protocol.Open] when tag verification fails. After a failed Open, the
 // protocol's transcript has diverged from the sender's because it absorbed a different ciphertext.
+//
+// More specific errors (ErrTruncated, ErrTagMismatch, ErrDesynchronized, and ErrTooLarge) are returned
+// where the cause of a failure is known. Each wraps ErrInvalidCiphertext, so callers which do not need to distinguish
+// between them should continue to check errors.Is(err, ErrInvalidCiphertext).
 var ErrInvalidCiphertext = errors.New("thyrse: authentication failed")
 
+var (
+	// ErrTruncated is returned when a ciphertext, tag, or stream ends before it is complete.
+	ErrTruncated = fmt.Errorf("%w: truncated", ErrInvalidCiphertext)
+
+	// ErrTagMismatch is returned when a well-formed ciphertext's tag does not match the expected tag.
+	ErrTagMismatch = fmt.Errorf("%w: tag mismatch", ErrInvalidCiphertext)
+
+	// ErrDesynchronized is returned when a receiver's transcript has diverged from the sender's after an earlier
+	// failure, so no further data can be authenticated.
+	ErrDesynchronized = fmt.Errorf("%w: desynchronized", ErrInvalidCiphertext)
+
+	// ErrTooLarge is returned when a value in a ciphertext exceeds a receiver's limits.
+	ErrTooLarge = fmt.Errorf("%w: too large", ErrInvalidCiphertext)
+)
+
+// ErrClosed is returned when a writer, log, or other stateful object is used after it has been closed. It indicates
+// misuse by the caller rather than a problem with any ciphertext, so it does not wrap ErrInvalidCiphertext.
+var ErrClosed = errors.New("thyrse: closed")
+
+// ErrTranscriptMismatch is returned by [Protocol.VerifyTranscriptTag] when a peer's transcript tag does not match.
+// After a failed verification, the protocol's transcript has diverged from the peer's because it absorbed a different
+// tag.
+var ErrTranscriptMismatch = errors.New("thyrse: transcript mismatch")
+
+// ErrNotSerializable is returned by [Protocol.MarshalBinary] when the protocol is not serializable (see
+// [Protocol.SetSerializable]), did not record its whole transcript since its last chain boundary, or has absorbed more
+// than 4 KiB since then. Call [Protocol.Ratchet] before serializing.
+var ErrNotSerializable = errors.New("thyrse: protocol state is not serializable")
+
+// ErrInvalidState is returned by [Protocol.UnmarshalBinary] when the serialized state is malformed, corrupted, or of an
+// unsupported version.
+var ErrInvalidState = errors.New("thyrse: invalid serialized state")
+
+// ErrUnsupportedVersion is returned by [Protocol.CheckVersion] when a protocol's application protocol version is
+// missing or older than required.
+var ErrUnsupportedVersion = errors.New("thyrse: unsupported protocol version")
+
 // Protocol is a transcript-based cryptographic protocol instance.
 //
 // Operations append frames to an internal transcript. Finalizing operations evaluate KT128 over the
@@ -31,17 +85,65 @@ var ErrInvalidCiphertext = errors.New("thyrse: authentication failed")
 // the lists they delimit. Reading right to left from an op code, every variable-length element is therefore
 // delimited by information already read, making the transcript a recoverable encoding of the operation sequence.
 type Protocol struct {
-	h *kt128.Hasher
+	h            *kt128.Hasher
+	absorbed     uint64
+	transcript   []byte // bytes absorbed since the last chain boundary, if recorded
+	serializable bool   // whether to record the transcript
+	autoRatchet  uint64
+	version      uint32
+	versioned    bool
+	stats        Stats
+	rekey        RekeyPolicy
+	rekeyMark    [2]uint64 // messages and bytes encrypted as of the last ratchet
+	secure       *secureState
+	cleanup      runtime.Cleanup
 }
 
+var (
+	_ encoding.BinaryMarshaler   = (*Protocol)(nil)
+	_ encoding.BinaryUnmarshaler = (*Protocol)(nil)
+)
+
 // New creates a new protocol instance with the given label for domain separation. The label establishes the protocol
 // identity: two protocols using different labels produce cryptographically independent transcripts.
 func New(label string) *Protocol {
-	p := &Protocol{h: kt128.New(nil)}
-	p.writeLabelOp(label, opInit)
+	p := &Protocol{}
+	p.init(secureMemory.Load())
+	p.writeInit(label)
 	return p
 }
 
+// NewWithVersion creates a new protocol instance like [New], and binds an explicit application protocol version into the
+// transcript, so peers using different versions of an application protocol produce independent transcripts. It is
+// equivalent to calling [New] followed by [Protocol.Mix] with the label "protocol-version" and the version as a 4-byte
+// big endian integer.
+//
+// The version can be checked later with [Protocol.Version] and [Protocol.CheckVersion]. It is inherited by clones and
+// forks, and included in [Protocol.MarshalBinary] output.
+func NewWithVersion(label string, version uint32) *Protocol {
+	p := New(label)
+	// Record the public version frame along with the Init frame, so the new protocol can still be made serializable.
+	p.serializable = true
+	p.Mix("protocol-version", binary.BigEndian.AppendUint32(nil, version))
+	p.serializable = false
+	p.version, p.versioned = version, true
+	return p
+}
+
+// Version returns the application protocol version bound by [NewWithVersion], and whether the protocol has one.
+func (p *Protocol) Version() (version uint32, ok bool) {
+	return p.version, p.versioned
+}
+
+// CheckVersion returns ErrUnsupportedVersion unless the protocol was created by [NewWithVersion] with a version of at
+// least minVersion. Use it to reject protocols negotiated down to versions which are no longer acceptable.
+func (p *Protocol) CheckVersion(minVersion uint32) error {
+	if !p.versioned || p.version < minVersion {
+		return ErrUnsupportedVersion
+	}
+	return nil
+}
+
 // Equal compares the two Protocol instances in constant time, returning 1 if they are equal, 0 if not.
 func (p *Protocol) Equal(other *Protocol) int {
 	return p.h.Equal(other.h)
@@ -56,6 +158,129 @@ func (p *Protocol) String() string {
 func (p *Protocol) Mix(label string, data []byte) {
 	p.writeLabel(label)
 	p.writeStringOp(data, opMix)
+	p.stats.Mixes++
+	p.maybeRatchet()
+}
+
+// MixUint64 absorbs v into the protocol transcript as an 8-byte big endian integer. It is equivalent to calling
+// [Protocol.Mix] with the encoded value.
+func (p *Protocol) MixUint64(label string, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	p.Mix(label, buf[:])
+}
+
+// MixString absorbs the bytes of s into the protocol transcript. It is equivalent to calling [Protocol.Mix] with
+// []byte(s).
+func (p *Protocol) MixString(label, s string) {
+	p.Mix(label, []byte(s))
+}
+
+// MixBool absorbs v into the protocol transcript as a single byte, 0x01 for true and 0x00 for false. It is equivalent to
+// calling [Protocol.Mix] with the encoded value.
+func (p *Protocol) MixBool(label string, v bool) {
+	var buf [1]byte
+	if v {
+		buf[0] = 1
+	}
+	p.Mix(label, buf[:])
+}
+
+// AbsorbedBytes returns the number of transcript bytes absorbed since the last chain boundary (i.e. since [New] or the
+// last finalizing operation).
+//
+// KT128 absorbs the transcript incrementally, so each operation costs time proportional to its own input and
+// finalization cost does not grow with the transcript. AbsorbedBytes is a metric for monitoring how much input a
+// long-lived, rarely finalized transcript has accumulated.
+func (p *Protocol) AbsorbedBytes() uint64 {
+	return p.absorbed
+}
+
+// Stats records the operations a protocol has performed, for enforcing usage limits such as rekeying after a number of
+// messages or bytes.
+type Stats struct {
+	// Mixes, Forks, Derives, Ratchets, Masks, and Seals are the number of operations of each type performed since the
+	// protocol was created. Derives includes [Protocol.DeriveReader] and [Protocol.Check]; Masks includes
+	// [Protocol.Unmask]; Seals includes [Protocol.Open] and the detached variants. Automatic ratchets are counted.
+	Mixes, Forks, Derives, Ratchets, Masks, Seals uint64
+
+	// MaskedBytes and SealedBytes are the total plaintext lengths of those Mask and Seal operations.
+	MaskedBytes, SealedBytes uint64
+
+	// AbsorbedBytes is the number of transcript bytes absorbed since the last chain boundary, as returned by
+	// [Protocol.AbsorbedBytes].
+	AbsorbedBytes uint64
+}
+
+// Stats returns the protocol's operation counts. They are inherited by clones and forks, and are included in
+// [Protocol.MarshalBinary] output, so a policy such as "ratchet after 2^30 sealed bytes" can be enforced by comparing
+// against the counts recorded at the last ratchet.
+func (p *Protocol) Stats() Stats {
+	s := p.stats
+	s.AbsorbedBytes = p.absorbed
+	return s
+}
+
+// SetAutoRatchet configures the protocol to call [Protocol.Ratchet] with the label "auto-ratchet" after any
+// [Protocol.Mix], [Protocol.Mask], or [Protocol.Unmask] which leaves [Protocol.AbsorbedBytes] at or above threshold.
+// This bounds how much input a Mix-only transcript accumulates before its state is irreversibly advanced. A threshold of
+// zero disables automatic ratcheting, which is the default.
+//
+// Automatic ratchets are part of the transcript, so all parties must use the same threshold. The threshold is inherited
+// by clones and forks, and is included in [Protocol.MarshalBinary] output.
+func (p *Protocol) SetAutoRatchet(threshold uint64) {
+	p.autoRatchet = threshold
+}
+
+// SetSerializable sets whether the protocol can be serialized with [Protocol.MarshalBinary]. Protocols are not
+// serializable by default: to serialize its state, a protocol must keep a copy of every byte it has absorbed since its
+// last chain boundary, including any keys mixed into it, so only protocols which will be serialized should do so.
+//
+// A protocol made serializable immediately after it is created by [New], [NewSecure], or [NewWithVersion] can be
+// serialized at any point.
+// Otherwise, it has not recorded what it has absorbed so far, and MarshalBinary returns ErrNotSerializable until its
+// next finalizing operation (e.g. [Protocol.Ratchet]). Serializability is inherited by clones and forks, and protocols
+// restored by [Protocol.UnmarshalBinary] are serializable. Disabling it wipes the recorded transcript.
+func (p *Protocol) SetSerializable(enabled bool) {
+	p.serializable = enabled
+	switch {
+	case enabled && p.secure != nil:
+		p.lockTranscript()
+	case !enabled && p.secure != nil:
+		p.secure.release()
+		p.transcript = nil
+	case !enabled && len(p.transcript) > 0:
+		secmem.Wipe(p.transcript)
+		p.transcript = p.transcript[:0]
+	}
+}
+
+// A RekeyPolicy limits how much a protocol encrypts between ratchets. See [Protocol.SetRekeyPolicy].
+type RekeyPolicy struct {
+	// Messages is the number of Mask, Unmask, Seal, and Open operations after which the protocol ratchets. Zero
+	// disables the limit.
+	Messages uint64
+
+	// Bytes is the total plaintext length of those operations after which the protocol ratchets. Zero disables the
+	// limit.
+	Bytes uint64
+
+	// OnRekey, if not nil, is called with the protocol's [Stats] after each automatic ratchet, including those caused by
+	// [Protocol.SetAutoRatchet].
+	OnRekey func(Stats)
+}
+
+// SetRekeyPolicy configures the protocol to call [Protocol.Ratchet] with the label "auto-ratchet" after any encrypting
+// operation which reaches one of the policy's limits, counted since the last ratchet (automatic or not). This gives
+// long-lived sessions forward secrecy limits without explicit ratchets. A zero policy disables it, which is the default.
+//
+// An Open whose tag does not verify never ratchets, as the protocol has already diverged from the sender's.
+//
+// Automatic ratchets are part of the transcript, so all parties must use the same limits. The policy is inherited by
+// clones and forks. Its limits and counts are included in [Protocol.MarshalBinary] output, but OnRekey is not, and must
+// be set again on a restored protocol.
+func (p *Protocol) SetRekeyPolicy(policy RekeyPolicy) {
+	p.rekey = policy
 }
 
 // Fork calls ForkN with the given label and values and returns the two branches.
@@ -78,6 +303,7 @@ func (p *Protocol) ForkN(label string, values ...[]byte) []*Protocol {
 		clone.writeInt(uint64(n))
 		clone.writeInt(uint64(i + 1))
 		clone.writeStringOp(values[i], opFork)
+		clone.stats.Forks++
 		clones[i] = clone
 	}
 
@@ -86,6 +312,7 @@ func (p *Protocol) ForkN(label string, values ...[]byte) []*Protocol {
 	p.writeInt(uint64(n))
 	p.writeInt(0)
 	p.writeStringOp(nil, opFork)
+	p.stats.Forks++
 
 	return clones
 }
@@ -100,16 +327,68 @@ func (p *Protocol) Derive(label string, dst []byte, outputLen int) []byte {
 
 	p.writeLabel(label)
 	p.writeIntOp(uint64(outputLen), opDerive)
+	p.derive(out)
+
+	return ret
+}
 
+// derive completes a Derive operation whose frame has been written, filling out with output.
+func (p *Protocol) derive(out []byte) {
+	p.stats.Derives++
 	cv := p.finalize(out)
 	p.resetChain(opDerive, cv[:])
+}
 
-	return ret
+// DeriveReader returns an io.ReadCloser which produces an unbounded stream of pseudorandom output that is a
+// deterministic function of the full transcript, for callers which cannot specify the output length up front (e.g.
+// rejection sampling).
+//
+// The output length is not bound into the transcript; instead, the frame records an output length of zero, which
+// [Protocol.Derive] never writes. The output is therefore independent of any fixed-length Derive output, and reading n
+// bytes does not produce the same output as Derive with an output length of n.
+//
+// The protocol MUST NOT be used until the reader is closed. Closing the reader advances the transcript as Derive does,
+// regardless of how much output was read.
+func (p *Protocol) DeriveReader(label string) io.ReadCloser {
+	p.writeLabel(label)
+	p.writeIntOp(0, opDerive)
+	p.stats.Derives++
+
+	return &deriveReader{p: p, cv: p.finalize(nil)}
+}
+
+// An Array is a fixed-size byte array type which can be produced by [DeriveArray].
+type Array interface {
+	~[8]byte | ~[16]byte | ~[32]byte | ~[64]byte
+}
+
+// DeriveArray is like [Protocol.Derive], but returns its output as a fixed-size array of type A, with an output length
+// of len(A). The output length is fixed at compile time and the output is returned by value, so DeriveArray does not
+// allocate.
+func DeriveArray[A Array](p *Protocol, label string) (a A) {
+	var buf [64]byte
+	out := p.Derive(label, buf[:0], len(a))
+	for i := range out {
+		a[i] = out[i]
+	}
+	secmem.Wipe(out)
+	return a
+}
+
+// Check derives len(expected) bytes as [Protocol.Derive] does and compares them to expected in constant time, returning
+// true if they are equal. The transcript advances identically whether or not the comparison succeeds. Panics if
+// expected is empty.
+func (p *Protocol) Check(label string, expected []byte) bool {
+	out := p.Derive(label, nil, len(expected))
+	defer secmem.Wipe(out)
+	return subtle.ConstantTimeCompare(out, expected) == 1
 }
 
 // Ratchet irreversibly advances the protocol state for forward secrecy. No user-visible output is produced.
 func (p *Protocol) Ratchet(label string) {
 	p.writeLabelOp(label, opRatchet)
+	p.stats.Ratchets++
+	p.rekeyMark = [2]uint64{p.stats.Masks + p.stats.Seals, p.stats.MaskedBytes + p.stats.SealedBytes}
 
 	cv := p.finalize(nil)
 	p.resetChain(opRatchet, cv[:])
@@ -124,13 +403,9 @@ func (p *Protocol) Mask(label string, dst, plaintext []byte) []byte {
 	p.writeLabel(label)
 	p.writeIntOp(uint64(len(plaintext)), opMask)
 
-	var key [keySize]byte
-	cv := p.finalize(key[:])
-
 	ret, ciphertext := mem.SliceForAppend(dst, len(plaintext))
-	p.resetChain(opMask, cv[:])
-	p.writeMaskedStringOp(opMaskData, key[:], ciphertext, plaintext, false)
-	clear(key[:])
+	p.maskChained(opMask, opMaskData, ciphertext, plaintext, false)
+	p.maybeRatchet()
 
 	return ret
 }
@@ -141,13 +416,9 @@ func (p *Protocol) Unmask(label string, dst, ciphertext []byte) []byte {
 	p.writeLabel(label)
 	p.writeIntOp(uint64(len(ciphertext)), opMask)
 
-	var key [keySize]byte
-	cv := p.finalize(key[:])
-
 	ret, plaintext := mem.SliceForAppend(dst, len(ciphertext))
-	p.resetChain(opMask, cv[:])
-	p.writeMaskedStringOp(opMaskData, key[:], plaintext, ciphertext, true)
-	clear(key[:])
+	p.maskChained(opMask, opMaskData, plaintext, ciphertext, true)
+	p.maybeRatchet()
 
 	return ret
 }
@@ -161,28 +432,30 @@ func (p *Protocol) Seal(label string, dst, plaintext []byte) []byte {
 
 	p.writeLabel(label)
 	p.writeIntOp(uint64(len(plaintext)), opSeal)
+	p.seal(ciphertext, tagDst, plaintext)
+	p.maybeRatchet()
 
-	var key [keySize]byte
-	cv := p.finalize(key[:])
+	return ret
+}
 
+// seal completes a Seal operation whose frame has been written, encrypting plaintext into ciphertext and writing the
+// tag to tagDst.
+func (p *Protocol) seal(ciphertext, tagDst, plaintext []byte) {
 	// Encrypt under opSealTag, absorbing the ciphertext into the transcript, then derive the wire tag (KT128 output)
 	// from that state. The completed seal then chains under opSeal, keeping the tag-derivation state distinct from the
 	// state subsequent operations follow.
-	p.resetChain(opSealTag, cv[:])
-	p.writeMaskedStringOp(opSealData, key[:], ciphertext, plaintext, false)
-	clear(key[:])
+	p.maskChained(opSealTag, opSealData, ciphertext, plaintext, false)
 
-	cv = p.finalize(tagDst)
+	cv := p.finalize(tagDst)
 	p.resetChain(opSeal, cv[:])
-
-	return ret
 }
 
 // Open decrypts and authenticates sealed data produced by Seal. The sealed input must be ciphertext with the tag
 // appended (as returned by Seal).
 //
-// On success, returns the plaintext. On failure, returns ErrInvalidCiphertext. The protocol's transcript diverges
-// from the sender's because it absorbs the received ciphertext before verification returns.
+// On success, returns the plaintext. On failure, returns ErrTruncated if the input is shorter than a tag, or
+// ErrTagMismatch otherwise; both wrap ErrInvalidCiphertext. The protocol's transcript diverges from the sender's
+// because it absorbs the received ciphertext before verification returns.
 func (p *Protocol) Open(label string, dst, sealed []byte) ([]byte, error) {
 	var ct, tt []byte
 	if len(sealed) < TagSize {
@@ -191,42 +464,239 @@ func (p *Protocol) Open(label string, dst, sealed []byte) ([]byte, error) {
 		ct = sealed[:len(sealed)-TagSize]
 		tt = sealed[len(sealed)-TagSize:]
 	}
+	return p.open(label, dst, ct, tt)
+}
 
+// SealDetached encrypts plaintext like [Protocol.Seal], but returns the ciphertext (appended to dst) and the
+// [TagSize]-byte tag separately, for wire formats which carry the tag in its own field. The ciphertext and tag share an
+// underlying array, but appending to the ciphertext does not overwrite the tag.
+func (p *Protocol) SealDetached(label string, dst, plaintext []byte) (ciphertext, tag []byte) {
+	ret := p.Seal(label, dst, plaintext)
+	n := len(ret) - TagSize
+	return ret[:n:n], ret[n:]
+}
+
+// OpenDetached decrypts and authenticates a ciphertext and tag produced by [Protocol.SealDetached]. It is equivalent to
+// calling [Protocol.Open] with the tag appended to the ciphertext.
+func (p *Protocol) OpenDetached(label string, dst, ciphertext, tag []byte) ([]byte, error) {
+	return p.open(label, dst, ciphertext, tag)
+}
+
+// open decrypts ct, verifying it against the received tag tt.
+func (p *Protocol) open(label string, dst, ct, tt []byte) ([]byte, error) {
 	p.writeLabel(label)
 	p.writeIntOp(uint64(len(ct)), opSeal)
 
-	var key [keySize]byte
-	cv := p.finalize(key[:])
-
 	// Decrypt under opSealTag, absorbing the received ciphertext into the transcript, then recompute the wire tag
 	// (KT128 output) from that state and compare it against the received tag. The completed open chains under opSeal.
 	ret, plaintext := mem.SliceForAppend(dst, len(ct))
-	p.resetChain(opSealTag, cv[:])
-	p.writeMaskedStringOp(opSealData, key[:], plaintext, ct, true)
-	clear(key[:])
+	p.maskChained(opSealTag, opSealData, plaintext, ct, true)
 
 	var tag [TagSize]byte
-	cv = p.finalize(tag[:])
+	cv := p.finalize(tag[:])
 	p.resetChain(opSeal, cv[:])
 
 	if subtle.ConstantTimeCompare(tag[:], tt) != 1 {
-		clear(plaintext)
-		return nil, ErrInvalidCiphertext
+		secmem.Wipe(plaintext)
+		if len(tt) < TagSize {
+			return nil, ErrTruncated
+		}
+		return nil, ErrTagMismatch
 	}
 
+	// Only automatically ratchet once the tag is verified, so a failed open (e.g. by TryOpen, which discards the
+	// state) never reports a rekey.
+	p.maybeRatchet()
 	return ret, nil
 }
 
+// TranscriptTag derives a [TranscriptTagSize]-byte tag committing to the full transcript, absorbs it, and returns it.
+// The tag is sent to a peer, who confirms that both sides observed the same transcript by passing it to
+// [Protocol.VerifyTranscriptTag] with the same label. Mutual confirmation uses two exchanges with distinct labels, one
+// in each direction.
+func (p *Protocol) TranscriptTag(label string) []byte {
+	tag := p.Derive(label, nil, TranscriptTagSize)
+	p.Mix(label, tag)
+	return tag
+}
+
+// VerifyTranscriptTag derives the tag [Protocol.TranscriptTag] would produce for label, absorbs the peer's tag, and
+// compares the two in constant time. On success, the transcript is identical to the peer's. On failure, returns
+// ErrTranscriptMismatch, and the transcript diverges from the peer's because it absorbed a different tag.
+func (p *Protocol) VerifyTranscriptTag(label string, tag []byte) error {
+	expected := DeriveArray[[TranscriptTagSize]byte](p, label)
+	p.Mix(label, tag)
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		return ErrTranscriptMismatch
+	}
+	return nil
+}
+
+// TranscriptHash derives and returns a [TranscriptHashSize]-byte digest committing to the full transcript, for use as
+// the message of an external signature over it, e.g. as in a TLS CertificateVerify message. The signer and verifier
+// each call TranscriptHash with the same label at the same point in the protocol and sign or verify the digest with a
+// scheme of their choosing.
+//
+// It is equivalent to calling [Protocol.Derive] with label and an output length of TranscriptHashSize, and advances
+// the transcript as Derive does. The digest is not absorbed; mix the signature into the transcript afterward to bind
+// it. The digest reveals nothing about any secrets in the transcript, so it may be sent or logged.
+func (p *Protocol) TranscriptHash(label string) []byte {
+	return p.Derive(label, nil, TranscriptHashSize)
+}
+
 // Clone returns an independent copy of the protocol state. The original and clone evolve independently.
 func (p *Protocol) Clone() *Protocol {
-	return &Protocol{h: p.h.Clone()}
+	c := &Protocol{
+		h:            p.h.Clone(),
+		absorbed:     p.absorbed,
+		transcript:   p.transcript,
+		serializable: p.serializable,
+		autoRatchet:  p.autoRatchet,
+		version:      p.version,
+		versioned:    p.versioned,
+		stats:        p.stats,
+		rekey:        p.rekey,
+		rekeyMark:    p.rekeyMark,
+	}
+	if p.secure != nil {
+		c.makeSecure()
+	} else if len(p.transcript) > 0 {
+		c.transcript = bytes.Clone(p.transcript)
+	} else {
+		c.transcript = nil
+	}
+	return c
 }
 
 // Clear overwrites the protocol state with zeros and invalidates the instance. After Clear, the instance must not be
 // used.
 func (p *Protocol) Clear() {
-	p.h.Reset()
+	if p.secure != nil {
+		p.cleanup.Stop()
+		p.secure.clear()
+		p.secure = nil
+	} else {
+		p.h.Reset()
+		secmem.Wipe(p.transcript)
+	}
 	p.h = nil
+	p.absorbed = 0
+	p.transcript = nil
+	p.serializable = false
+	p.stats = Stats{}
+	p.rekey = RekeyPolicy{}
+	p.rekeyMark = [2]uint64{}
+}
+
+// MarshalBinary returns a serialized copy of the protocol state, which can be restored with
+// [Protocol.UnmarshalBinary], e.g. to persist a long-lived session across process restarts.
+//
+// Only serializable protocols can be serialized (see [Protocol.SetSerializable]). They record the transcript bytes they
+// have absorbed since their last chain boundary (i.e. since [New] or the last finalizing operation), and the serialized
+// state consists of them. A state can therefore be serialized at any point, unless more than 4 KiB has been absorbed
+// since the last chain boundary (e.g. after mixing a large input), in which case MarshalBinary returns
+// ErrNotSerializable; call [Protocol.Ratchet] first.
+//
+// The serialized state contains the protocol's chain value and any inputs mixed in since, which are as sensitive as
+// any key mixed into it, and must be stored accordingly. Its checksum detects accidental corruption but does not
+// authenticate it; use [Protocol.Seal] with a separate key to protect it from tampering.
+//
+// The automatic ratchet threshold set by [Protocol.SetAutoRatchet] is included, so a restored protocol ratchets at the
+// same points as the original, as is the application protocol version bound by [NewWithVersion], so a restored
+// protocol passes the same [Protocol.CheckVersion] checks, and the operation counts returned by [Protocol.Stats]. The
+// limits set by [Protocol.SetRekeyPolicy] and the counts they apply to are included, but its OnRekey callback is not.
+//
+// Layout:
+//
+//	version (1B) || auto-ratchet threshold (8B) || versioned (1B) || application version (4B) ||
+//	operation counts (8 × 8B) || rekey limits (2 × 8B) || rekey counts (2 × 8B) || transcript || checksum (16B)
+func (p *Protocol) MarshalBinary() ([]byte, error) {
+	if !p.serializable || p.absorbed != uint64(len(p.transcript)) {
+		return nil, ErrNotSerializable
+	}
+
+	b := make([]byte, 0, stateHeaderSize+len(p.transcript)+stateChecksumSize)
+	b = append(b, stateVersion)
+	b = binary.BigEndian.AppendUint64(b, p.autoRatchet)
+	if p.versioned {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = binary.BigEndian.AppendUint32(b, p.version)
+	for _, c := range p.counters() {
+		b = binary.BigEndian.AppendUint64(b, *c)
+	}
+	b = append(b, p.transcript...)
+	sum := stateChecksum(b)
+	return append(b, sum[:]...), nil
+}
+
+// UnmarshalBinary restores the protocol state serialized by [Protocol.MarshalBinary], replacing the receiver's state.
+// The restored protocol is serializable, and is secure if the receiver was (see [NewSecure]) or [SetSecureMemory] is
+// enabled. Returns
+// ErrInvalidState if data is malformed, fails its checksum, or has an unsupported version.
+func (p *Protocol) UnmarshalBinary(data []byte) error {
+	if len(data) < stateHeaderSize+stateChecksumSize {
+		return ErrInvalidState
+	}
+
+	body := data[:len(data)-stateChecksumSize]
+	if sum := stateChecksum(body); subtle.ConstantTimeCompare(sum[:], data[len(body):]) != 1 {
+		return ErrInvalidState
+	}
+	if body[0] != stateVersion {
+		return ErrInvalidState
+	}
+
+	autoRatchet, versioned, version := binary.BigEndian.Uint64(body[1:]), body[9], binary.BigEndian.Uint32(body[10:])
+	transcript := body[stateHeaderSize:]
+	if versioned > 1 || (versioned == 0 && version != 0) || len(transcript) == 0 || len(transcript) > maxTranscriptSize {
+		return ErrInvalidState
+	}
+	secure := p.secure != nil || secureMemory.Load()
+	if p.h != nil {
+		p.Clear()
+	}
+	p.init(secure)
+	p.SetSerializable(true)
+	p.write(transcript)
+	p.autoRatchet = autoRatchet
+	p.version, p.versioned = version, versioned == 1
+	for i, c := range p.counters() {
+		*c = binary.BigEndian.Uint64(body[1+8+1+4+8*i:])
+	}
+	return nil
+}
+
+// counters returns pointers to the protocol's serialized counters, in serialization order: its operation counts,
+// rekey limits, and rekey counts.
+func (p *Protocol) counters() [12]*uint64 {
+	s := &p.stats
+	return [...]*uint64{
+		&s.Mixes, &s.Forks, &s.Derives, &s.Ratchets, &s.Masks, &s.Seals, &s.MaskedBytes, &s.SealedBytes,
+		&p.rekey.Messages, &p.rekey.Bytes, &p.rekeyMark[0], &p.rekeyMark[1],
+	}
+}
+
+// autoRatcheting reports whether the protocol ratchets automatically.
+func (p *Protocol) autoRatcheting() bool {
+	return p.autoRatchet != 0 || p.rekey.Messages != 0 || p.rekey.Bytes != 0
+}
+
+// maybeRatchet ratchets the protocol if automatic ratcheting is enabled and its threshold or one of its rekey policy's
+// limits has been reached.
+func (p *Protocol) maybeRatchet() {
+	messages := p.stats.Masks + p.stats.Seals - p.rekeyMark[0]
+	n := p.stats.MaskedBytes + p.stats.SealedBytes - p.rekeyMark[1]
+	if (p.autoRatchet != 0 && p.absorbed >= p.autoRatchet) ||
+		(p.rekey.Messages != 0 && messages >= p.rekey.Messages) ||
+		(p.rekey.Bytes != 0 && n >= p.rekey.Bytes) {
+		p.Ratchet("auto-ratchet")
+		if p.rekey.OnRekey != nil {
+			p.rekey.OnRekey(p.Stats())
+		}
+	}
 }
 
 // finalize derives one KT128 output bundle for the current transcript. The
@@ -241,13 +711,70 @@ func (p *Protocol) finalize(dst []byte) [chainValueSize]byte {
 	return cv
 }
 
+// maskChained finalizes the transcript into a chain value and a key, chains the transcript under op, and writes the
+// dataOp frame for src, masked into dst with the key. The key is derived, used, and wiped here, so callers never hold a
+// copy of it.
+func (p *Protocol) maskChained(op, dataOp byte, dst, src []byte, unmask bool) {
+	if dataOp == opSealData {
+		p.stats.Seals++
+		p.stats.SealedBytes += uint64(len(src))
+	} else {
+		p.stats.Masks++
+		p.stats.MaskedBytes += uint64(len(src))
+	}
+
+	var key [keySize]byte
+	cv := p.finalize(key[:])
+	p.resetChain(op, cv[:])
+	p.writeMaskedStringOp(dataOp, key[:], dst, src, unmask)
+	secmem.Wipe(key[:])
+}
+
+// write absorbs b into the transcript, counting the bytes absorbed since the last chain boundary. If the protocol is
+// serializable, it also records them for [Protocol.MarshalBinary], as long as none have been missed and there are no
+// more than maxTranscriptSize of them; otherwise, any recorded bytes are wiped.
+func (p *Protocol) write(b []byte) {
+	_, _ = p.h.Write(b)
+	p.absorbed += uint64(len(b))
+
+	if p.serializable && p.absorbed <= maxTranscriptSize && uint64(len(p.transcript)+len(b)) == p.absorbed {
+		if n := len(p.transcript) + len(b); n > cap(p.transcript) {
+			// Grow the buffer by hand, so the old one can be wiped rather than left for the garbage collector.
+			grown := make([]byte, len(p.transcript), min(max(2*cap(p.transcript), n, 128), maxTranscriptSize))
+			copy(grown, p.transcript)
+			secmem.Wipe(p.transcript)
+			p.transcript = grown
+		}
+		p.transcript = append(p.transcript, b...)
+	} else if len(p.transcript) > 0 {
+		secmem.Wipe(p.transcript)
+		p.transcript = p.transcript[:0]
+	}
+}
+
+// writeInit writes the Init frame for label. The frame, which holds nothing but the public label, is kept as the
+// recorded transcript even if the protocol is not serializable, so that a new protocol can be made serializable before
+// anything else is absorbed. The next write discards it.
+func (p *Protocol) writeInit(label string) {
+	buf := make([]byte, 0, len(label)+enc.MaxIntSize+1)
+	buf = append(buf, label...)
+	buf = enc.RightEncode(buf, uint64(len(label)))
+	buf = append(buf, opInit)
+	p.write(buf)
+	if p.transcript == nil {
+		p.transcript = buf
+	} else {
+		p.transcript = append(p.transcript[:0], buf...)
+	}
+}
+
 // writeLabel writes label || right_encode(len(label)), the leftmost field of every operation frame, in a single call
 // to h.Write.
 func (p *Protocol) writeLabel(label string) {
 	buf := make([]byte, 0, len(label)+enc.MaxIntSize)
 	buf = append(buf, label...)
 	buf = enc.RightEncode(buf, uint64(len(label)))
-	_, _ = p.h.Write(buf)
+	p.write(buf)
 }
 
 // writeLabelOp writes label || right_encode(len(label)) || op, a complete label-only frame, in a single call to
@@ -257,17 +784,17 @@ func (p *Protocol) writeLabelOp(label string, op byte) {
 	buf = append(buf, label...)
 	buf = enc.RightEncode(buf, uint64(len(label)))
 	buf = append(buf, op)
-	_, _ = p.h.Write(buf)
+	p.write(buf)
 }
 
 // writeStringOp writes data || right_encode(len(data)) || op, a length-suffixed byte-string field closing the current
 // frame. The data is written directly without copying.
 func (p *Protocol) writeStringOp(data []byte, op byte) {
 	var buf [enc.MaxIntSize + 1]byte
-	_, _ = p.h.Write(data)
+	p.write(data)
 	b := enc.RightEncode(buf[:0], uint64(len(data)))
 	b = append(b, op)
-	_, _ = p.h.Write(b)
+	p.write(b)
 }
 
 // writeMaskedStringOp encrypts (or decrypts) src under AES-128-CTR with key, writing the result to dst, and absorbs the
@@ -278,11 +805,32 @@ func (p *Protocol) writeStringOp(data []byte, op byte) {
 // between the AES-CTR pass and the KT128 pass. When decrypting, each window's ciphertext is absorbed before it is
 // overwritten with plaintext, so dst may alias src. The window size does not affect the transcript: KT128 hashes the
 // same byte sequence regardless of how it is chunked.
+//
+// Messages of up to smallMessageSize bytes are encrypted with a keystream generated into a pooled buffer (see
+// [smallKeystream]) rather than with a cipher.Stream, which saves an allocation on the latency-critical path of small
+// Seal and Open calls.
 func (p *Protocol) writeMaskedStringOp(op byte, key, dst, src []byte, decrypt bool) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		panic("thyrse: " + err.Error())
 	}
+
+	if len(src) <= smallMessageSize {
+		ks := smallKeystream(block, len(src))
+		if decrypt {
+			p.write(src)
+			subtle.XORBytes(dst, src, ks)
+		} else {
+			subtle.XORBytes(dst, src, ks)
+			p.write(dst)
+		}
+		buf := (*[smallMessageSize]byte)(ks[:cap(ks)])
+		secmem.Wipe(buf[:])
+		keystreamPool.Put(buf)
+		p.writeIntOp(uint64(len(src)), op)
+		return
+	}
+
 	stream := cipher.NewCTR(block, zeroIV[:])
 
 	window := ctrWindowSize(len(src))
@@ -290,24 +838,24 @@ func (p *Protocol) writeMaskedStringOp(op byte, key, dst, src []byte, decrypt bo
 		end := min(off+window, len(src))
 		if decrypt {
 			// Absorb the ciphertext before decrypting in place over it.
-			_, _ = p.h.Write(src[off:end])
+			p.write(src[off:end])
 			stream.XORKeyStream(dst[off:end], src[off:end])
 		} else {
 			stream.XORKeyStream(dst[off:end], src[off:end])
-			_, _ = p.h.Write(dst[off:end])
+			p.write(dst[off:end])
 		}
 	}
 
 	var buf [enc.MaxIntSize + 1]byte
 	b := enc.RightEncode(buf[:0], uint64(len(src)))
 	b = append(b, op)
-	_, _ = p.h.Write(b)
+	p.write(b)
 }
 
 // writeInt writes right_encode(v).
 func (p *Protocol) writeInt(v uint64) {
 	var buf [enc.MaxIntSize]byte
-	_, _ = p.h.Write(enc.RightEncode(buf[:0], v))
+	p.write(enc.RightEncode(buf[:0], v))
 }
 
 // writeIntOp writes right_encode(v) || op, an integer field closing the current frame, in a single call to h.Write.
@@ -315,7 +863,7 @@ func (p *Protocol) writeIntOp(v uint64, op byte) {
 	var buf [enc.MaxIntSize + 1]byte
 	b := enc.RightEncode(buf[:0], v)
 	b = append(b, op)
-	_, _ = p.h.Write(b)
+	p.write(b)
 }
 
 // resetChain resets the transcript with a chain frame seeded by a chainValueSize-byte chain value.
@@ -323,7 +871,7 @@ func (p *Protocol) writeIntOp(v uint64, op byte) {
 // The frame layout is assembled into a stack buffer and written in a single h.Write call. Like all frames, it reads
 // right to left: the op code is last, the count of encoded values sits immediately before it, and each value's
 // right-encoded byte length sits to its right. The origin op code is a raw single byte at a position fixed once the
-// values are stripped, so it carries no length suffix.
+// values are stripped, so it carries no length suffix. The chain value and the buffer are wiped once absorbed.
 //
 // Layout (38 bytes):
 //
@@ -331,6 +879,9 @@ func (p *Protocol) writeIntOp(v uint64, op byte) {
 //	                           ╰─RE(32)─╯ ╰─RE(1)──╯
 func (p *Protocol) resetChain(originOp byte, chainValue []byte) {
 	p.h.Reset()
+	p.absorbed = 0
+	secmem.Wipe(p.transcript)
+	p.transcript = p.transcript[:0]
 
 	var buf [38]byte
 	buf[0] = originOp
@@ -340,7 +891,43 @@ func (p *Protocol) resetChain(originOp byte, chainValue []byte) {
 	buf[35] = 1 // right_encode(1) — encoded value count
 	buf[36] = 1
 	buf[37] = opChain
-	_, _ = p.h.Write(buf[:])
+	p.write(buf[:])
+	secmem.Wipe(buf[:])
+	secmem.Wipe(chainValue)
+}
+
+// deriveReader reads pseudorandom output from a finalized transcript, and chains the transcript when closed.
+type deriveReader struct {
+	p      *Protocol
+	cv     [chainValueSize]byte
+	closed bool
+}
+
+func (d *deriveReader) Read(b []byte) (int, error) {
+	if d.closed {
+		return 0, errDeriveReaderClosed
+	}
+	return d.p.h.Read(b)
+}
+
+func (d *deriveReader) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	d.p.resetChain(opDerive, d.cv[:])
+	return nil
+}
+
+var errDeriveReaderClosed = errors.New("thyrse: derive reader closed")
+
+// stateChecksum returns the checksum of serialized state b.
+func stateChecksum(b []byte) [stateChecksumSize]byte {
+	var sum [stateChecksumSize]byte
+	h := kt128.New([]byte("thyrse state checksum"))
+	_, _ = h.Write(b)
+	_, _ = h.Read(sum[:])
+	return sum
 }
 
 const (
@@ -350,6 +937,19 @@ const (
 	// keySize is the AES-128 key size in bytes derived per Mask/Seal operation.
 	keySize = 16
 
+	// stateVersion is the version of the serialization format used by MarshalBinary.
+	stateVersion = 0x02
+
+	// maxTranscriptSize is the maximum number of bytes absorbed since the last chain boundary which MarshalBinary
+	// serializes.
+	maxTranscriptSize = 4 << 10
+
+	// stateHeaderSize is the size in bytes of the fields which precede the transcript in serialized state.
+	stateHeaderSize = 1 + 8 + 1 + 4 + 8*8 + 4*8
+
+	// stateChecksumSize is the size in bytes of the checksum appended to serialized state.
+	stateChecksumSize = 16
+
 	// Operation codes.
 	opInit     = 0x01
 	opMix      = 0x02
@@ -372,6 +972,28 @@ const (
 // start never repeats a (key, counter) pair across operations.
 var zeroIV [aes.BlockSize]byte
 
+// smallMessageSize is the maximum length of a message encrypted with [smallKeystream].
+const smallMessageSize = 256
+
+// keystreamPool holds buffers for [smallKeystream]. The AES block's Encrypt method is called through an interface, so a
+// buffer passed to it would escape to the heap if it were allocated on the stack.
+var keystreamPool = sync.Pool{New: func() any { return new([smallMessageSize]byte) }}
+
+// smallKeystream returns the first n bytes of the AES-CTR keystream for block with an all-zero initial counter, in a
+// buffer from keystreamPool. It is identical to the keystream of cipher.NewCTR(block, zeroIV[:]).
+func smallKeystream(block cipher.Block, n int) []byte {
+	buf := keystreamPool.Get().(*[smallMessageSize]byte)
+	for i := 0; i < n; i += aes.BlockSize {
+		// The counter for block i/BlockSize is a 128-bit big endian integer. With at most smallMessageSize/BlockSize
+		// blocks, it fits in the final byte.
+		ctr := buf[i : i+aes.BlockSize]
+		clear(ctr)
+		ctr[aes.BlockSize-1] = byte(i / aes.BlockSize)
+		block.Encrypt(ctr, ctr)
+	}
+	return buf[:n]
+}
+
 // ctrWindowSize returns the window size in bytes over which AES-CTR encryption and KT128 absorption are interleaved for
 // an n-byte message.
 //
@@ -2,7 +2,10 @@ package thyrse
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/codahale/thyrse/internal/enc"
@@ -112,6 +115,70 @@ func TestDerive(t *testing.T) {
 	})
 }
 
+func TestDeriveReader(t *testing.T) {
+	read := func(p *Protocol, sizes ...int) []byte {
+		r := p.DeriveReader("stream")
+		var out []byte
+		for _, n := range sizes {
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, buf...)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("chunking independent", func(t *testing.T) {
+		a := read(newKeyed("test", []byte("key")), 1000)
+		b := read(newKeyed("test", []byte("key")), 1, 167, 168, 664)
+		if !bytes.Equal(a, b) {
+			t.Error("output depends on read sizes")
+		}
+	})
+
+	t.Run("distinct from Derive", func(t *testing.T) {
+		got := read(newKeyed("test", []byte("key")), 32)
+		if want := newKeyed("test", []byte("key")).Derive("stream", nil, 32); bytes.Equal(got, want) {
+			t.Error("DeriveReader output equals Derive output")
+		}
+	})
+
+	t.Run("close advances transcript", func(t *testing.T) {
+		a, b := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+		read(a, 10)
+		read(b, 10000)
+		if a.Equal(b) != 1 {
+			t.Error("transcript depends on amount read")
+		}
+
+		c := newKeyed("test", []byte("key"))
+		c.writeLabel("stream")
+		c.writeIntOp(0, opDerive)
+		cv := c.finalize(nil)
+		c.resetChain(opDerive, cv[:])
+		if a.Equal(c) != 1 {
+			t.Error("Close did not chain like Derive")
+		}
+	})
+
+	t.Run("read after close", func(t *testing.T) {
+		r := New("test").DeriveReader("stream")
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("second Close() err = %v, want nil", err)
+		}
+		if _, err := r.Read(make([]byte, 8)); err == nil {
+			t.Error("Read() err = nil, want error")
+		}
+	})
+}
+
 func TestSeal(t *testing.T) {
 	t.Run("round trip", func(t *testing.T) {
 		key := []byte("32-byte-key-material-for-testing!")
@@ -253,6 +320,183 @@ func TestOpen(t *testing.T) {
 			t.Fatal("short Open should advance and diverge state")
 		}
 	})
+
+	t.Run("error causes", func(t *testing.T) {
+		sealed := seal()
+		sealed[0] ^= 0xFF
+		if _, err := newKeyed("test.seal", key).Open("message", nil, sealed); !errors.Is(err, ErrTagMismatch) {
+			t.Errorf("got %v, want ErrTagMismatch", err)
+		}
+		if _, err := newKeyed("test.seal", key).Open("message", nil, sealed[:TagSize-1]); !errors.Is(err, ErrTruncated) {
+			t.Errorf("got %v, want ErrTruncated", err)
+		}
+	})
+}
+
+func TestSealAllocs(t *testing.T) {
+	// The only remaining allocation is the AES key schedule: crypto/aes has no way to key a cipher.Block in place.
+	p := newKeyed("test", []byte("key"))
+	plaintext, buf := make([]byte, smallMessageSize), make([]byte, 0, smallMessageSize+TagSize)
+	sealed := p.Clone().Seal("message", nil, plaintext)
+
+	if allocs := testing.AllocsPerRun(10, func() { p.Seal("message", buf, plaintext) }); allocs > 1 {
+		t.Errorf("Seal() allocs = %v, want <= 1", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { _, _ = p.Open("message", buf, sealed) }); allocs > 1 {
+		t.Errorf("Open() allocs = %v, want <= 1", allocs)
+	}
+}
+
+func TestSmallKeystream(t *testing.T) {
+	block, err := aes.NewCipher([]byte("yellow submarine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range smallMessageSize + 1 {
+		want := make([]byte, n)
+		cipher.NewCTR(block, zeroIV[:]).XORKeyStream(want, want)
+		if got := smallKeystream(block, n); !bytes.Equal(got, want) {
+			t.Fatalf("smallKeystream(%d) = %x, want = %x", n, got, want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, err := range []error{ErrTruncated, ErrTagMismatch, ErrDesynchronized, ErrTooLarge} {
+		if !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("errors.Is(%v, ErrInvalidCiphertext) = false, want true", err)
+		}
+	}
+
+	if errors.Is(ErrClosed, ErrInvalidCiphertext) {
+		t.Error("errors.Is(ErrClosed, ErrInvalidCiphertext) = true, want false")
+	}
+}
+
+func TestSealDetached(t *testing.T) {
+	key := []byte("32-byte-key-material-for-testing!")
+
+	t.Run("matches Seal", func(t *testing.T) {
+		sealed := newKeyed("test.seal", key).Seal("message", nil, []byte("secret"))
+		ct, tag := newKeyed("test.seal", key).SealDetached("message", nil, []byte("secret"))
+
+		if got, want := ct, sealed[:len(sealed)-TagSize]; !bytes.Equal(got, want) {
+			t.Errorf("ciphertext = %x, want = %x", got, want)
+		}
+		if got, want := tag, sealed[len(sealed)-TagSize:]; !bytes.Equal(got, want) {
+			t.Errorf("tag = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("append does not clobber tag", func(t *testing.T) {
+		ct, tag := newKeyed("test.seal", key).SealDetached("message", []byte("prefix"), []byte("secret"))
+		want := bytes.Clone(tag)
+		_ = append(ct, make([]byte, TagSize)...)
+		if !bytes.Equal(tag, want) {
+			t.Error("appending to ciphertext modified the tag")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ct, tag := newKeyed("test.seal", key).SealDetached("message", nil, []byte("secret"))
+
+		got, err := newKeyed("test.seal", key).OpenDetached("message", nil, ct, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []byte("secret"); !bytes.Equal(got, want) {
+			t.Errorf("OpenDetached() = %q, want = %q", got, want)
+		}
+	})
+
+	t.Run("tampered tag", func(t *testing.T) {
+		ct, tag := newKeyed("test.seal", key).SealDetached("message", nil, []byte("secret"))
+		tag[0] ^= 0xFF
+
+		if _, err := newKeyed("test.seal", key).OpenDetached("message", nil, ct, tag); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Fatalf("got %v, want ErrInvalidCiphertext", err)
+		}
+	})
+
+	t.Run("short tag", func(t *testing.T) {
+		ct, tag := newKeyed("test.seal", key).SealDetached("message", nil, []byte("secret"))
+
+		if _, err := newKeyed("test.seal", key).OpenDetached("message", nil, ct, tag[:TagSize-1]); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Fatalf("got %v, want ErrInvalidCiphertext", err)
+		}
+	})
+}
+
+func TestTranscriptTag(t *testing.T) {
+	t.Run("mutual confirmation", func(t *testing.T) {
+		alice := newKeyed("test", []byte("shared"))
+		bob := newKeyed("test", []byte("shared"))
+
+		if err := bob.VerifyTranscriptTag("alice-confirm", alice.TranscriptTag("alice-confirm")); err != nil {
+			t.Fatalf("VerifyTranscriptTag(alice): %v", err)
+		}
+		if err := alice.VerifyTranscriptTag("bob-confirm", bob.TranscriptTag("bob-confirm")); err != nil {
+			t.Fatalf("VerifyTranscriptTag(bob): %v", err)
+		}
+
+		if alice.Equal(bob) != 1 {
+			t.Fatal("transcripts diverged after successful confirmation")
+		}
+	})
+
+	t.Run("mismatched transcripts", func(t *testing.T) {
+		alice := newKeyed("test", []byte("shared"))
+		bob := newKeyed("test", []byte("different"))
+
+		err := bob.VerifyTranscriptTag("confirm", alice.TranscriptTag("confirm"))
+		if !errors.Is(err, ErrTranscriptMismatch) {
+			t.Fatalf("got %v, want ErrTranscriptMismatch", err)
+		}
+	})
+
+	t.Run("tampered tag diverges", func(t *testing.T) {
+		alice := newKeyed("test", []byte("shared"))
+		bob := newKeyed("test", []byte("shared"))
+
+		tag := alice.TranscriptTag("confirm")
+		tag[0] ^= 1
+		if err := bob.VerifyTranscriptTag("confirm", tag); !errors.Is(err, ErrTranscriptMismatch) {
+			t.Fatalf("got %v, want ErrTranscriptMismatch", err)
+		}
+
+		if alice.Equal(bob) != 0 {
+			t.Fatal("transcripts should diverge after failed confirmation")
+		}
+	})
+
+	t.Run("short tag", func(t *testing.T) {
+		p := New("test")
+		if err := p.VerifyTranscriptTag("confirm", nil); !errors.Is(err, ErrTranscriptMismatch) {
+			t.Fatalf("got %v, want ErrTranscriptMismatch", err)
+		}
+	})
+}
+
+func TestTranscriptHash(t *testing.T) {
+	t.Run("matches Derive", func(t *testing.T) {
+		a, b := newKeyed("test", []byte("shared")), newKeyed("test", []byte("shared"))
+		got := a.TranscriptHash("certificate-verify")
+		want := b.Derive("certificate-verify", nil, TranscriptHashSize)
+		if !bytes.Equal(got, want) {
+			t.Errorf("TranscriptHash() = %x, want = %x", got, want)
+		}
+		if a.Equal(b) != 1 {
+			t.Error("TranscriptHash advanced the transcript differently than Derive")
+		}
+	})
+
+	t.Run("commits to transcript", func(t *testing.T) {
+		a, b := newKeyed("test", []byte("shared")), newKeyed("test", []byte("different"))
+		if bytes.Equal(a.TranscriptHash("certificate-verify"), b.TranscriptHash("certificate-verify")) {
+			t.Error("different transcripts produced the same hash")
+		}
+	})
 }
 
 func TestMask(t *testing.T) {
@@ -315,6 +559,70 @@ func TestMask(t *testing.T) {
 	})
 }
 
+func TestDeriveArray(t *testing.T) {
+	t.Run("matches Derive", func(t *testing.T) {
+		p1, p2 := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+
+		got, want := DeriveArray[[32]byte](p1, "output"), p2.Derive("output", nil, 32)
+		if !bytes.Equal(got[:], want) {
+			t.Fatalf("DeriveArray() = %x, want %x", got, want)
+		}
+		if p1.Equal(p2) != 1 {
+			t.Fatal("DeriveArray() and Derive() left different states")
+		}
+	})
+
+	t.Run("named type", func(t *testing.T) {
+		type nonce [16]byte
+		got, want := DeriveArray[nonce](New("test"), "nonce"), New("test").Derive("nonce", nil, 16)
+		if !bytes.Equal(got[:], want) {
+			t.Fatalf("DeriveArray() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		p := New("test")
+		if allocs := testing.AllocsPerRun(10, func() { _ = DeriveArray[[64]byte](p, "output") }); allocs != 0 {
+			t.Errorf("DeriveArray() allocs = %v, want 0", allocs)
+		}
+	})
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("matches Derive", func(t *testing.T) {
+		p1, p2 := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+
+		if !p1.Check("tag", p2.Derive("tag", nil, 16)) {
+			t.Fatal("Check() = false, want = true")
+		}
+		if p1.Equal(p2) != 1 {
+			t.Fatal("Check() and Derive() left different states")
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		p1, p2 := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+
+		tag := p2.Derive("tag", nil, 16)
+		tag[0] ^= 1
+		if p1.Check("tag", tag) {
+			t.Fatal("Check() = true, want = false")
+		}
+		if p1.Equal(p2) != 1 {
+			t.Fatal("Check() advanced the transcript differently on failure")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Check() with empty expected did not panic")
+			}
+		}()
+		New("test").Check("tag", nil)
+	})
+}
+
 func TestRatchet(t *testing.T) {
 	t.Run("changes derive output", func(t *testing.T) {
 		p1 := New("test")
@@ -396,6 +704,82 @@ func TestClone(t *testing.T) {
 	})
 }
 
+func TestNewWithVersion(t *testing.T) {
+	t.Run("equivalent transcript", func(t *testing.T) {
+		p1 := NewWithVersion("test", 2)
+
+		p2 := New("test")
+		p2.Mix("protocol-version", []byte{0, 0, 0, 2})
+
+		if p1.Equal(p2) != 1 {
+			t.Fatal("NewWithVersion should be equivalent to New and Mix")
+		}
+	})
+
+	t.Run("different versions", func(t *testing.T) {
+		if NewWithVersion("test", 1).Equal(NewWithVersion("test", 2)) != 0 {
+			t.Fatal("different versions should not be equal")
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		if v, ok := NewWithVersion("test", 3).Version(); v != 3 || !ok {
+			t.Errorf("Version() = %d, %v, want = 3, true", v, ok)
+		}
+		if v, ok := New("test").Version(); v != 0 || ok {
+			t.Errorf("Version() = %d, %v, want = 0, false", v, ok)
+		}
+	})
+
+	t.Run("inherited", func(t *testing.T) {
+		p := NewWithVersion("test", 3)
+		left, right := p.Clone().Fork("role", []byte("a"), []byte("b"))
+		for _, q := range []*Protocol{p.Clone(), left, right} {
+			if v, ok := q.Version(); v != 3 || !ok {
+				t.Errorf("Version() = %d, %v, want = 3, true", v, ok)
+			}
+		}
+	})
+
+	t.Run("check version", func(t *testing.T) {
+		p := NewWithVersion("test", 3)
+		for _, minVersion := range []uint32{0, 3} {
+			if err := p.CheckVersion(minVersion); err != nil {
+				t.Errorf("CheckVersion(%d) err = %v, want = nil", minVersion, err)
+			}
+		}
+		if err := p.CheckVersion(4); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("CheckVersion(4) err = %v, want = %v", err, ErrUnsupportedVersion)
+		}
+		if err := New("test").CheckVersion(0); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("CheckVersion(0) err = %v, want = %v", err, ErrUnsupportedVersion)
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		for _, p := range []*Protocol{NewWithVersion("test", 3), New("test")} {
+			p.SetSerializable(true)
+			state, err := p.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var restored Protocol
+			if err := restored.UnmarshalBinary(state); err != nil {
+				t.Fatal(err)
+			}
+
+			wantV, wantOK := p.Version()
+			if v, ok := restored.Version(); v != wantV || ok != wantOK {
+				t.Errorf("Version() = %d, %v, want = %d, %v", v, ok, wantV, wantOK)
+			}
+			if got, want := restored.CheckVersion(3), p.CheckVersion(3); got != want {
+				t.Errorf("CheckVersion(3) err = %v, want = %v", got, want)
+			}
+		}
+	})
+}
+
 func TestEqual(t *testing.T) {
 	t.Run("same state", func(t *testing.T) {
 		p1 := New("test")
@@ -573,6 +957,447 @@ func TestClear(t *testing.T) {
 	})
 }
 
+func TestMixTyped(t *testing.T) {
+	for name, tc := range map[string]struct {
+		mix  func(p *Protocol)
+		want []byte
+	}{
+		"uint64": {func(p *Protocol) { p.MixUint64("v", 0x0102030405060708) }, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		"string": {func(p *Protocol) { p.MixString("v", "hello") }, []byte("hello")},
+		"true":   {func(p *Protocol) { p.MixBool("v", true) }, []byte{1}},
+		"false":  {func(p *Protocol) { p.MixBool("v", false) }, []byte{0}},
+	} {
+		got, want := New("test"), New("test")
+		tc.mix(got)
+		want.Mix("v", tc.want)
+		if got.Equal(want) != 1 {
+			t.Errorf("%s: transcript does not match Mix of canonical encoding", name)
+		}
+	}
+}
+
+func TestAbsorbedBytes(t *testing.T) {
+	p := New("test")
+	start := p.AbsorbedBytes()
+
+	p.Mix("key", make([]byte, 100))
+	if got, want := p.AbsorbedBytes()-start, uint64(100+len("key")+2+2+1); got != want {
+		t.Errorf("AbsorbedBytes() grew by %d, want = %d", got, want)
+	}
+
+	p.Ratchet("ratchet")
+	if got, want := p.AbsorbedBytes(), uint64(38); got != want {
+		t.Errorf("AbsorbedBytes() = %d after Ratchet, want = %d", got, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Run("counts", func(t *testing.T) {
+		p := New("test")
+		p.Mix("key", []byte("secret"))
+		p.MixUint64("counter", 1)
+		p.Derive("output", nil, 16)
+		p.Check("tag", make([]byte, 16))
+		p.Mask("message", nil, make([]byte, 10))
+		p.Unmask("message", nil, make([]byte, 5))
+		sealed := p.Seal("message", nil, make([]byte, 20))
+		_, _ = p.Open("message", nil, sealed)
+		p.Ratchet("ratchet")
+		_, right := p.Fork("role", []byte("a"), []byte("b"))
+
+		want := Stats{
+			Mixes: 2, Forks: 1, Derives: 2, Ratchets: 1, Masks: 2, Seals: 2,
+			MaskedBytes: 15, SealedBytes: 40, AbsorbedBytes: p.AbsorbedBytes(),
+		}
+		if got := p.Stats(); got != want {
+			t.Errorf("Stats() = %+v, want = %+v", got, want)
+		}
+
+		want.AbsorbedBytes = right.AbsorbedBytes()
+		if got := right.Stats(); got != want {
+			t.Errorf("fork Stats() = %+v, want = %+v", got, want)
+		}
+	})
+
+	t.Run("auto-ratchet", func(t *testing.T) {
+		p := New("test")
+		p.SetAutoRatchet(64)
+		p.Mix("data", make([]byte, 64))
+		if got, want := p.Stats().Ratchets, uint64(1); got != want {
+			t.Errorf("Stats().Ratchets = %d, want = %d", got, want)
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.Mix("key", []byte("secret"))
+		p.Seal("message", nil, make([]byte, 20))
+
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var restored Protocol
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := restored.Stats(), p.Stats(); got != want {
+			t.Errorf("Stats() = %+v, want = %+v", got, want)
+		}
+	})
+}
+
+func TestSetAutoRatchet(t *testing.T) {
+	t.Run("ratchets at threshold", func(t *testing.T) {
+		p := New("test")
+		p.SetAutoRatchet(1024)
+
+		want := New("test")
+		for range 3 {
+			p.Mix("data", make([]byte, 600))
+			want.Mix("data", make([]byte, 600))
+			if want.AbsorbedBytes() >= 1024 {
+				want.Ratchet("auto-ratchet")
+			}
+		}
+
+		if p.Equal(want) != 1 {
+			t.Error("auto-ratcheted transcript does not match explicit ratchets")
+		}
+		if p.AbsorbedBytes() >= 1024 {
+			t.Errorf("AbsorbedBytes() = %d, want < 1024", p.AbsorbedBytes())
+		}
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.SetAutoRatchet(64)
+		ct := p.Mask("message", nil, make([]byte, 128))
+
+		q := New("test")
+		q.SetAutoRatchet(64)
+		q.Unmask("message", nil, ct)
+
+		if p.Equal(q) != 1 {
+			t.Error("Mask and Unmask diverged")
+		}
+		if _, err := p.MarshalBinary(); err != nil {
+			t.Errorf("MarshalBinary() err = %v, want nil after auto-ratchet", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := New("test")
+		p.Mix("data", make([]byte, 4096))
+
+		want := New("test")
+		want.SetAutoRatchet(1024)
+		want.SetAutoRatchet(0)
+		want.Mix("data", make([]byte, 4096))
+
+		if p.Equal(want) != 1 {
+			t.Error("disabled auto-ratchet changed the transcript")
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.SetAutoRatchet(64)
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var restored Protocol
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		p.Mix("data", make([]byte, 128))
+		restored.Mix("data", make([]byte, 128))
+		if p.Equal(&restored) != 1 {
+			t.Error("restored protocol did not auto-ratchet")
+		}
+	})
+
+	t.Run("inherited by clones", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.SetAutoRatchet(64)
+		clone := p.Clone()
+		clone.Mix("data", make([]byte, 128))
+		if _, err := clone.MarshalBinary(); err != nil {
+			t.Errorf("MarshalBinary() err = %v, want nil after auto-ratchet", err)
+		}
+	})
+}
+
+func TestSetRekeyPolicy(t *testing.T) {
+	t.Run("messages", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		var rekeys []Stats
+		p.SetRekeyPolicy(RekeyPolicy{Messages: 2, OnRekey: func(s Stats) { rekeys = append(rekeys, s) }})
+
+		want := newKeyed("test", []byte("key"))
+		for i := range 5 {
+			p.Seal("message", nil, []byte("hello"))
+			want.Seal("message", nil, []byte("hello"))
+			if i%2 == 1 {
+				want.Ratchet("auto-ratchet")
+			}
+		}
+
+		if p.Equal(want) != 1 {
+			t.Error("auto-ratcheted transcript does not match explicit ratchets")
+		}
+		if got, want := len(rekeys), 2; got != want {
+			t.Fatalf("OnRekey called %d times, want = %d", got, want)
+		}
+		if got, want := rekeys[1].Seals, uint64(4); got != want {
+			t.Errorf("OnRekey Stats().Seals = %d, want = %d", got, want)
+		}
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		p.SetRekeyPolicy(RekeyPolicy{Bytes: 100})
+
+		for _, n := range []int{60, 60, 30, 30, 50} {
+			p.Mask("message", nil, make([]byte, n))
+		}
+
+		want := newKeyed("test", []byte("key"))
+		want.Mask("message", nil, make([]byte, 60))
+		want.Mask("message", nil, make([]byte, 60))
+		want.Ratchet("auto-ratchet")
+		want.Mask("message", nil, make([]byte, 30))
+		want.Mask("message", nil, make([]byte, 30))
+		want.Mask("message", nil, make([]byte, 50))
+		want.Ratchet("auto-ratchet")
+
+		if p.Equal(want) != 1 {
+			t.Error("auto-ratcheted transcript does not match explicit ratchets")
+		}
+	})
+
+	t.Run("manual ratchet resets counts", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		p.SetRekeyPolicy(RekeyPolicy{Messages: 2})
+		p.Seal("message", nil, nil)
+		p.Ratchet("manual")
+		p.Seal("message", nil, nil)
+
+		if got, want := p.Stats().Ratchets, uint64(1); got != want {
+			t.Errorf("Stats().Ratchets = %d, want = %d", got, want)
+		}
+	})
+
+	t.Run("open", func(t *testing.T) {
+		p, q := newKeyed("test", []byte("key")), newKeyed("test", []byte("key"))
+		p.SetRekeyPolicy(RekeyPolicy{Messages: 1})
+		q.SetRekeyPolicy(RekeyPolicy{Messages: 1})
+
+		for range 3 {
+			if _, err := q.Open("message", nil, p.Seal("message", nil, []byte("hello"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p.Equal(q) != 1 {
+			t.Error("Seal and Open diverged")
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		p := newKeyed("test", []byte("key"))
+		p.SetSerializable(true)
+		p.SetRekeyPolicy(RekeyPolicy{Messages: 2})
+		p.Seal("message", nil, nil)
+
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var restored Protocol
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		p.Seal("message", nil, nil)
+		restored.Seal("message", nil, nil)
+		if p.Equal(&restored) != 1 {
+			t.Error("restored protocol did not apply the rekey policy")
+		}
+		if got, want := restored.Stats().Ratchets, uint64(1); got != want {
+			t.Errorf("Stats().Ratchets = %d, want = %d", got, want)
+		}
+	})
+}
+
+func TestMarshalBinary(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		for name, p := range map[string]*Protocol{
+			"init": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				return p
+			}(),
+			"derive": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Mix("key", []byte("secret"))
+				p.Derive("output", nil, 16)
+				return p
+			}(),
+			"ratchet": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Mix("key", []byte("secret"))
+				p.Ratchet("ratchet")
+				return p
+			}(),
+			"seal": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Mix("key", []byte("secret"))
+				p.Seal("message", nil, []byte("hello"))
+				return p
+			}(),
+			"mix": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Ratchet("ratchet")
+				p.Mix("key", []byte("secret"))
+				return p
+			}(),
+			"mask": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Mask("message", nil, []byte("hello"))
+				return p
+			}(),
+			"fork": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Derive("output", nil, 16)
+				_, right := p.Fork("role", []byte("a"), []byte("b"))
+				return right
+			}(),
+			"long": func() *Protocol {
+				p := New("test")
+				p.SetSerializable(true)
+				p.Mix("data", make([]byte, 4000))
+				return p
+			}(),
+		} {
+			data, err := p.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%s: MarshalBinary() err = %v", name, err)
+			}
+
+			var restored Protocol
+			if err := restored.UnmarshalBinary(data); err != nil {
+				t.Fatalf("%s: UnmarshalBinary() err = %v", name, err)
+			}
+			if restored.Equal(p) != 1 {
+				t.Errorf("%s: restored state differs from original", name)
+			}
+
+			p.Mix("next", []byte("input"))
+			restored.Mix("next", []byte("input"))
+			if got, want := restored.Derive("output", nil, 32), p.Derive("output", nil, 32); !bytes.Equal(got, want) {
+				t.Errorf("%s: Derive() = %x, want = %x", name, got, want)
+			}
+		}
+	})
+
+	t.Run("not serializable", func(t *testing.T) {
+		p := New("test")
+		p.Mix("key", []byte("secret"))
+		if _, err := p.MarshalBinary(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("MarshalBinary() err = %v, want = %v", err, ErrNotSerializable)
+		}
+		if len(p.transcript) != 0 {
+			t.Errorf("len(transcript) = %d, want 0", len(p.transcript))
+		}
+	})
+
+	t.Run("enabled late", func(t *testing.T) {
+		p := New("test")
+		p.Mix("key", []byte("secret"))
+		p.SetSerializable(true)
+		p.Mix("data", []byte("more"))
+		if _, err := p.MarshalBinary(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("MarshalBinary() err = %v, want = %v", err, ErrNotSerializable)
+		}
+
+		p.Ratchet("ratchet")
+		p.Mix("data", []byte("more"))
+		if _, err := p.MarshalBinary(); err != nil {
+			t.Errorf("MarshalBinary() err = %v, want nil after Ratchet", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.Mix("key", []byte("secret"))
+		p.SetSerializable(false)
+		if _, err := p.MarshalBinary(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("MarshalBinary() err = %v, want = %v", err, ErrNotSerializable)
+		}
+		if len(p.transcript) != 0 {
+			t.Errorf("len(transcript) = %d, want 0", len(p.transcript))
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.Mix("data", make([]byte, 4<<10))
+		if _, err := p.MarshalBinary(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("MarshalBinary() err = %v, want = %v", err, ErrNotSerializable)
+		}
+		if len(p.transcript) != 0 {
+			t.Errorf("len(transcript) = %d, want 0", len(p.transcript))
+		}
+
+		p.Ratchet("ratchet")
+		if _, err := p.MarshalBinary(); err != nil {
+			t.Errorf("MarshalBinary() err = %v, want nil after Ratchet", err)
+		}
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		p := New("test")
+		p.SetSerializable(true)
+		p.Ratchet("ratchet")
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		badVersion := bytes.Clone(data)
+		badVersion[0] = 0xff
+		sum := stateChecksum(badVersion[:len(badVersion)-stateChecksumSize])
+		copy(badVersion[len(badVersion)-stateChecksumSize:], sum[:])
+
+		for name, b := range map[string][]byte{
+			"empty":     nil,
+			"truncated": data[:len(data)-1],
+			"corrupted": append([]byte{data[0], data[1] ^ 1}, data[2:]...),
+			"version":   badVersion,
+		} {
+			var restored Protocol
+			if err := restored.UnmarshalBinary(b); !errors.Is(err, ErrInvalidState) {
+				t.Errorf("%s: UnmarshalBinary() err = %v, want = %v", name, err, ErrInvalidState)
+			}
+		}
+	})
+}
+
 func TestResetChainEncoding(t *testing.T) {
 	var chainValue [chainValueSize]byte
 	for i := range chainValue {
@@ -580,7 +1405,8 @@ func TestResetChainEncoding(t *testing.T) {
 	}
 
 	got := New("discarded")
-	got.resetChain(opMask, chainValue[:])
+	cv := chainValue // resetChain wipes its chain value
+	got.resetChain(opMask, cv[:])
 
 	want := New("discarded")
 	want.h.Reset()
@@ -0,0 +1,30 @@
+package thyrse
+
+const (
+	// SpecVersion is the version of the Thyrse transcript specification implemented by this package. Implementations
+	// with the same SpecVersion produce identical transcripts for all stable operations; operations enabled by the
+	// thyrse_experimental build tag (see [Experimental]) are outside the specification and may differ.
+	SpecVersion = 1
+
+	// LibraryVersion is the version of this Go package.
+	LibraryVersion = "0.1.0"
+)
+
+// NewWithSpec creates a new protocol instance like [New], and binds [SpecVersion] and [Experimental] into the
+// transcript immediately after its Init frame. Peers which implement different versions of the specification, or of
+// which only one was built with the thyrse_experimental build tag, then produce independent transcripts, so the
+// incompatibility surfaces as a failed [Protocol.Open] or [Protocol.VerifyTranscriptTag] rather than as silently
+// different outputs from an operation one peer encodes differently.
+//
+// It is equivalent to calling [New] followed by [Protocol.Mix] with the label "thyrse-spec" and a 2-byte value: the
+// specification version, then 0x01 for experimental builds or 0x00 otherwise. All peers must use NewWithSpec, or none.
+func NewWithSpec(label string) *Protocol {
+	p := New(label)
+	var spec [2]byte
+	spec[0] = SpecVersion
+	if Experimental {
+		spec[1] = 1
+	}
+	p.Mix("thyrse-spec", spec[:])
+	return p
+}
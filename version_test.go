@@ -0,0 +1,24 @@
+package thyrse
+
+import "testing"
+
+func TestNewWithSpec(t *testing.T) {
+	t.Run("equivalent transcript", func(t *testing.T) {
+		want := New("test")
+		if Experimental {
+			want.Mix("thyrse-spec", []byte{SpecVersion, 1})
+		} else {
+			want.Mix("thyrse-spec", []byte{SpecVersion, 0})
+		}
+
+		if NewWithSpec("test").Equal(want) != 1 {
+			t.Fatal("NewWithSpec should be equivalent to New and Mix")
+		}
+	})
+
+	t.Run("distinct from New", func(t *testing.T) {
+		if NewWithSpec("test").Equal(New("test")) != 0 {
+			t.Fatal("NewWithSpec should not be equal to New")
+		}
+	})
+}
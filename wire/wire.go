@@ -0,0 +1,201 @@
+// Package wire provides a framing format for messages sealed with a thyrse.Protocol, so request/response protocols
+// agree on message boundaries without each inventing its own envelope.
+//
+// Each frame carries a label and a payload sealed with [thyrse.Protocol.Seal] under that label:
+//
+//	uvarint(len(label)) || label || uvarint(len(sealed)) || sealed
+//
+// The label is sent in the clear so a receiver can dispatch on it (e.g. "request", "response", or "error") before
+// opening the payload. It is bound into the transcript by Seal, so a frame whose label was modified fails to open.
+// Frames must be opened in the order they were sealed, by a protocol whose transcript matches the sender's.
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/codahale/thyrse"
+)
+
+// MaxLabelSize is the maximum length of a frame's label, in bytes.
+const MaxLabelSize = 255
+
+// ErrInvalidFrame is returned when a frame is malformed.
+var ErrInvalidFrame = errors.New("thyrse/wire: invalid frame")
+
+// AppendFrame seals payload with p under the given label and appends the resulting frame to dst, returning the updated
+// slice. Panics if label is longer than MaxLabelSize.
+func AppendFrame(dst []byte, p *thyrse.Protocol, label string, payload []byte) []byte {
+	if len(label) > MaxLabelSize {
+		panic("thyrse/wire: label too long")
+	}
+
+	dst = binary.AppendUvarint(dst, uint64(len(label)))
+	dst = append(dst, label...)
+	dst = binary.AppendUvarint(dst, uint64(len(payload)+thyrse.TagSize))
+	return p.Seal(label, dst, payload)
+}
+
+// ParseFrame parses the first frame in data and opens its payload with p. It returns the frame's label and payload,
+// and the data following the frame.
+//
+// Returns ErrInvalidFrame if the frame is malformed, thyrse.ErrTruncated if data ends before the frame does, or the
+// error from [thyrse.Protocol.Open] if the payload fails to open, in which case p's transcript has diverged from the
+// sender's.
+func ParseFrame(p *thyrse.Protocol, data []byte) (label string, payload, rest []byte, err error) {
+	labelLen, n := binary.Uvarint(data)
+	switch {
+	case n == 0:
+		return "", nil, nil, thyrse.ErrTruncated
+	case n < 0 || labelLen > MaxLabelSize:
+		return "", nil, nil, ErrInvalidFrame
+	}
+	data = data[n:]
+	if uint64(len(data)) < labelLen {
+		return "", nil, nil, thyrse.ErrTruncated
+	}
+	label, data = string(data[:labelLen]), data[labelLen:]
+
+	sealedLen, n := binary.Uvarint(data)
+	switch {
+	case n == 0:
+		return "", nil, nil, thyrse.ErrTruncated
+	case n < 0 || sealedLen < thyrse.TagSize:
+		return "", nil, nil, ErrInvalidFrame
+	}
+	data = data[n:]
+	if uint64(len(data)) < sealedLen {
+		return "", nil, nil, thyrse.ErrTruncated
+	}
+
+	payload, err = p.Open(label, nil, data[:sealedLen])
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return label, payload, data[sealedLen:], nil
+}
+
+// A Writer writes frames to an underlying io.Writer.
+type Writer struct {
+	p   *thyrse.Protocol
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter returns a Writer which seals frames with p and writes them to w. The protocol MUST NOT be used elsewhere
+// while the Writer is in use.
+func NewWriter(p *thyrse.Protocol, w io.Writer) *Writer {
+	return &Writer{p: p, w: w}
+}
+
+// WriteFrame seals payload under the given label and writes the frame to the underlying writer in a single call.
+// Panics if label is longer than MaxLabelSize.
+func (w *Writer) WriteFrame(label string, payload []byte) error {
+	w.buf = AppendFrame(w.buf[:0], w.p, label, payload)
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// A Reader reads frames from an underlying io.Reader.
+type Reader struct {
+	p              *thyrse.Protocol
+	r              *bufio.Reader
+	maxPayloadSize int
+	buf            []byte
+	err            error
+}
+
+// NewReader returns a Reader which reads frames from r and opens them with p. Frames with payloads longer than
+// maxPayloadSize bytes are rejected before they are read. The protocol MUST NOT be used elsewhere while the Reader is in
+// use.
+func NewReader(p *thyrse.Protocol, r io.Reader, maxPayloadSize int) *Reader {
+	return &Reader{p: p, r: bufio.NewReader(r), maxPayloadSize: maxPayloadSize}
+}
+
+// ReadFrame reads the next frame and returns its label and payload.
+//
+// Returns io.EOF if the underlying reader ends at a frame boundary, thyrse.ErrTruncated if it ends within a frame,
+// ErrInvalidFrame if the frame is malformed, thyrse.ErrTooLarge if its payload is longer than the Reader's maximum, or
+// the error from [thyrse.Protocol.Open] if its payload fails to open. After any error other than io.EOF, the Reader's
+// transcript may have diverged from the sender's, and subsequent calls return thyrse.ErrDesynchronized.
+func (r *Reader) ReadFrame() (label string, payload []byte, err error) {
+	if r.err != nil {
+		return "", nil, r.err
+	}
+
+	label, payload, err = r.readFrame()
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = thyrse.ErrDesynchronized
+	}
+	return label, payload, err
+}
+
+func (r *Reader) readFrame() (string, []byte, error) {
+	if _, err := r.r.Peek(1); err != nil {
+		return "", nil, err
+	}
+
+	labelLen, err := r.readUvarint()
+	switch {
+	case err != nil:
+		return "", nil, err
+	case labelLen > MaxLabelSize:
+		return "", nil, ErrInvalidFrame
+	}
+
+	label := make([]byte, labelLen)
+	if _, err := io.ReadFull(r.r, label); err != nil {
+		return "", nil, readErr(err)
+	}
+
+	sealedLen, err := r.readUvarint()
+	switch {
+	case err != nil:
+		return "", nil, err
+	case sealedLen < thyrse.TagSize:
+		return "", nil, ErrInvalidFrame
+	case sealedLen-thyrse.TagSize > uint64(r.maxPayloadSize):
+		return "", nil, thyrse.ErrTooLarge
+	}
+
+	if uint64(cap(r.buf)) < sealedLen {
+		r.buf = make([]byte, sealedLen)
+	}
+	sealed := r.buf[:sealedLen]
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return "", nil, readErr(err)
+	}
+
+	payload, err := r.p.Open(string(label), nil, sealed)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(label), payload, nil
+}
+
+// readUvarint reads a uvarint length within a frame.
+func (r *Reader) readUvarint() (uint64, error) {
+	b, err := r.r.Peek(binary.MaxVarintLen64)
+	v, n := binary.Uvarint(b)
+	switch {
+	case n == 0 && err != nil:
+		return 0, readErr(err)
+	case n == 0:
+		return 0, ErrInvalidFrame
+	case n < 0:
+		return 0, ErrInvalidFrame
+	}
+	_, _ = r.r.Discard(n)
+	return v, nil
+}
+
+// readErr converts an error from the underlying reader within a frame, mapping a premature end of input to
+// thyrse.ErrTruncated.
+func readErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return thyrse.ErrTruncated
+	}
+	return err
+}
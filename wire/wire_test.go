@@ -0,0 +1,145 @@
+package wire_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/thyrse"
+	"github.com/codahale/thyrse/internal/testdata"
+	"github.com/codahale/thyrse/wire"
+)
+
+func newProtocol() *thyrse.Protocol {
+	p := thyrse.New("wire")
+	p.Mix("key", []byte("shared key"))
+	return p
+}
+
+func TestFrame(t *testing.T) {
+	sender, receiver := newProtocol(), newProtocol()
+
+	var frames []byte
+	frames = wire.AppendFrame(frames, sender, "request", []byte("hello"))
+	frames = wire.AppendFrame(frames, sender, "response", nil)
+
+	t.Run("transcript", func(t *testing.T) {
+		p := newProtocol()
+		want := append([]byte{7}, "request"...)
+		want = append(want, 5+thyrse.TagSize)
+		want = p.Seal("request", want, []byte("hello"))
+		if got := frames[:len(want)]; !bytes.Equal(got, want) {
+			t.Errorf("AppendFrame() = %x, want = %x", got, want)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		label, payload, rest, err := wire.ParseFrame(receiver, frames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if label != "request" || !bytes.Equal(payload, []byte("hello")) {
+			t.Errorf("ParseFrame() = %q, %q, want = %q, %q", label, payload, "request", "hello")
+		}
+
+		label, payload, rest, err = wire.ParseFrame(receiver, rest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if label != "response" || len(payload) != 0 || len(rest) != 0 {
+			t.Errorf("ParseFrame() = %q, %q, %x, want = %q, empty, empty", label, payload, rest, "response")
+		}
+	})
+
+	t.Run("modified label", func(t *testing.T) {
+		modified := bytes.Clone(frames)
+		modified[1] ^= 1
+		if _, _, _, err := wire.ParseFrame(newProtocol(), modified); !errors.Is(err, thyrse.ErrTagMismatch) {
+			t.Errorf("ParseFrame() err = %v, want = %v", err, thyrse.ErrTagMismatch)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			data []byte
+			want error
+		}{
+			"empty":             {nil, thyrse.ErrTruncated},
+			"truncated label":   {frames[:3], thyrse.ErrTruncated},
+			"truncated length":  {frames[:8], thyrse.ErrTruncated},
+			"truncated payload": {frames[:20], thyrse.ErrTruncated},
+			"long label":        {[]byte{0x80, 0x02}, wire.ErrInvalidFrame},
+			"short payload":     {[]byte{0, thyrse.TagSize - 1}, wire.ErrInvalidFrame},
+			"overflow":          {bytes.Repeat([]byte{0xff}, 11), wire.ErrInvalidFrame},
+		} {
+			if _, _, _, err := wire.ParseFrame(newProtocol(), tc.data); !errors.Is(err, tc.want) {
+				t.Errorf("%s: ParseFrame() err = %v, want = %v", name, err, tc.want)
+			}
+		}
+	})
+}
+
+func TestReader(t *testing.T) {
+	var buf bytes.Buffer
+	w := wire.NewWriter(newProtocol(), &buf)
+	for _, payload := range [][]byte{[]byte("one"), nil, bytes.Repeat([]byte("x"), 1000)} {
+		if err := w.WriteFrame("message", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	frames := buf.Bytes()
+
+	t.Run("round trip", func(t *testing.T) {
+		r := wire.NewReader(newProtocol(), bytes.NewReader(frames), 1000)
+		for _, want := range [][]byte{[]byte("one"), nil, bytes.Repeat([]byte("x"), 1000)} {
+			label, payload, err := r.ReadFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if label != "message" || !bytes.Equal(payload, want) {
+				t.Errorf("ReadFrame() = %q, %q, want = %q, %q", label, payload, "message", want)
+			}
+		}
+		if _, _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+			t.Errorf("ReadFrame() err = %v, want = %v", err, io.EOF)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		r := wire.NewReader(newProtocol(), bytes.NewReader(frames), 999)
+		_, _, _ = r.ReadFrame()
+		_, _, _ = r.ReadFrame()
+		if _, _, err := r.ReadFrame(); !errors.Is(err, thyrse.ErrTooLarge) {
+			t.Errorf("ReadFrame() err = %v, want = %v", err, thyrse.ErrTooLarge)
+		}
+		if _, _, err := r.ReadFrame(); !errors.Is(err, thyrse.ErrDesynchronized) {
+			t.Errorf("ReadFrame() err = %v, want = %v", err, thyrse.ErrDesynchronized)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		r := wire.NewReader(newProtocol(), bytes.NewReader(frames[:len(frames)-1]), 1000)
+		_, _, _ = r.ReadFrame()
+		_, _, _ = r.ReadFrame()
+		if _, _, err := r.ReadFrame(); !errors.Is(err, thyrse.ErrTruncated) {
+			t.Errorf("ReadFrame() err = %v, want = %v", err, thyrse.ErrTruncated)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		want := errors.New("read error")
+		r := wire.NewReader(newProtocol(), &testdata.ErrReader{Err: want}, 1000)
+		if _, _, err := r.ReadFrame(); !errors.Is(err, want) {
+			t.Errorf("ReadFrame() err = %v, want = %v", err, want)
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		want := errors.New("write error")
+		w := wire.NewWriter(newProtocol(), &testdata.ErrWriter{Err: want})
+		if err := w.WriteFrame("message", nil); !errors.Is(err, want) {
+			t.Errorf("WriteFrame() err = %v, want = %v", err, want)
+		}
+	})
+}